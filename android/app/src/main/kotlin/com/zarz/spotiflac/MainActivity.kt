@@ -2254,6 +2254,14 @@ class MainActivity: FlutterFragmentActivity() {
                             }
                             result.success(response)
                         }
+                        "buildFilePath" -> {
+                            val template = call.argument<String>("template") ?: ""
+                            val metadata = call.argument<String>("metadata") ?: "{}"
+                            val response = withContext(Dispatchers.IO) {
+                                Gobackend.buildFilePath(template, metadata)
+                            }
+                            result.success(response)
+                        }
                         "sanitizeFilename" -> {
                             val filename = call.argument<String>("filename") ?: ""
                             val response = withContext(Dispatchers.IO) {
@@ -3112,6 +3120,20 @@ class MainActivity: FlutterFragmentActivity() {
                             }
                             result.success(null)
                         }
+                        "setBandwidthSettings" -> {
+                            val settingsJson = call.argument<String>("settings_json") ?: ""
+                            withContext(Dispatchers.IO) {
+                                Gobackend.setBandwidthSettingsJSON(settingsJson)
+                            }
+                            result.success(null)
+                        }
+                        "setNetworkMetered" -> {
+                            val metered = call.argument<Boolean>("metered") ?: false
+                            withContext(Dispatchers.IO) {
+                                Gobackend.setNetworkMetered(metered)
+                            }
+                            result.success(null)
+                        }
                         "setMetadataProviderPriority" -> {
                             val priorityJson = call.argument<String>("priority") ?: "[]"
                             withContext(Dispatchers.IO) {
@@ -0,0 +1,228 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	albumReplayGainCollecting = "collecting"
+	albumReplayGainReady      = "ready"
+	albumReplayGainAnalyzing  = "analyzing"
+	albumReplayGainFinalized  = "finalized"
+	albumReplayGainFailed     = "failed"
+)
+
+type AlbumReplayGainStatus struct {
+	AlbumKey        string                 `json:"album_key"`
+	Album           string                 `json:"album"`
+	AlbumArtist     string                 `json:"album_artist"`
+	ExpectedTracks  int                    `json:"expected_tracks"`
+	CompletedTracks int                    `json:"completed_tracks"`
+	Status          string                 `json:"status"`
+	Error           string                 `json:"error,omitempty"`
+	Result          *ReplayGainAlbumResult `json:"result,omitempty"`
+	UpdatedAt       int64                  `json:"updated_at"`
+
+	files map[string]string
+}
+
+var (
+	albumReplayGainStates   = make(map[string]*AlbumReplayGainStatus)
+	albumReplayGainStatesMu sync.Mutex
+)
+
+func albumReplayGainKey(req DownloadRequest) string {
+	if id := strings.TrimSpace(req.AlbumID); id != "" {
+		return "id:" + id
+	}
+	album := strings.TrimSpace(req.AlbumName)
+	if album == "" {
+		return ""
+	}
+	artist := strings.TrimSpace(req.AlbumArtist)
+	if artist == "" {
+		artist = strings.TrimSpace(req.ArtistName)
+	}
+	return strings.ToLower(artist + "\x00" + album)
+}
+
+func albumReplayGainExpectedTracks(req DownloadRequest) int {
+	if req.AlbumTrackCount > 0 {
+		return req.AlbumTrackCount
+	}
+	// total_tracks is per disc, so it only covers the album for single-disc releases.
+	if req.TotalDiscs <= 1 {
+		return req.TotalTracks
+	}
+	return 0
+}
+
+func albumReplayGainTrackKey(req DownloadRequest, filePath string) string {
+	if req.TrackNumber > 0 {
+		return fmt.Sprintf("%d-%d", max(req.DiscNumber, 1), req.TrackNumber)
+	}
+	return filePath
+}
+
+// recordAlbumDownloadForReplayGain registers a completed download and marks
+// the album ready once every expected track has landed. Tags are only written
+// by FinalizeAlbumReplayGain, because the host may still be post-processing
+// the files when the download returns.
+func recordAlbumDownloadForReplayGain(req DownloadRequest, filePath string) {
+	if !req.AutoReplayGain {
+		return
+	}
+	filePath = strings.TrimSpace(filePath)
+	if filePath == "" || shouldSkipQualityProbe(filePath) {
+		return
+	}
+	if _, ok := pcmDecoderOpeners[strings.ToLower(filepath.Ext(filePath))]; !ok {
+		GoLog("[ReplayGain] Skipping album gain for unsupported format: %s\n", filepath.Base(filePath))
+		return
+	}
+
+	key := albumReplayGainKey(req)
+	expected := albumReplayGainExpectedTracks(req)
+	if key == "" || expected <= 0 {
+		return
+	}
+
+	albumReplayGainStatesMu.Lock()
+	state, ok := albumReplayGainStates[key]
+	if !ok || state.Status == albumReplayGainFinalized {
+		albumArtist := strings.TrimSpace(req.AlbumArtist)
+		if albumArtist == "" {
+			albumArtist = strings.TrimSpace(req.ArtistName)
+		}
+		state = &AlbumReplayGainStatus{
+			AlbumKey:    key,
+			Album:       req.AlbumName,
+			AlbumArtist: albumArtist,
+			Status:      albumReplayGainCollecting,
+			files:       make(map[string]string),
+		}
+		albumReplayGainStates[key] = state
+	}
+	if state.Status == albumReplayGainAnalyzing {
+		albumReplayGainStatesMu.Unlock()
+		return
+	}
+
+	state.files[albumReplayGainTrackKey(req, filePath)] = filePath
+	state.ExpectedTracks = expected
+	state.CompletedTracks = len(state.files)
+	state.UpdatedAt = time.Now().UnixMilli()
+	state.Status = albumReplayGainCollecting
+	if state.CompletedTracks >= state.ExpectedTracks {
+		state.Status = albumReplayGainReady
+		GoLog("[ReplayGain] All %d tracks of %q downloaded, album ready for finalizing\n", state.CompletedTracks, state.Album)
+	}
+	albumReplayGainStatesMu.Unlock()
+}
+
+// FinalizeAlbumReplayGain analyzes a ready album and writes track and album
+// gain to every file. The host calls it once its own post-processing of the
+// album's files is done; it returns the album's final status.
+func FinalizeAlbumReplayGain(albumKey string) (string, error) {
+	albumReplayGainStatesMu.Lock()
+	state, ok := albumReplayGainStates[albumKey]
+	if !ok {
+		albumReplayGainStatesMu.Unlock()
+		return "", fmt.Errorf("unknown album %q", albumKey)
+	}
+	if state.Status != albumReplayGainReady && state.Status != albumReplayGainFailed {
+		status := state.Status
+		albumReplayGainStatesMu.Unlock()
+		return "", fmt.Errorf("album %q is %s, not ready", albumKey, status)
+	}
+	state.Status = albumReplayGainAnalyzing
+	files := make([]string, 0, len(state.files))
+	for _, path := range state.files {
+		files = append(files, path)
+	}
+	albumReplayGainStatesMu.Unlock()
+
+	sort.Strings(files)
+	GoLog("[ReplayGain] Analyzing %d tracks of %q\n", len(files), state.Album)
+	finalizeAlbumDownloadReplayGain(state, files)
+
+	albumReplayGainStatesMu.Lock()
+	jsonBytes, err := json.Marshal(state)
+	albumReplayGainStatesMu.Unlock()
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+func finalizeAlbumDownloadReplayGain(state *AlbumReplayGainStatus, files []string) {
+	album := &ReplayGainAlbumResult{Album: state.Album, Tracks: analyzeReplayGainTracks(files)}
+
+	var problems []string
+	for _, track := range album.Tracks {
+		if track.Error != "" {
+			problems = append(problems, filepath.Base(track.FilePath)+": "+track.Error)
+		}
+	}
+
+	// Album gain over a partial set of tracks would be wrong, so nothing is
+	// written unless every track could be measured.
+	status := albumReplayGainFailed
+	if len(problems) == 0 {
+		finalizeReplayGainAlbum(album, true)
+		status = albumReplayGainFinalized
+		for _, track := range album.Tracks {
+			if !track.Written {
+				problems = append(problems, "tags not written for "+filepath.Base(track.FilePath))
+			}
+		}
+	}
+
+	albumReplayGainStatesMu.Lock()
+	state.Status = status
+	state.Result = album
+	state.Error = strings.Join(problems, "; ")
+	state.UpdatedAt = time.Now().UnixMilli()
+	albumReplayGainStatesMu.Unlock()
+
+	if status == albumReplayGainFailed {
+		GoLog("[ReplayGain] Album %q analysis failed: %s\n", state.Album, state.Error)
+	} else {
+		GoLog("[ReplayGain] Album %q finalized\n", state.Album)
+	}
+}
+
+func GetAlbumReplayGainStatus() string {
+	albumReplayGainStatesMu.Lock()
+	defer albumReplayGainStatesMu.Unlock()
+
+	states := make([]AlbumReplayGainStatus, 0, len(albumReplayGainStates))
+	for _, state := range albumReplayGainStates {
+		states = append(states, *state)
+	}
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].UpdatedAt > states[j].UpdatedAt
+	})
+
+	jsonBytes, _ := json.Marshal(states)
+	return string(jsonBytes)
+}
+
+// ClearAlbumReplayGainStatus drops finished albums; albums still collecting
+// or being analyzed are kept.
+func ClearAlbumReplayGainStatus() {
+	albumReplayGainStatesMu.Lock()
+	defer albumReplayGainStatesMu.Unlock()
+
+	for key, state := range albumReplayGainStates {
+		if state.Status == albumReplayGainFinalized || state.Status == albumReplayGainFailed {
+			delete(albumReplayGainStates, key)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package gobackend
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func testMP4Box(typ string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	box := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(box, uint32(size))
+	copy(box[4:], typ)
+	for _, p := range payload {
+		box = append(box, p...)
+	}
+	return box
+}
+
+// wrapTestFLACInMP4 rewraps a native FLAC stream the way DASH delivers it:
+// metadata blocks in dfLa, frames in mdat.
+func wrapTestFLACInMP4(flacData []byte) []byte {
+	const metadataEnd = 4 + 4 + 34
+	dfLa := testMP4Box("dfLa", make([]byte, 4), flacData[4:metadataEnd])
+	entry := testMP4Box("fLaC", make([]byte, 28), dfLa)
+	stsd := testMP4Box("stsd", []byte{0, 0, 0, 0, 0, 0, 0, 1}, entry)
+	udta := testMP4Box("udta", testMP4Box("meta", make([]byte, 4), testMP4Box("ilst")))
+	moov := testMP4Box("moov", testMP4Box("trak", testMP4Box("mdia", testMP4Box("minf", testMP4Box("stbl", stsd)))), udta)
+	return append(append(testMP4Box("ftyp", []byte("isom")), moov...), testMP4Box("mdat", flacData[metadataEnd:])...)
+}
+
+func TestAlbumReplayGainFinalizesWhenHostAsks(t *testing.T) {
+	t.Cleanup(ClearAlbumReplayGainStatus)
+
+	dir := t.TempDir()
+	tone := func(dbfs float64) []byte {
+		amplitude := math.Pow(10, dbfs/20) * 32767
+		return buildTestFLACWith(t, 12, 4096, func(ch, n int) int32 {
+			return int32(math.Round(amplitude * math.Sin(2*math.Pi*440*float64(n)/44100)))
+		})
+	}
+	flacPath := writeTestFLAC(t, dir, "01.flac", tone(-20))
+	m4aPath := writeTestFLAC(t, dir, "02.m4a", wrapTestFLACInMP4(tone(-24)))
+
+	req := DownloadRequest{AlbumName: "Album", AlbumArtist: "Artist", TotalTracks: 2, AutoReplayGain: true}
+	req.TrackNumber = 1
+	recordAlbumDownloadForReplayGain(req, flacPath)
+	recordAlbumDownloadForReplayGain(req, flacPath)
+
+	var states []AlbumReplayGainStatus
+	_ = json.Unmarshal([]byte(GetAlbumReplayGainStatus()), &states)
+	if len(states) != 1 || states[0].Status != albumReplayGainCollecting || states[0].CompletedTracks != 1 {
+		t.Fatalf("expected album to wait for remaining track: %+v", states)
+	}
+
+	if _, err := FinalizeAlbumReplayGain(states[0].AlbumKey); err == nil {
+		t.Fatal("expected finalize to refuse an incomplete album")
+	}
+
+	req.TrackNumber = 2
+	recordAlbumDownloadForReplayGain(req, m4aPath)
+
+	states = nil
+	_ = json.Unmarshal([]byte(GetAlbumReplayGainStatus()), &states)
+	if len(states) != 1 || states[0].Status != albumReplayGainReady {
+		t.Fatalf("expected album to wait for the host: %+v", states)
+	}
+	if meta, err := ReadMetadata(flacPath); err != nil || meta.ReplayGainAlbumGain != "" {
+		t.Fatalf("no tags may be written before the host finalizes: %+v, %v", meta, err)
+	}
+
+	raw, err := FinalizeAlbumReplayGain(states[0].AlbumKey)
+	if err != nil {
+		t.Fatalf("FinalizeAlbumReplayGain: %v", err)
+	}
+	var status AlbumReplayGainStatus
+	if err := json.Unmarshal([]byte(raw), &status); err != nil || status.Status != albumReplayGainFinalized || status.Result == nil {
+		t.Fatalf("expected finalized album: %s", raw)
+	}
+	result := status.Result
+	if len(result.Tracks) != 2 || result.Tracks[1].WriteMethod != "native_m4a_replaygain" {
+		t.Fatalf("expected native M4A write: %+v", result.Tracks)
+	}
+	if result.AlbumGainDB <= result.Tracks[0].TrackGainDB || result.AlbumGainDB >= result.Tracks[1].TrackGainDB {
+		t.Fatalf("album gain should sit between track gains: %+v", result)
+	}
+
+	meta, err := ReadMetadata(filepath.Join(dir, "01.flac"))
+	if err != nil || meta.ReplayGainAlbumGain == "" {
+		t.Fatalf("expected album gain tag on FLAC: %+v, %v", meta, err)
+	}
+}
+
+func TestMP4FLACDecoderRejectsMalformedDfLa(t *testing.T) {
+	dir := t.TempDir()
+	build := func(dfLa []byte) []byte {
+		entry := testMP4Box("fLaC", make([]byte, 28), dfLa)
+		stsd := testMP4Box("stsd", []byte{0, 0, 0, 0, 0, 0, 0, 1}, entry)
+		moov := testMP4Box("moov", testMP4Box("trak", testMP4Box("mdia", testMP4Box("minf", testMP4Box("stbl", stsd)))))
+		return append(testMP4Box("ftyp", []byte("isom")), moov...)
+	}
+
+	truncated := testMP4Box("dfLa", []byte{0, 0})
+	oversized := testMP4Box("dfLa", make([]byte, 8))
+	binary.BigEndian.PutUint32(oversized, 1<<30)
+
+	for name, dfLa := range map[string][]byte{"truncated.m4a": truncated, "oversized.m4a": oversized} {
+		path := writeTestFLAC(t, dir, name, build(dfLa))
+		if dec, err := openMP4FLACDecoderFile(path); err == nil {
+			dec.Close()
+			t.Fatalf("%s: expected malformed dfLa to be rejected", name)
+		}
+	}
+}
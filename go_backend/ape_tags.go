@@ -391,6 +391,11 @@ func APETagToAudioMetadata(tag *APETag) *AudioMetadata {
 			metadata.ReplayGainAlbumGain = value
 		case "REPLAYGAIN_ALBUM_PEAK":
 			metadata.ReplayGainAlbumPeak = value
+		default:
+			// APEv2 separates multiple values with NUL.
+			for _, part := range strings.Split(value, "\x00") {
+				metadata.TrackIdentifiers.setTag(key, part)
+			}
 		}
 	}
 
@@ -436,6 +441,13 @@ func AudioMetadataToAPEItems(metadata *AudioMetadata) []APETagItem {
 	addItem("REPLAYGAIN_TRACK_PEAK", metadata.ReplayGainTrackPeak)
 	addItem("REPLAYGAIN_ALBUM_GAIN", metadata.ReplayGainAlbumGain)
 	addItem("REPLAYGAIN_ALBUM_PEAK", metadata.ReplayGainAlbumPeak)
+	for _, tag := range identifierTags {
+		value := *tag.value(&metadata.TrackIdentifiers)
+		if tag.multi {
+			value = strings.Join(splitIdentifierValues(value), "\x00")
+		}
+		addItem(tag.vorbis, value)
+	}
 
 	return items
 }
@@ -464,6 +476,9 @@ func apeKeysFromFields(fields map[string]string) map[string]struct{} {
 		"replaygain_album_gain": "REPLAYGAIN_ALBUM_GAIN",
 		"replaygain_album_peak": "REPLAYGAIN_ALBUM_PEAK",
 	}
+	for _, tag := range identifierTags {
+		mapping[tag.field] = tag.vorbis
+	}
 	result := make(map[string]struct{})
 	for fk, apeKey := range mapping {
 		if _, present := fields[fk]; present {
@@ -0,0 +1,29 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// writeJSONFileAtomic writes value to path through a temp file and a rename,
+// so readers never see a half-written file. Callers serialise writes to the
+// same path themselves.
+func writeJSONFileAtomic(path string, value any) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
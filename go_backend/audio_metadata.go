@@ -35,6 +35,8 @@ type AudioMetadata struct {
 	ReplayGainTrackPeak string
 	ReplayGainAlbumGain string
 	ReplayGainAlbumPeak string
+
+	TrackIdentifiers
 }
 
 type MP3Quality struct {
@@ -328,6 +330,11 @@ func parseID3v23Frames(data []byte, metadata *AudioMetadata, version byte, tagUn
 				metadata.ReplayGainAlbumGain = userValue
 			case "REPLAYGAIN_ALBUM_PEAK":
 				metadata.ReplayGainAlbumPeak = userValue
+			default:
+				// ID3v2.4 separates multiple values with NUL.
+				for _, part := range strings.Split(userValue, "\x00") {
+					metadata.TrackIdentifiers.setTag(desc, part)
+				}
 			}
 		}
 
@@ -1078,6 +1085,8 @@ func parseVorbisComments(data []byte, metadata *AudioMetadata) {
 			metadata.ReplayGainAlbumGain = value
 		case "REPLAYGAIN_ALBUM_PEAK":
 			metadata.ReplayGainAlbumPeak = value
+		default:
+			metadata.TrackIdentifiers.setTag(key, value)
 		}
 	}
 
@@ -0,0 +1,195 @@
+package gobackend
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+)
+
+const (
+	spectrumFFTSize         = 4096
+	spectrumWindowsPerSec   = 4
+	spectrumMinDropDb       = 20.0
+	spectrumLossyMaxCutoff  = 20500
+	spectrumUpsampledCutoff = 24500
+	spectrumSilenceRMS      = 1e-4
+)
+
+type SpectralCutoffResult struct {
+	SampleRate      int     `json:"sampleRate"`
+	CutoffHz        int     `json:"cutoffHz"`
+	DropDb          float64 `json:"dropDb"`
+	AnalyzedSeconds float64 `json:"analyzedSeconds"`
+	LikelyLossy     bool    `json:"likelyLossy"`
+	LikelyUpsampled bool    `json:"likelyUpsampled"`
+}
+
+func fftInPlace(x []complex128) {
+	n := len(x)
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			half := size / 2
+			for k := 0; k < half; k++ {
+				even := x[start+k]
+				odd := x[start+k+half] * w
+				x[start+k] = even + odd
+				x[start+k+half] = even - odd
+				w *= step
+			}
+		}
+	}
+}
+
+// analyzeFLACSpectralCutoff averages the power spectrum of up to maxSeconds
+// of audio and looks for the brick-wall low-pass that lossy encoders leave
+// behind. Lossless masters roll off gradually; MP3/AAC sources drop by tens
+// of dB within a few hundred Hz around 16-20 kHz.
+func analyzeFLACSpectralCutoff(filePath string, maxSeconds int) (*SpectralCutoffResult, error) {
+	dec, err := openFLACDecoderFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	sampleRate := dec.Info.SampleRate
+	scale := 1.0 / float64(int64(1)<<(dec.Info.BitsPerSample-1))
+	hop := max(sampleRate/spectrumWindowsPerSec, spectrumFFTSize)
+	maxSamples := int64(math.MaxInt64)
+	if maxSeconds > 0 {
+		maxSamples = int64(maxSeconds) * int64(sampleRate)
+	}
+
+	window := make([]float64, spectrumFFTSize)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(spectrumFFTSize-1))
+	}
+
+	power := make([]float64, spectrumFFTSize/2)
+	buf := make([]float64, 0, spectrumFFTSize)
+	fft := make([]complex128, spectrumFFTSize)
+	windows := 0
+	var consumed int64
+	skip := 0
+
+	for consumed < maxSamples {
+		frame, err := dec.NextFrame()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for i := 0; i < frame.Header.BlockSize; i++ {
+			consumed++
+			if skip > 0 {
+				skip--
+				continue
+			}
+			var mono float64
+			for _, ch := range frame.Samples {
+				mono += float64(ch[i])
+			}
+			buf = append(buf, mono*scale/float64(len(frame.Samples)))
+			if len(buf) < spectrumFFTSize {
+				continue
+			}
+
+			var energy float64
+			for j, v := range buf {
+				energy += v * v
+				fft[j] = complex(v*window[j], 0)
+			}
+			buf = buf[:0]
+			skip = hop - spectrumFFTSize
+			if math.Sqrt(energy/spectrumFFTSize) < spectrumSilenceRMS {
+				continue
+			}
+
+			fftInPlace(fft)
+			for j := range power {
+				re, im := real(fft[j]), imag(fft[j])
+				power[j] += re*re + im*im
+			}
+			windows++
+		}
+	}
+
+	if windows == 0 {
+		return nil, fmt.Errorf("not enough non-silent audio to analyze")
+	}
+
+	result := detectSpectralCutoff(power, sampleRate)
+	result.AnalyzedSeconds = float64(consumed) / float64(sampleRate)
+	return result, nil
+}
+
+func detectSpectralCutoff(power []float64, sampleRate int) *SpectralCutoffResult {
+	bins := len(power)
+	binHz := float64(sampleRate) / float64(2*bins)
+	levels := make([]float64, bins)
+	for i, p := range power {
+		levels[i] = 10 * math.Log10(p+1e-20)
+	}
+
+	meanLevel := func(fromHz, toHz float64) float64 {
+		from := max(int(fromHz/binHz), 0)
+		to := min(int(toHz/binHz), bins-1)
+		if to < from {
+			return levels[from]
+		}
+		var sum float64
+		for i := from; i <= to; i++ {
+			sum += levels[i]
+		}
+		return sum / float64(to-from+1)
+	}
+
+	nyquist := float64(sampleRate) / 2
+	result := &SpectralCutoffResult{SampleRate: sampleRate, CutoffHz: int(nyquist)}
+
+	bestDrop := 0.0
+	bestHz := nyquist
+	for hz := 10000.0; hz < nyquist-1000; hz += binHz {
+		below := meanLevel(hz-1000, hz-100)
+		above := meanLevel(hz+100, math.Min(hz+1000, nyquist))
+		if drop := below - above; drop > bestDrop {
+			bestDrop = drop
+			bestHz = hz
+		}
+	}
+
+	result.DropDb = math.Round(bestDrop*10) / 10
+	if bestDrop < spectrumMinDropDb {
+		return result
+	}
+
+	// A real cutoff keeps everything above it near the floor, so reject drops
+	// that are followed by content coming back up.
+	if meanLevel(bestHz+100, nyquist) > meanLevel(bestHz-1000, bestHz-100)-spectrumMinDropDb {
+		return result
+	}
+
+	result.CutoffHz = int(bestHz)
+	if result.CutoffHz <= spectrumLossyMaxCutoff {
+		result.LikelyLossy = true
+	} else if sampleRate > 48000 && result.CutoffHz <= spectrumUpsampledCutoff {
+		result.LikelyUpsampled = true
+	}
+	return result
+}
@@ -0,0 +1,241 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	meteredQualityLossless = "lossless"
+	meteredQualityLossy    = "lossy"
+
+	bandwidthSleepSlice = 250 * time.Millisecond
+)
+
+// MeteredNetworkPolicy is applied to downloads started while the host
+// reports a metered network. MaxQuality caps built-in providers at CD
+// quality ("lossless") or AAC ("lossy"); extensions keep their own quality
+// names and are only throttled.
+type MeteredNetworkPolicy struct {
+	PauseQueue         bool   `json:"pause_queue"`
+	MaxQuality         string `json:"max_quality,omitempty"`
+	SkipCoverAndLyrics bool   `json:"skip_cover_and_lyrics"`
+	LimitKBps          int    `json:"limit_kbps,omitempty"`
+}
+
+// BandwidthSettings caps download throughput in KiB/s across all downloads
+// and per item. Zero means unlimited.
+type BandwidthSettings struct {
+	GlobalLimitKBps int                  `json:"global_limit_kbps"`
+	ItemLimitKBps   int                  `json:"item_limit_kbps"`
+	Metered         MeteredNetworkPolicy `json:"metered"`
+}
+
+var (
+	bandwidthMu       sync.RWMutex
+	bandwidthSettings BandwidthSettings
+	networkMetered    bool
+
+	globalBandwidthLimiter = &byteRateLimiter{}
+)
+
+func SetBandwidthSettings(settingsJSON string) error {
+	var settings BandwidthSettings
+	if strings.TrimSpace(settingsJSON) != "" {
+		if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
+			return fmt.Errorf("invalid bandwidth settings: %w", err)
+		}
+	}
+	if settings.GlobalLimitKBps < 0 || settings.ItemLimitKBps < 0 || settings.Metered.LimitKBps < 0 {
+		return fmt.Errorf("bandwidth limits must not be negative")
+	}
+	settings.Metered.MaxQuality = strings.ToLower(strings.TrimSpace(settings.Metered.MaxQuality))
+	switch settings.Metered.MaxQuality {
+	case "", meteredQualityLossless, meteredQualityLossy:
+	default:
+		return fmt.Errorf("unknown metered max_quality %q", settings.Metered.MaxQuality)
+	}
+
+	bandwidthMu.Lock()
+	bandwidthSettings = settings
+	bandwidthMu.Unlock()
+	GoLog("[Bandwidth] Limits set: global %d KiB/s, per item %d KiB/s\n", settings.GlobalLimitKBps, settings.ItemLimitKBps)
+	return nil
+}
+
+// GetBandwidthSettings returns the settings together with the network state
+// last reported by the host.
+func GetBandwidthSettings() (string, error) {
+	bandwidthMu.RLock()
+	settings := bandwidthSettings
+	metered := networkMetered
+	bandwidthMu.RUnlock()
+
+	jsonBytes, err := json.Marshal(struct {
+		BandwidthSettings
+		NetworkMetered bool `json:"network_metered"`
+		QueuePaused    bool `json:"queue_paused"`
+	}{
+		BandwidthSettings: settings,
+		NetworkMetered:    metered,
+		QueuePaused:       metered && settings.Metered.PauseQueue,
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+// SetNetworkMetered is called by the host whenever connectivity changes.
+func SetNetworkMetered(metered bool) {
+	bandwidthMu.Lock()
+	changed := networkMetered != metered
+	networkMetered = metered
+	bandwidthMu.Unlock()
+	if changed {
+		GoLog("[Bandwidth] Network metered: %v\n", metered)
+	}
+}
+
+// meteredPolicy returns the policy to apply, and false when the network is
+// not metered.
+func meteredPolicy() (MeteredNetworkPolicy, bool) {
+	bandwidthMu.RLock()
+	defer bandwidthMu.RUnlock()
+	return bandwidthSettings.Metered, networkMetered
+}
+
+// bandwidthLimits returns the global and per-item limits in bytes per second.
+func bandwidthLimits() (global, item float64) {
+	bandwidthMu.RLock()
+	settings := bandwidthSettings
+	metered := networkMetered
+	bandwidthMu.RUnlock()
+
+	globalKBps := settings.GlobalLimitKBps
+	if metered && settings.Metered.LimitKBps > 0 && (globalKBps == 0 || settings.Metered.LimitKBps < globalKBps) {
+		globalKBps = settings.Metered.LimitKBps
+	}
+	return float64(globalKBps) * 1024, float64(settings.ItemLimitKBps) * 1024
+}
+
+// applyMeteredDownloadPolicy refuses to start a download while the queue is
+// paused and drops cover and lyrics fetching when the policy asks for it.
+func applyMeteredDownloadPolicy(req *DownloadRequest) error {
+	policy, metered := meteredPolicy()
+	if !metered {
+		return nil
+	}
+	if policy.PauseQueue {
+		traceDecision(req.ItemID, "metered_policy", "", "paused", nil)
+		return ErrMeteredNetwork
+	}
+	if policy.SkipCoverAndLyrics && (req.CoverURL != "" || req.EmbedLyrics) {
+		GoLog("[Bandwidth] Metered network, skipping cover and lyrics for %s\n", req.TrackName)
+		req.CoverURL = ""
+		req.EmbedMaxQualityCover = false
+		req.EmbedLyrics = false
+		traceDecision(req.ItemID, "metered_policy", "", "skip_extras", nil)
+	}
+	return nil
+}
+
+// meteredQualityForBuiltIn lowers a Tidal/Qobuz quality to the metered
+// policy's cap. It is applied once, where each provider maps its quality.
+// Qobuz only serves FLAC, so it is skipped under the lossy cap.
+func meteredQualityForBuiltIn(provider, quality string) (string, error) {
+	policy, metered := meteredPolicy()
+	if !metered {
+		return quality, nil
+	}
+	switch policy.MaxQuality {
+	case meteredQualityLossy:
+		if provider == "qobuz" {
+			return "", newDownloadError(provider, ErrQualityUnavailable, fmt.Errorf("no lossy stream within the metered quality cap"))
+		}
+		return "HIGH", nil
+	case meteredQualityLossless:
+		if quality != "HIGH" {
+			return "LOSSLESS", nil
+		}
+	}
+	return quality, nil
+}
+
+// byteRateLimiter is a byte-granular token bucket holding at most one
+// second of traffic. Reservations may overdraw it; the caller then waits
+// until the debt is repaid.
+type byteRateLimiter struct {
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+func (l *byteRateLimiter) reserve(n int, rate float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	if rate <= 0 {
+		l.tokens = 0
+		l.last = now
+		return 0
+	}
+	if l.last.IsZero() {
+		l.tokens = rate
+	} else {
+		l.tokens = min(rate, l.tokens+now.Sub(l.last).Seconds()*rate)
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / rate * float64(time.Second))
+}
+
+// throttleDownload charges n bytes to the global and item buckets and sleeps
+// off whichever is further behind, returning early if the item is cancelled.
+func throttleDownload(itemID string, item *byteRateLimiter, n int) error {
+	globalRate, itemRate := bandwidthLimits()
+	if globalRate <= 0 && itemRate <= 0 {
+		return nil
+	}
+	wait := max(globalBandwidthLimiter.reserve(n, globalRate), item.reserve(n, itemRate))
+	for wait > 0 {
+		if itemID != "" && isDownloadCancelled(itemID) {
+			return ErrDownloadCancelled
+		}
+		slice := min(wait, bandwidthSleepSlice)
+		time.Sleep(slice)
+		wait -= slice
+	}
+	return nil
+}
+
+// throttledReader applies the bandwidth limits to a response body that is
+// not copied through an ItemProgressWriter. Readers for the same item share
+// one limiter so the per-item cap spans all of them.
+type throttledReader struct {
+	reader  io.Reader
+	itemID  string
+	limiter *byteRateLimiter
+}
+
+func newThrottledReader(r io.Reader, itemID string, limiter *byteRateLimiter) *throttledReader {
+	return &throttledReader{reader: r, itemID: itemID, limiter: limiter}
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	n, err := r.reader.Read(p)
+	if n > 0 {
+		if throttleErr := throttleDownload(r.itemID, r.limiter, n); throttleErr != nil {
+			return n, throttleErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,105 @@
+package gobackend
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func resetBandwidthForTest(t *testing.T) {
+	t.Cleanup(func() {
+		SetBandwidthSettings("")
+		SetNetworkMetered(false)
+	})
+}
+
+func TestByteRateLimiterReserve(t *testing.T) {
+	var l byteRateLimiter
+	const rate = 100 * 1024
+
+	if wait := l.reserve(rate, rate); wait != 0 {
+		t.Fatalf("first second of traffic should pass immediately, waited %v", wait)
+	}
+	wait := l.reserve(rate/2, rate)
+	if wait < 450*time.Millisecond || wait > 550*time.Millisecond {
+		t.Fatalf("expected about 500ms wait for half a second of overdraft, got %v", wait)
+	}
+	if wait := l.reserve(10*rate, 0); wait != 0 {
+		t.Fatalf("unlimited rate must never wait, got %v", wait)
+	}
+}
+
+func TestBandwidthLimitsUseMeteredCap(t *testing.T) {
+	resetBandwidthForTest(t)
+
+	if err := SetBandwidthSettings(`{"global_limit_kbps":2048,"item_limit_kbps":512,"metered":{"limit_kbps":256}}`); err != nil {
+		t.Fatalf("SetBandwidthSettings: %v", err)
+	}
+	global, item := bandwidthLimits()
+	if global != 2048*1024 || item != 512*1024 {
+		t.Fatalf("unexpected unmetered limits: %v %v", global, item)
+	}
+
+	SetNetworkMetered(true)
+	if global, _ := bandwidthLimits(); global != 256*1024 {
+		t.Fatalf("expected metered cap to apply, got %v", global)
+	}
+
+	if err := SetBandwidthSettings(`{"metered":{"max_quality":"flac"}}`); err == nil {
+		t.Fatal("expected unknown max_quality to be rejected")
+	}
+	if err := SetBandwidthSettings(`{"global_limit_kbps":-1}`); err == nil {
+		t.Fatal("expected negative limit to be rejected")
+	}
+}
+
+func TestMeteredDownloadPolicy(t *testing.T) {
+	resetBandwidthForTest(t)
+
+	if err := SetBandwidthSettings(`{"metered":{"max_quality":"lossless","skip_cover_and_lyrics":true}}`); err != nil {
+		t.Fatalf("SetBandwidthSettings: %v", err)
+	}
+
+	req := DownloadRequest{Quality: "HI_RES_LOSSLESS", CoverURL: "https://example.com/cover.jpg", EmbedLyrics: true}
+	if err := applyMeteredDownloadPolicy(&req); err != nil || req.CoverURL == "" {
+		t.Fatalf("policy must not apply on an unmetered network: %v %+v", err, req)
+	}
+	if got, _ := meteredQualityForBuiltIn("tidal", "HI_RES_LOSSLESS"); got != "HI_RES_LOSSLESS" {
+		t.Fatalf("unexpected unmetered quality %s", got)
+	}
+
+	SetNetworkMetered(true)
+	if err := applyMeteredDownloadPolicy(&req); err != nil {
+		t.Fatalf("applyMeteredDownloadPolicy: %v", err)
+	}
+	if req.CoverURL != "" || req.EmbedLyrics {
+		t.Fatalf("expected cover and lyrics to be skipped: %+v", req)
+	}
+	if got, _ := meteredQualityForBuiltIn("qobuz", normalizeQualityForBuiltIn("alac")); got != "LOSSLESS" {
+		t.Fatalf("expected lossless cap, got %s", got)
+	}
+	if got, _ := meteredQualityForBuiltIn("tidal", "HIGH"); got != "HIGH" {
+		t.Fatalf("lossy quality must not be raised, got %s", got)
+	}
+
+	if err := SetBandwidthSettings(`{"metered":{"max_quality":"lossy"}}`); err != nil {
+		t.Fatalf("SetBandwidthSettings: %v", err)
+	}
+	if got, err := meteredQualityForBuiltIn("tidal", "HI_RES_LOSSLESS"); err != nil || got != "HIGH" {
+		t.Fatalf("expected Tidal AAC under the lossy cap, got %s %v", got, err)
+	}
+	if _, err := meteredQualityForBuiltIn("qobuz", "LOSSLESS"); !errors.Is(err, ErrQualityUnavailable) {
+		t.Fatalf("expected Qobuz to be skipped under the lossy cap, got %v", err)
+	}
+
+	if err := SetBandwidthSettings(`{"metered":{"pause_queue":true}}`); err != nil {
+		t.Fatalf("SetBandwidthSettings: %v", err)
+	}
+	err := applyMeteredDownloadPolicy(&DownloadRequest{})
+	if !errors.Is(err, ErrMeteredNetwork) {
+		t.Fatalf("expected ErrMeteredNetwork, got %v", err)
+	}
+	if classified := classifyDownloadError("", err); !classified.Retryable || downloadErrorTypeOf(classified.Kind) != "metered_paused" {
+		t.Fatalf("unexpected classification %+v", classified)
+	}
+}
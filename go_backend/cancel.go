@@ -3,16 +3,55 @@ package gobackend
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
 )
 
 // ErrDownloadCancelled is returned when a download is cancelled by the user.
 var ErrDownloadCancelled = errors.New("download cancelled")
 
+// DownloadCancelledError reports which stage of a download was aborted by a
+// user cancel. It matches ErrDownloadCancelled with errors.Is.
+type DownloadCancelledError struct {
+	ItemID string
+	Stage  string
+}
+
+func (e *DownloadCancelledError) Error() string {
+	if e.Stage == "" {
+		return ErrDownloadCancelled.Error()
+	}
+	return fmt.Sprintf("%s during %s", ErrDownloadCancelled.Error(), e.Stage)
+}
+
+func (e *DownloadCancelledError) Is(target error) bool {
+	return target == ErrDownloadCancelled
+}
+
+// asDownloadCancelled turns err into a DownloadCancelledError when ctx was
+// cancelled, so callers never mistake an aborted request for a provider
+// failure.
+func asDownloadCancelled(ctx context.Context, itemID, stage string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var cancelled *DownloadCancelledError
+	if errors.As(err, &cancelled) {
+		return err
+	}
+	if ctx.Err() != nil || errors.Is(err, ErrDownloadCancelled) {
+		return &DownloadCancelledError{ItemID: itemID, Stage: stage}
+	}
+	return err
+}
+
+// cancelEntry is shared by nested stages of one download; refs counts the
+// initDownloadCancel calls still waiting for their clearDownloadCancel.
 type cancelEntry struct {
 	ctx      context.Context
 	cancel   context.CancelFunc
 	canceled bool
+	refs     int
 }
 
 var (
@@ -28,25 +67,41 @@ func initDownloadCancel(itemID string) context.Context {
 	cancelMu.Lock()
 	defer cancelMu.Unlock()
 
-	if entry, ok := cancelMap[itemID]; ok {
-		if entry.ctx == nil {
-			ctx, cancel := context.WithCancel(context.Background())
-			entry.ctx = ctx
-			entry.cancel = cancel
-			if entry.canceled && entry.cancel != nil {
-				entry.cancel()
-			}
-		}
-		return entry.ctx
+	entry := downloadCancelEntryLocked(itemID)
+	entry.refs++
+	return entry.ctx
+}
+
+// downloadCancelContext returns the context of itemID without taking a
+// reference, for requests made on behalf of a download that is already
+// running. It never adds an entry: nothing would clear it once the request
+// finishes.
+func downloadCancelContext(itemID string) context.Context {
+	if itemID == "" {
+		return context.Background()
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
-	cancelMap[itemID] = &cancelEntry{
-		ctx:      ctx,
-		cancel:   cancel,
-		canceled: false,
+	cancelMu.Lock()
+	defer cancelMu.Unlock()
+	if _, ok := cancelMap[itemID]; !ok {
+		return withDownloadItemID(context.Background(), itemID)
 	}
-	return ctx
+	return downloadCancelEntryLocked(itemID).ctx
+}
+
+func downloadCancelEntryLocked(itemID string) *cancelEntry {
+	entry, ok := cancelMap[itemID]
+	if !ok {
+		entry = &cancelEntry{}
+		cancelMap[itemID] = entry
+	}
+	if entry.ctx == nil {
+		entry.ctx, entry.cancel = context.WithCancel(withDownloadItemID(context.Background(), itemID))
+		if entry.canceled {
+			entry.cancel()
+		}
+	}
+	return entry
 }
 
 func cancelDownload(itemID string) {
@@ -87,6 +142,16 @@ func clearDownloadCancel(itemID string) {
 	}
 
 	cancelMu.Lock()
-	delete(cancelMap, itemID)
-	cancelMu.Unlock()
+	defer cancelMu.Unlock()
+	entry, ok := cancelMap[itemID]
+	if !ok {
+		return
+	}
+	entry.refs--
+	if entry.refs <= 0 {
+		if entry.cancel != nil {
+			entry.cancel()
+		}
+		delete(cancelMap, itemID)
+	}
 }
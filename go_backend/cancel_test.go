@@ -0,0 +1,88 @@
+package gobackend
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCancelDownloadAbortsInFlightURLFetch(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-release:
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	const itemID = "cancel-url-fetch"
+	ctx := initDownloadCancel(itemID)
+	defer clearDownloadCancel(itemID)
+
+	time.AfterFunc(100*time.Millisecond, func() { cancelDownload(itemID) })
+	start := time.Now()
+	_, err := fetchTidalURLWithRetry(ctx, server.URL, 1, "LOSSLESS", 30*time.Second)
+	if err == nil {
+		t.Fatal("expected cancelled fetch to fail")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("fetch took %v after cancel", elapsed)
+	}
+
+	err = asDownloadCancelled(ctx, itemID, "download URL lookup", err)
+	var cancelled *DownloadCancelledError
+	if !errors.As(err, &cancelled) || cancelled.Stage != "download URL lookup" {
+		t.Fatalf("expected DownloadCancelledError, got %v", err)
+	}
+	if !errors.Is(err, ErrDownloadCancelled) {
+		t.Fatal("DownloadCancelledError should match ErrDownloadCancelled")
+	}
+}
+
+func TestNestedDownloadCancelSharesContext(t *testing.T) {
+	const itemID = "cancel-nested"
+	outer := initDownloadCancel(itemID)
+	defer clearDownloadCancel(itemID)
+
+	inner := initDownloadCancel(itemID)
+	if inner != outer {
+		t.Fatal("nested init should return the running download's context")
+	}
+	clearDownloadCancel(itemID)
+
+	cancelDownload(itemID)
+	select {
+	case <-outer.Done():
+	case <-time.After(time.Second):
+		t.Fatal("cancel after an inner clear should still reach the outer context")
+	}
+}
+
+func TestDownloadCancelContextDoesNotLeakEntries(t *testing.T) {
+	const itemID = "cancel-lookup"
+	if ctx := downloadCancelContext(itemID); ctx.Err() != nil {
+		t.Fatalf("unknown item should get a live context, got %v", ctx.Err())
+	}
+	cancelMu.Lock()
+	_, leaked := cancelMap[itemID]
+	cancelMu.Unlock()
+	if leaked {
+		t.Fatal("looking up an unknown item must not add a cancel entry")
+	}
+
+	ctx := initDownloadCancel(itemID)
+	if got := downloadCancelContext(itemID); got != ctx {
+		t.Fatal("lookup should return the running download's context")
+	}
+	clearDownloadCancel(itemID)
+	cancelMu.Lock()
+	_, leaked = cancelMap[itemID]
+	cancelMu.Unlock()
+	if leaked {
+		t.Fatal("entry should be removed once the download clears it")
+	}
+}
@@ -1,6 +1,7 @@
 package gobackend
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
@@ -26,7 +27,7 @@ func convertSmallToMedium(imageURL string) string {
 	return imageURL
 }
 
-func downloadCoverToMemory(coverURL string, maxQuality bool) ([]byte, error) {
+func downloadCoverToMemory(ctx context.Context, coverURL string, maxQuality bool) ([]byte, error) {
 	if coverURL == "" {
 		return nil, fmt.Errorf("no cover URL provided")
 	}
@@ -52,7 +53,7 @@ func downloadCoverToMemory(coverURL string, maxQuality bool) ([]byte, error) {
 
 	client := NewHTTPClientWithTimeout(DefaultTimeout)
 
-	req, err := http.NewRequest("GET", downloadURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -1306,7 +1306,14 @@ func (c *DeezerClient) doGetJSON(ctx context.Context, endpoint string, dst inter
 	}
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("deezer API returned status %d: %s", resp.StatusCode, string(body))
+		err := fmt.Errorf("deezer API returned status %d: %s", resp.StatusCode, string(body))
+		switch resp.StatusCode {
+		case http.StatusTooManyRequests:
+			return newDownloadError("deezer", ErrRateLimited, err)
+		case http.StatusUnauthorized:
+			return newDownloadError("deezer", ErrAuthExpired, err)
+		}
+		return err
 	}
 
 	return json.Unmarshal(body, dst)
@@ -0,0 +1,257 @@
+package gobackend
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"time"
+)
+
+type DiagnosticsBundleRequest struct {
+	OutputPath string         `json:"output_path"`
+	Settings   map[string]any `json:"settings,omitempty"`
+}
+
+type DiagnosticsBundleResult struct {
+	Path      string   `json:"path"`
+	SizeBytes int64    `json:"size_bytes"`
+	Files     []string `json:"files"`
+}
+
+var (
+	sensitiveSettingKeyPattern = regexp.MustCompile(`(?i)(password|passwd|secret|token|cookie|session|api[_-]?key|auth|credential|private)`)
+	urlUserInfoPattern         = regexp.MustCompile(`(?i)([a-z][a-z0-9+.-]*://)[^/@\s]+@`)
+)
+
+func redactDiagnosticsString(value string) string {
+	value = urlUserInfoPattern.ReplaceAllString(value, "${1}[REDACTED]@")
+	return sanitizeSensitiveLogText(value)
+}
+
+// redactDiagnosticsValue walks decoded JSON, blanking strings under sensitive
+// keys and scrubbing credentials out of the remaining ones.
+func redactDiagnosticsValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			if text, ok := item.(string); ok && text != "" && sensitiveSettingKeyPattern.MatchString(key) {
+				out[key] = "[REDACTED]"
+				continue
+			}
+			out[key] = redactDiagnosticsValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redactDiagnosticsValue(item)
+		}
+		return out
+	case string:
+		return redactDiagnosticsString(v)
+	}
+	return value
+}
+
+// backendDiagnosticsSettings gathers the settings the backend holds itself.
+func backendDiagnosticsSettings() map[string]any {
+	settings := map[string]any{
+		"provider_priority":      GetProviderPriority(),
+		"songlink_region":        GetSongLinkRegion(),
+		"logging_enabled":        GetLogBuffer().IsLoggingEnabled(),
+		"extension_fallback_ids": GetExtensionFallbackProviderIDs(),
+	}
+	getters := map[string]func() (string, error){
+		"proxy":      GetProxySettings,
+		"dns":        GetDNSOverHTTPSSettings,
+		"log_levels": GetLogLevels,
+		"log_file":   GetLogFileSettings,
+		"tls":        GetTLSFingerprintSettings,
+		"bandwidth":  GetBandwidthSettings,
+	}
+	for key, get := range getters {
+		raw, err := get()
+		if err != nil {
+			settings[key] = map[string]any{"error": err.Error()}
+			continue
+		}
+		var decoded any
+		if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+			continue
+		}
+		settings[key] = decoded
+	}
+	return settings
+}
+
+func diagnosticsExtensions() []map[string]any {
+	extensions := getExtensionManager().GetAllExtensions()
+	list := make([]map[string]any, 0, len(extensions))
+	for _, ext := range extensions {
+		info := map[string]any{
+			"id":      ext.ID,
+			"enabled": ext.Enabled,
+		}
+		if ext.Manifest != nil {
+			info["name"] = ext.Manifest.Name
+			info["version"] = ext.Manifest.Version
+			info["types"] = ext.Manifest.Types
+		}
+		if ext.Error != "" {
+			info["error"] = redactDiagnosticsString(ext.Error)
+		}
+		list = append(list, info)
+	}
+	return list
+}
+
+func diagnosticsVersions() map[string]any {
+	return map[string]any{
+		"app_version": GetAppVersion(),
+		"go_version":  runtime.Version(),
+		"os":          runtime.GOOS,
+		"arch":        runtime.GOARCH,
+		"created_at":  time.Now().Format(logTimestampFormat),
+	}
+}
+
+// CreateDiagnosticsBundle zips the logs, redacted settings, extension list,
+// versions, provider health and download traces into one file for bug
+// reports.
+func CreateDiagnosticsBundle(requestJSON string) (string, error) {
+	var req DiagnosticsBundleRequest
+	if err := json.Unmarshal([]byte(requestJSON), &req); err != nil {
+		return "", fmt.Errorf("invalid diagnostics request: %w", err)
+	}
+	req.OutputPath = strings.TrimSpace(req.OutputPath)
+	if req.OutputPath == "" {
+		return "", fmt.Errorf("output_path is required")
+	}
+	if err := os.MkdirAll(filepath.Dir(req.OutputPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	tmpPath := req.OutputPath + ".tmp"
+	file, err := os.Create(tmpPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to create bundle: %w", err)
+	}
+	zw := zip.NewWriter(file)
+	result := DiagnosticsBundleResult{Path: req.OutputPath}
+
+	writeJSON := func(name string, value any) error {
+		w, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(value); err != nil {
+			return err
+		}
+		result.Files = append(result.Files, name)
+		return nil
+	}
+	writeRaw := func(name, raw string) error {
+		var decoded any
+		if err := json.Unmarshal([]byte(raw), &decoded); err != nil {
+			decoded = raw
+		}
+		return writeJSON(name, decoded)
+	}
+
+	err = func() error {
+		if err := writeJSON("versions.json", diagnosticsVersions()); err != nil {
+			return err
+		}
+		settings := map[string]any{"backend": backendDiagnosticsSettings()}
+		if req.Settings != nil {
+			settings["app"] = req.Settings
+		}
+		if err := writeJSON("settings.json", redactDiagnosticsValue(settings)); err != nil {
+			return err
+		}
+		if err := writeJSON("extensions.json", diagnosticsExtensions()); err != nil {
+			return err
+		}
+		if err := writeJSON("provider_health.json", providerHealth.snapshot()); err != nil {
+			return err
+		}
+		if err := writeJSON("rate_limits.json", hostRateLimiters.snapshot()); err != nil {
+			return err
+		}
+		if traces, err := ExportDownloadTracesJSON(); err == nil {
+			if err := writeRaw("download_traces.json", redactDiagnosticsString(traces)); err != nil {
+				return err
+			}
+		}
+		if err := writeRaw("logs/buffer.json", GetLogs()); err != nil {
+			return err
+		}
+		for _, path := range logSink.logFiles() {
+			if err := addRedactedLogFile(zw, "logs/"+filepath.Base(path), path); err != nil {
+				if os.IsNotExist(err) {
+					continue
+				}
+				return err
+			}
+			result.Files = append(result.Files, "logs/"+filepath.Base(path))
+		}
+		return nil
+	}()
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to write diagnostics bundle: %w", err)
+	}
+	if err := os.Rename(tmpPath, req.OutputPath); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to save diagnostics bundle: %w", err)
+	}
+
+	if info, err := os.Stat(req.OutputPath); err == nil {
+		result.SizeBytes = info.Size()
+	}
+	GoLog("[Diagnostics] Bundle written to %s (%d files, %d bytes)\n", req.OutputPath, len(result.Files), result.SizeBytes)
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+// addRedactedLogFile copies a log file into the bundle line by line, running
+// each line through the redaction again in case it predates a pattern.
+func addRedactedLogFile(zw *zip.Writer, name, path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(src)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if _, err := fmt.Fprintln(w, redactDiagnosticsString(scanner.Text())); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
@@ -0,0 +1,359 @@
+package gobackend
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	dohMinCacheTTL   = 30 * time.Second
+	dohMaxCacheTTL   = time.Hour
+	dohMaxCacheSize  = 512
+	dohQueryTimeout  = 5 * time.Second
+	dohMaxAnswerSize = 64 * 1024
+)
+
+// Endpoints are addressed by IP so the resolver never needs DNS itself.
+var defaultDoHEndpoints = []string{
+	"https://1.1.1.1/dns-query",
+	"https://8.8.8.8/dns-query",
+}
+
+// DNSOverHTTPSSettings enables the RFC 8484 resolver for every backend
+// dialer. Endpoints are tried in order; unless Strict is set, the system
+// resolver is used when all of them fail.
+type DNSOverHTTPSSettings struct {
+	Enabled   bool     `json:"enabled"`
+	Endpoints []string `json:"endpoints,omitempty"`
+	Strict    bool     `json:"strict,omitempty"`
+}
+
+type dohCacheEntry struct {
+	ips       []net.IP
+	expiresAt time.Time
+}
+
+var (
+	dohMu       sync.RWMutex
+	dohSettings DNSOverHTTPSSettings
+
+	dohCacheMu sync.Mutex
+	dohCache   = make(map[string]dohCacheEntry)
+
+	systemLookupIP = net.DefaultResolver.LookupIP
+
+	// dohHTTPClient dials endpoints with the system resolver and no proxy.
+	dohHTTPClient = &http.Client{
+		Transport: &http.Transport{
+			DialContext: (&net.Dialer{
+				Timeout:   10 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			MaxIdleConnsPerHost: 2,
+			IdleConnTimeout:     90 * time.Second,
+			TLSHandshakeTimeout: 10 * time.Second,
+			ForceAttemptHTTP2:   true,
+		},
+		Timeout: dohQueryTimeout,
+	}
+)
+
+func getDoHSettings() DNSOverHTTPSSettings {
+	dohMu.RLock()
+	defer dohMu.RUnlock()
+	return dohSettings
+}
+
+func isDoHEnabled() bool {
+	return getDoHSettings().Enabled
+}
+
+func SetDNSOverHTTPSSettings(settingsJSON string) error {
+	var settings DNSOverHTTPSSettings
+	if strings.TrimSpace(settingsJSON) != "" {
+		if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
+			return fmt.Errorf("invalid DNS-over-HTTPS settings: %w", err)
+		}
+	}
+	endpoints := make([]string, 0, len(settings.Endpoints))
+	for _, endpoint := range settings.Endpoints {
+		endpoint = strings.TrimSpace(endpoint)
+		if endpoint == "" {
+			continue
+		}
+		if !strings.HasPrefix(strings.ToLower(endpoint), "https://") {
+			return fmt.Errorf("DNS-over-HTTPS endpoint must use https: %s", endpoint)
+		}
+		endpoints = append(endpoints, endpoint)
+	}
+	settings.Endpoints = endpoints
+
+	dohMu.Lock()
+	dohSettings = settings
+	dohMu.Unlock()
+
+	clearDoHCache()
+	privateIPCacheMu.Lock()
+	privateIPCache = make(map[string]privateIPCacheEntry)
+	privateIPCacheMu.Unlock()
+	CloseIdleConnections()
+
+	GoLog("[DoH] DNS-over-HTTPS enabled=%v endpoints=%d strict=%v\n", settings.Enabled, len(settings.Endpoints), settings.Strict)
+	return nil
+}
+
+func GetDNSOverHTTPSSettings() (string, error) {
+	jsonBytes, err := json.Marshal(getDoHSettings())
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+func clearDoHCache() {
+	dohCacheMu.Lock()
+	dohCache = make(map[string]dohCacheEntry)
+	dohCacheMu.Unlock()
+}
+
+func getDoHCache(host string) ([]net.IP, bool) {
+	dohCacheMu.Lock()
+	defer dohCacheMu.Unlock()
+	entry, ok := dohCache[host]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(entry.expiresAt) {
+		delete(dohCache, host)
+		return nil, false
+	}
+	return entry.ips, true
+}
+
+func setDoHCache(host string, ips []net.IP, ttl time.Duration) {
+	ttl = min(max(ttl, dohMinCacheTTL), dohMaxCacheTTL)
+	now := time.Now()
+
+	dohCacheMu.Lock()
+	defer dohCacheMu.Unlock()
+	if len(dohCache) >= dohMaxCacheSize {
+		for key, entry := range dohCache {
+			if now.After(entry.expiresAt) {
+				delete(dohCache, key)
+			}
+		}
+		if len(dohCache) >= dohMaxCacheSize {
+			dohCache = make(map[string]dohCacheEntry)
+		}
+	}
+	dohCache[host] = dohCacheEntry{ips: ips, expiresAt: now.Add(ttl)}
+}
+
+// queryDoH sends one question to endpoint and returns the addresses and the
+// smallest TTL among them.
+func queryDoH(ctx context.Context, endpoint, host string, qtype dnsmessage.Type) ([]net.IP, time.Duration, error) {
+	name, err := dnsmessage.NewName(host + ".")
+	if err != nil {
+		return nil, 0, err
+	}
+	query := dnsmessage.Message{
+		Header: dnsmessage.Header{RecursionDesired: true},
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  qtype,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(packed))
+	if err != nil {
+		return nil, 0, err
+	}
+	req.Header.Set("Content-Type", "application/dns-message")
+	req.Header.Set("Accept", "application/dns-message")
+	resp, err := dohHTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, 0, fmt.Errorf("DoH endpoint returned HTTP %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, dohMaxAnswerSize))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var answer dnsmessage.Message
+	if err := answer.Unpack(body); err != nil {
+		return nil, 0, fmt.Errorf("invalid DoH answer: %w", err)
+	}
+	if answer.RCode == dnsmessage.RCodeNameError {
+		return nil, 0, &net.DNSError{Err: "no such host", Name: host, Server: endpoint, IsNotFound: true}
+	}
+	if answer.RCode != dnsmessage.RCodeSuccess {
+		return nil, 0, fmt.Errorf("DoH query for %s failed: %s", host, answer.RCode)
+	}
+
+	var ips []net.IP
+	ttl := dohMaxCacheTTL
+	for _, resource := range answer.Answers {
+		switch body := resource.Body.(type) {
+		case *dnsmessage.AResource:
+			ips = append(ips, net.IP(body.A[:]))
+		case *dnsmessage.AAAAResource:
+			ips = append(ips, net.IP(body.AAAA[:]))
+		default:
+			continue
+		}
+		ttl = min(ttl, time.Duration(resource.Header.TTL)*time.Second)
+	}
+	return ips, ttl, nil
+}
+
+// resolveDoH looks up A and AAAA records, trying endpoints in order.
+func resolveDoH(ctx context.Context, host string, endpoints []string) ([]net.IP, error) {
+	if ips, ok := getDoHCache(host); ok {
+		return ips, nil
+	}
+
+	var lastErr error
+	for _, endpoint := range endpoints {
+		ips, ttl, err := queryDoH(ctx, endpoint, host, dnsmessage.TypeA)
+		if err != nil {
+			var dnsErr *net.DNSError
+			if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+				return nil, err
+			}
+			lastErr = err
+			continue
+		}
+		if ips6, ttl6, err := queryDoH(ctx, endpoint, host, dnsmessage.TypeAAAA); err == nil && len(ips6) > 0 {
+			ips = append(ips, ips6...)
+			ttl = min(ttl, ttl6)
+		}
+		if len(ips) == 0 {
+			return nil, &net.DNSError{Err: "no addresses", Name: host, Server: endpoint, IsNotFound: true}
+		}
+		setDoHCache(host, ips, ttl)
+		return ips, nil
+	}
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no DNS-over-HTTPS endpoints")
+	}
+	return nil, lastErr
+}
+
+// lookupHostIPs resolves host with DNS-over-HTTPS when enabled, falling back
+// to the system resolver unless the settings are strict.
+func lookupHostIPs(ctx context.Context, host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	settings := getDoHSettings()
+	if !settings.Enabled || host == "localhost" {
+		return systemLookupIP(ctx, "ip", host)
+	}
+
+	endpoints := settings.Endpoints
+	if len(endpoints) == 0 {
+		endpoints = defaultDoHEndpoints
+	}
+	queryCtx, cancel := context.WithTimeout(ctx, dohQueryTimeout*time.Duration(len(endpoints)))
+	defer cancel()
+	ips, err := resolveDoH(queryCtx, strings.ToLower(host), endpoints)
+	if err == nil || settings.Strict {
+		return ips, err
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return nil, err
+	}
+	GoLog("[DoH] Lookup for %s failed, using system resolver: %v\n", host, err)
+	return systemLookupIP(ctx, "ip", host)
+}
+
+// resolvingDial dials addr, resolving the host through lookupHostIPs and
+// trying each address in turn.
+func resolvingDial(ctx context.Context, dialer *net.Dialer, network, addr string) (net.Conn, error) {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil || !isDoHEnabled() || net.ParseIP(host) != nil {
+		return dialer.DialContext(ctx, network, addr)
+	}
+
+	ips, err := lookupHostIPs(ctx, host)
+	if err != nil {
+		return nil, &net.OpError{Op: "dial", Net: network, Err: err}
+	}
+	var lastErr error
+	for _, ip := range ips {
+		if network == "tcp4" && ip.To4() == nil || network == "tcp6" && ip.To4() != nil {
+			continue
+		}
+		conn, err := dialer.DialContext(ctx, network, net.JoinHostPort(ip.String(), port))
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	if lastErr == nil {
+		lastErr = &net.OpError{Op: "dial", Net: network, Err: fmt.Errorf("no usable address for %s", host)}
+	}
+	return nil, lastErr
+}
+
+func newResolvingDialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		return resolvingDial(ctx, dialer, network, addr)
+	}
+}
+
+type resolvedHost struct {
+	Host    string   `json:"host"`
+	IPs     []string `json:"ips"`
+	Private bool     `json:"private"`
+	DoH     bool     `json:"doh"`
+}
+
+// ResolveHost resolves host the way the backend dialers do; it is meant for
+// diagnosing DNS blocking.
+func ResolveHost(host string) (string, error) {
+	host = strings.TrimSpace(host)
+	if host == "" {
+		return "", fmt.Errorf("host is required")
+	}
+	ips, err := lookupHostIPs(context.Background(), host)
+	if err != nil {
+		return "", err
+	}
+	result := resolvedHost{Host: host, DoH: isDoHEnabled()}
+	for _, ip := range ips {
+		result.IPs = append(result.IPs, ip.String())
+		if isPrivateIPAddr(ip) {
+			result.Private = true
+		}
+	}
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
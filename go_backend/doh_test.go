@@ -0,0 +1,115 @@
+package gobackend
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+func newTestDoHServer(t *testing.T, answers map[string]net.IP, queries *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(queries, 1)
+		body, _ := io.ReadAll(r.Body)
+		var query dnsmessage.Message
+		if err := query.Unpack(body); err != nil || len(query.Questions) != 1 {
+			http.Error(w, "bad query", http.StatusBadRequest)
+			return
+		}
+		question := query.Questions[0]
+		answer := dnsmessage.Message{
+			Header:    dnsmessage.Header{ID: query.ID, Response: true},
+			Questions: query.Questions,
+		}
+		ip, ok := answers[question.Name.String()]
+		switch {
+		case !ok:
+			answer.RCode = dnsmessage.RCodeNameError
+		case question.Type == dnsmessage.TypeA && ip.To4() != nil:
+			var a [4]byte
+			copy(a[:], ip.To4())
+			answer.Answers = append(answer.Answers, dnsmessage.Resource{
+				Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: dnsmessage.ClassINET, TTL: 300},
+				Body:   &dnsmessage.AResource{A: a},
+			})
+		}
+		packed, _ := answer.Pack()
+		w.Header().Set("Content-Type", "application/dns-message")
+		_, _ = w.Write(packed)
+	}))
+	t.Cleanup(server.Close)
+
+	origClient := dohHTTPClient
+	dohHTTPClient = server.Client()
+	t.Cleanup(func() {
+		dohHTTPClient = origClient
+		_ = SetDNSOverHTTPSSettings("")
+	})
+	return server
+}
+
+func TestLookupHostIPsUsesDoHAndCache(t *testing.T) {
+	var queries int32
+	server := newTestDoHServer(t, map[string]net.IP{
+		"blocked.example.":  net.ParseIP("93.184.216.34"),
+		"internal.example.": net.ParseIP("10.0.0.5"),
+	}, &queries)
+	if err := SetDNSOverHTTPSSettings(`{"enabled": true, "endpoints": ["` + server.URL + `/dns-query"], "strict": true}`); err != nil {
+		t.Fatalf("SetDNSOverHTTPSSettings failed: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		ips, err := lookupHostIPs(context.Background(), "blocked.example")
+		if err != nil {
+			t.Fatalf("lookup failed: %v", err)
+		}
+		if len(ips) != 1 || ips[0].String() != "93.184.216.34" {
+			t.Fatalf("unexpected ips %v", ips)
+		}
+	}
+	if got := atomic.LoadInt32(&queries); got != 2 {
+		t.Fatalf("expected one A and one AAAA query thanks to the cache, got %d", got)
+	}
+
+	if _, err := lookupHostIPs(context.Background(), "missing.example"); err == nil {
+		t.Fatal("expected NXDOMAIN error")
+	}
+	if !isPrivateIP("internal.example") {
+		t.Fatal("private address resolved via DoH should be rejected")
+	}
+}
+
+func TestLookupHostIPsFallsBackToSystemResolver(t *testing.T) {
+	var queries int32
+	newTestDoHServer(t, nil, &queries)
+	origLookup := systemLookupIP
+	defer func() { systemLookupIP = origLookup }()
+	systemLookupIP = func(ctx context.Context, network, host string) ([]net.IP, error) {
+		return []net.IP{net.ParseIP("203.0.113.7")}, nil
+	}
+
+	if err := SetDNSOverHTTPSSettings(`{"enabled": true, "endpoints": ["https://127.0.0.1:1/dns-query"]}`); err != nil {
+		t.Fatalf("SetDNSOverHTTPSSettings failed: %v", err)
+	}
+	ips, err := lookupHostIPs(context.Background(), "fallback.example")
+	if err != nil || len(ips) != 1 || ips[0].String() != "203.0.113.7" {
+		t.Fatalf("expected system resolver result, got %v, %v", ips, err)
+	}
+
+	if err := SetDNSOverHTTPSSettings(`{"enabled": true, "endpoints": ["https://127.0.0.1:1/dns-query"], "strict": true}`); err != nil {
+		t.Fatalf("SetDNSOverHTTPSSettings failed: %v", err)
+	}
+	if _, err := lookupHostIPs(context.Background(), "fallback.example"); err == nil {
+		t.Fatal("strict mode should not fall back to the system resolver")
+	}
+
+	if err := SetDNSOverHTTPSSettings(`{"enabled": true, "endpoints": ["http://1.1.1.1/dns-query"]}`); err == nil {
+		t.Fatal("expected non-https endpoint to be rejected")
+	}
+}
@@ -0,0 +1,281 @@
+package gobackend
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Download failure kinds. Errors returned by the download pipeline match one
+// of these with errors.Is once they have been classified.
+var (
+	ErrTrackNotFound      = errors.New("track not found")
+	ErrRegionLocked       = errors.New("track is region locked")
+	ErrQualityUnavailable = errors.New("requested quality unavailable")
+	ErrRateLimited        = errors.New("rate limited")
+	ErrISPBlocked         = errors.New("blocked by ISP")
+	ErrDiskFull           = errors.New("no space left on device")
+	ErrAuthExpired        = errors.New("authentication expired")
+	ErrExtensionCrashed   = errors.New("extension crashed")
+	ErrNetwork            = errors.New("network error")
+	ErrPermissionDenied   = errors.New("permission denied")
+	ErrIntegrityFailed    = errors.New("integrity check failed")
+	ErrMeteredNetwork     = errors.New("download queue paused on metered network")
+)
+
+// downloadErrorKinds maps each kind to its DownloadResponse.ErrorType and
+// whether trying again later may succeed. Order decides which kind wins when
+// an error matches several.
+var downloadErrorKinds = []struct {
+	kind      error
+	errorType string
+	retryable bool
+}{
+	{ErrDownloadCancelled, "cancelled", false},
+	{ErrMeteredNetwork, "metered_paused", true},
+	{ErrISPBlocked, "isp_blocked", false},
+	{ErrIntegrityFailed, "integrity_failed", true},
+	{ErrDiskFull, "disk_full", false},
+	{ErrPermissionDenied, "permission", false},
+	{ErrAuthExpired, "auth_expired", false},
+	{ErrRegionLocked, "region_locked", false},
+	{ErrQualityUnavailable, "quality_unavailable", false},
+	{ErrRateLimited, "rate_limit", true},
+	{ErrExtensionCrashed, "extension_crashed", true},
+	{ErrNetwork, "network", true},
+	{ErrTrackNotFound, "not_found", false},
+}
+
+// DownloadError tags a provider failure with its kind. Error keeps the
+// wrapped message so callers matching on text see no difference.
+type DownloadError struct {
+	Kind      error
+	Provider  string
+	Retryable bool
+	Err       error
+}
+
+func (e *DownloadError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	if e.Kind != nil {
+		return e.Kind.Error()
+	}
+	return "download failed"
+}
+
+func (e *DownloadError) Unwrap() []error {
+	errs := make([]error, 0, 2)
+	if e.Kind != nil {
+		errs = append(errs, e.Kind)
+	}
+	if e.Err != nil {
+		errs = append(errs, e.Err)
+	}
+	return errs
+}
+
+func newDownloadError(provider string, kind, err error) *DownloadError {
+	return &DownloadError{
+		Kind:      kind,
+		Provider:  provider,
+		Retryable: downloadErrorKindRetryable(kind),
+		Err:       err,
+	}
+}
+
+// classifyDownloadError returns err as a *DownloadError, keeping the kind of
+// one already in its chain and inferring it otherwise. Kind stays nil when
+// nothing matches.
+func classifyDownloadError(provider string, err error) *DownloadError {
+	if err == nil {
+		return nil
+	}
+	var downloadErr *DownloadError
+	if errors.As(err, &downloadErr) {
+		if downloadErr == err && (downloadErr.Provider != "" || provider == "") {
+			return downloadErr
+		}
+		if downloadErr.Provider != "" {
+			provider = downloadErr.Provider
+		}
+		return &DownloadError{
+			Kind:      downloadErr.Kind,
+			Provider:  provider,
+			Retryable: downloadErr.Retryable,
+			Err:       err,
+		}
+	}
+	return newDownloadError(provider, downloadErrorKindOf(err), err)
+}
+
+// classifyDownloadErrorOr is classifyDownloadError with a kind to fall back
+// on when the error itself gives no hint.
+func classifyDownloadErrorOr(provider string, fallback, err error) error {
+	classified := classifyDownloadError(provider, err)
+	if classified == nil {
+		return nil
+	}
+	if classified.Kind == nil {
+		classified.Kind = fallback
+		classified.Retryable = downloadErrorKindRetryable(fallback)
+	}
+	return classified
+}
+
+func downloadErrorKindOf(err error) error {
+	for _, entry := range downloadErrorKinds {
+		if errors.Is(err, entry.kind) {
+			return entry.kind
+		}
+	}
+	if errors.Is(err, syscall.ENOSPC) {
+		return ErrDiskFull
+	}
+	if errors.Is(err, os.ErrPermission) {
+		return ErrPermissionDenied
+	}
+	var jsErr *JSExecutionError
+	if errors.As(err, &jsErr) {
+		if jsErr.IsTimeout {
+			return ErrNetwork
+		}
+		return ErrExtensionCrashed
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return ErrNetwork
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return ErrNetwork
+	}
+	return downloadErrorKindFromMessage(err.Error())
+}
+
+// downloadErrorKindFromMessage covers errors that only arrive as text, such
+// as extension error messages and aggregated mirror failures.
+func downloadErrorKindFromMessage(msg string) error {
+	lowerMsg := strings.ToLower(msg)
+	containsAny := func(needles ...string) bool {
+		for _, needle := range needles {
+			if strings.Contains(lowerMsg, needle) {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch {
+	case containsAny("isp blocking", "try using vpn", "change dns"):
+		return ErrISPBlocked
+	case containsAny("cancel"):
+		return ErrDownloadCancelled
+	case containsAny("integrity check failed"):
+		return ErrIntegrityFailed
+	case containsAny("no space left", "disk full", "storage full"):
+		return ErrDiskFull
+	case containsAny("permission", "operation not permitted", "access denied",
+		"failed to create file", "failed to create directory"):
+		return ErrPermissionDenied
+	case containsAny("http 401", "status 401", "unauthorized", "token expired",
+		"session expired", "login required", "auth expired"):
+		return ErrAuthExpired
+	case containsAny("region locked", "region-locked", "not available in your region",
+		"not available in your country", "geo-restricted", "preview instead of full"):
+		return ErrRegionLocked
+	case containsAny("quality unavailable", "quality not available", "no quality available"):
+		return ErrQualityUnavailable
+	case containsAny("not found", "not available", "no results", "all services failed"):
+		return ErrTrackNotFound
+	case containsAny("rate limit", "rate limited", "429", "too many requests"):
+		return ErrRateLimited
+	case containsAny("network", "connection", "timeout", "dial"):
+		return ErrNetwork
+	}
+	return nil
+}
+
+func downloadErrorKindRetryable(kind error) bool {
+	for _, entry := range downloadErrorKinds {
+		if entry.kind == kind {
+			return entry.retryable
+		}
+	}
+	return false
+}
+
+func downloadErrorTypeOf(kind error) string {
+	for _, entry := range downloadErrorKinds {
+		if entry.kind == kind {
+			return entry.errorType
+		}
+	}
+	return "unknown"
+}
+
+// DownloadAttempt is one provider's outcome within a single download.
+type DownloadAttempt struct {
+	Provider   string `json:"provider"`
+	Success    bool   `json:"success"`
+	Error      string `json:"error,omitempty"`
+	ErrorType  string `json:"error_type,omitempty"`
+	Retryable  bool   `json:"retryable,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type downloadAttempts struct {
+	list []DownloadAttempt
+}
+
+func (a *downloadAttempts) record(provider string, start time.Time, err error) {
+	attempt := DownloadAttempt{
+		Provider:   provider,
+		Success:    err == nil,
+		DurationMs: time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		classified := classifyDownloadError(provider, err)
+		attempt.Error = err.Error()
+		attempt.ErrorType = downloadErrorTypeOf(classified.Kind)
+		attempt.Retryable = classified.Retryable
+	}
+	a.list = append(a.list, attempt)
+}
+
+// applyDownloadError fills the failure fields of resp from err. fallbackType
+// is used when err has no recognisable kind.
+func applyDownloadError(resp *DownloadResponse, err error, fallbackType string) {
+	classified := classifyDownloadError(resp.Service, err)
+	if classified == nil {
+		return
+	}
+	resp.Success = false
+	resp.ErrorType = downloadErrorTypeOf(classified.Kind)
+	if classified.Kind == nil && fallbackType != "" {
+		resp.ErrorType = fallbackType
+	}
+	resp.Retryable = classified.Retryable
+}
+
+// extensionDownloadError turns a failed extension result into a typed error.
+// Extensions may report one of the DownloadResponse error types themselves.
+func extensionDownloadError(providerID string, result *ExtDownloadResult) error {
+	err := errors.New(result.ErrorMessage)
+	switch result.ErrorType {
+	case "init_error", "script_error", "internal_error":
+		return newDownloadError(providerID, ErrExtensionCrashed, err)
+	case "timeout":
+		return newDownloadError(providerID, ErrNetwork, err)
+	}
+	for _, entry := range downloadErrorKinds {
+		if entry.errorType == result.ErrorType {
+			return newDownloadError(providerID, entry.kind, err)
+		}
+	}
+	return classifyDownloadError(providerID, err)
+}
@@ -0,0 +1,106 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestClassifyDownloadErrorKinds(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		kind      error
+		errorType string
+		retryable bool
+	}{
+		{"typed rate limit", newDownloadError("tidal", ErrRateLimited, fmt.Errorf("rate limited")), ErrRateLimited, "rate_limit", true},
+		{"wrapped typed", fmt.Errorf("failed to get download URL: %w", newDownloadError("tidal", ErrRegionLocked, fmt.Errorf("returned PREVIEW instead of FULL"))), ErrRegionLocked, "region_locked", false},
+		{"isp blocking", &ISPBlockingError{Domain: "example.com", Reason: "reset"}, ErrISPBlocked, "isp_blocked", false},
+		{"integrity", &FLACIntegrityError{}, ErrIntegrityFailed, "integrity_failed", true},
+		{"disk full", fmt.Errorf("write failed: %w", syscall.ENOSPC), ErrDiskFull, "disk_full", false},
+		{"cancelled", &DownloadCancelledError{Stage: "audio download"}, ErrDownloadCancelled, "cancelled", false},
+		{"script crash", &JSExecutionError{Message: "TypeError: x is undefined"}, ErrExtensionCrashed, "extension_crashed", true},
+		{"auth text", errors.New("HTTP 401 unauthorized"), ErrAuthExpired, "auth_expired", false},
+		{"not found text", errors.New("track not found on Tidal"), ErrTrackNotFound, "not_found", false},
+		{"unknown", errors.New("something odd"), nil, "unknown", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			classified := classifyDownloadError("tidal", tt.err)
+			if classified.Kind != tt.kind {
+				t.Fatalf("kind = %v, want %v", classified.Kind, tt.kind)
+			}
+			if got := downloadErrorTypeOf(classified.Kind); got != tt.errorType {
+				t.Fatalf("error type = %q, want %q", got, tt.errorType)
+			}
+			if classified.Retryable != tt.retryable {
+				t.Fatalf("retryable = %v, want %v", classified.Retryable, tt.retryable)
+			}
+			if classified.Error() != tt.err.Error() {
+				t.Fatalf("message changed: %q", classified.Error())
+			}
+			if tt.kind != nil && !errors.Is(classified, tt.kind) {
+				t.Fatalf("errors.Is(%v) failed", tt.kind)
+			}
+		})
+	}
+}
+
+func TestDownloadErrorKeepsUnderlyingChain(t *testing.T) {
+	cancelled := &DownloadCancelledError{ItemID: "item", Stage: "track resolution"}
+	err := classifyDownloadErrorOr("qobuz", ErrTrackNotFound, cancelled)
+
+	var got *DownloadCancelledError
+	if !errors.As(err, &got) || got.Stage != "track resolution" {
+		t.Fatalf("expected DownloadCancelledError in chain, got %v", err)
+	}
+	if errors.Is(err, ErrTrackNotFound) {
+		t.Fatal("cancelled error should not fall back to not found")
+	}
+
+	err = classifyDownloadErrorOr("qobuz", ErrTrackNotFound, errors.New("no match"))
+	var downloadErr *DownloadError
+	if !errors.As(err, &downloadErr) || downloadErr.Provider != "qobuz" || !errors.Is(err, ErrTrackNotFound) {
+		t.Fatalf("expected qobuz not found error, got %#v", err)
+	}
+}
+
+func TestExtensionDownloadErrorMapsResultType(t *testing.T) {
+	err := extensionDownloadError("ext", &ExtDownloadResult{ErrorMessage: "boom", ErrorType: "script_error"})
+	if !errors.Is(err, ErrExtensionCrashed) {
+		t.Fatalf("script_error should be a crash, got %v", err)
+	}
+	err = extensionDownloadError("ext", &ExtDownloadResult{ErrorMessage: "not in catalog", ErrorType: "region_locked"})
+	if !errors.Is(err, ErrRegionLocked) {
+		t.Fatalf("extension-reported kind should be kept, got %v", err)
+	}
+}
+
+func TestDownloadErrorResponseIncludesAttempts(t *testing.T) {
+	var attempts downloadAttempts
+	attempts.record("tidal", time.Now(), newDownloadError("tidal", ErrRateLimited, errors.New("rate limited")))
+	attempts.record("qobuz", time.Now(), errors.New("track not found"))
+
+	raw, _ := downloadErrorResponse("All services failed. Last error: track not found", errors.New("track not found"), attempts.list)
+	var resp DownloadResponse
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.ErrorType != "not_found" || resp.Retryable {
+		t.Fatalf("unexpected response type: %+v", resp)
+	}
+	if len(resp.Attempts) != 2 {
+		t.Fatalf("attempts = %d, want 2", len(resp.Attempts))
+	}
+	if resp.Attempts[0].ErrorType != "rate_limit" || !resp.Attempts[0].Retryable {
+		t.Fatalf("unexpected first attempt: %+v", resp.Attempts[0])
+	}
+	if resp.Attempts[1].Provider != "qobuz" || resp.Attempts[1].ErrorType != "not_found" {
+		t.Fatalf("unexpected second attempt: %+v", resp.Attempts[1])
+	}
+}
@@ -0,0 +1,298 @@
+package gobackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	maxDownloadTraces      = 100
+	maxDownloadTraceSpans  = 300
+	downloadTraceStatusOK  = "ok"
+	downloadTraceStatusErr = "error"
+)
+
+// TraceSpan is one timed step of a download. Zero-length spans record
+// decisions such as a rejected match or a reordered provider list.
+type TraceSpan struct {
+	Name       string         `json:"name"`
+	Provider   string         `json:"provider,omitempty"`
+	StartedAt  int64          `json:"started_at"`
+	DurationMs int64          `json:"duration_ms"`
+	Status     string         `json:"status"`
+	Error      string         `json:"error,omitempty"`
+	Attrs      map[string]any `json:"attrs,omitempty"`
+}
+
+type DownloadTrace struct {
+	ItemID    string      `json:"item_id"`
+	StartedAt int64       `json:"started_at"`
+	UpdatedAt int64       `json:"updated_at"`
+	Dropped   int         `json:"dropped_spans,omitempty"`
+	Spans     []TraceSpan `json:"spans"`
+
+	active int
+}
+
+var (
+	downloadTracesMu   sync.Mutex
+	downloadTraces     = make(map[string]*DownloadTrace)
+	downloadTraceOrder []string
+)
+
+type downloadItemIDKey struct{}
+
+func withDownloadItemID(ctx context.Context, itemID string) context.Context {
+	return context.WithValue(ctx, downloadItemIDKey{}, itemID)
+}
+
+func downloadItemIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	itemID, _ := ctx.Value(downloadItemIDKey{}).(string)
+	return itemID
+}
+
+// beginDownloadTrace starts a fresh trace for itemID unless a download of the
+// same item is already running, in which case its spans are appended to the
+// running trace. Every call must be paired with endDownloadTrace.
+func beginDownloadTrace(itemID string) {
+	if itemID == "" {
+		return
+	}
+	downloadTracesMu.Lock()
+	defer downloadTracesMu.Unlock()
+
+	trace, ok := downloadTraces[itemID]
+	if ok && trace.active > 0 {
+		trace.active++
+		return
+	}
+	now := time.Now().UnixMilli()
+	if !ok {
+		downloadTraceOrder = append(downloadTraceOrder, itemID)
+		for len(downloadTraceOrder) > maxDownloadTraces {
+			delete(downloadTraces, downloadTraceOrder[0])
+			downloadTraceOrder = downloadTraceOrder[1:]
+		}
+	}
+	downloadTraces[itemID] = &DownloadTrace{ItemID: itemID, StartedAt: now, UpdatedAt: now, active: 1}
+}
+
+func endDownloadTrace(itemID string) {
+	if itemID == "" {
+		return
+	}
+	downloadTracesMu.Lock()
+	defer downloadTracesMu.Unlock()
+	if trace, ok := downloadTraces[itemID]; ok && trace.active > 0 {
+		trace.active--
+	}
+}
+
+func appendTraceSpan(itemID string, span TraceSpan) {
+	if itemID == "" {
+		return
+	}
+	if span.Error != "" {
+		span.Error = sanitizeSensitiveLogText(span.Error)
+	}
+	downloadTracesMu.Lock()
+	defer downloadTracesMu.Unlock()
+
+	trace, ok := downloadTraces[itemID]
+	if !ok {
+		return
+	}
+	trace.UpdatedAt = time.Now().UnixMilli()
+	if len(trace.Spans) >= maxDownloadTraceSpans {
+		trace.Dropped++
+		return
+	}
+	trace.Spans = append(trace.Spans, span)
+}
+
+// traceSpan times one step of a download. A nil *traceSpan is valid and
+// records nothing, so callers need not check whether tracing applies.
+type traceSpan struct {
+	itemID string
+	span   TraceSpan
+	start  time.Time
+}
+
+func startTraceSpan(itemID, name, provider string) *traceSpan {
+	if itemID == "" {
+		return nil
+	}
+	start := time.Now()
+	return &traceSpan{
+		itemID: itemID,
+		span:   TraceSpan{Name: name, Provider: provider, StartedAt: start.UnixMilli()},
+		start:  start,
+	}
+}
+
+func startTraceSpanContext(ctx context.Context, name, provider string) *traceSpan {
+	return startTraceSpan(downloadItemIDFromContext(ctx), name, provider)
+}
+
+func (s *traceSpan) set(key string, value any) *traceSpan {
+	if s == nil {
+		return nil
+	}
+	if s.span.Attrs == nil {
+		s.span.Attrs = make(map[string]any)
+	}
+	s.span.Attrs[key] = value
+	return s
+}
+
+func (s *traceSpan) end(err error) {
+	if s == nil {
+		return
+	}
+	s.span.DurationMs = time.Since(s.start).Milliseconds()
+	s.span.Status = downloadTraceStatusOK
+	if err != nil {
+		s.span.Status = downloadTraceStatusErr
+		s.span.Error = err.Error()
+		if classified := classifyDownloadError(s.span.Provider, err); classified.Kind != nil {
+			s.set("error_type", downloadErrorTypeOf(classified.Kind))
+		}
+	}
+	appendTraceSpan(s.itemID, s.span)
+}
+
+// traceDecision records an instant decision, such as skipping a provider.
+func traceDecision(itemID, name, provider, status string, attrs map[string]any) {
+	if itemID == "" {
+		return
+	}
+	appendTraceSpan(itemID, TraceSpan{
+		Name:      name,
+		Provider:  provider,
+		StartedAt: time.Now().UnixMilli(),
+		Status:    status,
+		Attrs:     attrs,
+	})
+}
+
+// traceMatchDecision records whether a provider's track was accepted for the
+// request, with the similarity scores behind the decision. reason is empty
+// for an accepted match.
+func traceMatchDecision(req DownloadRequest, source, title, artist, isrc string, durationSec int, reason string) {
+	if req.ItemID == "" {
+		return
+	}
+	attrs := map[string]any{
+		"source":       source,
+		"candidate":    strings.TrimSpace(artist + " - " + title),
+		"title_score":  roundTraceScore(calculateStringSimilarity(normalizeStringForMatching(req.TrackName), normalizeStringForMatching(title))),
+		"artist_score": roundTraceScore(calculateStringSimilarity(normalizeStringForMatching(req.ArtistName), normalizeStringForMatching(artist))),
+		"isrc_match":   req.ISRC != "" && strings.EqualFold(strings.TrimSpace(req.ISRC), strings.TrimSpace(isrc)),
+	}
+	if expected := req.DurationMS / 1000; expected > 0 && durationSec > 0 {
+		diff := expected - durationSec
+		if diff < 0 {
+			diff = -diff
+		}
+		attrs["duration_diff_s"] = diff
+	}
+	status := "accepted"
+	if reason != "" {
+		status = "rejected"
+		attrs["reason"] = reason
+	}
+	traceDecision(req.ItemID, "match", "", status, attrs)
+}
+
+func roundTraceScore(score float64) float64 {
+	return float64(int(score*1000+0.5)) / 1000
+}
+
+func GetDownloadTraceJSON(itemID string) (string, error) {
+	downloadTracesMu.Lock()
+	trace, ok := downloadTraces[itemID]
+	var snapshot DownloadTrace
+	if ok {
+		snapshot = *trace
+		snapshot.Spans = append([]TraceSpan(nil), trace.Spans...)
+	}
+	downloadTracesMu.Unlock()
+
+	if !ok {
+		return "", fmt.Errorf("no download trace for item %s", itemID)
+	}
+	jsonBytes, err := json.Marshal(snapshot)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+// ExportDownloadTracesJSON returns every retained trace, oldest first.
+func ExportDownloadTracesJSON() (string, error) {
+	downloadTracesMu.Lock()
+	traces := make([]DownloadTrace, 0, len(downloadTraceOrder))
+	for _, itemID := range downloadTraceOrder {
+		trace := *downloadTraces[itemID]
+		trace.Spans = append([]TraceSpan(nil), trace.Spans...)
+		traces = append(traces, trace)
+	}
+	downloadTracesMu.Unlock()
+
+	jsonBytes, err := json.Marshal(traces)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+func ClearDownloadTrace(itemID string) {
+	downloadTracesMu.Lock()
+	defer downloadTracesMu.Unlock()
+	if _, ok := downloadTraces[itemID]; !ok {
+		return
+	}
+	delete(downloadTraces, itemID)
+	for i, id := range downloadTraceOrder {
+		if id == itemID {
+			downloadTraceOrder = append(downloadTraceOrder[:i], downloadTraceOrder[i+1:]...)
+			break
+		}
+	}
+}
+
+func ClearAllDownloadTraces() {
+	downloadTracesMu.Lock()
+	defer downloadTracesMu.Unlock()
+	downloadTraces = make(map[string]*DownloadTrace)
+	downloadTraceOrder = nil
+}
+
+// endExtensionDownloadSpan closes an extension download span, noting when
+// decryption is left to the app.
+func endExtensionDownloadSpan(span *traceSpan, result *ExtDownloadResult, err error) {
+	if err == nil && result != nil && !result.Success {
+		err = extensionDownloadError(span.provider(), result)
+	}
+	if err == nil && result != nil {
+		span.set("bit_depth", result.BitDepth).set("sample_rate", result.SampleRate)
+		if decryption := normalizeDownloadDecryptionInfo(result.Decryption, result.DecryptionKey); decryption != nil {
+			span.set("decrypt", decryption.Strategy)
+		}
+	}
+	span.end(err)
+}
+
+func (s *traceSpan) provider() string {
+	if s == nil {
+		return ""
+	}
+	return s.span.Provider
+}
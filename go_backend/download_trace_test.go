@@ -0,0 +1,82 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestDownloadTraceRecordsSpansAndMatchDecisions(t *testing.T) {
+	const itemID = "trace-item"
+	defer ClearDownloadTrace(itemID)
+
+	ctx := initDownloadCancel(itemID)
+	defer clearDownloadCancel(itemID)
+	beginDownloadTrace(itemID)
+
+	startTraceSpanContext(ctx, "url_fetch", "qobuz").set("quality", "27").end(errors.New("HTTP 429"))
+
+	req := DownloadRequest{ItemID: itemID, TrackName: "Song", ArtistName: "Artist", DurationMS: 180000}
+	if trackMatchesRequest(req, resolvedTrackInfo{Title: "Song", ArtistName: "Artist", Duration: 240}, "test") {
+		t.Fatal("duration mismatch should be rejected")
+	}
+	endDownloadTrace(itemID)
+
+	raw, err := GetDownloadTraceJSON(itemID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var trace DownloadTrace
+	if err := json.Unmarshal([]byte(raw), &trace); err != nil {
+		t.Fatal(err)
+	}
+	if len(trace.Spans) != 2 {
+		t.Fatalf("spans = %d, want 2: %s", len(trace.Spans), raw)
+	}
+
+	fetch := trace.Spans[0]
+	if fetch.Name != "url_fetch" || fetch.Status != "error" || fetch.Attrs["error_type"] != "rate_limit" {
+		t.Fatalf("unexpected fetch span: %+v", fetch)
+	}
+	match := trace.Spans[1]
+	if match.Name != "match" || match.Status != "rejected" || match.Attrs["reason"] != "duration_mismatch" {
+		t.Fatalf("unexpected match span: %+v", match)
+	}
+	if match.Attrs["title_score"] != 1.0 || match.Attrs["duration_diff_s"] != 60.0 {
+		t.Fatalf("unexpected match scores: %+v", match.Attrs)
+	}
+}
+
+func TestNestedDownloadTraceKeepsRunningTrace(t *testing.T) {
+	const itemID = "trace-nested"
+	defer ClearDownloadTrace(itemID)
+
+	beginDownloadTrace(itemID)
+	traceDecision(itemID, "provider_order", "", "ok", nil)
+	beginDownloadTrace(itemID)
+	traceDecision(itemID, "skip", "ext", "skipped", nil)
+	endDownloadTrace(itemID)
+	endDownloadTrace(itemID)
+
+	raw, err := GetDownloadTraceJSON(itemID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var trace DownloadTrace
+	if err := json.Unmarshal([]byte(raw), &trace); err != nil {
+		t.Fatal(err)
+	}
+	if len(trace.Spans) != 2 {
+		t.Fatalf("nested begin should not reset the trace: %s", raw)
+	}
+
+	beginDownloadTrace(itemID)
+	endDownloadTrace(itemID)
+	raw, _ = GetDownloadTraceJSON(itemID)
+	if err := json.Unmarshal([]byte(raw), &trace); err != nil {
+		t.Fatal(err)
+	}
+	if len(trace.Spans) != 0 {
+		t.Fatalf("a new download should start a fresh trace: %s", raw)
+	}
+}
@@ -83,6 +83,10 @@ func formatMusicBrainzGenre(tags []musicBrainzTag) string {
 }
 
 func FetchMusicBrainzGenreByISRC(isrc string) (string, error) {
+	return fetchMusicBrainzGenreByISRCContext(context.Background(), isrc)
+}
+
+func fetchMusicBrainzGenreByISRCContext(ctx context.Context, isrc string) (string, error) {
 	normalizedISRC := strings.ToUpper(strings.TrimSpace(isrc))
 	if normalizedISRC == "" {
 		return "", fmt.Errorf("no ISRC provided")
@@ -96,7 +100,7 @@ func FetchMusicBrainzGenreByISRC(isrc string) (string, error) {
 		url.QueryEscape(query),
 	)
 
-	req, err := http.NewRequest(http.MethodGet, reqURL, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
 	if err != nil {
 		return "", err
 	}
@@ -105,6 +109,9 @@ func FetchMusicBrainzGenreByISRC(isrc string) (string, error) {
 	var resp *http.Response
 	var lastErr error
 	for attempt := 0; attempt < 3; attempt++ {
+		if err := musicBrainzRateLimiter.Wait(ctx); err != nil {
+			return "", err
+		}
 		resp, lastErr = client.Do(req)
 		if lastErr == nil && resp.StatusCode == http.StatusOK {
 			break
@@ -113,7 +120,9 @@ func FetchMusicBrainzGenreByISRC(isrc string) (string, error) {
 			resp.Body.Close()
 		}
 		if attempt < 2 {
-			time.Sleep(2 * time.Second)
+			if err := sleepWithContext(ctx, 2*time.Second); err != nil {
+				return "", err
+			}
 		}
 	}
 
@@ -178,10 +187,18 @@ type DownloadRequest struct {
 	TidalID              string `json:"tidal_id,omitempty"`
 	QobuzID              string `json:"qobuz_id,omitempty"`
 	DeezerID             string `json:"deezer_id,omitempty"`
+	UPC                  string `json:"upc,omitempty"`
 	LyricsMode           string `json:"lyrics_mode,omitempty"`
+	LyricsFormat         string `json:"lyrics_format,omitempty"`
 	UseExtensions        bool   `json:"use_extensions,omitempty"`
 	UseFallback          bool   `json:"use_fallback,omitempty"`
 	SongLinkRegion       string `json:"songlink_region,omitempty"`
+	VerifyIntegrity      bool   `json:"verify_integrity,omitempty"`
+	AlbumID              string `json:"album_id,omitempty"`
+	AlbumTrackCount      int    `json:"album_track_count,omitempty"`
+	AutoReplayGain       bool   `json:"auto_replaygain,omitempty"`
+
+	ReleaseIdentifiers
 }
 
 type DownloadResponse struct {
@@ -190,6 +207,7 @@ type DownloadResponse struct {
 	FilePath               string                  `json:"file_path,omitempty"`
 	Error                  string                  `json:"error,omitempty"`
 	ErrorType              string                  `json:"error_type,omitempty"`
+	Retryable              bool                    `json:"retryable,omitempty"`
 	AlreadyExists          bool                    `json:"already_exists,omitempty"`
 	ActualBitDepth         int                     `json:"actual_bit_depth,omitempty"`
 	ActualSampleRate       int                     `json:"actual_sample_rate,omitempty"`
@@ -213,6 +231,8 @@ type DownloadResponse struct {
 	LyricsLRC              string                  `json:"lyrics_lrc,omitempty"`
 	DecryptionKey          string                  `json:"decryption_key,omitempty"`
 	Decryption             *DownloadDecryptionInfo `json:"decryption,omitempty"`
+	Attempts               []DownloadAttempt       `json:"attempts,omitempty"`
+	Quality                string                  `json:"quality,omitempty"`
 }
 
 type DownloadResult struct {
@@ -236,13 +256,14 @@ type DownloadResult struct {
 	LyricsLRC     string
 	DecryptionKey string
 	Decryption    *DownloadDecryptionInfo
+	Quality       string
 }
 
 var fetchDeezerExtendedMetadataByISRC = func(ctx context.Context, isrc string) (*AlbumExtendedMetadata, error) {
 	return GetDeezerClient().GetExtendedMetadataByISRC(ctx, isrc)
 }
 
-var fetchMusicBrainzGenreByISRC = FetchMusicBrainzGenreByISRC
+var fetchMusicBrainzGenreByISRC = fetchMusicBrainzGenreByISRCContext
 
 type reEnrichRequest struct {
 	FilePath      string   `json:"file_path"`
@@ -268,6 +289,8 @@ type reEnrichRequest struct {
 	DurationMs    int64    `json:"duration_ms"`
 	SearchOnline  bool     `json:"search_online"`
 	UpdateFields  []string `json:"update_fields,omitempty"`
+
+	ReleaseIdentifiers
 }
 
 // shouldUpdateField returns true if the given field group should be updated.
@@ -336,6 +359,9 @@ func applyReEnrichTrackMetadata(req *reEnrichRequest, track ExtTrackMetadata) {
 		if track.ISRC != "" {
 			req.ISRC = track.ISRC
 		}
+		if !track.ReleaseIdentifiers.IsEmpty() {
+			req.ReleaseIdentifiers = track.ReleaseIdentifiers
+		}
 	}
 	if req.shouldUpdateField("cover") {
 		if coverURL := track.ResolvedCoverURL(); coverURL != "" {
@@ -557,6 +583,8 @@ func extTrackFromTrackMetadata(track *TrackMetadata, providerID string) *ExtTrac
 		DeezerID:    deezerID,
 		SpotifyID:   track.SpotifyID,
 		Composer:    track.Composer,
+
+		ReleaseIdentifiers: track.ReleaseIdentifiers,
 	}
 }
 
@@ -754,6 +782,7 @@ func buildDownloadSuccessResponse(
 		LyricsLRC:        result.LyricsLRC,
 		DecryptionKey:    result.DecryptionKey,
 		Decryption:       normalizeDownloadDecryptionInfo(result.Decryption, result.DecryptionKey),
+		Quality:          result.Quality,
 	}
 }
 
@@ -818,6 +847,7 @@ func applyExtendedMetadataFields(
 }
 
 func enrichExtraMetadataByISRC(
+	ctx context.Context,
 	logPrefix string,
 	isrc string,
 	genre *string,
@@ -829,17 +859,17 @@ func enrichExtraMetadataByISRC(
 		return
 	}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	deezerCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
 	defer cancel()
 
-	extMeta, err := fetchDeezerExtendedMetadataByISRC(ctx, normalizedISRC)
+	extMeta, err := fetchDeezerExtendedMetadataByISRC(deezerCtx, normalizedISRC)
 	if err != nil {
 		GoLog("[%s] Failed to get extended metadata from Deezer: %v\n", logPrefix, err)
 	}
 	applyExtendedMetadataFields(genre, label, copyright, extMeta)
 
 	if genre != nil && *genre == "" {
-		musicBrainzGenre, err := fetchMusicBrainzGenreByISRC(normalizedISRC)
+		musicBrainzGenre, err := fetchMusicBrainzGenreByISRC(ctx, normalizedISRC)
 		if err != nil {
 			GoLog("[%s] Failed to get genre from MusicBrainz: %v\n", logPrefix, err)
 		} else if musicBrainzGenre != "" {
@@ -865,16 +895,19 @@ func enrichExtraMetadataByISRC(
 	}
 }
 
-func enrichRequestExtendedMetadata(req *DownloadRequest) {
+func enrichRequestExtendedMetadata(ctx context.Context, req *DownloadRequest) {
 	if req == nil {
 		return
 	}
 
+	applyMusicBrainzReleaseIdentifiers(ctx, req)
+
 	if req.ISRC == "" || (req.Genre != "" && req.Label != "" && req.Copyright != "") {
 		return
 	}
 
 	enrichExtraMetadataByISRC(
+		ctx,
 		"DownloadWithFallback",
 		req.ISRC,
 		&req.Genre,
@@ -910,14 +943,25 @@ func DownloadTrack(requestJSON string) (string, error) {
 		AddAllowedDownloadDir(req.OutputDir)
 	}
 
-	enrichRequestExtendedMetadata(&req)
+	ctx := initDownloadCancel(req.ItemID)
+	defer clearDownloadCancel(req.ItemID)
+	beginDownloadTrace(req.ItemID)
+	defer endDownloadTrace(req.ItemID)
+
+	if err := applyMeteredDownloadPolicy(&req); err != nil {
+		return downloadErrorResponse(err.Error(), err, nil)
+	}
+
+	enrichRequestExtendedMetadata(ctx, &req)
 
 	var result DownloadResult
 	var err error
+	var attempts downloadAttempts
+	start := time.Now()
 
 	switch req.Service {
 	case "tidal":
-		tidalResult, tidalErr := downloadFromTidal(req)
+		tidalResult, tidalErr := downloadFromTidal(ctx, req)
 		if tidalErr == nil {
 			result = DownloadResult{
 				FilePath:    tidalResult.FilePath,
@@ -930,12 +974,13 @@ func DownloadTrack(requestJSON string) (string, error) {
 				TrackNumber: tidalResult.TrackNumber,
 				DiscNumber:  tidalResult.DiscNumber,
 				ISRC:        tidalResult.ISRC,
+				Quality:     tidalResult.Quality,
 				LyricsLRC:   tidalResult.LyricsLRC,
 			}
 		}
 		err = tidalErr
 	case "qobuz":
-		qobuzResult, qobuzErr := downloadFromQobuz(req)
+		qobuzResult, qobuzErr := downloadFromQobuz(ctx, req)
 		if qobuzErr == nil {
 			result = DownloadResult{
 				FilePath:    qobuzResult.FilePath,
@@ -948,6 +993,7 @@ func DownloadTrack(requestJSON string) (string, error) {
 				TrackNumber: qobuzResult.TrackNumber,
 				DiscNumber:  qobuzResult.DiscNumber,
 				ISRC:        qobuzResult.ISRC,
+				Quality:     qobuzResult.Quality,
 				CoverURL:    qobuzResult.CoverURL,
 				LyricsLRC:   qobuzResult.LyricsLRC,
 			}
@@ -958,7 +1004,8 @@ func DownloadTrack(requestJSON string) (string, error) {
 	}
 
 	if err != nil {
-		return errorResponse(err.Error())
+		attempts.record(req.Service, start, err)
+		return downloadErrorResponse(err.Error(), err, attempts.list)
 	}
 
 	if len(result.FilePath) > 7 && result.FilePath[:7] == "EXISTS:" {
@@ -973,10 +1020,19 @@ func DownloadTrack(requestJSON string) (string, error) {
 			actualPath,
 			true,
 		)
+		attempts.record(req.Service, start, nil)
+		resp.Attempts = attempts.list
+		recordAlbumDownloadForReplayGain(req, resp.FilePath)
 		jsonBytes, _ := json.Marshal(resp)
 		return string(jsonBytes), nil
 	}
 
+	if err := verifyDownloadedFile(req, result.FilePath, result.Decryption); err != nil {
+		attempts.record(req.Service, start, err)
+		return downloadErrorResponse(err.Error(), err, attempts.list)
+	}
+	attempts.record(req.Service, start, nil)
+
 	enrichResultQualityFromFile(&result)
 
 	resp := buildDownloadSuccessResponse(
@@ -987,6 +1043,8 @@ func DownloadTrack(requestJSON string) (string, error) {
 		result.FilePath,
 		false,
 	)
+	resp.Attempts = attempts.list
+	recordAlbumDownloadForReplayGain(req, resp.FilePath)
 
 	jsonBytes, _ := json.Marshal(resp)
 	return string(jsonBytes), nil
@@ -1053,7 +1111,16 @@ func DownloadWithFallback(requestJSON string) (string, error) {
 		AddAllowedDownloadDir(req.OutputDir)
 	}
 
-	enrichRequestExtendedMetadata(&req)
+	ctx := initDownloadCancel(req.ItemID)
+	defer clearDownloadCancel(req.ItemID)
+	beginDownloadTrace(req.ItemID)
+	defer endDownloadTrace(req.ItemID)
+
+	if err := applyMeteredDownloadPolicy(&req); err != nil {
+		return downloadErrorResponse(err.Error(), err, nil)
+	}
+
+	enrichRequestExtendedMetadata(ctx, &req)
 
 	allServices := []string{"tidal", "qobuz"}
 	preferredService := req.Service
@@ -1073,17 +1140,20 @@ func DownloadWithFallback(requestJSON string) (string, error) {
 	GoLog("[DownloadWithFallback] Service order: %v\n", services)
 
 	var lastErr error
+	var attempts downloadAttempts
 
 	for _, service := range services {
 		GoLog("[DownloadWithFallback] Trying service: %s\n", service)
 		req.Service = service
+		start := time.Now()
+		attemptSpan := startTraceSpan(req.ItemID, "attempt", service)
 
 		var result DownloadResult
 		var err error
 
 		switch service {
 		case "tidal":
-			tidalResult, tidalErr := downloadFromTidal(req)
+			tidalResult, tidalErr := downloadFromTidal(ctx, req)
 			if tidalErr == nil {
 				result = DownloadResult{
 					FilePath:    tidalResult.FilePath,
@@ -1096,6 +1166,7 @@ func DownloadWithFallback(requestJSON string) (string, error) {
 					TrackNumber: tidalResult.TrackNumber,
 					DiscNumber:  tidalResult.DiscNumber,
 					ISRC:        tidalResult.ISRC,
+					Quality:     tidalResult.Quality,
 					LyricsLRC:   tidalResult.LyricsLRC,
 				}
 			} else if !errors.Is(tidalErr, ErrDownloadCancelled) {
@@ -1103,7 +1174,7 @@ func DownloadWithFallback(requestJSON string) (string, error) {
 			}
 			err = tidalErr
 		case "qobuz":
-			qobuzResult, qobuzErr := downloadFromQobuz(req)
+			qobuzResult, qobuzErr := downloadFromQobuz(ctx, req)
 			if qobuzErr == nil {
 				result = DownloadResult{
 					FilePath:    qobuzResult.FilePath,
@@ -1116,6 +1187,7 @@ func DownloadWithFallback(requestJSON string) (string, error) {
 					TrackNumber: qobuzResult.TrackNumber,
 					DiscNumber:  qobuzResult.DiscNumber,
 					ISRC:        qobuzResult.ISRC,
+					Quality:     qobuzResult.Quality,
 					CoverURL:    qobuzResult.CoverURL,
 					LyricsLRC:   qobuzResult.LyricsLRC,
 				}
@@ -1126,7 +1198,9 @@ func DownloadWithFallback(requestJSON string) (string, error) {
 		}
 
 		if err != nil && errors.Is(err, ErrDownloadCancelled) {
-			return errorResponse("Download cancelled")
+			attempts.record(service, start, err)
+			attemptSpan.end(err)
+			return downloadErrorResponse("Download cancelled", err, attempts.list)
 		}
 
 		if err == nil {
@@ -1142,10 +1216,23 @@ func DownloadWithFallback(requestJSON string) (string, error) {
 					actualPath,
 					true,
 				)
+				attempts.record(service, start, nil)
+				attemptSpan.end(nil)
+				resp.Attempts = attempts.list
+				recordAlbumDownloadForReplayGain(req, resp.FilePath)
 				jsonBytes, _ := json.Marshal(resp)
 				return string(jsonBytes), nil
 			}
 
+			if verifyErr := verifyDownloadedFile(req, result.FilePath, result.Decryption); verifyErr != nil {
+				lastErr = verifyErr
+				attempts.record(service, start, verifyErr)
+				attemptSpan.end(verifyErr)
+				continue
+			}
+			attempts.record(service, start, nil)
+			attemptSpan.end(nil)
+
 			enrichResultQualityFromFile(&result)
 
 			resp := buildDownloadSuccessResponse(
@@ -1156,14 +1243,18 @@ func DownloadWithFallback(requestJSON string) (string, error) {
 				result.FilePath,
 				false,
 			)
+			resp.Attempts = attempts.list
+			recordAlbumDownloadForReplayGain(req, resp.FilePath)
 			jsonBytes, _ := json.Marshal(resp)
 			return string(jsonBytes), nil
 		}
 
 		lastErr = err
+		attempts.record(service, start, err)
+		attemptSpan.end(err)
 	}
 
-	return errorResponse("All services failed. Last error: " + lastErr.Error())
+	return downloadErrorResponse("All services failed. Last error: "+lastErr.Error(), lastErr, attempts.list)
 }
 
 func GetDownloadProgress() string {
@@ -1251,6 +1342,7 @@ func ReadFileMetadata(filePath string) (string, error) {
 				result["genre"] = oggMeta.Genre
 				result["composer"] = oggMeta.Composer
 				result["comment"] = oggMeta.Comment
+				addIdentifierFields(result, oggMeta.TrackIdentifiers)
 				quality, qualityErr := GetOggQuality(filePath)
 				if qualityErr == nil {
 					result["sample_rate"] = quality.SampleRate
@@ -1280,6 +1372,7 @@ func ReadFileMetadata(filePath string) (string, error) {
 			result["replaygain_track_peak"] = metadata.ReplayGainTrackPeak
 			result["replaygain_album_gain"] = metadata.ReplayGainAlbumGain
 			result["replaygain_album_peak"] = metadata.ReplayGainAlbumPeak
+			addIdentifierFields(result, metadata.TrackIdentifiers)
 
 			quality, qualityErr := GetAudioQuality(filePath)
 			if qualityErr == nil {
@@ -1316,6 +1409,7 @@ func ReadFileMetadata(filePath string) (string, error) {
 			result["replaygain_track_peak"] = meta.ReplayGainTrackPeak
 			result["replaygain_album_gain"] = meta.ReplayGainAlbumGain
 			result["replaygain_album_peak"] = meta.ReplayGainAlbumPeak
+			addIdentifierFields(result, meta.TrackIdentifiers)
 		}
 		quality, qualityErr := GetM4AQuality(filePath)
 		if qualityErr == nil {
@@ -1348,6 +1442,7 @@ func ReadFileMetadata(filePath string) (string, error) {
 			result["replaygain_track_peak"] = meta.ReplayGainTrackPeak
 			result["replaygain_album_gain"] = meta.ReplayGainAlbumGain
 			result["replaygain_album_peak"] = meta.ReplayGainAlbumPeak
+			addIdentifierFields(result, meta.TrackIdentifiers)
 		}
 		quality, qualityErr := GetMP3Quality(filePath)
 		if qualityErr == nil {
@@ -1381,6 +1476,7 @@ func ReadFileMetadata(filePath string) (string, error) {
 			result["replaygain_track_peak"] = meta.ReplayGainTrackPeak
 			result["replaygain_album_gain"] = meta.ReplayGainAlbumGain
 			result["replaygain_album_peak"] = meta.ReplayGainAlbumPeak
+			addIdentifierFields(result, meta.TrackIdentifiers)
 		}
 		quality, qualityErr := GetOggQuality(filePath)
 		if qualityErr == nil {
@@ -1416,6 +1512,7 @@ func ReadFileMetadata(filePath string) (string, error) {
 				result["replaygain_track_peak"] = meta.ReplayGainTrackPeak
 				result["replaygain_album_gain"] = meta.ReplayGainAlbumGain
 				result["replaygain_album_peak"] = meta.ReplayGainAlbumPeak
+				addIdentifierFields(result, meta.TrackIdentifiers)
 			}
 		}
 	} else {
@@ -1430,6 +1527,12 @@ func ReadFileMetadata(filePath string) (string, error) {
 	return string(jsonBytes), nil
 }
 
+func addIdentifierFields(result map[string]interface{}, ids TrackIdentifiers) {
+	for key, value := range ids.fields() {
+		result[key] = value
+	}
+}
+
 // ParseCueSheet is called from Dart to get track listing and timing data for CUE splitting.
 // audioDir, if non-empty, overrides the directory used for resolving the
 // referenced audio file (useful for SAF temp file scenarios).
@@ -1541,6 +1644,7 @@ func EditFileMetadata(filePath, metadataJSON string) (string, error) {
 			ReplayGainTrackPeak: fields["replaygain_track_peak"],
 			ReplayGainAlbumGain: fields["replaygain_album_gain"],
 			ReplayGainAlbumPeak: fields["replaygain_album_peak"],
+			TrackIdentifiers:    identifiersFromFields(fields),
 		}
 
 		newItems := AudioMetadataToAPEItems(meta)
@@ -1598,14 +1702,21 @@ func EditFileMetadata(filePath, metadataJSON string) (string, error) {
 		return string(jsonBytes), nil
 	}
 
-	if isM4AFile && hasOnlyM4AReplayGainFields(fields) {
+	if isM4AFile && hasOnlyM4AFreeformFields(fields) {
 		if err := EditM4AReplayGain(filePath, fields); err != nil {
 			return "", fmt.Errorf("failed to write M4A metadata: %w", err)
 		}
+		if err := EditM4AIdentifiers(filePath, fields); err != nil {
+			return "", fmt.Errorf("failed to write M4A identifiers: %w", err)
+		}
 
+		method := "native_m4a_replaygain"
+		if hasIdentifierFields(fields) {
+			method = "native_m4a_freeform"
+		}
 		resp := map[string]any{
 			"success": true,
-			"method":  "native_m4a_replaygain",
+			"method":  method,
 		}
 		jsonBytes, _ := json.Marshal(resp)
 		return string(jsonBytes), nil
@@ -1620,7 +1731,10 @@ func EditFileMetadata(filePath, metadataJSON string) (string, error) {
 	return string(jsonBytes), nil
 }
 
-func hasOnlyM4AReplayGainFields(fields map[string]string) bool {
+// hasOnlyM4AFreeformFields reports whether every field can be written as an
+// iTunes freeform atom (ReplayGain and identifier tags), so ffmpeg is not
+// needed. Empty identifier fields count because they clear the tag.
+func hasOnlyM4AFreeformFields(fields map[string]string) bool {
 	allowed := map[string]struct{}{
 		"replaygain_track_gain": {},
 		"replaygain_track_peak": {},
@@ -1628,19 +1742,22 @@ func hasOnlyM4AReplayGainFields(fields map[string]string) bool {
 		"replaygain_album_peak": {},
 	}
 
-	hasReplayGain := false
+	hasFreeform := hasIdentifierFields(fields)
 	for key, value := range fields {
+		if isIdentifierField(key) {
+			continue
+		}
 		if strings.TrimSpace(value) == "" {
 			continue
 		}
 		if _, ok := allowed[strings.ToLower(strings.TrimSpace(key))]; ok {
-			hasReplayGain = true
+			hasFreeform = true
 			continue
 		}
 		return false
 	}
 
-	return hasReplayGain
+	return hasFreeform
 }
 
 func SetDownloadDirectory(path string) error {
@@ -1692,6 +1809,17 @@ func BuildFilename(template string, metadataJSON string) (string, error) {
 	return filename, nil
 }
 
+// BuildFilePath renders a template that may contain folders and returns the
+// relative path with every segment sanitized.
+func BuildFilePath(template string, metadataJSON string) (string, error) {
+	var metadata map[string]interface{}
+	if err := json.Unmarshal([]byte(metadataJSON), &metadata); err != nil {
+		return "", err
+	}
+
+	return buildFilePathFromTemplate(template, metadata), nil
+}
+
 func SanitizeFilename(filename string) string {
 	return sanitizeFilename(filename)
 }
@@ -1710,6 +1838,10 @@ func FetchLyrics(spotifyID, trackName, artistName string, durationMs int64) (str
 		"sync_type":    lyrics.SyncType,
 		"lines":        lyrics.Lines,
 		"instrumental": lyrics.Instrumental,
+		"provider":     lyrics.Provider,
+		"score":        lyrics.Score,
+		"alternates":   lyrics.Alternates,
+		"layers":       lyrics.Layers,
 	}
 
 	jsonBytes, err := json.Marshal(result)
@@ -1745,6 +1877,24 @@ func GetLyricsLRC(spotifyID, trackName, artistName string, filePath string, dura
 }
 
 func GetLyricsLRCWithSource(spotifyID, trackName, artistName string, filePath string, durationMs int64) (string, error) {
+	if local := storedLyricsOverride("", artistName, trackName, float64(durationMs)/1000.0); local != nil {
+		lrcContent := "[instrumental:true]"
+		if !local.Instrumental {
+			lrcContent = convertToLRCWithMetadata(local, trackName, artistName)
+		}
+		result := map[string]interface{}{
+			"lyrics":       lrcContent,
+			"source":       local.Source,
+			"sync_type":    local.SyncType,
+			"instrumental": local.Instrumental,
+		}
+		jsonBytes, err := json.Marshal(result)
+		if err != nil {
+			return "", err
+		}
+		return string(jsonBytes), nil
+	}
+
 	if filePath != "" {
 		lyrics, err := ExtractLyrics(filePath)
 		if err == nil && lyrics != "" {
@@ -1817,6 +1967,18 @@ func EmbedLyricsToFile(filePath, lyrics string) (string, error) {
 	return string(jsonBytes), nil
 }
 
+// EmbedLyricsToFileWithFormat embeds LRC content after converting it to
+// lrc, enhanced_lrc or plain; other formats embed the content unchanged.
+func EmbedLyricsToFileWithFormat(filePath, lyrics, format string) (string, error) {
+	return EmbedLyricsToFile(filePath, lyricsForEmbedding(lyrics, format))
+}
+
+// ConvertLyrics re-serializes LRC or enhanced LRC content as lrc,
+// enhanced_lrc, ttml, srt or plain text.
+func ConvertLyrics(lrcContent, format string) (string, error) {
+	return convertLRCContent(lrcContent, format)
+}
+
 // RewriteSplitArtistTagsExport rewrites ARTIST and ALBUMARTIST Vorbis
 // comments in a FLAC file as multiple separate entries (one per artist).
 // Call this after FFmpeg metadata embedding to fix split artist tags,
@@ -2289,42 +2451,34 @@ func GetTidalURLFromDeezerTrack(deezerTrackID string) (string, error) {
 }
 
 func errorResponse(msg string) (string, error) {
-	errorType := "unknown"
-	lowerMsg := strings.ToLower(msg)
-
-	if strings.Contains(lowerMsg, "isp blocking") ||
-		strings.Contains(lowerMsg, "try using vpn") ||
-		strings.Contains(lowerMsg, "change dns") {
-		errorType = "isp_blocked"
-	} else if strings.Contains(lowerMsg, "cancel") {
-		errorType = "cancelled"
-	} else if strings.Contains(lowerMsg, "permission") ||
-		strings.Contains(lowerMsg, "operation not permitted") ||
-		strings.Contains(lowerMsg, "access denied") ||
-		strings.Contains(lowerMsg, "failed to create file") ||
-		strings.Contains(lowerMsg, "failed to create directory") {
-		errorType = "permission"
-	} else if strings.Contains(lowerMsg, "not found") ||
-		strings.Contains(lowerMsg, "not available") ||
-		strings.Contains(lowerMsg, "no results") ||
-		strings.Contains(lowerMsg, "track not found") ||
-		strings.Contains(lowerMsg, "all services failed") {
-		errorType = "not_found"
-	} else if strings.Contains(lowerMsg, "rate limit") ||
-		strings.Contains(lowerMsg, "429") ||
-		strings.Contains(lowerMsg, "too many requests") {
-		errorType = "rate_limit"
-	} else if strings.Contains(lowerMsg, "network") ||
-		strings.Contains(lowerMsg, "connection") ||
-		strings.Contains(lowerMsg, "timeout") ||
-		strings.Contains(lowerMsg, "dial") {
-		errorType = "network"
+	resp := DownloadResponse{
+		Success:   false,
+		Error:     msg,
+		ErrorType: "unknown",
+	}
+	if kind := downloadErrorKindFromMessage(msg); kind != nil {
+		resp.ErrorType = downloadErrorTypeOf(kind)
+		resp.Retryable = downloadErrorKindRetryable(kind)
 	}
+	jsonBytes, _ := json.Marshal(resp)
+	return string(jsonBytes), nil
+}
 
+// downloadErrorResponse reports a typed pipeline failure together with the
+// attempts made so far.
+func downloadErrorResponse(msg string, err error, attempts []DownloadAttempt) (string, error) {
 	resp := DownloadResponse{
 		Success:   false,
 		Error:     msg,
-		ErrorType: errorType,
+		ErrorType: "unknown",
+		Attempts:  attempts,
+	}
+	applyDownloadError(&resp, err, "")
+	if resp.ErrorType == "unknown" {
+		if kind := downloadErrorKindFromMessage(msg); kind != nil {
+			resp.ErrorType = downloadErrorTypeOf(kind)
+			resp.Retryable = downloadErrorKindRetryable(kind)
+		}
 	}
 	jsonBytes, _ := json.Marshal(resp)
 	return string(jsonBytes), nil
@@ -2335,7 +2489,7 @@ func DownloadCoverToFile(coverURL string, outputPath string, maxQuality bool) er
 		return fmt.Errorf("no cover URL provided")
 	}
 
-	data, err := downloadCoverToMemory(coverURL, maxQuality)
+	data, err := downloadCoverToMemory(context.Background(), coverURL, maxQuality)
 	if err != nil {
 		return fmt.Errorf("failed to download cover: %w", err)
 	}
@@ -2379,9 +2533,12 @@ func ExtractCoverToFile(audioPath string, outputPath string) error {
 }
 
 func FetchAndSaveLyrics(trackName, artistName, spotifyID string, durationMs int64, outputPath string, audioFilePath string) error {
+	// Lyrics pinned in the local store win over embedded and fetched lyrics.
+	hasStoredLyrics := storedLyricsOverride("", artistName, trackName, float64(durationMs)/1000.0) != nil
+
 	// If the audio file already has embedded lyrics or a sidecar .lrc,
 	// use those directly instead of making redundant network requests.
-	if audioFilePath != "" {
+	if audioFilePath != "" && !hasStoredLyrics {
 		existing, err := ExtractLyrics(audioFilePath)
 		if err == nil && strings.TrimSpace(existing) != "" {
 			if err := os.WriteFile(outputPath, []byte(existing), 0644); err != nil {
@@ -2515,9 +2672,24 @@ func ReEnrichFile(requestJSON string) (string, error) {
 			GoLog("[ReEnrich] Skipping provider search: no usable title/artist/album query\n")
 		}
 
+		if found && req.ISRC != "" && req.shouldUpdateField("release_info") &&
+			req.MusicBrainzRecordingID == "" && isMetadataProviderEnabled("musicbrainz") {
+			ctx, cancel := context.WithTimeout(context.Background(), 2*musicBrainzAPITimeout)
+			if mbTrack, err := GetMusicBrainzClient().LookupByISRC(ctx, req.ISRC, musicBrainzMatchHints{
+				AlbumName:   req.AlbumName,
+				TotalTracks: req.TotalTracks,
+				DurationMS:  int(req.DurationMs),
+			}); err == nil {
+				req.ReleaseIdentifiers.mergeMissing(mbTrack.ReleaseIdentifiers)
+			} else {
+				GoLog("[ReEnrich] MusicBrainz lookup failed: %v\n", err)
+			}
+			cancel()
+		}
+
 		// Try to enrich extra metadata from ISRC if not already set.
 		if found && req.ISRC != "" && req.shouldUpdateField("extra") && (req.Genre == "" || req.Label == "" || req.Copyright == "") {
-			enrichExtraMetadataByISRC("ReEnrich", req.ISRC, &req.Genre, &req.Label, &req.Copyright)
+			enrichExtraMetadataByISRC(context.Background(), "ReEnrich", req.ISRC, &req.Genre, &req.Label, &req.Copyright)
 		}
 
 		if !found {
@@ -2537,7 +2709,7 @@ func ReEnrichFile(requestJSON string) (string, error) {
 	var coverTempPath string
 	var coverDataBytes []byte
 	if req.CoverURL != "" && req.shouldUpdateField("cover") {
-		coverData, err := downloadCoverToMemory(req.CoverURL, req.MaxQuality)
+		coverData, err := downloadCoverToMemory(context.Background(), req.CoverURL, req.MaxQuality)
 		if err != nil {
 			GoLog("[ReEnrich] Failed to download cover: %v\n", err)
 		} else {
@@ -2598,7 +2770,7 @@ func ReEnrichFile(requestJSON string) (string, error) {
 	if req.EmbedLyrics && req.shouldUpdateField("lyrics") {
 		client := NewLyricsClient()
 		durationSec := float64(req.DurationMs) / 1000.0
-		lyrics, err := client.FetchLyricsAllSources(req.SpotifyID, req.TrackName, req.ArtistName, durationSec)
+		lyrics, err := client.FetchLyricsAllSourcesWithISRC(req.SpotifyID, req.ISRC, req.TrackName, req.ArtistName, durationSec)
 		if err != nil {
 			GoLog("[ReEnrich] Lyrics not found: %v\n", err)
 		} else if !lyrics.Instrumental {
@@ -2631,6 +2803,9 @@ func ReEnrichFile(requestJSON string) (string, error) {
 	if req.shouldUpdateField("release_info") {
 		enrichedMeta["release_date"] = req.ReleaseDate
 		enrichedMeta["isrc"] = req.ISRC
+		for key, value := range req.ReleaseIdentifiers.fields() {
+			enrichedMeta[key] = value
+		}
 	}
 	if req.shouldUpdateField("cover") {
 		enrichedMeta["cover_url"] = req.CoverURL
@@ -2665,6 +2840,7 @@ func ReEnrichFile(requestJSON string) (string, error) {
 		if req.shouldUpdateField("release_info") {
 			metadata.Date = req.ReleaseDate
 			metadata.ISRC = req.ISRC
+			metadata.ReleaseIdentifiers = req.ReleaseIdentifiers
 		}
 		if req.shouldUpdateField("lyrics") {
 			metadata.Lyrics = lyricsLRC
@@ -2970,6 +3146,9 @@ func DownloadWithExtensionsJSON(requestJSON string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if result.Success {
+		recordAlbumDownloadForReplayGain(req, result.FilePath)
+	}
 
 	jsonBytes, err := json.Marshal(result)
 	if err != nil {
@@ -4016,3 +4195,227 @@ func ReadAudioMetadataWithHintJSON(filePath, displayName string) (string, error)
 func ReadAudioMetadataWithHintAndCoverCacheKeyJSON(filePath, displayName, coverCacheKey string) (string, error) {
 	return ReadAudioMetadataWithDisplayNameAndCoverCacheKey(filePath, displayName, coverCacheKey)
 }
+
+func OpenLibraryDatabaseJSON(dbPath string) error {
+	return OpenLibraryDatabase(dbPath)
+}
+
+func CloseLibraryDatabaseJSON() error {
+	return CloseLibraryDatabase()
+}
+
+func ClearLibraryDatabaseJSON() error {
+	return ClearLibraryDatabase()
+}
+
+func UpsertLibraryTracksJSON(tracksJSON string) (int, error) {
+	return UpsertLibraryTracks(tracksJSON)
+}
+
+func RemoveLibraryTracksJSON(pathsJSON string) (int, error) {
+	return RemoveLibraryTracks(pathsJSON)
+}
+
+func QueryLibraryJSON(queryJSON string) (string, error) {
+	return QueryLibrary(queryJSON)
+}
+
+func GetLibraryAlbumsByArtistJSON(queryJSON string) (string, error) {
+	return GetLibraryAlbumsByArtist(queryJSON)
+}
+
+func GetLibraryStatsJSON() (string, error) {
+	return GetLibraryStats()
+}
+
+func ScanLibraryFolderIncrementalFromDatabaseJSON(folderPath string) (string, error) {
+	return ScanLibraryFolderIncrementalFromDatabase(folderPath)
+}
+
+func LibraryHealthReportJSON(folderPath string) (string, error) {
+	return GenerateLibraryHealthReport(folderPath, "")
+}
+
+func LibraryHealthReportWithOptionsJSON(folderPath, optionsJSON string) (string, error) {
+	return GenerateLibraryHealthReport(folderPath, optionsJSON)
+}
+
+func GetLibraryHealthReportProgressJSON() string {
+	return GetLibraryHealthReportProgress()
+}
+
+func CancelLibraryHealthReportJSON() {
+	CancelLibraryHealthReport()
+}
+
+func VerifyFLACFileJSON(filePath string) (string, error) {
+	return VerifyFLACFile(filePath)
+}
+
+func VerifyLibraryIntegrityJSON(folderPath string) (string, error) {
+	return VerifyLibraryIntegrity(folderPath)
+}
+
+func GetLibraryIntegrityProgressJSON() string {
+	return GetLibraryIntegrityProgress()
+}
+
+func CancelLibraryIntegrityVerifyJSON() {
+	CancelLibraryIntegrityVerify()
+}
+
+func AnalyzeReplayGainJSON(requestJSON string) (string, error) {
+	return AnalyzeReplayGain(requestJSON)
+}
+
+func AnalyzeReplayGainForFileJSON(filePath string, write bool) (string, error) {
+	return AnalyzeReplayGainForFile(filePath, write)
+}
+
+func GetAlbumReplayGainStatusJSON() string {
+	return GetAlbumReplayGainStatus()
+}
+
+func ClearAlbumReplayGainStatusJSON() {
+	ClearAlbumReplayGainStatus()
+}
+
+func FinalizeAlbumReplayGainJSON(albumKey string) (string, error) {
+	return FinalizeAlbumReplayGain(albumKey)
+}
+
+func LookupMusicBrainzByISRCJSON(isrc string) (string, error) {
+	return LookupMusicBrainzByISRC(isrc)
+}
+
+func PlanAlbumReEnrichJSON(requestJSON string) (string, error) {
+	return PlanAlbumReEnrich(requestJSON)
+}
+
+func ApplyAlbumReEnrichJSON(requestJSON string) (string, error) {
+	return ApplyAlbumReEnrich(requestJSON)
+}
+
+func DiscardAlbumReEnrichPlanJSON(planID string) {
+	DiscardAlbumReEnrichPlan(planID)
+}
+
+func TagFromFilenameJSON(requestJSON string) (string, error) {
+	return TagFromFilename(requestJSON)
+}
+
+func OrganizeLibraryJSON(requestJSON string) (string, error) {
+	return OrganizeLibrary(requestJSON)
+}
+
+func RollbackLibraryJournalJSON(journalPath string) (string, error) {
+	return RollbackLibraryJournal(journalPath)
+}
+
+func OpenLyricsStoreJSON(storePath string) error {
+	return OpenLyricsStore(storePath)
+}
+
+func CloseLyricsStoreJSON() error {
+	return CloseLyricsStore()
+}
+
+func SaveLocalLyricsJSON(requestJSON string) (string, error) {
+	return SaveLocalLyrics(requestJSON)
+}
+
+func RemoveLocalLyricsJSON(id string) (bool, error) {
+	return RemoveLocalLyrics(id)
+}
+
+func ListLocalLyricsJSON() (string, error) {
+	return ListLocalLyrics()
+}
+
+func ImportSidecarLyricsJSON(requestJSON string) (string, error) {
+	return ImportSidecarLyrics(requestJSON)
+}
+
+func BuildLyricsLayersJSON(requestJSON string) (string, error) {
+	return BuildLyricsLayers(requestJSON)
+}
+
+func LoadLyricsReadingDictionaryJSON(language, dictJSON string) (int, error) {
+	return LoadLyricsReadingDictionary(language, dictJSON)
+}
+
+func FixLyricsTimingJSON(requestJSON string) (string, error) {
+	return FixLyricsTiming(requestJSON)
+}
+
+func ValidateLyricsTimingJSON(content string, durationMs int64) (string, error) {
+	return ValidateLyricsTiming(content, durationMs)
+}
+
+func SetProxySettingsJSON(settingsJSON string) error {
+	return SetProxySettings(settingsJSON)
+}
+
+func GetProxySettingsJSON() (string, error) {
+	return GetProxySettings()
+}
+
+func CheckProxyJSON(proxyURL, targetURL string) (string, error) {
+	return CheckProxy(proxyURL, targetURL)
+}
+
+func SetDNSOverHTTPSSettingsJSON(settingsJSON string) error {
+	return SetDNSOverHTTPSSettings(settingsJSON)
+}
+
+func GetDNSOverHTTPSSettingsJSON() (string, error) {
+	return GetDNSOverHTTPSSettings()
+}
+
+func ResolveHostJSON(host string) (string, error) {
+	return ResolveHost(host)
+}
+
+func GetRateLimiterStateJSON() (string, error) {
+	return GetRateLimiterState()
+}
+
+func GetProviderHealthJSON() (string, error) {
+	return GetProviderHealth()
+}
+
+func SetLogLevelsJSON(settingsJSON string) error {
+	return SetLogLevels(settingsJSON)
+}
+
+func GetLogLevelsJSON() (string, error) {
+	return GetLogLevels()
+}
+
+func SetLogFileSettingsJSON(settingsJSON string) error {
+	return SetLogFileSettings(settingsJSON)
+}
+
+func GetLogFileSettingsJSON() (string, error) {
+	return GetLogFileSettings()
+}
+
+func CreateDiagnosticsBundleJSON(requestJSON string) (string, error) {
+	return CreateDiagnosticsBundle(requestJSON)
+}
+
+func SetTLSFingerprintSettingsJSON(settingsJSON string) error {
+	return SetTLSFingerprintSettings(settingsJSON)
+}
+
+func GetTLSFingerprintSettingsJSON() (string, error) {
+	return GetTLSFingerprintSettings()
+}
+
+func SetBandwidthSettingsJSON(settingsJSON string) error {
+	return SetBandwidthSettings(settingsJSON)
+}
+
+func GetBandwidthSettingsJSON() (string, error) {
+	return GetBandwidthSettings()
+}
@@ -187,7 +187,7 @@ func TestEnrichExtraMetadataByISRCFallsBackToMusicBrainzGenre(t *testing.T) {
 	fetchDeezerExtendedMetadataByISRC = func(ctx context.Context, isrc string) (*AlbumExtendedMetadata, error) {
 		return nil, nil
 	}
-	fetchMusicBrainzGenreByISRC = func(isrc string) (string, error) {
+	fetchMusicBrainzGenreByISRC = func(_ context.Context, isrc string) (string, error) {
 		if isrc != "TEST123" {
 			t.Fatalf("unexpected isrc: %q", isrc)
 		}
@@ -197,7 +197,7 @@ func TestEnrichExtraMetadataByISRCFallsBackToMusicBrainzGenre(t *testing.T) {
 	genre := ""
 	label := ""
 	copyright := ""
-	enrichExtraMetadataByISRC("DownloadWithFallback", "TEST123", &genre, &label, &copyright)
+	enrichExtraMetadataByISRC(context.Background(), "DownloadWithFallback", "TEST123", &genre, &label, &copyright)
 
 	if genre != "Alternative Rock" {
 		t.Fatalf("genre = %q, want fallback genre", genre)
@@ -226,7 +226,7 @@ func TestEnrichExtraMetadataByISRCPrefersDeezerGenre(t *testing.T) {
 			Copyright: "(C) Test",
 		}, nil
 	}
-	fetchMusicBrainzGenreByISRC = func(isrc string) (string, error) {
+	fetchMusicBrainzGenreByISRC = func(_ context.Context, isrc string) (string, error) {
 		musicBrainzCalled = true
 		return "Rock", nil
 	}
@@ -234,7 +234,7 @@ func TestEnrichExtraMetadataByISRCPrefersDeezerGenre(t *testing.T) {
 	genre := ""
 	label := ""
 	copyright := ""
-	enrichExtraMetadataByISRC("DownloadWithFallback", "TEST456", &genre, &label, &copyright)
+	enrichExtraMetadataByISRC(context.Background(), "DownloadWithFallback", "TEST456", &genre, &label, &copyright)
 
 	if genre != "Synthpop" {
 		t.Fatalf("genre = %q, want Deezer genre", genre)
@@ -1,6 +1,7 @@
 package gobackend
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -32,6 +33,7 @@ type ExtTrackMetadata struct {
 	ProviderID  string `json:"provider_id"`
 	ItemType    string `json:"item_type,omitempty"`
 	AlbumType   string `json:"album_type,omitempty"`
+	AlbumID     string `json:"album_id,omitempty"`
 
 	TidalID       string            `json:"tidal_id,omitempty"`
 	QobuzID       string            `json:"qobuz_id,omitempty"`
@@ -43,6 +45,8 @@ type ExtTrackMetadata struct {
 	Copyright string `json:"copyright,omitempty"`
 	Genre     string `json:"genre,omitempty"`
 	Composer  string `json:"composer,omitempty"`
+
+	ReleaseIdentifiers
 }
 
 func (t *ExtTrackMetadata) ResolvedCoverURL() string {
@@ -942,7 +946,7 @@ func GetMetadataProviderPriority() []string {
 
 func isBuiltInProvider(providerID string) bool {
 	switch providerID {
-	case "tidal", "qobuz":
+	case "tidal", "qobuz", "musicbrainz":
 		return true
 	default:
 		return false
@@ -961,14 +965,13 @@ func isBuiltInDownloadProvider(providerID string) bool {
 func normalizeQualityForBuiltIn(quality string) string {
 	switch strings.ToLower(strings.TrimSpace(quality)) {
 	case "alac", "hi_res_lossless", "lossless":
-		return "HI_RES_LOSSLESS"
+		quality = "HI_RES_LOSSLESS"
 	case "atmos", "ac3", "dolby_atmos":
-		return "LOSSLESS"
+		quality = "LOSSLESS"
 	case "aac", "aac-legacy":
-		return "LOSSLESS"
-	default:
-		return quality
+		quality = "LOSSLESS"
 	}
+	return quality
 }
 
 func normalizeBuiltInMetadataTrack(track TrackMetadata, providerID string) ExtTrackMetadata {
@@ -1007,7 +1010,10 @@ func normalizeBuiltInMetadataTrack(track TrackMetadata, providerID string) ExtTr
 		TidalID:     tidalID,
 		QobuzID:     qobuzID,
 		AlbumType:   track.AlbumType,
+		AlbumID:     track.AlbumID,
 		Composer:    track.Composer,
+
+		ReleaseIdentifiers: track.ReleaseIdentifiers,
 	}
 }
 
@@ -1030,6 +1036,8 @@ func searchBuiltInMetadataTracks(providerID, query string, limit int) ([]ExtTrac
 		return NewQobuzDownloader().SearchTracks(query, limit)
 	case "tidal":
 		return NewTidalDownloader().SearchTracks(query, limit)
+	case "musicbrainz":
+		return GetMusicBrainzClient().SearchTracks(query, limit)
 	default:
 		return nil, fmt.Errorf("unsupported built-in metadata provider: %s", providerID)
 	}
@@ -1120,6 +1128,26 @@ func (m *extensionManager) SearchTracksWithMetadataProviders(query string, limit
 }
 
 func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, error) {
+	var attempts downloadAttempts
+	resp, err := downloadWithExtensionFallback(req, &attempts)
+	if resp != nil {
+		resp.Attempts = attempts.list
+	}
+	return resp, err
+}
+
+func downloadWithExtensionFallback(req DownloadRequest, attempts *downloadAttempts) (*DownloadResponse, error) {
+	ctx := initDownloadCancel(req.ItemID)
+	defer clearDownloadCancel(req.ItemID)
+	beginDownloadTrace(req.ItemID)
+	defer endDownloadTrace(req.ItemID)
+
+	if err := applyMeteredDownloadPolicy(&req); err != nil {
+		resp := &DownloadResponse{Error: err.Error()}
+		applyDownloadError(resp, err, "")
+		return resp, nil
+	}
+
 	priority := GetProviderPriority()
 	extManager := getExtensionManager()
 	strictMode := !req.UseFallback
@@ -1132,6 +1160,7 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 		if selectedProvider != "" {
 			priority = []string{selectedProvider}
 			GoLog("[DownloadWithExtensionFallback] Strict mode enabled, provider locked to: %s\n", selectedProvider)
+			traceDecision(req.ItemID, "strict_mode", selectedProvider, "locked", nil)
 		}
 	}
 
@@ -1168,6 +1197,12 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 		GoLog("[DownloadWithExtensionFallback] New priority order: %v\n", priority)
 	}
 
+	traceDecision(req.ItemID, "provider_order", "", downloadTraceStatusOK, map[string]any{
+		"order":    priority,
+		"strict":   strictMode,
+		"selected": selectedProvider,
+	})
+
 	var lastErr error
 	var skipBuiltIn bool
 
@@ -1193,7 +1228,15 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 				Composer:    req.Composer,
 			}
 
+			enrichSpan := startTraceSpan(req.ItemID, "enrich", req.Source)
 			enrichedTrack, err := provider.EnrichTrack(trackMeta)
+			if enrichedTrack != nil {
+				enrichSpan.set("isrc", enrichedTrack.ISRC).
+					set("tidal_id", enrichedTrack.TidalID).
+					set("qobuz_id", enrichedTrack.QobuzID).
+					set("deezer_id", enrichedTrack.DeezerID)
+			}
+			enrichSpan.end(err)
 			if err == nil && enrichedTrack != nil {
 				if enrichedTrack.ISRC != "" && enrichedTrack.ISRC != req.ISRC {
 					GoLog("[DownloadWithExtensionFallback] ISRC enriched: %s -> %s\n", req.ISRC, enrichedTrack.ISRC)
@@ -1282,7 +1325,9 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 		searchQuery := req.TrackName + " " + req.ArtistName
 		GoLog("[DownloadWithExtensionFallback] Metadata incomplete, searching providers for: %s\n", searchQuery)
 
+		searchSpan := startTraceSpan(req.ItemID, "search", "").set("query", searchQuery)
 		tracks, searchErr := extManager.SearchTracksWithMetadataProviders(searchQuery, 5, true)
+		searchSpan.set("results", len(tracks)).end(searchErr)
 		if searchErr == nil && len(tracks) > 0 {
 			track := tracks[0]
 			GoLog("[DownloadWithExtensionFallback] Metadata match (%s): %s - %s (album: %s, date: %s, isrc: %s)\n",
@@ -1333,7 +1378,7 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 
 		if req.ISRC != "" &&
 			(req.Genre == "" || req.Label == "" || req.Copyright == "") {
-			enrichExtraMetadataByISRC("DownloadWithExtensionFallback", req.ISRC, &req.Genre, &req.Label, &req.Copyright)
+			enrichExtraMetadataByISRC(ctx, "DownloadWithExtensionFallback", req.ISRC, &req.Genre, &req.Label, &req.Copyright)
 		}
 	}
 
@@ -1357,6 +1402,8 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 				StartItemProgress(req.ItemID)
 			}
 
+			start := time.Now()
+			downloadSpan := startTraceSpan(req.ItemID, "download", req.Source)
 			result, err := provider.Download(trackID, req.Quality, outputPath, req.ItemID, func(percent int) {
 				if req.ItemID != "" {
 					normalized := float64(percent) / 100.0
@@ -1369,6 +1416,9 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 					SetItemProgress(req.ItemID, normalized, 0, 0)
 				}
 			})
+			if err == nil && result != nil && result.Success {
+				err = verifyDownloadedFile(req, result.FilePath, normalizeDownloadDecryptionInfo(result.Decryption, result.DecryptionKey))
+			}
 			if req.ItemID != "" {
 				if err == nil && result != nil && result.Success {
 					CompleteItemProgress(req.ItemID)
@@ -1377,7 +1427,9 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 				}
 			}
 
+			endExtensionDownloadSpan(downloadSpan, result, err)
 			if err == nil && result.Success {
+				attempts.record(req.Source, start, nil)
 				resp := &DownloadResponse{
 					Success:          true,
 					Message:          "Downloaded from " + req.Source,
@@ -1495,6 +1547,7 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 			}
 
 			if err != nil {
+				attempts.record(req.Source, start, err)
 				if errors.Is(err, ErrDownloadCancelled) {
 					return &DownloadResponse{
 						Success:   false,
@@ -1505,18 +1558,20 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 				}
 				lastErr = err
 			} else if result.ErrorMessage != "" {
-				lastErr = fmt.Errorf("%s", result.ErrorMessage)
+				lastErr = extensionDownloadError(req.Source, result)
+				attempts.record(req.Source, start, lastErr)
 			}
 			GoLog("[DownloadWithExtensionFallback] Source extension %s failed: %v\n", req.Source, lastErr)
 
 			if skipBuiltIn {
 				GoLog("[DownloadWithExtensionFallback] skipBuiltInFallback is true, not trying other providers\n")
-				return &DownloadResponse{
-					Success:   false,
-					Error:     "Download failed: " + lastErr.Error(),
-					ErrorType: "extension_error",
-					Service:   req.Source,
-				}, nil
+				resp := &DownloadResponse{
+					Success: false,
+					Error:   "Download failed: " + lastErr.Error(),
+					Service: req.Source,
+				}
+				applyDownloadError(resp, lastErr, "extension_error")
+				return resp, nil
 			}
 		} else {
 			GoLog("[DownloadWithExtensionFallback] Source extension %s not available or not a download provider\n", req.Source)
@@ -1535,11 +1590,13 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 
 		if skipBuiltIn && isBuiltInDownloadProvider(providerIDNormalized) {
 			GoLog("[DownloadWithExtensionFallback] Skipping built-in provider %s (skipBuiltInFallback)\n", providerID)
+			traceDecision(req.ItemID, "skip", providerID, "skipped", map[string]any{"reason": "skip_built_in_fallback"})
 			continue
 		}
 
 		if !isBuiltInDownloadProvider(providerIDNormalized) && !isExtensionFallbackAllowed(providerID) {
 			GoLog("[DownloadWithExtensionFallback] Skipping extension provider %s (not enabled for fallback)\n", providerID)
+			traceDecision(req.ItemID, "skip", providerID, "skipped", map[string]any{"reason": "fallback_disabled"})
 			continue
 		}
 
@@ -1550,13 +1607,20 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 			if (req.Genre == "" || req.Label == "" || req.Copyright == "") &&
 				req.ISRC != "" {
 				GoLog("[DownloadWithExtensionFallback] Enriching extra metadata from ISRC: %s\n", req.ISRC)
-				enrichExtraMetadataByISRC("DownloadWithExtensionFallback", req.ISRC, &req.Genre, &req.Label, &req.Copyright)
+				enrichExtraMetadataByISRC(ctx, "DownloadWithExtensionFallback", req.ISRC, &req.Genre, &req.Label, &req.Copyright)
 			}
 
 			origQuality := req.Quality
 			req.Quality = normalizeQualityForBuiltIn(req.Quality)
-			result, err := tryBuiltInProvider(providerIDNormalized, req)
+			start := time.Now()
+			attemptSpan := startTraceSpan(req.ItemID, "attempt", providerIDNormalized).set("quality", req.Quality)
+			result, err := tryBuiltInProvider(ctx, providerIDNormalized, req)
 			req.Quality = origQuality
+			if err == nil && result.Success {
+				err = verifyDownloadedFile(req, result.FilePath, result.Decryption)
+			}
+			attempts.record(providerIDNormalized, start, err)
+			attemptSpan.end(err)
 			if err == nil && result.Success {
 				result.Service = providerIDNormalized
 				if req.Label != "" {
@@ -1589,6 +1653,7 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 			ext, err := extManager.GetExtension(providerID)
 			if err != nil || !ext.Enabled || ext.Error != "" {
 				GoLog("[DownloadWithExtensionFallback] Extension %s not available\n", providerID)
+				traceDecision(req.ItemID, "skip", providerID, "skipped", map[string]any{"reason": "extension_unavailable"})
 				continue
 			}
 
@@ -1598,11 +1663,21 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 
 			provider := newExtensionProviderWrapper(ext)
 
+			start := time.Now()
+			availabilitySpan := startTraceSpan(req.ItemID, "availability", providerID)
 			availability, err := provider.CheckAvailability(req.ISRC, req.TrackName, req.ArtistName, req.SpotifyID, req.DeezerID)
+			if availability != nil {
+				availabilitySpan.set("available", availability.Available).set("track_id", availability.TrackID)
+			}
+			availabilitySpan.end(err)
 			if err != nil || !availability.Available {
 				GoLog("[DownloadWithExtensionFallback] %s: not available\n", providerID)
 				if err != nil {
 					lastErr = err
+					attempts.record(providerID, start, err)
+				} else {
+					attempts.record(providerID, start, newDownloadError(providerID, ErrTrackNotFound,
+						fmt.Errorf("track not available on %s", providerID)))
 				}
 				continue
 			}
@@ -1613,6 +1688,7 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 				StartItemProgress(req.ItemID)
 			}
 
+			downloadSpan := startTraceSpan(req.ItemID, "download", providerID)
 			result, err := provider.Download(availability.TrackID, req.Quality, outputPath, req.ItemID, func(percent int) {
 				if req.ItemID != "" {
 					normalized := float64(percent) / 100.0
@@ -1625,6 +1701,9 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 					SetItemProgress(req.ItemID, normalized, 0, 0)
 				}
 			})
+			if err == nil && result != nil && result.Success {
+				err = verifyDownloadedFile(req, result.FilePath, normalizeDownloadDecryptionInfo(result.Decryption, result.DecryptionKey))
+			}
 			if req.ItemID != "" {
 				if err == nil && result != nil && result.Success {
 					CompleteItemProgress(req.ItemID)
@@ -1633,7 +1712,9 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 				}
 			}
 
+			endExtensionDownloadSpan(downloadSpan, result, err)
 			if err == nil && result.Success {
+				attempts.record(providerID, start, nil)
 				resp := &DownloadResponse{
 					Success:          true,
 					Message:          "Downloaded from " + providerID,
@@ -1715,6 +1796,7 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 			}
 
 			if err != nil {
+				attempts.record(providerID, start, err)
 				if errors.Is(err, ErrDownloadCancelled) {
 					return &DownloadResponse{
 						Success:   false,
@@ -1725,18 +1807,20 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 				}
 				lastErr = err
 			} else if result.ErrorMessage != "" {
-				lastErr = fmt.Errorf("%s", result.ErrorMessage)
+				lastErr = extensionDownloadError(providerID, result)
+				attempts.record(providerID, start, lastErr)
 			}
 			GoLog("[DownloadWithExtensionFallback] %s failed: %v\n", providerID, lastErr)
 		}
 	}
 
 	if lastErr != nil {
-		return &DownloadResponse{
-			Success:   false,
-			Error:     "All providers failed. Last error: " + lastErr.Error(),
-			ErrorType: "not_found",
-		}, nil
+		resp := &DownloadResponse{
+			Success: false,
+			Error:   "All providers failed. Last error: " + lastErr.Error(),
+		}
+		applyDownloadError(resp, lastErr, "not_found")
+		return resp, nil
 	}
 
 	return &DownloadResponse{
@@ -1746,7 +1830,7 @@ func DownloadWithExtensionFallback(req DownloadRequest) (*DownloadResponse, erro
 	}, nil
 }
 
-func tryBuiltInProvider(providerID string, req DownloadRequest) (*DownloadResponse, error) {
+func tryBuiltInProvider(ctx context.Context, providerID string, req DownloadRequest) (*DownloadResponse, error) {
 	req.Service = providerID
 
 	var result DownloadResult
@@ -1754,7 +1838,7 @@ func tryBuiltInProvider(providerID string, req DownloadRequest) (*DownloadRespon
 
 	switch providerID {
 	case "tidal":
-		tidalResult, tidalErr := downloadFromTidal(req)
+		tidalResult, tidalErr := downloadFromTidal(ctx, req)
 		if tidalErr == nil {
 			result = DownloadResult{
 				FilePath:    tidalResult.FilePath,
@@ -1767,11 +1851,12 @@ func tryBuiltInProvider(providerID string, req DownloadRequest) (*DownloadRespon
 				TrackNumber: tidalResult.TrackNumber,
 				DiscNumber:  tidalResult.DiscNumber,
 				ISRC:        tidalResult.ISRC,
+				Quality:     tidalResult.Quality,
 			}
 		}
 		err = tidalErr
 	case "qobuz":
-		qobuzResult, qobuzErr := downloadFromQobuz(req)
+		qobuzResult, qobuzErr := downloadFromQobuz(ctx, req)
 		if qobuzErr == nil {
 			result = DownloadResult{
 				FilePath:    qobuzResult.FilePath,
@@ -1784,6 +1869,7 @@ func tryBuiltInProvider(providerID string, req DownloadRequest) (*DownloadRespon
 				TrackNumber: qobuzResult.TrackNumber,
 				DiscNumber:  qobuzResult.DiscNumber,
 				ISRC:        qobuzResult.ISRC,
+				Quality:     qobuzResult.Quality,
 				CoverURL:    qobuzResult.CoverURL,
 			}
 		}
@@ -1814,6 +1900,7 @@ func tryBuiltInProvider(providerID string, req DownloadRequest) (*DownloadRespon
 		Label:            req.Label,
 		Copyright:        req.Copyright,
 		LyricsLRC:        result.LyricsLRC,
+		Quality:          result.Quality,
 		DecryptionKey:    result.DecryptionKey,
 		Decryption:       normalizeDownloadDecryptionInfo(result.Decryption, result.DecryptionKey),
 	}, nil
@@ -1824,25 +1911,7 @@ func buildOutputPath(req DownloadRequest) string {
 		return strings.TrimSpace(req.OutputPath)
 	}
 
-	metadata := map[string]interface{}{
-		"title":        req.TrackName,
-		"artist":       req.ArtistName,
-		"album":        req.AlbumName,
-		"album_artist": req.AlbumArtist,
-		"track":        req.TrackNumber,
-		"track_number": req.TrackNumber,
-		"total_tracks": req.TotalTracks,
-		"disc":         req.DiscNumber,
-		"disc_number":  req.DiscNumber,
-		"total_discs":  req.TotalDiscs,
-		"year":         extractYear(req.ReleaseDate),
-		"date":         req.ReleaseDate,
-		"release_date": req.ReleaseDate,
-		"isrc":         req.ISRC,
-		"composer":     req.Composer,
-	}
-
-	filename := buildFilenameFromTemplate(req.FilenameFormat, metadata)
+	filename := buildFilePathFromTemplate(req.FilenameFormat, filenameTemplateMetadata(req, ""))
 	if filename == "" {
 		filename = sanitizeFilename(fmt.Sprintf("%s - %s", req.ArtistName, req.TrackName))
 	}
@@ -1858,10 +1927,11 @@ func buildOutputPath(req DownloadRequest) string {
 	if strings.TrimSpace(outputDir) == "" {
 		outputDir = filepath.Join(os.TempDir(), "spotiflac-downloads")
 	}
-	os.MkdirAll(outputDir, 0755)
+	outputPath := filepath.Join(outputDir, filename+ext)
+	os.MkdirAll(filepath.Dir(outputPath), 0755)
 	AddAllowedDownloadDir(outputDir)
 
-	return filepath.Join(outputDir, filename+ext)
+	return outputPath
 }
 
 func buildOutputPathForExtension(req DownloadRequest, ext *loadedExtension) string {
@@ -1882,25 +1952,7 @@ func buildOutputPathForExtension(req DownloadRequest, ext *loadedExtension) stri
 	os.MkdirAll(tempDir, 0755)
 	AddAllowedDownloadDir(tempDir)
 
-	metadata := map[string]interface{}{
-		"title":        req.TrackName,
-		"artist":       req.ArtistName,
-		"album":        req.AlbumName,
-		"album_artist": req.AlbumArtist,
-		"track":        req.TrackNumber,
-		"track_number": req.TrackNumber,
-		"total_tracks": req.TotalTracks,
-		"disc":         req.DiscNumber,
-		"disc_number":  req.DiscNumber,
-		"total_discs":  req.TotalDiscs,
-		"year":         extractYear(req.ReleaseDate),
-		"date":         req.ReleaseDate,
-		"release_date": req.ReleaseDate,
-		"isrc":         req.ISRC,
-		"composer":     req.Composer,
-	}
-
-	filename := buildFilenameFromTemplate(req.FilenameFormat, metadata)
+	filename := buildFilePathFromTemplate(req.FilenameFormat, filenameTemplateMetadata(req, ""))
 	if filename == "" {
 		filename = sanitizeFilename(fmt.Sprintf("%s - %s", req.ArtistName, req.TrackName))
 	}
@@ -1912,7 +1964,9 @@ func buildOutputPathForExtension(req DownloadRequest, ext *loadedExtension) stri
 		outputExt = "." + outputExt
 	}
 
-	return filepath.Join(tempDir, filename+outputExt)
+	outputPath := filepath.Join(tempDir, filename+outputExt)
+	os.MkdirAll(filepath.Dir(outputPath), 0755)
+	return outputPath
 }
 
 func canEmbedGenreLabel(filePath string) bool {
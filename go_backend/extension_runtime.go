@@ -1,6 +1,7 @@
 package gobackend
 
 import (
+	"context"
 	"fmt"
 	"net"
 	"net/http"
@@ -87,6 +88,7 @@ type extensionRuntime struct {
 	settings       map[string]interface{}
 	httpClient     *http.Client
 	downloadClient *http.Client
+	bypassClient   *http.Client
 	cookieJar      http.CookieJar
 	dataDir        string
 	vm             *goja.Runtime
@@ -139,6 +141,8 @@ func newExtensionRuntime(ext *loadedExtension) *extensionRuntime {
 
 	runtime.httpClient = newExtensionHTTPClient(ext, jar, extensionHTTPTimeout(ext, 30*time.Second))
 	runtime.downloadClient = newExtensionHTTPClient(ext, jar, DownloadTimeout)
+	runtime.bypassClient = newExtensionHTTPClient(ext, jar, extensionHTTPTimeout(ext, 30*time.Second))
+	runtime.bypassClient.Transport = &rateLimitedTransport{base: cloudflareBypassRoundTripper(), fallback: &extensionHostRateLimit}
 
 	return runtime
 }
@@ -219,16 +223,17 @@ func (r *extensionRuntime) bindDownloadCancelContext(req *http.Request) *http.Re
 		return req
 	}
 
-	return req.WithContext(initDownloadCancel(itemID))
+	return req.WithContext(downloadCancelContext(itemID))
 }
 
 func newExtensionHTTPClient(ext *loadedExtension, jar http.CookieJar, timeout time.Duration) *http.Client {
 	// Extension sandbox enforces HTTPS-only domains. Do not apply global
 	// allow_http scheme downgrade here, because some extension APIs (e.g.
 	// spotify-web) will redirect http -> https and can end up in 301 loops.
-	// We still reuse sharedTransport so insecure TLS compatibility mode remains effective.
+	// We still reuse sharedTransport so insecure TLS compatibility mode and the
+	// proxy settings remain effective.
 	client := &http.Client{
-		Transport: sharedTransport,
+		Transport: &rateLimitedTransport{base: sharedTransport, fallback: &extensionHostRateLimit},
 		Timeout:   timeout,
 		Jar:       jar,
 	}
@@ -289,7 +294,7 @@ func isPrivateIP(host string) bool {
 		return cached
 	}
 
-	ips, err := net.LookupIP(hostLower)
+	ips, err := lookupHostIPs(context.Background(), hostLower)
 	if err != nil {
 		setPrivateIPCache(hostLower, false, privateIPErrorCacheTTL)
 		return false
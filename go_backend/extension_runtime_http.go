@@ -242,6 +242,7 @@ func (r *extensionRuntime) httpRequest(call goja.FunctionCall) goja.Value {
 	method := "GET"
 	var bodyStr string
 	headers := make(map[string]string)
+	client := r.httpClient
 
 	if len(call.Arguments) > 1 && !goja.IsUndefined(call.Arguments[1]) && !goja.IsNull(call.Arguments[1]) {
 		optionsObj := call.Arguments[1].Export()
@@ -250,6 +251,12 @@ func (r *extensionRuntime) httpRequest(call goja.FunctionCall) goja.Value {
 				method = strings.ToUpper(m)
 			}
 
+			// cloudflareBypass sends this call through the uTLS client, which
+			// rotates TLS fingerprints when Cloudflare answers with a challenge.
+			if bypass, ok := opts["cloudflareBypass"].(bool); ok && bypass {
+				client = r.bypassClient
+			}
+
 			if bodyArg, ok := opts["body"]; ok && bodyArg != nil {
 				switch v := bodyArg.(type) {
 				case string:
@@ -299,7 +306,7 @@ func (r *extensionRuntime) httpRequest(call goja.FunctionCall) goja.Value {
 		req.Header.Set("Content-Type", "application/json")
 	}
 
-	resp, err := r.httpClient.Do(req)
+	resp, err := client.Do(req)
 	if err != nil {
 		return r.vm.ToValue(map[string]interface{}{
 			"error": err.Error(),
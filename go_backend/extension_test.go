@@ -303,6 +303,7 @@ func TestExtensionRuntime_BindDownloadCancelContext(t *testing.T) {
 
 	runtime := newExtensionRuntime(ext)
 	runtime.setActiveDownloadItemID("test-item")
+	initDownloadCancel("test-item")
 	t.Cleanup(func() {
 		clearDownloadCancel("test-item")
 		runtime.clearActiveDownloadItemID()
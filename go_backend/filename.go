@@ -2,6 +2,7 @@ package gobackend
 
 import (
 	"fmt"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -11,11 +12,9 @@ import (
 )
 
 var (
-	invalidChars                   = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
-	multiUnderscore                = regexp.MustCompile(`_+`)
-	formattedNumberPlaceholderExpr = regexp.MustCompile(`\{(track|disc):([0-9]+)\}`)
-	dateFormatPlaceholderExpr      = regexp.MustCompile(`\{date:([^{}]+)\}`)
-	yearPattern                    = regexp.MustCompile(`\d{4}`)
+	invalidChars    = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+	multiUnderscore = regexp.MustCompile(`_+`)
+	yearPattern     = regexp.MustCompile(`\d{4}`)
 )
 
 func sanitizeFilename(filename string) string {
@@ -60,65 +59,335 @@ func sanitizeFilename(filename string) string {
 	return sanitized
 }
 
+// templateSegmentBreak marks a folder boundary while rendering so that a "/"
+// inside a tag value is never mistaken for one.
+const templateSegmentBreak = "\x00"
+
+// buildFilenameFromTemplate renders a filename template. Besides plain
+// placeholders it supports:
+//
+//	{field} or {field:format}     track/disc/totals take a zero-pad width,
+//	                              date takes a strftime pattern
+//	{album_artist|artist|"VA"}    first non-empty field or quoted literal
+//	{title|lower|trunc:40}        upper, lower, titlecase, first, trunc:N
+//	{?total_discs>1:Disc {disc}/} section rendered when the condition holds
+//	                              (field, !field, ==, !=, >, >=, <, <=)
+//	{?:{album} - }                section rendered when all its fields are set
+//
+// Fields: title, artist, album, album_artist, track, disc, total_tracks,
+// total_discs, year, date, genre, label, isrc, composer, quality, bit_depth,
+// sample_rate and provider. A "/" outside placeholders starts a new folder;
+// unknown placeholders are left as written.
 func buildFilenameFromTemplate(template string, metadata map[string]interface{}) string {
 	if template == "" {
 		template = "{artist} - {title}"
 	}
 
-	result := replaceFormattedNumberPlaceholders(template, metadata)
-	result = replaceDateFormatPlaceholders(result, metadata)
+	result, _ := renderTemplate(template, metadata)
+	return strings.ReplaceAll(result, templateSegmentBreak, "/")
+}
 
-	dateValue := getDateValue(metadata)
-	yearValue := getString(metadata, "year")
-	if yearValue == "" {
-		yearValue = extractYear(dateValue)
+// buildFilePathFromTemplate renders a template into a relative path with each
+// folder and file name sanitized on its own. Segments that render empty,
+// such as an omitted disc folder, are dropped.
+func buildFilePathFromTemplate(template string, metadata map[string]interface{}) string {
+	if template == "" {
+		template = "{artist} - {title}"
 	}
 
-	placeholders := map[string]string{
-		"{title}":     getString(metadata, "title"),
-		"{artist}":    getString(metadata, "artist"),
-		"{album}":     getString(metadata, "album"),
-		"{track}":     formatTrackNumber(getInt(metadata, "track")),
-		"{track_raw}": formatRawNumber(getInt(metadata, "track")),
-		"{year}":      yearValue,
-		"{date}":      dateValue,
-		"{disc}":      formatDiscNumber(getInt(metadata, "disc")),
-		"{disc_raw}":  formatRawNumber(getInt(metadata, "disc")),
+	result, _ := renderTemplate(template, metadata)
+	var segments []string
+	for _, segment := range strings.Split(result, templateSegmentBreak) {
+		if strings.TrimSpace(segment) == "" {
+			continue
+		}
+		segments = append(segments, sanitizeFilename(segment))
+	}
+	if len(segments) == 0 {
+		return sanitizeFilename("")
 	}
+	return filepath.Join(segments...)
+}
 
-	for placeholder, value := range placeholders {
-		result = strings.ReplaceAll(result, placeholder, value)
+// renderTemplate also reports whether every known placeholder had a value,
+// which drives {?:...} sections.
+func renderTemplate(template string, metadata map[string]interface{}) (string, bool) {
+	var out strings.Builder
+	complete := true
+	for i := 0; i < len(template); {
+		switch template[i] {
+		case '/':
+			out.WriteString(templateSegmentBreak)
+			i++
+			continue
+		case '{':
+			end := matchingTemplateBrace(template, i)
+			if end < 0 {
+				break
+			}
+			inner := template[i+1 : end]
+			if spec, ok := strings.CutPrefix(inner, "?"); ok {
+				out.WriteString(renderTemplateConditional(spec, metadata))
+			} else if value, known := evaluateTemplateExpression(inner, metadata); known {
+				if value == "" {
+					complete = false
+				}
+				out.WriteString(value)
+			} else {
+				out.WriteString(template[i : end+1])
+			}
+			i = end + 1
+			continue
+		}
+		out.WriteByte(template[i])
+		i++
 	}
+	return out.String(), complete
+}
 
-	return result
+func matchingTemplateBrace(template string, open int) int {
+	depth := 0
+	for i := open; i < len(template); i++ {
+		switch template[i] {
+		case '"':
+			if depth > 0 {
+				if next := strings.IndexByte(template[i+1:], '"'); next >= 0 {
+					i += next + 1
+				}
+			}
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
 }
 
-func replaceFormattedNumberPlaceholders(template string, metadata map[string]interface{}) string {
-	return formattedNumberPlaceholderExpr.ReplaceAllStringFunc(template, func(match string) string {
-		parts := formattedNumberPlaceholderExpr.FindStringSubmatch(match)
-		if len(parts) != 3 {
+func renderTemplateConditional(spec string, metadata map[string]interface{}) string {
+	cond, body, ok := strings.Cut(spec, ":")
+	if !ok {
+		return ""
+	}
+	if strings.TrimSpace(cond) == "" {
+		rendered, complete := renderTemplate(body, metadata)
+		if !complete {
 			return ""
 		}
+		return rendered
+	}
+	if !evaluateTemplateCondition(cond, metadata) {
+		return ""
+	}
+	rendered, _ := renderTemplate(body, metadata)
+	return rendered
+}
 
-		number := getInt(metadata, parts[1])
-		width, err := strconv.Atoi(parts[2])
-		if err != nil {
-			return ""
+var templateConditionOperators = []string{">=", "<=", "!=", "==", ">", "<", "="}
+
+func evaluateTemplateCondition(cond string, metadata map[string]interface{}) bool {
+	cond = strings.TrimSpace(cond)
+	if name, ok := strings.CutPrefix(cond, "!"); ok && !strings.HasPrefix(name, "=") {
+		value, _ := lookupTemplateField(strings.TrimSpace(name), "", metadata)
+		return value == ""
+	}
+
+	for _, op := range templateConditionOperators {
+		idx := strings.Index(cond, op)
+		if idx < 0 {
+			continue
 		}
+		left, _ := lookupTemplateField(strings.TrimSpace(cond[:idx]), "", metadata)
+		right := strings.Trim(strings.TrimSpace(cond[idx+len(op):]), `"`)
+
+		leftNum, leftErr := strconv.ParseFloat(left, 64)
+		rightNum, rightErr := strconv.ParseFloat(right, 64)
+		if leftErr != nil || rightErr != nil {
+			if op == ">=" || op == "<=" || op == ">" || op == "<" {
+				if left == "" && rightErr == nil {
+					leftNum, leftErr = 0, nil
+				} else {
+					return false
+				}
+			} else {
+				equal := strings.EqualFold(left, right)
+				return equal == (op != "!=")
+			}
+		}
+		switch op {
+		case ">=":
+			return leftNum >= rightNum
+		case "<=":
+			return leftNum <= rightNum
+		case "!=":
+			return leftNum != rightNum
+		case ">":
+			return leftNum > rightNum
+		case "<":
+			return leftNum < rightNum
+		default:
+			return leftNum == rightNum
+		}
+	}
 
-		return formatNumberWithWidth(number, width)
-	})
+	value, _ := lookupTemplateField(cond, "", metadata)
+	return value != ""
 }
 
-func replaceDateFormatPlaceholders(template string, metadata map[string]interface{}) string {
-	return dateFormatPlaceholderExpr.ReplaceAllStringFunc(template, func(match string) string {
-		parts := dateFormatPlaceholderExpr.FindStringSubmatch(match)
-		if len(parts) != 2 {
-			return ""
+var templateFunctions = map[string]func(value, arg string) string{
+	"upper":     func(value, _ string) string { return strings.ToUpper(value) },
+	"lower":     func(value, _ string) string { return strings.ToLower(value) },
+	"titlecase": func(value, _ string) string { return titleCaseWords(value) },
+	"first": func(value, _ string) string {
+		if artists := splitArtistTagValues(value); len(artists) > 0 {
+			return artists[0]
+		}
+		return value
+	},
+	"trunc": func(value, arg string) string {
+		limit, err := strconv.Atoi(strings.TrimSpace(arg))
+		runes := []rune(value)
+		if err != nil || limit <= 0 || len(runes) <= limit {
+			return value
 		}
+		return strings.TrimSpace(string(runes[:limit]))
+	},
+}
 
-		return formatDateWithPattern(getDateValue(metadata), parts[1])
-	})
+// evaluateTemplateExpression resolves a "|" chain of fields, quoted
+// literals and functions. known is false when no term was recognised.
+func evaluateTemplateExpression(expr string, metadata map[string]interface{}) (string, bool) {
+	value := ""
+	known := false
+	for _, term := range splitTemplateTerms(expr) {
+		term = strings.TrimSpace(term)
+		if len(term) >= 2 && strings.HasPrefix(term, `"`) && strings.HasSuffix(term, `"`) {
+			if value == "" {
+				value = term[1 : len(term)-1]
+			}
+			known = true
+			continue
+		}
+
+		name, arg, _ := strings.Cut(term, ":")
+		if fn, ok := templateFunctions[name]; ok && known {
+			value = fn(value, arg)
+			continue
+		}
+		fieldValue, isField := lookupTemplateField(name, arg, metadata)
+		if !isField {
+			continue
+		}
+		known = true
+		if value == "" {
+			value = fieldValue
+		}
+	}
+	return strings.ReplaceAll(value, templateSegmentBreak, ""), known
+}
+
+func splitTemplateTerms(expr string) []string {
+	var terms []string
+	inQuote := false
+	last := 0
+	for i := 0; i < len(expr); i++ {
+		switch expr[i] {
+		case '"':
+			inQuote = !inQuote
+		case '|':
+			if !inQuote {
+				terms = append(terms, expr[last:i])
+				last = i + 1
+			}
+		}
+	}
+	return append(terms, expr[last:])
+}
+
+// lookupTemplateField returns a field's display value; isField is false for
+// names that are neither built-in fields nor keys in metadata.
+func lookupTemplateField(name, format string, metadata map[string]interface{}) (string, bool) {
+	switch name {
+	case "title", "artist", "album", "album_artist", "isrc", "genre", "label", "composer", "quality":
+		return getString(metadata, name), true
+	case "provider":
+		for _, key := range []string{"provider", "service", "source"} {
+			if value := getString(metadata, key); value != "" {
+				return value, true
+			}
+		}
+		return "", true
+	case "track", "disc", "total_tracks", "total_discs":
+		number := getInt(metadata, name)
+		if width, err := strconv.Atoi(format); err == nil && format != "" {
+			return formatNumberWithWidth(number, width), true
+		}
+		if name == "track" {
+			return formatTrackNumber(number), true
+		}
+		return formatRawNumber(number), true
+	case "track_raw", "disc_raw":
+		return formatRawNumber(getInt(metadata, strings.TrimSuffix(name, "_raw"))), true
+	case "bit_depth":
+		return formatRawNumber(getInt(metadata, name)), true
+	case "sample_rate":
+		return formatSampleRateKHz(metadata[name]), true
+	case "year":
+		if year := getString(metadata, "year"); year != "" {
+			return year, true
+		}
+		return extractYear(getDateValue(metadata)), true
+	case "date":
+		if format != "" {
+			return formatDateWithPattern(getDateValue(metadata), format), true
+		}
+		return getDateValue(metadata), true
+	}
+
+	if _, ok := metadata[name]; ok && name != "" {
+		return getString(metadata, name), true
+	}
+	return "", false
+}
+
+// formatSampleRateKHz accepts Hz (44100) or kHz (44.1) and renders kHz
+// without trailing zeros.
+func formatSampleRateKHz(value interface{}) string {
+	var rate float64
+	switch v := value.(type) {
+	case int:
+		rate = float64(v)
+	case int64:
+		rate = float64(v)
+	case float64:
+		rate = v
+	case string:
+		rate, _ = strconv.ParseFloat(strings.TrimSpace(v), 64)
+	}
+	if rate <= 0 {
+		return ""
+	}
+	if rate >= 1000 {
+		rate /= 1000
+	}
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
+
+func titleCaseWords(value string) string {
+	var builder strings.Builder
+	startOfWord := true
+	for _, r := range value {
+		if startOfWord {
+			builder.WriteRune(unicode.ToUpper(r))
+		} else {
+			builder.WriteRune(r)
+		}
+		startOfWord = unicode.IsSpace(r) || r == '(' || r == '[' || r == '-'
+	}
+	return builder.String()
 }
 
 func getDateValue(metadata map[string]interface{}) string {
@@ -158,6 +427,10 @@ func getInt(m map[string]interface{}, key string) int {
 		candidateKeys = append(candidateKeys, "track_number")
 	case "disc":
 		candidateKeys = append(candidateKeys, "disc_number")
+	case "total_tracks":
+		candidateKeys = append(candidateKeys, "track_total")
+	case "total_discs":
+		candidateKeys = append(candidateKeys, "disc_total")
 	}
 
 	for _, candidate := range candidateKeys {
@@ -324,3 +597,33 @@ func extractYear(date string) string {
 	}
 	return date
 }
+
+// filenameTemplateMetadata collects the template fields known before a
+// download starts. Callers add bit_depth and sample_rate when the provider
+// reports them up front.
+func filenameTemplateMetadata(req DownloadRequest, provider string) map[string]interface{} {
+	if provider == "" {
+		provider = req.Service
+	}
+	return map[string]interface{}{
+		"title":        req.TrackName,
+		"artist":       req.ArtistName,
+		"album":        req.AlbumName,
+		"album_artist": req.AlbumArtist,
+		"track":        req.TrackNumber,
+		"track_number": req.TrackNumber,
+		"total_tracks": req.TotalTracks,
+		"disc":         req.DiscNumber,
+		"disc_number":  req.DiscNumber,
+		"total_discs":  req.TotalDiscs,
+		"year":         extractYear(req.ReleaseDate),
+		"date":         req.ReleaseDate,
+		"release_date": req.ReleaseDate,
+		"isrc":         req.ISRC,
+		"composer":     req.Composer,
+		"genre":        req.Genre,
+		"label":        req.Label,
+		"quality":      req.Quality,
+		"provider":     provider,
+	}
+}
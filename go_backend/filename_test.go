@@ -1,6 +1,9 @@
 package gobackend
 
-import "testing"
+import (
+	"path/filepath"
+	"testing"
+)
 
 func TestBuildFilenameFromTemplate_WithRawTrackAndDisc(t *testing.T) {
 	metadata := map[string]interface{}{
@@ -98,3 +101,71 @@ func TestSanitizeFilenameFallsBackToUnknownWhenEmpty(t *testing.T) {
 		t.Fatalf("expected %q, got %q", "Unknown", got)
 	}
 }
+
+func TestBuildFilenameFromTemplate_FallbacksAndFunctions(t *testing.T) {
+	metadata := map[string]interface{}{
+		"title":  "a very long song title",
+		"artist": "Main Artist, Guest",
+	}
+
+	formatted := buildFilenameFromTemplate(`{album_artist|artist|first} - {title|titlecase|trunc:11} - {genre|"Unknown"|upper}`, metadata)
+	expected := "Main Artist - A Very Long - UNKNOWN"
+	if formatted != expected {
+		t.Fatalf("expected %q, got %q", expected, formatted)
+	}
+}
+
+func TestBuildFilenameFromTemplate_ConditionalSections(t *testing.T) {
+	template := "{artist}{?: - {album}} - {title}{?bit_depth>16: [{bit_depth}-{sample_rate}]}"
+
+	formatted := buildFilenameFromTemplate(template, map[string]interface{}{
+		"artist": "Artist",
+		"title":  "Song",
+	})
+	if formatted != "Artist - Song" {
+		t.Fatalf("expected empty sections to be dropped, got %q", formatted)
+	}
+
+	formatted = buildFilenameFromTemplate(template, map[string]interface{}{
+		"artist":      "Artist",
+		"album":       "Album",
+		"title":       "Song",
+		"bit_depth":   24,
+		"sample_rate": 96000,
+	})
+	if formatted != "Artist - Album - Song [24-96]" {
+		t.Fatalf("unexpected conditional output %q", formatted)
+	}
+
+	if got := buildFilenameFromTemplate("{title} {unknown}", map[string]interface{}{"title": "Song"}); got != "Song {unknown}" {
+		t.Fatalf("unknown placeholders should be kept, got %q", got)
+	}
+}
+
+func TestBuildFilePathFromTemplate_SanitizesSegments(t *testing.T) {
+	template := "{album_artist|artist}/{album}/{?total_discs>1:Disc {disc}/}{track} {title}"
+
+	path := buildFilePathFromTemplate(template, map[string]interface{}{
+		"artist":      "AC/DC",
+		"album":       "Back: In Black",
+		"title":       "Hells Bells",
+		"track":       1,
+		"disc":        1,
+		"total_discs": 1,
+	})
+	if expected := filepath.Join("AC DC", "Back In Black", "01 Hells Bells"); path != expected {
+		t.Fatalf("expected %q, got %q", expected, path)
+	}
+
+	path = buildFilePathFromTemplate(template, map[string]interface{}{
+		"artist":      "Artist",
+		"album":       "Album",
+		"title":       "Song",
+		"track":       3,
+		"disc":        2,
+		"total_discs": 2,
+	})
+	if expected := filepath.Join("Artist", "Album", "Disc 2", "03 Song"); path != expected {
+		t.Fatalf("expected %q, got %q", expected, path)
+	}
+}
@@ -0,0 +1,356 @@
+package gobackend
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+type FLACVerifyResult struct {
+	FilePath        string `json:"filePath"`
+	Valid           bool   `json:"valid"`
+	Frames          int    `json:"frames"`
+	DecodedSamples  int64  `json:"decodedSamples"`
+	ExpectedSamples int64  `json:"expectedSamples"`
+	MD5Checked      bool   `json:"md5Checked"`
+	MD5Match        bool   `json:"md5Match"`
+	ExpectedMD5     string `json:"expectedMd5,omitempty"`
+	ActualMD5       string `json:"actualMd5,omitempty"`
+	ErrorKind       string `json:"errorKind,omitempty"`
+	Error           string `json:"error,omitempty"`
+	ErrorOffset     int64  `json:"errorOffset,omitempty"`
+	ElapsedMS       int64  `json:"elapsedMs"`
+}
+
+const (
+	flacVerifyErrorOpen          = "unreadable"
+	flacVerifyErrorMD5           = "md5_mismatch"
+	flacVerifyErrorSampleCount   = "sample_count_mismatch"
+	flacVerifyErrorCancelled     = "cancelled"
+	flacVerifyProgressEveryBytes = 1 << 20
+)
+
+// FLACIntegrityError is returned by download paths when the written file
+// fails verification, so fallback logic can treat it like a provider failure.
+type FLACIntegrityError struct {
+	Result *FLACVerifyResult
+}
+
+func (e *FLACIntegrityError) Error() string {
+	if e.Result == nil {
+		return "FLAC integrity check failed"
+	}
+	return fmt.Sprintf("FLAC integrity check failed (%s): %s", e.Result.ErrorKind, e.Result.Error)
+}
+
+func (e *FLACIntegrityError) Is(target error) bool {
+	return target == ErrIntegrityFailed
+}
+
+func writeFLACSamplesForMD5(buf *bytes.Buffer, samples [][]int32, bps int) {
+	width := (bps + 7) / 8
+	blockSize := len(samples[0])
+	for i := 0; i < blockSize; i++ {
+		for _, ch := range samples {
+			v := uint32(ch[i])
+			for b := 0; b < width; b++ {
+				buf.WriteByte(byte(v >> (8 * b)))
+			}
+		}
+	}
+}
+
+// verifyFLACFile decodes every frame, relying on the decoder's CRC-8/CRC-16
+// checks, then compares the decoded sample count and MD5 with STREAMINFO.
+// An error is only returned for cancellation; decode failures are reported
+// through the result.
+func verifyFLACFile(filePath string, cancelCh <-chan struct{}, onProgress func(done, total int64)) (*FLACVerifyResult, error) {
+	started := time.Now()
+	result := &FLACVerifyResult{FilePath: filePath}
+	finish := func() *FLACVerifyResult {
+		result.ElapsedMS = time.Since(started).Milliseconds()
+		return result
+	}
+
+	var totalBytes int64
+	if info, err := os.Stat(filePath); err == nil {
+		totalBytes = info.Size()
+	}
+
+	dec, err := openFLACDecoderFile(filePath)
+	if err != nil {
+		result.ErrorKind = flacVerifyErrorOpen
+		result.Error = err.Error()
+		return finish(), nil
+	}
+	defer dec.Close()
+
+	result.ExpectedSamples = dec.Info.TotalSamples
+	var zeroMD5 [16]byte
+	hasMD5 := dec.Info.MD5 != zeroMD5
+	hasher := md5.New()
+	var sampleBuf bytes.Buffer
+	var lastReported int64
+
+	for {
+		select {
+		case <-cancelCh:
+			result.ErrorKind = flacVerifyErrorCancelled
+			return finish(), fmt.Errorf("verification cancelled")
+		default:
+		}
+
+		frame, err := dec.NextFrame()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			var frameErr *flacFrameError
+			if errors.As(err, &frameErr) {
+				result.ErrorKind = frameErr.Kind
+				result.ErrorOffset = frameErr.Offset
+			} else {
+				result.ErrorKind = flacFrameErrorData
+			}
+			result.Error = err.Error()
+			return finish(), nil
+		}
+
+		result.Frames++
+		result.DecodedSamples += int64(frame.Header.BlockSize)
+		if hasMD5 {
+			sampleBuf.Reset()
+			writeFLACSamplesForMD5(&sampleBuf, frame.Samples, frame.Header.BitsPerSample)
+			hasher.Write(sampleBuf.Bytes())
+		}
+
+		if onProgress != nil && frame.Offset-lastReported >= flacVerifyProgressEveryBytes {
+			lastReported = frame.Offset
+			onProgress(frame.Offset, totalBytes)
+		}
+	}
+
+	if onProgress != nil {
+		onProgress(totalBytes, totalBytes)
+	}
+
+	if result.ExpectedSamples > 0 && result.DecodedSamples != result.ExpectedSamples {
+		result.ErrorKind = flacVerifyErrorSampleCount
+		result.Error = fmt.Sprintf("decoded %d samples, STREAMINFO declares %d", result.DecodedSamples, result.ExpectedSamples)
+		return finish(), nil
+	}
+
+	if hasMD5 {
+		result.MD5Checked = true
+		result.ExpectedMD5 = hex.EncodeToString(dec.Info.MD5[:])
+		result.ActualMD5 = hex.EncodeToString(hasher.Sum(nil))
+		result.MD5Match = result.ExpectedMD5 == result.ActualMD5
+		if !result.MD5Match {
+			result.ErrorKind = flacVerifyErrorMD5
+			result.Error = "decoded audio MD5 does not match STREAMINFO"
+			return finish(), nil
+		}
+	}
+
+	result.Valid = true
+	return finish(), nil
+}
+
+// verifyDownloadedFile runs the integrity check for requests that opted in.
+// Non-FLAC, SAF and still-encrypted outputs are skipped because they cannot
+// be decoded here. A failing file is removed so the next provider starts clean.
+func verifyDownloadedFile(req DownloadRequest, filePath string, decryption *DownloadDecryptionInfo) error {
+	if !req.VerifyIntegrity || decryption != nil {
+		return nil
+	}
+	path := strings.TrimSpace(filePath)
+	if shouldSkipQualityProbe(path) || !strings.EqualFold(filepath.Ext(path), ".flac") {
+		return nil
+	}
+
+	result, err := verifyFLACFile(path, nil, nil)
+	if err != nil {
+		return err
+	}
+	if result.Valid {
+		GoLog("[Verify] %s passed integrity check (%d frames, md5=%v)\n", filepath.Base(path), result.Frames, result.MD5Checked)
+		return nil
+	}
+
+	GoLog("[Verify] %s failed integrity check: %s\n", filepath.Base(path), result.Error)
+	if removeErr := os.Remove(path); removeErr != nil && !os.IsNotExist(removeErr) {
+		GoLog("[Verify] Failed to remove corrupt file %s: %v\n", path, removeErr)
+	}
+	return &FLACIntegrityError{Result: result}
+}
+
+type LibraryIntegrityProgress struct {
+	TotalFiles   int     `json:"total_files"`
+	CheckedFiles int     `json:"checked_files"`
+	FailedFiles  int     `json:"failed_files"`
+	CurrentFile  string  `json:"current_file"`
+	TotalBytes   int64   `json:"total_bytes"`
+	CheckedBytes int64   `json:"checked_bytes"`
+	ProgressPct  float64 `json:"progress_pct"`
+	IsComplete   bool    `json:"is_complete"`
+}
+
+type LibraryIntegrityReport struct {
+	Folder       string             `json:"folder"`
+	TotalFiles   int                `json:"totalFiles"`
+	ValidFiles   int                `json:"validFiles"`
+	InvalidFiles int                `json:"invalidFiles"`
+	Cancelled    bool               `json:"cancelled,omitempty"`
+	Results      []FLACVerifyResult `json:"results"`
+}
+
+var (
+	libraryIntegrityProgress   LibraryIntegrityProgress
+	libraryIntegrityProgressMu sync.RWMutex
+	libraryIntegrityCancel     chan struct{}
+	libraryIntegrityCancelMu   sync.Mutex
+)
+
+func VerifyFLACFile(filePath string) (string, error) {
+	result, err := verifyFLACFile(filePath, nil, nil)
+	if err != nil {
+		return "", err
+	}
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+func VerifyLibraryIntegrity(folderPath string) (string, error) {
+	if folderPath == "" {
+		return "", fmt.Errorf("folder path is empty")
+	}
+	info, err := os.Stat(folderPath)
+	if err != nil {
+		return "", fmt.Errorf("folder not found: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("path is not a folder: %s", folderPath)
+	}
+
+	libraryIntegrityCancelMu.Lock()
+	if libraryIntegrityCancel != nil {
+		close(libraryIntegrityCancel)
+	}
+	libraryIntegrityCancel = make(chan struct{})
+	cancelCh := libraryIntegrityCancel
+	libraryIntegrityCancelMu.Unlock()
+
+	libraryIntegrityProgressMu.Lock()
+	libraryIntegrityProgress = LibraryIntegrityProgress{}
+	libraryIntegrityProgressMu.Unlock()
+
+	allFiles, err := collectLibraryAudioFiles(folderPath, cancelCh)
+	if err != nil {
+		return "", err
+	}
+
+	var files []string
+	var totalBytes int64
+	for _, file := range allFiles {
+		if strings.ToLower(filepath.Ext(file.path)) != ".flac" {
+			continue
+		}
+		files = append(files, file.path)
+		if fi, err := os.Stat(file.path); err == nil {
+			totalBytes += fi.Size()
+		}
+	}
+
+	libraryIntegrityProgressMu.Lock()
+	libraryIntegrityProgress.TotalFiles = len(files)
+	libraryIntegrityProgress.TotalBytes = totalBytes
+	libraryIntegrityProgressMu.Unlock()
+
+	report := LibraryIntegrityReport{
+		Folder:     folderPath,
+		TotalFiles: len(files),
+		Results:    []FLACVerifyResult{},
+	}
+
+	var completedBytes int64
+	for i, path := range files {
+		libraryIntegrityProgressMu.Lock()
+		libraryIntegrityProgress.CurrentFile = filepath.Base(path)
+		libraryIntegrityProgressMu.Unlock()
+
+		result, err := verifyFLACFile(path, cancelCh, func(done, _ int64) {
+			libraryIntegrityProgressMu.Lock()
+			libraryIntegrityProgress.CheckedBytes = completedBytes + done
+			if totalBytes > 0 {
+				libraryIntegrityProgress.ProgressPct = float64(completedBytes+done) / float64(totalBytes) * 100
+			}
+			libraryIntegrityProgressMu.Unlock()
+		})
+		if err != nil {
+			report.Cancelled = true
+			break
+		}
+
+		if fi, statErr := os.Stat(path); statErr == nil {
+			completedBytes += fi.Size()
+		}
+		if result.Valid {
+			report.ValidFiles++
+		} else {
+			report.InvalidFiles++
+			GoLog("[Verify] Integrity failure in %s: %s\n", path, result.Error)
+		}
+		report.Results = append(report.Results, *result)
+
+		libraryIntegrityProgressMu.Lock()
+		libraryIntegrityProgress.CheckedFiles = i + 1
+		libraryIntegrityProgress.FailedFiles = report.InvalidFiles
+		libraryIntegrityProgressMu.Unlock()
+	}
+
+	libraryIntegrityProgressMu.Lock()
+	libraryIntegrityProgress.IsComplete = true
+	if !report.Cancelled {
+		libraryIntegrityProgress.ProgressPct = 100
+	}
+	libraryIntegrityProgressMu.Unlock()
+
+	GoLog("[Verify] Library integrity check: %d valid, %d invalid of %d FLAC files\n",
+		report.ValidFiles, report.InvalidFiles, report.TotalFiles)
+
+	jsonBytes, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal report: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+func GetLibraryIntegrityProgress() string {
+	libraryIntegrityProgressMu.RLock()
+	defer libraryIntegrityProgressMu.RUnlock()
+
+	jsonBytes, _ := json.Marshal(libraryIntegrityProgress)
+	return string(jsonBytes)
+}
+
+func CancelLibraryIntegrityVerify() {
+	libraryIntegrityCancelMu.Lock()
+	defer libraryIntegrityCancelMu.Unlock()
+
+	if libraryIntegrityCancel != nil {
+		close(libraryIntegrityCancel)
+		libraryIntegrityCancel = nil
+	}
+}
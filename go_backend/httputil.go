@@ -62,10 +62,11 @@ var (
 )
 
 var sharedTransport = &http.Transport{
-	DialContext: (&net.Dialer{
+	Proxy: proxyForRequest,
+	DialContext: newResolvingDialContext(&net.Dialer{
 		Timeout:   30 * time.Second,
 		KeepAlive: 30 * time.Second,
-	}).DialContext,
+	}),
 	MaxIdleConns:          100,
 	MaxIdleConnsPerHost:   10,
 	MaxConnsPerHost:       20,
@@ -80,10 +81,11 @@ var sharedTransport = &http.Transport{
 }
 
 var metadataTransport = &http.Transport{
-	DialContext: (&net.Dialer{
+	Proxy: proxyForRequest,
+	DialContext: newResolvingDialContext(&net.Dialer{
 		Timeout:   30 * time.Second,
 		KeepAlive: 30 * time.Second,
-	}).DialContext,
+	}),
 	MaxIdleConns:          30,
 	MaxIdleConnsPerHost:   5,
 	MaxConnsPerHost:       10,
@@ -132,6 +134,7 @@ func GetDownloadClient() *http.Client {
 func CloseIdleConnections() {
 	sharedTransport.CloseIdleConnections()
 	metadataTransport.CloseIdleConnections()
+	closeIdleBypassConnections()
 }
 
 func SetNetworkCompatibilityOptions(allowHTTP, insecureTLS bool) {
@@ -174,7 +177,7 @@ type compatibilityTransport struct {
 }
 
 func newCompatibilityTransport(base http.RoundTripper) http.RoundTripper {
-	return &compatibilityTransport{base: base}
+	return &compatibilityTransport{base: &rateLimitedTransport{base: base}}
 }
 
 func (t *compatibilityTransport) RoundTrip(req *http.Request) (*http.Response, error) {
@@ -281,7 +284,9 @@ func DoRequestWithRetry(client *http.Client, req *http.Request, config RetryConf
 			if attempt < config.MaxRetries {
 				GoLog("[HTTP] Request failed (attempt %d/%d): %v, retrying in %v...\n",
 					attempt+1, config.MaxRetries+1, err, delay)
-				time.Sleep(delay)
+				if err := sleepWithContext(req.Context(), delay); err != nil {
+					return nil, err
+				}
 				delay = calculateNextDelay(delay, config)
 			}
 			continue
@@ -300,7 +305,9 @@ func DoRequestWithRetry(client *http.Client, req *http.Request, config RetryConf
 			lastErr = fmt.Errorf("rate limited (429)")
 			if attempt < config.MaxRetries {
 				GoLog("[HTTP] Rate limited, waiting %v before retry...\n", delay)
-				time.Sleep(delay)
+				if err := sleepWithContext(req.Context(), delay); err != nil {
+					return nil, err
+				}
 				delay = calculateNextDelay(delay, config)
 			}
 			continue
@@ -332,7 +339,9 @@ func DoRequestWithRetry(client *http.Client, req *http.Request, config RetryConf
 			lastErr = fmt.Errorf("server error: HTTP %d", resp.StatusCode)
 			if attempt < config.MaxRetries {
 				GoLog("[HTTP] Server error %d, retrying in %v...\n", resp.StatusCode, delay)
-				time.Sleep(delay)
+				if err := sleepWithContext(req.Context(), delay); err != nil {
+					return nil, err
+				}
 				delay = calculateNextDelay(delay, config)
 			}
 			continue
@@ -417,6 +426,7 @@ func BuildErrorMessage(apiURL string, statusCode int, responsePreview string) st
 type ISPBlockingError struct {
 	Domain      string
 	Reason      string
+	Suggestion  string
 	OriginalErr error
 }
 
@@ -424,7 +434,42 @@ func (e *ISPBlockingError) Error() string {
 	return fmt.Sprintf("ISP blocking detected for %s: %s", e.Domain, e.Reason)
 }
 
+func (e *ISPBlockingError) Is(target error) bool {
+	return target == ErrISPBlocked
+}
+
 func IsISPBlocking(err error, requestURL string) *ISPBlockingError {
+	ispErr := detectISPBlocking(err, requestURL)
+	if ispErr != nil {
+		ispErr.Suggestion = ispBlockingSuggestion(ispErr.Domain, err)
+	}
+	return ispErr
+}
+
+// ispBlockingSuggestion points at the network settings: DNS-over-HTTPS for
+// DNS failures, then a failing proxy, a configured proxy that bypasses this
+// domain, or none at all.
+func ispBlockingSuggestion(domain string, err error) string {
+	host := domain
+	if h, _, splitErr := net.SplitHostPort(domain); splitErr == nil {
+		host = h
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) || strings.Contains(strings.ToLower(err.Error()), "no such host") {
+		if !isDoHEnabled() {
+			return "Enable DNS-over-HTTPS to bypass DNS blocking, or retry via an HTTP or SOCKS5 proxy"
+		}
+	}
+	if proxyURL := proxyForHost(host); proxyURL != nil {
+		return fmt.Sprintf("Request already went through proxy %s - check that the proxy is reachable or try another one", proxyURL.Redacted())
+	}
+	if hasProxyConfigured() {
+		return fmt.Sprintf("Retry via proxy by routing %s through the configured proxy", host)
+	}
+	return "Retry via an HTTP or SOCKS5 proxy, use a VPN, or change your DNS to 1.1.1.1 or 8.8.8.8"
+}
+
+func detectISPBlocking(err error, requestURL string) *ISPBlockingError {
 	if err == nil {
 		return nil
 	}
@@ -528,7 +573,7 @@ func CheckAndLogISPBlocking(err error, requestURL string, tag string) bool {
 		LogError(tag, "Domain: %s", ispErr.Domain)
 		LogError(tag, "Reason: %s", ispErr.Reason)
 		LogError(tag, "Original error: %v", ispErr.OriginalErr)
-		LogError(tag, "Suggestion: Try using a VPN or changing your DNS to 1.1.1.1 or 8.8.8.8")
+		LogError(tag, "Suggestion: %s", ispErr.Suggestion)
 		return true
 	}
 	return false
@@ -562,7 +607,7 @@ func WrapErrorWithISPCheck(err error, requestURL string, tag string) error {
 
 	if CheckAndLogISPBlocking(err, requestURL, tag) {
 		domain := extractDomain(requestURL)
-		return fmt.Errorf("ISP blocking detected for %s - try using VPN or change DNS to 1.1.1.1/8.8.8.8: %w", domain, err)
+		return fmt.Errorf("ISP blocking detected for %s - %s: %w", domain, ispBlockingSuggestion(domain, err), err)
 	}
 
 	return err
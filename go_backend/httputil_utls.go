@@ -1,11 +1,7 @@
-//go:build !ios
-
 package gobackend
 
 import (
 	"context"
-	"crypto/tls"
-	"io"
 	"net"
 	"net/http"
 	"net/url"
@@ -16,10 +12,30 @@ import (
 	"golang.org/x/net/http2"
 )
 
+var utlsHelloProfiles = map[string]utls.ClientHelloID{
+	"chrome_133":         utls.HelloChrome_133,
+	"chrome_131":         utls.HelloChrome_131,
+	"chrome_120":         utls.HelloChrome_120,
+	"firefox_120":        utls.HelloFirefox_120,
+	"firefox_105":        utls.HelloFirefox_105,
+	"safari_16":          utls.HelloSafari_16_0,
+	"ios_14":             utls.HelloIOS_14,
+	"edge_85":            utls.HelloEdge_85,
+	tlsProfileRandomized: utls.HelloRandomizedALPN,
+}
+
+func utlsHelloForProfile(profile string) utls.ClientHelloID {
+	if hello, ok := utlsHelloProfiles[profile]; ok {
+		return hello
+	}
+	return utls.HelloChrome_Auto
+}
+
 type utlsTransport struct {
-	dialer       *net.Dialer
-	mu           sync.Mutex
-	h2Transports map[string]*http2.Transport
+	dialer  *net.Dialer
+	h2      *http2.Transport
+	mu      sync.Mutex
+	h2Conns map[string]*http2.ClientConn
 }
 
 func newUTLSTransport() *utlsTransport {
@@ -28,20 +44,62 @@ func newUTLSTransport() *utlsTransport {
 			Timeout:   30 * Second,
 			KeepAlive: 30 * Second,
 		},
-		h2Transports: make(map[string]*http2.Transport),
+		h2: &http2.Transport{
+			IdleConnTimeout: 90 * Second,
+		},
+		h2Conns: make(map[string]*http2.ClientConn),
 	}
 }
 
+// RoundTrip sends req with the host's current fingerprint profile. A
+// Cloudflare challenge moves the host on to the next profile and, when the
+// body can be replayed, retries with it.
 func (t *utlsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	if req.URL.Scheme != "https" {
 		return sharedTransport.RoundTrip(req)
 	}
 
 	host := req.URL.Hostname()
-	port := t.getPort(req.URL)
-	addr := net.JoinHostPort(host, port)
+	attempts := tlsFingerprints.maxAttempts()
+	for attempt := 1; ; attempt++ {
+		profile := tlsFingerprints.profileFor(host)
+		resp, err := t.roundTripProfile(req, profile)
+		if err != nil || attempt >= attempts || !isCloudflareChallenge(resp) {
+			return resp, err
+		}
+		retry := replayableRequest(req)
+		if retry == nil {
+			return resp, nil
+		}
+		resp.Body.Close()
+
+		next := tlsFingerprints.rotate(host, profile)
+		LogWarn("HTTP", "Cloudflare challenge from %s with %s fingerprint, retrying with %s", host, profile, next)
+		req = retry
+	}
+}
+
+func (t *utlsTransport) roundTripProfile(req *http.Request, profile string) (*http.Response, error) {
+	// A Chrome User-Agent on a Firefox or Safari ClientHello is an easy bot
+	// signal, so browser User-Agents follow the profile.
+	if ua := userAgentForTLSProfile(profile); ua != "" && isBrowserUserAgent(req.Header.Get("User-Agent")) {
+		req = req.Clone(req.Context())
+		req.Header.Set("User-Agent", ua)
+	}
+
+	host := req.URL.Hostname()
+	addr := net.JoinHostPort(host, t.getPort(req.URL))
+	key := addr + "|" + profile
+
+	if cc := t.pooledConn(key); cc != nil {
+		resp, err := cc.RoundTrip(req)
+		if err != nil {
+			t.dropConn(key, cc)
+		}
+		return resp, err
+	}
 
-	conn, err := t.dialer.DialContext(req.Context(), "tcp", addr)
+	conn, err := dialWithProxy(req.Context(), t.dialer, addr)
 	if err != nil {
 		return nil, err
 	}
@@ -49,24 +107,28 @@ func (t *utlsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	tlsConn := utls.UClient(conn, &utls.Config{
 		ServerName: host,
 		NextProtos: []string{"h2", "http/1.1"},
-	}, utls.HelloChrome_Auto)
+	}, utlsHelloForProfile(profile))
 
-	if err := tlsConn.Handshake(); err != nil {
+	if err := tlsConn.HandshakeContext(req.Context()); err != nil {
 		conn.Close()
 		return nil, err
 	}
 
-	negotiatedProto := tlsConn.ConnectionState().NegotiatedProtocol
+	if tlsConn.ConnectionState().NegotiatedProtocol == "h2" {
+		cc, err := t.h2.NewClientConn(tlsConn)
+		if err != nil {
+			tlsConn.Close()
+			return nil, err
+		}
+		t.mu.Lock()
+		t.h2Conns[key] = cc
+		t.mu.Unlock()
 
-	if negotiatedProto == "h2" {
-		h2Transport := &http2.Transport{
-			DialTLSContext: func(ctx context.Context, network, addr string, cfg *tls.Config) (net.Conn, error) {
-				return tlsConn, nil
-			},
-			AllowHTTP:          false,
-			DisableCompression: false,
+		resp, err := cc.RoundTrip(req)
+		if err != nil {
+			t.dropConn(key, cc)
 		}
-		return h2Transport.RoundTrip(req)
+		return resp, err
 	}
 
 	transport := &http.Transport{
@@ -79,6 +141,42 @@ func (t *utlsTransport) RoundTrip(req *http.Request) (*http.Response, error) {
 	return transport.RoundTrip(req)
 }
 
+func (t *utlsTransport) pooledConn(key string) *http2.ClientConn {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cc, ok := t.h2Conns[key]
+	if !ok {
+		return nil
+	}
+	if !cc.CanTakeNewRequest() {
+		delete(t.h2Conns, key)
+		return nil
+	}
+	return cc
+}
+
+func (t *utlsTransport) dropConn(key string, cc *http2.ClientConn) {
+	t.mu.Lock()
+	if t.h2Conns[key] == cc {
+		delete(t.h2Conns, key)
+	}
+	t.mu.Unlock()
+	cc.Close()
+}
+
+// closeConns lets in-flight HTTP/2 streams finish, then closes every pooled
+// connection so the next request dials with the current proxy and profile.
+func (t *utlsTransport) closeConns() {
+	t.mu.Lock()
+	conns := t.h2Conns
+	t.h2Conns = make(map[string]*http2.ClientConn)
+	t.mu.Unlock()
+
+	for _, cc := range conns {
+		go cc.Shutdown(context.Background())
+	}
+}
+
 func (t *utlsTransport) getPort(u *url.URL) string {
 	if u.Port() != "" {
 		return u.Port()
@@ -92,7 +190,7 @@ func (t *utlsTransport) getPort(u *url.URL) string {
 var cloudflareBypassTransport = newUTLSTransport()
 
 var cloudflareBypassClient = &http.Client{
-	Transport: cloudflareBypassTransport,
+	Transport: &rateLimitedTransport{base: cloudflareBypassTransport},
 	Timeout:   DefaultTimeout,
 }
 
@@ -100,47 +198,27 @@ func GetCloudflareBypassClient() *http.Client {
 	return cloudflareBypassClient
 }
 
+func cloudflareBypassRoundTripper() http.RoundTripper {
+	return cloudflareBypassTransport
+}
+
+func closeIdleBypassConnections() {
+	cloudflareBypassTransport.closeConns()
+}
+
 func DoRequestWithCloudflareBypass(req *http.Request) (*http.Response, error) {
 	req.Header.Set("User-Agent", userAgentForURL(req.URL))
 
 	resp, err := sharedClient.Do(req)
 	if err == nil {
-		if resp.StatusCode == 403 || resp.StatusCode == 503 {
-			body, readErr := io.ReadAll(resp.Body)
+		if isCloudflareChallenge(resp) {
 			resp.Body.Close()
+			LogDebug("HTTP", "Cloudflare detected, retrying with %s TLS fingerprint...", tlsFingerprints.profileFor(req.URL.Hostname()))
+
+			reqCopy := req.Clone(req.Context())
+			reqCopy.Header.Set("User-Agent", userAgentForURL(reqCopy.URL))
 
-			if readErr == nil {
-				bodyStr := strings.ToLower(string(body))
-				cloudflareMarkers := []string{
-					"cloudflare", "cf-ray", "checking your browser",
-					"please wait", "ddos protection", "ray id",
-					"enable javascript", "challenge-platform",
-				}
-
-				isCloudflare := false
-				for _, marker := range cloudflareMarkers {
-					if strings.Contains(bodyStr, marker) {
-						isCloudflare = true
-						break
-					}
-				}
-
-				if isCloudflare {
-					LogDebug("HTTP", "Cloudflare detected, retrying with Chrome TLS fingerprint...")
-
-					reqCopy := req.Clone(req.Context())
-					reqCopy.Header.Set("User-Agent", userAgentForURL(reqCopy.URL))
-
-					return cloudflareBypassClient.Do(reqCopy)
-				}
-			}
-
-			return &http.Response{
-				Status:     resp.Status,
-				StatusCode: resp.StatusCode,
-				Header:     resp.Header,
-				Body:       io.NopCloser(strings.NewReader(string(body))),
-			}, nil
+			return cloudflareBypassClient.Do(reqCopy)
 		}
 		return resp, nil
 	}
@@ -152,7 +230,7 @@ func DoRequestWithCloudflareBypass(req *http.Request) (*http.Response, error) {
 		strings.Contains(errStr, "connection reset")
 
 	if tlsRelated {
-		LogDebug("HTTP", "TLS error detected, retrying with Chrome TLS fingerprint: %v", err)
+		LogDebug("HTTP", "TLS error detected, retrying with %s TLS fingerprint: %v", tlsFingerprints.profileFor(req.URL.Hostname()), err)
 
 		reqCopy := req.Clone(req.Context())
 		reqCopy.Header.Set("User-Agent", userAgentForURL(reqCopy.URL))
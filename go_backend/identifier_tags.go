@@ -0,0 +1,237 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// ProviderIdentifiers are the streaming-service IDs and source details
+// resolved while downloading a track.
+type ProviderIdentifiers struct {
+	SpotifyTrackID string `json:"spotify_track_id,omitempty"`
+	DeezerTrackID  string `json:"deezer_track_id,omitempty"`
+	TidalTrackID   string `json:"tidal_track_id,omitempty"`
+	QobuzTrackID   string `json:"qobuz_track_id,omitempty"`
+	SourceURL      string `json:"source_url,omitempty"`
+	UPC            string `json:"upc,omitempty"`
+}
+
+// TrackIdentifiers is the full set of identifier tags written to files.
+type TrackIdentifiers struct {
+	ReleaseIdentifiers
+	ProviderIdentifiers
+}
+
+// fields returns the non-empty identifiers keyed by their JSON names, which
+// are also the field names used by ReadFileMetadata and EditFileMetadata.
+func (t TrackIdentifiers) fields() map[string]string {
+	fields := make(map[string]string)
+	raw, _ := json.Marshal(t)
+	_ = json.Unmarshal(raw, &fields)
+	return fields
+}
+
+type identifierTag struct {
+	field    string // ReadFileMetadata / EditFileMetadata field name
+	vorbis   string // Vorbis comment and APEv2 key
+	freeform string // iTunes freeform atom and ID3 TXXX description
+	multi    bool
+	value    func(*TrackIdentifiers) *string
+}
+
+// identifierTags follows the names MusicBrainz Picard uses, so beets, Picard
+// and media servers pick them up without extra configuration.
+var identifierTags = []identifierTag{
+	{"musicbrainz_recording_id", "MUSICBRAINZ_TRACKID", "MusicBrainz Track Id", false,
+		func(t *TrackIdentifiers) *string { return &t.MusicBrainzRecordingID }},
+	{"musicbrainz_track_id", "MUSICBRAINZ_RELEASETRACKID", "MusicBrainz Release Track Id", false,
+		func(t *TrackIdentifiers) *string { return &t.MusicBrainzTrackID }},
+	{"musicbrainz_release_id", "MUSICBRAINZ_ALBUMID", "MusicBrainz Album Id", false,
+		func(t *TrackIdentifiers) *string { return &t.MusicBrainzReleaseID }},
+	{"musicbrainz_release_group_id", "MUSICBRAINZ_RELEASEGROUPID", "MusicBrainz Release Group Id", false,
+		func(t *TrackIdentifiers) *string { return &t.MusicBrainzReleaseGroupID }},
+	{"musicbrainz_artist_id", "MUSICBRAINZ_ARTISTID", "MusicBrainz Artist Id", true,
+		func(t *TrackIdentifiers) *string { return &t.MusicBrainzArtistID }},
+	{"musicbrainz_album_artist_id", "MUSICBRAINZ_ALBUMARTISTID", "MusicBrainz Album Artist Id", true,
+		func(t *TrackIdentifiers) *string { return &t.MusicBrainzAlbumArtistID }},
+	{"original_date", "ORIGINALDATE", "ORIGINALDATE", false,
+		func(t *TrackIdentifiers) *string { return &t.OriginalDate }},
+	{"barcode", "BARCODE", "BARCODE", false,
+		func(t *TrackIdentifiers) *string { return &t.Barcode }},
+	{"catalog_number", "CATALOGNUMBER", "CATALOGNUMBER", false,
+		func(t *TrackIdentifiers) *string { return &t.CatalogNumber }},
+	{"media", "MEDIA", "MEDIA", false,
+		func(t *TrackIdentifiers) *string { return &t.Media }},
+	{"release_country", "RELEASECOUNTRY", "MusicBrainz Album Release Country", false,
+		func(t *TrackIdentifiers) *string { return &t.ReleaseCountry }},
+	{"spotify_track_id", "SPOTIFY_TRACK_ID", "SPOTIFY_TRACK_ID", false,
+		func(t *TrackIdentifiers) *string { return &t.SpotifyTrackID }},
+	{"deezer_track_id", "DEEZER_TRACK_ID", "DEEZER_TRACK_ID", false,
+		func(t *TrackIdentifiers) *string { return &t.DeezerTrackID }},
+	{"tidal_track_id", "TIDAL_TRACK_ID", "TIDAL_TRACK_ID", false,
+		func(t *TrackIdentifiers) *string { return &t.TidalTrackID }},
+	{"qobuz_track_id", "QOBUZ_TRACK_ID", "QOBUZ_TRACK_ID", false,
+		func(t *TrackIdentifiers) *string { return &t.QobuzTrackID }},
+	{"source_url", "SOURCE_URL", "SOURCE_URL", false,
+		func(t *TrackIdentifiers) *string { return &t.SourceURL }},
+	{"upc", "UPC", "UPC", false,
+		func(t *TrackIdentifiers) *string { return &t.UPC }},
+}
+
+// setTag stores value when key is a known Vorbis/APE key or freeform/TXXX
+// name. Repeated multi-valued tags are appended with "; ".
+func (t *TrackIdentifiers) setTag(key, value string) bool {
+	key = strings.TrimSpace(key)
+	value = strings.TrimSpace(value)
+	for _, tag := range identifierTags {
+		if !strings.EqualFold(key, tag.vorbis) && !strings.EqualFold(key, tag.freeform) {
+			continue
+		}
+		if value == "" {
+			return true
+		}
+		dst := tag.value(t)
+		if tag.multi && *dst != "" {
+			*dst += "; " + value
+		} else {
+			*dst = value
+		}
+		return true
+	}
+	return false
+}
+
+// identifiersFromFields reads identifier values from an editor fields map.
+func identifiersFromFields(fields map[string]string) TrackIdentifiers {
+	var ids TrackIdentifiers
+	for _, tag := range identifierTags {
+		*tag.value(&ids) = strings.TrimSpace(fields[tag.field])
+	}
+	return ids
+}
+
+func isIdentifierField(key string) bool {
+	for _, tag := range identifierTags {
+		if tag.field == key {
+			return true
+		}
+	}
+	return false
+}
+
+func hasIdentifierFields(fields map[string]string) bool {
+	for _, tag := range identifierTags {
+		if hasMapKey(fields, tag.field) {
+			return true
+		}
+	}
+	return false
+}
+
+func splitIdentifierValues(value string) []string {
+	var values []string
+	for _, part := range strings.Split(value, ";") {
+		if part = strings.TrimSpace(part); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
+
+// stripProviderIDPrefix turns "tidal:123" into "123"; IDs for other providers
+// are rejected so a prefixed ID never lands in the wrong tag.
+func stripProviderIDPrefix(id, provider string) string {
+	id = strings.TrimSpace(id)
+	prefix, rest, ok := strings.Cut(id, ":")
+	if !ok {
+		return id
+	}
+	if strings.EqualFold(prefix, provider) {
+		return strings.TrimSpace(rest)
+	}
+	return ""
+}
+
+// providerIdentifiersFromRequest collects the provider IDs the caller
+// already resolved. SpotifyID may carry another provider's prefixed ID when
+// the track came from a non-Spotify search.
+func providerIdentifiersFromRequest(req DownloadRequest) ProviderIdentifiers {
+	ids := ProviderIdentifiers{
+		DeezerTrackID: stripProviderIDPrefix(req.DeezerID, "deezer"),
+		TidalTrackID:  stripProviderIDPrefix(req.TidalID, "tidal"),
+		QobuzTrackID:  stripProviderIDPrefix(req.QobuzID, "qobuz"),
+		UPC:           strings.TrimSpace(req.UPC),
+	}
+
+	spotifyID := strings.TrimSpace(req.SpotifyID)
+	if prefix, rest, ok := strings.Cut(spotifyID, ":"); ok {
+		rest = strings.TrimSpace(rest)
+		switch strings.ToLower(prefix) {
+		case "spotify":
+			ids.SpotifyTrackID = strings.TrimPrefix(rest, "track:")
+		case "deezer":
+			if ids.DeezerTrackID == "" {
+				ids.DeezerTrackID = rest
+			}
+		case "tidal":
+			if ids.TidalTrackID == "" {
+				ids.TidalTrackID = rest
+			}
+		case "qobuz":
+			if ids.QobuzTrackID == "" {
+				ids.QobuzTrackID = rest
+			}
+		}
+	} else {
+		ids.SpotifyTrackID = spotifyID
+	}
+
+	if ids.SpotifyTrackID != "" {
+		ids.SourceURL = spotifyTrackBaseURL + ids.SpotifyTrackID
+	}
+	return ids
+}
+
+func trackIdentifiersForDownload(req DownloadRequest, provider ProviderIdentifiers) TrackIdentifiers {
+	ids := TrackIdentifiers{
+		ReleaseIdentifiers:  req.ReleaseIdentifiers,
+		ProviderIdentifiers: providerIdentifiersFromRequest(req),
+	}
+	if provider.SpotifyTrackID != "" {
+		ids.SpotifyTrackID = provider.SpotifyTrackID
+	}
+	if provider.DeezerTrackID != "" {
+		ids.DeezerTrackID = provider.DeezerTrackID
+	}
+	if provider.TidalTrackID != "" {
+		ids.TidalTrackID = provider.TidalTrackID
+	}
+	if provider.QobuzTrackID != "" {
+		ids.QobuzTrackID = provider.QobuzTrackID
+	}
+	if provider.SourceURL != "" {
+		ids.SourceURL = provider.SourceURL
+	}
+	if provider.UPC != "" {
+		ids.UPC = provider.UPC
+	}
+	return ids
+}
+
+func formatProviderTrackID(id int64) string {
+	if id <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// libraryIdentifierFields returns nil when no identifiers were found so the
+// field is omitted from scan results.
+func libraryIdentifierFields(ids TrackIdentifiers) map[string]string {
+	fields := ids.fields()
+	if len(fields) == 0 {
+		return nil
+	}
+	return fields
+}
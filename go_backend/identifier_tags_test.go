@@ -0,0 +1,106 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestProviderIdentifiersFromRequest(t *testing.T) {
+	ids := providerIdentifiersFromRequest(DownloadRequest{
+		SpotifyID: "tidal:111",
+		QobuzID:   "qobuz:222",
+		DeezerID:  "333",
+		UPC:       " 0001 ",
+	})
+	want := ProviderIdentifiers{TidalTrackID: "111", QobuzTrackID: "222", DeezerTrackID: "333", UPC: "0001"}
+	if ids != want {
+		t.Fatalf("providerIdentifiersFromRequest = %+v, want %+v", ids, want)
+	}
+
+	ids = providerIdentifiersFromRequest(DownloadRequest{SpotifyID: "4uLU6hMCjMI75M1A2tKUQC", TidalID: "qobuz:9"})
+	if ids.SpotifyTrackID != "4uLU6hMCjMI75M1A2tKUQC" || ids.TidalTrackID != "" ||
+		ids.SourceURL != spotifyTrackBaseURL+"4uLU6hMCjMI75M1A2tKUQC" {
+		t.Fatalf("unexpected identifiers: %+v", ids)
+	}
+}
+
+var testIdentifierFields = map[string]string{
+	"musicbrainz_recording_id": "rec-1",
+	"musicbrainz_release_id":   "rel-1",
+	"musicbrainz_artist_id":    "art-a; art-b",
+	"tidal_track_id":           "111",
+	"source_url":               "https://tidal.com/browse/track/111",
+	"upc":                      "0001",
+}
+
+func assertIdentifierFields(t *testing.T, label string, got map[string]string) {
+	t.Helper()
+	for key, want := range testIdentifierFields {
+		if got[key] != want {
+			t.Errorf("%s: %s = %q, want %q", label, key, got[key], want)
+		}
+	}
+}
+
+func TestIdentifierTagsRoundTripThroughNativeWriters(t *testing.T) {
+	dir := t.TempDir()
+	fieldsJSON, _ := json.Marshal(testIdentifierFields)
+
+	flacPath := writeTestFLAC(t, dir, "track.flac", buildTestFLAC(t, 1, 32))
+	if _, err := EditFileMetadata(flacPath, string(fieldsJSON)); err != nil {
+		t.Fatalf("EditFileMetadata(flac) failed: %v", err)
+	}
+	raw, err := ReadFileMetadata(flacPath)
+	if err != nil {
+		t.Fatalf("ReadFileMetadata failed: %v", err)
+	}
+	var read map[string]any
+	_ = json.Unmarshal([]byte(raw), &read)
+	readFields := map[string]string{}
+	for key := range testIdentifierFields {
+		readFields[key], _ = read[key].(string)
+	}
+	assertIdentifierFields(t, "ReadFileMetadata", readFields)
+
+	scanned, err := scanAudioFile(flacPath, "")
+	if err != nil {
+		t.Fatalf("scanAudioFile failed: %v", err)
+	}
+	assertIdentifierFields(t, "LibraryScanResult", scanned.Identifiers)
+
+	nameAtom := testMP4Box("\xa9nam", testMP4Box("data", []byte{0, 0, 0, 1, 0, 0, 0, 0}, []byte("Song")))
+	udta := testMP4Box("udta", testMP4Box("meta", make([]byte, 4), testMP4Box("ilst", nameAtom)))
+	m4aPath := filepath.Join(dir, "track.m4a")
+	if err := os.WriteFile(m4aPath, append(testMP4Box("ftyp", []byte("M4A ")), testMP4Box("moov", udta)...), 0644); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := EditFileMetadata(m4aPath, string(fieldsJSON))
+	if err != nil {
+		t.Fatalf("EditFileMetadata(m4a) failed: %v", err)
+	}
+	var editResp map[string]any
+	_ = json.Unmarshal([]byte(resp), &editResp)
+	if editResp["method"] != "native_m4a_freeform" {
+		t.Fatalf("expected native freeform write, got %s", resp)
+	}
+	m4aMeta, err := ReadM4ATags(m4aPath)
+	if err != nil {
+		t.Fatalf("ReadM4ATags failed: %v", err)
+	}
+	assertIdentifierFields(t, "M4A", m4aMeta.TrackIdentifiers.fields())
+
+	clearJSON, _ := json.Marshal(map[string]string{"upc": ""})
+	if _, err := EditFileMetadata(m4aPath, string(clearJSON)); err != nil {
+		t.Fatalf("clearing M4A identifier failed: %v", err)
+	}
+	if m4aMeta, _ = ReadM4ATags(m4aPath); m4aMeta.UPC != "" || m4aMeta.MusicBrainzReleaseID != "rel-1" {
+		t.Fatalf("expected only UPC to be cleared, got %+v", m4aMeta.TrackIdentifiers)
+	}
+
+	apeMeta := APETagToAudioMetadata(&APETag{Items: AudioMetadataToAPEItems(&AudioMetadata{
+		TrackIdentifiers: identifiersFromFields(testIdentifierFields),
+	})})
+	assertIdentifierFields(t, "APE", apeMeta.TrackIdentifiers.fields())
+}
@@ -2,6 +2,7 @@ package gobackend
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -53,8 +54,10 @@ func NewIDHSClient() *IDHSClient {
 	return globalIDHSClient
 }
 
-func (c *IDHSClient) Search(link string, adapters []string) (*IDHSSearchResponse, error) {
-	idhsRateLimiter.WaitForSlot()
+func (c *IDHSClient) Search(ctx context.Context, link string, adapters []string) (*IDHSSearchResponse, error) {
+	if err := idhsRateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
 
 	reqBody := IDHSSearchRequest{
 		Link:     link,
@@ -66,7 +69,7 @@ func (c *IDHSClient) Search(link string, adapters []string) (*IDHSSearchResponse
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", "https://idonthavespotify.sjdonado.com/api/search?v=1", bytes.NewBuffer(jsonBody))
+	req, err := http.NewRequestWithContext(ctx, "POST", "https://idonthavespotify.sjdonado.com/api/search?v=1", bytes.NewBuffer(jsonBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -84,7 +87,7 @@ func (c *IDHSClient) Search(link string, adapters []string) (*IDHSSearchResponse
 		return nil, fmt.Errorf("invalid link or missing parameters")
 	}
 	if resp.StatusCode == 429 {
-		return nil, fmt.Errorf("IDHS rate limit exceeded")
+		return nil, newDownloadError("idhs", ErrRateLimited, fmt.Errorf("IDHS rate limit exceeded"))
 	}
 	if resp.StatusCode == 500 {
 		return nil, fmt.Errorf("IDHS processing failed")
@@ -111,7 +114,7 @@ func (c *IDHSClient) GetAvailabilityFromSpotify(spotifyTrackID string) (*TrackAv
 
 	adapters := []string{"tidal", "deezer"}
 
-	result, err := c.Search(spotifyURL, adapters)
+	result, err := c.Search(context.Background(), spotifyURL, adapters)
 	if err != nil {
 		return nil, err
 	}
@@ -142,12 +145,12 @@ func (c *IDHSClient) GetAvailabilityFromSpotify(spotifyTrackID string) (*TrackAv
 	return availability, nil
 }
 
-func (c *IDHSClient) GetAvailabilityFromDeezer(deezerTrackID string) (*TrackAvailability, error) {
+func (c *IDHSClient) GetAvailabilityFromDeezer(ctx context.Context, deezerTrackID string) (*TrackAvailability, error) {
 	deezerURL := fmt.Sprintf("https://www.deezer.com/track/%s", deezerTrackID)
 
 	adapters := []string{"spotify", "tidal"}
 
-	result, err := c.Search(deezerURL, adapters)
+	result, err := c.Search(ctx, deezerURL, adapters)
 	if err != nil {
 		return nil, err
 	}
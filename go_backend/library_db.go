@@ -0,0 +1,779 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	libraryDatabaseVersion   = 1
+	libraryDatabaseSaveDelay = 2 * time.Second
+)
+
+type libraryDatabase struct {
+	mu      sync.RWMutex
+	writeMu sync.Mutex
+	path    string
+	tracks  map[string]LibraryScanResult
+
+	saveMu    sync.Mutex
+	saveTimer *time.Timer
+}
+
+type libraryDatabaseFile struct {
+	Version   int                 `json:"version"`
+	UpdatedAt string              `json:"updatedAt"`
+	Tracks    []LibraryScanResult `json:"tracks"`
+}
+
+type LibraryQuery struct {
+	Search        string `json:"search,omitempty"`
+	Artist        string `json:"artist,omitempty"`
+	AlbumArtist   string `json:"albumArtist,omitempty"`
+	Album         string `json:"album,omitempty"`
+	Genre         string `json:"genre,omitempty"`
+	Format        string `json:"format,omitempty"`
+	BitDepth      int    `json:"bitDepth,omitempty"`
+	MinBitDepth   int    `json:"minBitDepth,omitempty"`
+	SampleRate    int    `json:"sampleRate,omitempty"`
+	MinSampleRate int    `json:"minSampleRate,omitempty"`
+	YearFrom      int    `json:"yearFrom,omitempty"`
+	YearTo        int    `json:"yearTo,omitempty"`
+	MissingISRC   bool   `json:"missingIsrc,omitempty"`
+	MissingCover  bool   `json:"missingCover,omitempty"`
+	PathPrefix    string `json:"pathPrefix,omitempty"`
+	SortBy        string `json:"sortBy,omitempty"`
+	SortDesc      bool   `json:"sortDesc,omitempty"`
+	Offset        int    `json:"offset,omitempty"`
+	Limit         int    `json:"limit,omitempty"`
+}
+
+type LibraryQueryResult struct {
+	Total  int                 `json:"total"`
+	Offset int                 `json:"offset"`
+	Limit  int                 `json:"limit"`
+	Items  []LibraryScanResult `json:"items"`
+}
+
+type LibraryAlbumSummary struct {
+	Album         string   `json:"album"`
+	AlbumArtist   string   `json:"albumArtist"`
+	Year          int      `json:"year,omitempty"`
+	TrackCount    int      `json:"trackCount"`
+	DiscCount     int      `json:"discCount"`
+	TotalDuration int      `json:"totalDuration"`
+	TotalSize     int64    `json:"totalSize"`
+	CoverPath     string   `json:"coverPath,omitempty"`
+	Formats       []string `json:"formats"`
+}
+
+type LibraryAlbumArtistGroup struct {
+	AlbumArtist string                `json:"albumArtist"`
+	TrackCount  int                   `json:"trackCount"`
+	Albums      []LibraryAlbumSummary `json:"albums"`
+}
+
+type LibraryFormatStats struct {
+	Format     string `json:"format"`
+	TrackCount int    `json:"trackCount"`
+	TotalSize  int64  `json:"totalSize"`
+}
+
+type LibraryStats struct {
+	TotalTracks   int                  `json:"totalTracks"`
+	TotalAlbums   int                  `json:"totalAlbums"`
+	TotalArtists  int                  `json:"totalArtists"`
+	TotalSize     int64                `json:"totalSize"`
+	TotalDuration int64                `json:"totalDuration"`
+	Formats       []LibraryFormatStats `json:"formats"`
+}
+
+var (
+	libraryDB   *libraryDatabase
+	libraryDBMu sync.RWMutex
+)
+
+func getLibraryDatabase() *libraryDatabase {
+	libraryDBMu.RLock()
+	defer libraryDBMu.RUnlock()
+	return libraryDB
+}
+
+func requireLibraryDatabase() (*libraryDatabase, error) {
+	db := getLibraryDatabase()
+	if db == nil {
+		return nil, fmt.Errorf("library database is not open")
+	}
+	return db, nil
+}
+
+func loadLibraryDatabase(dbPath string) (*libraryDatabase, error) {
+	db := &libraryDatabase{
+		path:   dbPath,
+		tracks: make(map[string]LibraryScanResult),
+	}
+
+	data, err := os.ReadFile(dbPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return db, nil
+		}
+		return nil, err
+	}
+
+	var file libraryDatabaseFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse library database: %w", err)
+	}
+	if file.Version > libraryDatabaseVersion {
+		return nil, fmt.Errorf("library database version %d is newer than supported %d", file.Version, libraryDatabaseVersion)
+	}
+
+	for _, track := range file.Tracks {
+		if track.FilePath == "" {
+			continue
+		}
+		db.tracks[track.FilePath] = track
+	}
+	return db, nil
+}
+
+// persist writes the database now. writeMu is held from the snapshot to the
+// rename so a slower writer can never replace a newer snapshot.
+func (db *libraryDatabase) persist() error {
+	db.writeMu.Lock()
+	defer db.writeMu.Unlock()
+
+	db.mu.RLock()
+	file := libraryDatabaseFile{
+		Version:   libraryDatabaseVersion,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		Tracks:    make([]LibraryScanResult, 0, len(db.tracks)),
+	}
+	for _, track := range db.tracks {
+		file.Tracks = append(file.Tracks, track)
+	}
+	db.mu.RUnlock()
+
+	sort.Slice(file.Tracks, func(i, j int) bool {
+		return file.Tracks[i].FilePath < file.Tracks[j].FilePath
+	})
+	return writeJSONFileAtomic(db.path, file)
+}
+
+// scheduleSave batches mutations into one write libraryDatabaseSaveDelay
+// after the first of them.
+func (db *libraryDatabase) scheduleSave() {
+	db.saveMu.Lock()
+	defer db.saveMu.Unlock()
+	if db.saveTimer != nil {
+		return
+	}
+	db.saveTimer = time.AfterFunc(libraryDatabaseSaveDelay, func() {
+		if err := db.flush(); err != nil {
+			GoLog("[LibraryDB] Failed to persist: %v\n", err)
+		}
+	})
+}
+
+// flush cancels a pending save and writes the database immediately.
+func (db *libraryDatabase) flush() error {
+	db.saveMu.Lock()
+	if db.saveTimer != nil {
+		db.saveTimer.Stop()
+		db.saveTimer = nil
+	}
+	db.saveMu.Unlock()
+	return db.persist()
+}
+
+func (db *libraryDatabase) upsert(tracks []LibraryScanResult) int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	return db.upsertLocked(tracks)
+}
+
+func (db *libraryDatabase) upsertLocked(tracks []LibraryScanResult) int {
+	count := 0
+	for _, track := range tracks {
+		if track.FilePath == "" {
+			continue
+		}
+		if track.ID == "" {
+			track.ID = generateLibraryID(track.FilePath)
+		}
+		db.tracks[track.FilePath] = track
+		count++
+	}
+	return count
+}
+
+func (db *libraryDatabase) remove(paths []string) int {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	count := 0
+	for _, path := range paths {
+		if _, ok := db.tracks[path]; ok {
+			delete(db.tracks, path)
+			count++
+		}
+	}
+	return count
+}
+
+// replaceFolder drops rows under folderPath that are not part of the new scan,
+// so a full rescan also forgets files deleted since the last one. Both steps
+// happen under one lock so readers never see the folder half replaced.
+func (db *libraryDatabase) replaceFolder(folderPath string, tracks []LibraryScanResult) {
+	keep := make(map[string]bool, len(tracks))
+	for _, track := range tracks {
+		keep[track.FilePath] = true
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	for path := range db.tracks {
+		if isPathInLibraryFolder(path, folderPath) && !keep[path] {
+			delete(db.tracks, path)
+		}
+	}
+	db.upsertLocked(tracks)
+}
+
+func (db *libraryDatabase) existingFiles(folderPath string) map[string]int64 {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	existing := make(map[string]int64, len(db.tracks))
+	for path, track := range db.tracks {
+		if folderPath == "" || isPathInLibraryFolder(path, folderPath) {
+			existing[path] = track.FileModTime
+		}
+	}
+	return existing
+}
+
+func (db *libraryDatabase) snapshot() []LibraryScanResult {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+
+	tracks := make([]LibraryScanResult, 0, len(db.tracks))
+	for _, track := range db.tracks {
+		tracks = append(tracks, track)
+	}
+	return tracks
+}
+
+func isPathInLibraryFolder(path, folderPath string) bool {
+	if folderPath == "" {
+		return true
+	}
+	folder := strings.TrimRight(folderPath, "/\\")
+	if path == folder {
+		return true
+	}
+	return strings.HasPrefix(path, folder+"/") || strings.HasPrefix(path, folder+"\\")
+}
+
+// syncLibraryDatabaseWithFullScan and syncLibraryDatabaseWithIncrementalScan
+// keep the store current whenever the host runs a regular library scan.
+func syncLibraryDatabaseWithFullScan(folderPath string, results []LibraryScanResult) {
+	db := getLibraryDatabase()
+	if db == nil {
+		return
+	}
+	db.replaceFolder(folderPath, results)
+	db.scheduleSave()
+}
+
+func syncLibraryDatabaseWithIncrementalScan(result IncrementalScanResult) {
+	db := getLibraryDatabase()
+	if db == nil {
+		return
+	}
+	if len(result.Scanned) == 0 && len(result.DeletedPaths) == 0 {
+		return
+	}
+	db.remove(result.DeletedPaths)
+	db.upsert(result.Scanned)
+	db.scheduleSave()
+}
+
+func libraryReleaseYear(releaseDate string) int {
+	date := strings.TrimSpace(releaseDate)
+	if len(date) < 4 {
+		return 0
+	}
+	year, err := strconv.Atoi(date[:4])
+	if err != nil {
+		return 0
+	}
+	return year
+}
+
+func libraryAlbumArtistOf(track LibraryScanResult) string {
+	if strings.TrimSpace(track.AlbumArtist) != "" {
+		return track.AlbumArtist
+	}
+	return track.ArtistName
+}
+
+func libraryFieldEquals(value, filter string) bool {
+	return strings.EqualFold(strings.TrimSpace(value), strings.TrimSpace(filter))
+}
+
+func (q *LibraryQuery) matches(track LibraryScanResult) bool {
+	if q.Search != "" {
+		needle := strings.ToLower(strings.TrimSpace(q.Search))
+		if !strings.Contains(strings.ToLower(track.TrackName), needle) &&
+			!strings.Contains(strings.ToLower(track.ArtistName), needle) &&
+			!strings.Contains(strings.ToLower(track.AlbumName), needle) &&
+			!strings.Contains(strings.ToLower(track.AlbumArtist), needle) {
+			return false
+		}
+	}
+	if q.Artist != "" && !libraryFieldEquals(track.ArtistName, q.Artist) {
+		matched := false
+		for _, artist := range splitArtistTagValues(track.ArtistName) {
+			if libraryFieldEquals(artist, q.Artist) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if q.AlbumArtist != "" && !libraryFieldEquals(libraryAlbumArtistOf(track), q.AlbumArtist) {
+		return false
+	}
+	if q.Album != "" && !libraryFieldEquals(track.AlbumName, q.Album) {
+		return false
+	}
+	if q.Genre != "" && !libraryFieldEquals(track.Genre, q.Genre) {
+		return false
+	}
+	if q.Format != "" && !libraryFieldEquals(track.Format, strings.TrimPrefix(q.Format, ".")) {
+		return false
+	}
+	if q.BitDepth > 0 && track.BitDepth != q.BitDepth {
+		return false
+	}
+	if q.MinBitDepth > 0 && track.BitDepth < q.MinBitDepth {
+		return false
+	}
+	if q.SampleRate > 0 && track.SampleRate != q.SampleRate {
+		return false
+	}
+	if q.MinSampleRate > 0 && track.SampleRate < q.MinSampleRate {
+		return false
+	}
+	if q.YearFrom > 0 || q.YearTo > 0 {
+		year := libraryReleaseYear(track.ReleaseDate)
+		if year == 0 {
+			return false
+		}
+		if q.YearFrom > 0 && year < q.YearFrom {
+			return false
+		}
+		if q.YearTo > 0 && year > q.YearTo {
+			return false
+		}
+	}
+	if q.MissingISRC && strings.TrimSpace(track.ISRC) != "" {
+		return false
+	}
+	if q.MissingCover && strings.TrimSpace(track.CoverPath) != "" {
+		return false
+	}
+	if q.PathPrefix != "" && !isPathInLibraryFolder(track.FilePath, q.PathPrefix) {
+		return false
+	}
+	return true
+}
+
+func compareLibraryStrings(a, b string) int {
+	return strings.Compare(strings.ToLower(a), strings.ToLower(b))
+}
+
+func compareLibraryInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+func compareLibraryAlbumOrder(a, b LibraryScanResult) int {
+	if c := compareLibraryStrings(libraryAlbumArtistOf(a), libraryAlbumArtistOf(b)); c != 0 {
+		return c
+	}
+	if c := compareLibraryStrings(a.AlbumName, b.AlbumName); c != 0 {
+		return c
+	}
+	if c := compareLibraryInts(int64(a.DiscNumber), int64(b.DiscNumber)); c != 0 {
+		return c
+	}
+	if c := compareLibraryInts(int64(a.TrackNumber), int64(b.TrackNumber)); c != 0 {
+		return c
+	}
+	return compareLibraryStrings(a.TrackName, b.TrackName)
+}
+
+func compareLibraryTracks(a, b LibraryScanResult, sortBy string) int {
+	var c int
+	switch strings.ToLower(sortBy) {
+	case "title":
+		c = compareLibraryStrings(a.TrackName, b.TrackName)
+	case "artist":
+		c = compareLibraryStrings(a.ArtistName, b.ArtistName)
+	case "album":
+		c = compareLibraryStrings(a.AlbumName, b.AlbumName)
+	case "year":
+		c = compareLibraryInts(int64(libraryReleaseYear(a.ReleaseDate)), int64(libraryReleaseYear(b.ReleaseDate)))
+	case "duration":
+		c = compareLibraryInts(int64(a.Duration), int64(b.Duration))
+	case "size":
+		c = compareLibraryInts(a.FileSize, b.FileSize)
+	case "modified":
+		c = compareLibraryInts(a.FileModTime, b.FileModTime)
+	case "added":
+		c = compareLibraryStrings(a.ScannedAt, b.ScannedAt)
+	case "format":
+		c = compareLibraryStrings(a.Format, b.Format)
+	case "quality":
+		c = compareLibraryInts(int64(a.BitDepth), int64(b.BitDepth))
+		if c == 0 {
+			c = compareLibraryInts(int64(a.SampleRate), int64(b.SampleRate))
+		}
+		if c == 0 {
+			c = compareLibraryInts(int64(a.Bitrate), int64(b.Bitrate))
+		}
+	}
+	if c != 0 {
+		return c
+	}
+	if c = compareLibraryAlbumOrder(a, b); c != 0 {
+		return c
+	}
+	return strings.Compare(a.FilePath, b.FilePath)
+}
+
+func (db *libraryDatabase) query(q LibraryQuery) LibraryQueryResult {
+	db.mu.RLock()
+	matched := make([]LibraryScanResult, 0, len(db.tracks))
+	for _, track := range db.tracks {
+		if q.matches(track) {
+			matched = append(matched, track)
+		}
+	}
+	db.mu.RUnlock()
+
+	sort.Slice(matched, func(i, j int) bool {
+		c := compareLibraryTracks(matched[i], matched[j], q.SortBy)
+		if q.SortDesc {
+			return c > 0
+		}
+		return c < 0
+	})
+
+	total := len(matched)
+	offset := max(q.Offset, 0)
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if q.Limit > 0 && offset+q.Limit < total {
+		end = offset + q.Limit
+	}
+
+	return LibraryQueryResult{
+		Total:  total,
+		Offset: offset,
+		Limit:  q.Limit,
+		Items:  matched[offset:end],
+	}
+}
+
+func (db *libraryDatabase) albumsByArtist(q LibraryQuery) []LibraryAlbumArtistGroup {
+	type albumKey struct {
+		artist string
+		album  string
+	}
+
+	groups := make(map[string]*LibraryAlbumArtistGroup)
+	albums := make(map[albumKey]*LibraryAlbumSummary)
+	albumDiscs := make(map[albumKey]map[int]bool)
+	albumFormats := make(map[albumKey]map[string]bool)
+
+	db.mu.RLock()
+	for _, track := range db.tracks {
+		if !q.matches(track) {
+			continue
+		}
+
+		artist := libraryAlbumArtistOf(track)
+		artistKey := strings.ToLower(strings.TrimSpace(artist))
+		group, ok := groups[artistKey]
+		if !ok {
+			group = &LibraryAlbumArtistGroup{AlbumArtist: artist}
+			groups[artistKey] = group
+		}
+		group.TrackCount++
+
+		key := albumKey{artist: artistKey, album: strings.ToLower(strings.TrimSpace(track.AlbumName))}
+		album, ok := albums[key]
+		if !ok {
+			album = &LibraryAlbumSummary{
+				Album:       track.AlbumName,
+				AlbumArtist: artist,
+			}
+			albums[key] = album
+			albumDiscs[key] = make(map[int]bool)
+			albumFormats[key] = make(map[string]bool)
+		}
+		album.TrackCount++
+		album.TotalDuration += track.Duration
+		album.TotalSize += track.FileSize
+		if album.Year == 0 {
+			album.Year = libraryReleaseYear(track.ReleaseDate)
+		}
+		if album.CoverPath == "" {
+			album.CoverPath = track.CoverPath
+		}
+		albumDiscs[key][max(track.DiscNumber, 1)] = true
+		if track.Format != "" {
+			albumFormats[key][strings.ToLower(track.Format)] = true
+		}
+	}
+	db.mu.RUnlock()
+
+	for key, album := range albums {
+		album.DiscCount = len(albumDiscs[key])
+		album.Formats = make([]string, 0, len(albumFormats[key]))
+		for format := range albumFormats[key] {
+			album.Formats = append(album.Formats, format)
+		}
+		sort.Strings(album.Formats)
+		groups[key.artist].Albums = append(groups[key.artist].Albums, *album)
+	}
+
+	result := make([]LibraryAlbumArtistGroup, 0, len(groups))
+	for _, group := range groups {
+		sort.Slice(group.Albums, func(i, j int) bool {
+			if group.Albums[i].Year != group.Albums[j].Year {
+				return group.Albums[i].Year < group.Albums[j].Year
+			}
+			return compareLibraryStrings(group.Albums[i].Album, group.Albums[j].Album) < 0
+		})
+		result = append(result, *group)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return compareLibraryStrings(result[i].AlbumArtist, result[j].AlbumArtist) < 0
+	})
+	return result
+}
+
+func (db *libraryDatabase) stats() LibraryStats {
+	stats := LibraryStats{Formats: []LibraryFormatStats{}}
+	formats := make(map[string]*LibraryFormatStats)
+	albums := make(map[string]bool)
+	artists := make(map[string]bool)
+
+	db.mu.RLock()
+	for _, track := range db.tracks {
+		stats.TotalTracks++
+		stats.TotalSize += track.FileSize
+		stats.TotalDuration += int64(track.Duration)
+
+		artist := strings.ToLower(strings.TrimSpace(libraryAlbumArtistOf(track)))
+		artists[artist] = true
+		albums[artist+"\x00"+strings.ToLower(strings.TrimSpace(track.AlbumName))] = true
+
+		format := strings.ToLower(track.Format)
+		entry, ok := formats[format]
+		if !ok {
+			entry = &LibraryFormatStats{Format: format}
+			formats[format] = entry
+		}
+		entry.TrackCount++
+		entry.TotalSize += track.FileSize
+	}
+	db.mu.RUnlock()
+
+	stats.TotalAlbums = len(albums)
+	stats.TotalArtists = len(artists)
+	for _, entry := range formats {
+		stats.Formats = append(stats.Formats, *entry)
+	}
+	sort.Slice(stats.Formats, func(i, j int) bool {
+		if stats.Formats[i].TrackCount != stats.Formats[j].TrackCount {
+			return stats.Formats[i].TrackCount > stats.Formats[j].TrackCount
+		}
+		return stats.Formats[i].Format < stats.Formats[j].Format
+	})
+	return stats
+}
+
+func parseLibraryQuery(queryJSON string) (LibraryQuery, error) {
+	var q LibraryQuery
+	if strings.TrimSpace(queryJSON) == "" {
+		return q, nil
+	}
+	if err := json.Unmarshal([]byte(queryJSON), &q); err != nil {
+		return q, fmt.Errorf("invalid library query: %w", err)
+	}
+	return q, nil
+}
+
+func OpenLibraryDatabase(dbPath string) error {
+	if strings.TrimSpace(dbPath) == "" {
+		return fmt.Errorf("library database path is empty")
+	}
+
+	db, err := loadLibraryDatabase(dbPath)
+	if err != nil {
+		return err
+	}
+
+	// The previous database may still have a save pending; write it to its
+	// own path before it is dropped.
+	libraryDBMu.Lock()
+	if previous := libraryDB; previous != nil {
+		if err := previous.flush(); err != nil {
+			GoLog("[LibraryDB] Failed to persist %s before reopening: %v\n", previous.path, err)
+		}
+	}
+	libraryDB = db
+	libraryDBMu.Unlock()
+
+	GoLog("[LibraryDB] Opened %s with %d tracks\n", dbPath, len(db.tracks))
+	return nil
+}
+
+func CloseLibraryDatabase() error {
+	libraryDBMu.Lock()
+	db := libraryDB
+	libraryDB = nil
+	libraryDBMu.Unlock()
+
+	if db == nil {
+		return nil
+	}
+	return db.flush()
+}
+
+func ClearLibraryDatabase() error {
+	db, err := requireLibraryDatabase()
+	if err != nil {
+		return err
+	}
+
+	db.mu.Lock()
+	db.tracks = make(map[string]LibraryScanResult)
+	db.mu.Unlock()
+	return db.flush()
+}
+
+func UpsertLibraryTracks(tracksJSON string) (int, error) {
+	db, err := requireLibraryDatabase()
+	if err != nil {
+		return 0, err
+	}
+
+	var tracks []LibraryScanResult
+	if err := json.Unmarshal([]byte(tracksJSON), &tracks); err != nil {
+		return 0, fmt.Errorf("invalid tracks JSON: %w", err)
+	}
+
+	count := db.upsert(tracks)
+	db.scheduleSave()
+	return count, nil
+}
+
+func RemoveLibraryTracks(pathsJSON string) (int, error) {
+	db, err := requireLibraryDatabase()
+	if err != nil {
+		return 0, err
+	}
+
+	var paths []string
+	if err := json.Unmarshal([]byte(pathsJSON), &paths); err != nil {
+		return 0, fmt.Errorf("invalid paths JSON: %w", err)
+	}
+
+	count := db.remove(paths)
+	if count > 0 {
+		db.scheduleSave()
+	}
+	return count, nil
+}
+
+func QueryLibrary(queryJSON string) (string, error) {
+	db, err := requireLibraryDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	q, err := parseLibraryQuery(queryJSON)
+	if err != nil {
+		return "", err
+	}
+
+	jsonBytes, err := json.Marshal(db.query(q))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal query result: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+func GetLibraryAlbumsByArtist(queryJSON string) (string, error) {
+	db, err := requireLibraryDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	q, err := parseLibraryQuery(queryJSON)
+	if err != nil {
+		return "", err
+	}
+
+	jsonBytes, err := json.Marshal(db.albumsByArtist(q))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal albums: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+func GetLibraryStats() (string, error) {
+	db, err := requireLibraryDatabase()
+	if err != nil {
+		return "", err
+	}
+
+	jsonBytes, err := json.Marshal(db.stats())
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal stats: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+// ScanLibraryFolderIncrementalFromDatabase uses the stored rows as the
+// existing-files snapshot, so the host no longer has to pass one in.
+func ScanLibraryFolderIncrementalFromDatabase(folderPath string) (string, error) {
+	db, err := requireLibraryDatabase()
+	if err != nil {
+		return "{}", err
+	}
+	return scanLibraryFolderIncrementalWithExistingFiles(folderPath, db.existingFiles(folderPath))
+}
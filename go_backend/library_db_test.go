@@ -0,0 +1,170 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func openTestLibraryDatabase(t *testing.T) string {
+	t.Helper()
+	dbPath := filepath.Join(t.TempDir(), "library.json")
+	if err := OpenLibraryDatabase(dbPath); err != nil {
+		t.Fatalf("OpenLibraryDatabase failed: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = CloseLibraryDatabase()
+	})
+	return dbPath
+}
+
+func seedTestLibraryTracks(t *testing.T) {
+	t.Helper()
+	tracks := []LibraryScanResult{
+		{FilePath: "/music/a/01.flac", TrackName: "One", ArtistName: "Alpha", AlbumName: "First", TrackNumber: 1, Format: "flac", BitDepth: 24, SampleRate: 96000, ReleaseDate: "2001-02-03", ISRC: "AAA", FileSize: 300, CoverPath: "/c/a.jpg"},
+		{FilePath: "/music/a/02.flac", TrackName: "Two", ArtistName: "Alpha", AlbumName: "First", TrackNumber: 2, Format: "flac", BitDepth: 16, SampleRate: 44100, ReleaseDate: "2001", FileSize: 200},
+		{FilePath: "/music/b/01.mp3", TrackName: "Three", ArtistName: "Beta, Alpha", AlbumArtist: "Beta", AlbumName: "Second", TrackNumber: 1, Format: "mp3", Bitrate: 320, ReleaseDate: "2015", ISRC: "BBB", FileSize: 100},
+	}
+	payload, _ := json.Marshal(tracks)
+	if _, err := UpsertLibraryTracks(string(payload)); err != nil {
+		t.Fatalf("UpsertLibraryTracks failed: %v", err)
+	}
+}
+
+func TestQueryLibraryFiltersSortsAndPaginates(t *testing.T) {
+	openTestLibraryDatabase(t)
+	seedTestLibraryTracks(t)
+
+	raw, err := QueryLibrary(`{"artist":"alpha","sortBy":"title","limit":1,"offset":1}`)
+	if err != nil {
+		t.Fatalf("QueryLibrary failed: %v", err)
+	}
+	var result LibraryQueryResult
+	if err := json.Unmarshal([]byte(raw), &result); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if result.Total != 3 {
+		t.Fatalf("expected split artist credit to match, total=%d", result.Total)
+	}
+	if len(result.Items) != 1 || result.Items[0].TrackName != "Three" {
+		t.Fatalf("unexpected page: %+v", result.Items)
+	}
+
+	raw, _ = QueryLibrary(`{"format":"flac","minBitDepth":24,"yearFrom":2000,"yearTo":2005}`)
+	result = LibraryQueryResult{}
+	_ = json.Unmarshal([]byte(raw), &result)
+	if result.Total != 1 || result.Items[0].TrackName != "One" {
+		t.Fatalf("unexpected quality filter result: %+v", result)
+	}
+
+	raw, _ = QueryLibrary(`{"missingIsrc":true,"missingCover":true}`)
+	result = LibraryQueryResult{}
+	_ = json.Unmarshal([]byte(raw), &result)
+	if result.Total != 1 || result.Items[0].TrackName != "Two" {
+		t.Fatalf("unexpected missing-data filter result: %+v", result)
+	}
+}
+
+func TestLibraryDatabaseAggregationsAndPersistence(t *testing.T) {
+	dbPath := openTestLibraryDatabase(t)
+	seedTestLibraryTracks(t)
+
+	raw, err := GetLibraryAlbumsByArtist("")
+	if err != nil {
+		t.Fatalf("GetLibraryAlbumsByArtist failed: %v", err)
+	}
+	var groups []LibraryAlbumArtistGroup
+	_ = json.Unmarshal([]byte(raw), &groups)
+	if len(groups) != 2 || groups[0].AlbumArtist != "Alpha" || groups[0].Albums[0].TrackCount != 2 {
+		t.Fatalf("unexpected album groups: %+v", groups)
+	}
+
+	raw, _ = GetLibraryStats()
+	var stats LibraryStats
+	_ = json.Unmarshal([]byte(raw), &stats)
+	if stats.TotalTracks != 3 || stats.TotalSize != 600 || stats.Formats[0].Format != "flac" || stats.Formats[0].TotalSize != 500 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	if _, err := RemoveLibraryTracks(`["/music/b/01.mp3"]`); err != nil {
+		t.Fatalf("RemoveLibraryTracks failed: %v", err)
+	}
+	if err := CloseLibraryDatabase(); err != nil {
+		t.Fatalf("CloseLibraryDatabase failed: %v", err)
+	}
+	if err := OpenLibraryDatabase(dbPath); err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+
+	existing := getLibraryDatabase().existingFiles("/music/a")
+	if len(existing) != 2 {
+		t.Fatalf("expected 2 persisted rows under /music/a, got %d", len(existing))
+	}
+	if len(getLibraryDatabase().existingFiles("/music/b")) != 0 {
+		t.Fatal("expected removed row to stay removed after reopen")
+	}
+}
+
+func TestLibraryDatabaseBatchesConcurrentWrites(t *testing.T) {
+	dbPath := openTestLibraryDatabase(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			payload := fmt.Sprintf(`[{"filePath":"/music/c/%02d.flac"}]`, i)
+			if _, err := UpsertLibraryTracks(payload); err != nil {
+				t.Errorf("UpsertLibraryTracks failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Fatalf("expected writes to be deferred, stat err: %v", err)
+	}
+	if err := CloseLibraryDatabase(); err != nil {
+		t.Fatalf("CloseLibraryDatabase failed: %v", err)
+	}
+	if err := OpenLibraryDatabase(dbPath); err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if got := len(getLibraryDatabase().existingFiles("/music/c")); got != 20 {
+		t.Fatalf("expected 20 persisted rows, got %d", got)
+	}
+}
+
+func TestOpenLibraryDatabaseFlushesPreviousDatabase(t *testing.T) {
+	firstPath := openTestLibraryDatabase(t)
+	if _, err := UpsertLibraryTracks(`[{"filePath":"/music/d/01.flac"}]`); err != nil {
+		t.Fatalf("UpsertLibraryTracks failed: %v", err)
+	}
+
+	openTestLibraryDatabase(t)
+	if err := OpenLibraryDatabase(firstPath); err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if got := len(getLibraryDatabase().existingFiles("/music/d")); got != 1 {
+		t.Fatalf("pending save of the replaced database was lost, got %d rows", got)
+	}
+}
+
+func TestFullScanOfEmptiedFolderDropsRows(t *testing.T) {
+	openTestLibraryDatabase(t)
+	folder := t.TempDir()
+	payload, _ := json.Marshal([]LibraryScanResult{{FilePath: filepath.Join(folder, "gone.flac")}})
+	if _, err := UpsertLibraryTracks(string(payload)); err != nil {
+		t.Fatalf("UpsertLibraryTracks failed: %v", err)
+	}
+
+	if _, err := ScanLibraryFolder(folder); err != nil {
+		t.Fatalf("ScanLibraryFolder failed: %v", err)
+	}
+	if got := len(getLibraryDatabase().existingFiles(folder)); got != 0 {
+		t.Fatalf("expected rows of an emptied folder to be dropped, got %d", got)
+	}
+}
@@ -0,0 +1,433 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	healthIssueFilenameOnly       = "metadata_from_filename"
+	healthIssueMissingCover       = "missing_cover"
+	healthIssueMissingLyrics      = "missing_lyrics"
+	healthIssueMissingISRC        = "missing_isrc"
+	healthIssueMissingGenre       = "missing_genre"
+	healthIssueMissingTotals      = "missing_track_totals"
+	healthIssueAlbumTrackGap      = "album_track_gap"
+	healthIssueAlbumMixedQuality  = "album_mixed_quality"
+	healthIssueLossyInLossless    = "lossy_in_lossless_container"
+	healthIssueUpsampled          = "upsampled_hires"
+	healthIssueUnreadable         = "unreadable_file"
+	healthIssueCorruptAudioStream = "corrupt_audio_stream"
+)
+
+const (
+	healthActionReEnrich        = "re_enrich_metadata"
+	healthActionEmbedCover      = "embed_cover"
+	healthActionFetchLyrics     = "fetch_lyrics"
+	healthActionDownloadMissing = "download_missing_tracks"
+	healthActionRedownloadAlbum = "redownload_album"
+	healthActionRedownload      = "redownload_lossless"
+	healthActionRemoveOrReplace = "remove_or_redownload"
+)
+
+const (
+	healthSeverityInfo    = "info"
+	healthSeverityWarning = "warning"
+	healthSeverityError   = "error"
+)
+
+type LibraryHealthIssue struct {
+	Type            string `json:"type"`
+	Severity        string `json:"severity"`
+	FilePath        string `json:"filePath,omitempty"`
+	Album           string `json:"album,omitempty"`
+	AlbumArtist     string `json:"albumArtist,omitempty"`
+	Detail          string `json:"detail"`
+	SuggestedAction string `json:"suggestedAction"`
+}
+
+type LibraryHealthReport struct {
+	Folder       string               `json:"folder"`
+	GeneratedAt  string               `json:"generatedAt"`
+	TotalFiles   int                  `json:"totalFiles"`
+	CheckedFiles int                  `json:"checkedFiles"`
+	Summary      map[string]int       `json:"summary"`
+	Issues       []LibraryHealthIssue `json:"issues"`
+}
+
+type LibraryHealthOptions struct {
+	CheckLyrics        bool `json:"checkLyrics"`
+	CheckCover         bool `json:"checkCover"`
+	SpectralAnalysis   bool `json:"spectralAnalysis"`
+	SpectralMaxSeconds int  `json:"spectralMaxSeconds"`
+}
+
+func defaultLibraryHealthOptions() LibraryHealthOptions {
+	return LibraryHealthOptions{
+		CheckLyrics:        true,
+		CheckCover:         true,
+		SpectralAnalysis:   true,
+		SpectralMaxSeconds: 60,
+	}
+}
+
+type libraryHealthChecker struct {
+	opts   LibraryHealthOptions
+	report *LibraryHealthReport
+}
+
+func (c *libraryHealthChecker) add(issue LibraryHealthIssue) {
+	c.report.Issues = append(c.report.Issues, issue)
+	c.report.Summary[issue.Type]++
+}
+
+func (c *libraryHealthChecker) addFileIssue(track *LibraryScanResult, issueType, severity, action, detail string) {
+	c.add(LibraryHealthIssue{
+		Type:            issueType,
+		Severity:        severity,
+		FilePath:        track.FilePath,
+		Album:           track.AlbumName,
+		AlbumArtist:     libraryAlbumArtistOf(*track),
+		Detail:          detail,
+		SuggestedAction: action,
+	})
+}
+
+// probeLibraryAudioStream reads the format-specific stream header, which the
+// tag scanners skip over when they fall back to filename metadata.
+func probeLibraryAudioStream(filePath string) error {
+	var err error
+	switch strings.ToLower(filepath.Ext(filePath)) {
+	case ".flac":
+		_, err = GetAudioQuality(filePath)
+	case ".m4a":
+		_, err = GetM4AQuality(filePath)
+	case ".mp3":
+		_, err = GetMP3Quality(filePath)
+	case ".opus", ".ogg":
+		_, err = GetOggQuality(filePath)
+	default:
+		var info os.FileInfo
+		info, err = os.Stat(filePath)
+		if err == nil && info.Size() == 0 {
+			err = fmt.Errorf("file is empty")
+		}
+	}
+	return err
+}
+
+func (c *libraryHealthChecker) checkFile(track *LibraryScanResult) {
+	if err := probeLibraryAudioStream(track.FilePath); err != nil {
+		c.addFileIssue(track, healthIssueUnreadable, healthSeverityError, healthActionRemoveOrReplace,
+			fmt.Sprintf("Audio stream could not be parsed: %v", err))
+		return
+	}
+
+	if track.MetadataFromFilename {
+		c.addFileIssue(track, healthIssueFilenameOnly, healthSeverityWarning, healthActionReEnrich,
+			"No readable tags; title and artist were guessed from the filename")
+	}
+	if strings.TrimSpace(track.ISRC) == "" {
+		c.addFileIssue(track, healthIssueMissingISRC, healthSeverityInfo, healthActionReEnrich, "ISRC tag is missing")
+	}
+	if strings.TrimSpace(track.Genre) == "" {
+		c.addFileIssue(track, healthIssueMissingGenre, healthSeverityInfo, healthActionReEnrich, "Genre tag is missing")
+	}
+
+	var missingTotals []string
+	if track.TotalTracks == 0 {
+		missingTotals = append(missingTotals, "track total")
+	}
+	if track.TotalDiscs == 0 {
+		missingTotals = append(missingTotals, "disc total")
+	}
+	if len(missingTotals) > 0 {
+		c.addFileIssue(track, healthIssueMissingTotals, healthSeverityInfo, healthActionReEnrich,
+			"Missing "+strings.Join(missingTotals, " and "))
+	}
+
+	if c.opts.CheckCover && track.CoverPath == "" {
+		if data, _, err := extractAnyCoverArt(track.FilePath); err != nil || len(data) == 0 {
+			c.addFileIssue(track, healthIssueMissingCover, healthSeverityWarning, healthActionEmbedCover, "No embedded cover art")
+		}
+	}
+
+	if c.opts.CheckLyrics {
+		if lyrics, err := ExtractLyrics(track.FilePath); err != nil || strings.TrimSpace(lyrics) == "" {
+			c.addFileIssue(track, healthIssueMissingLyrics, healthSeverityInfo, healthActionFetchLyrics, "No embedded or sidecar lyrics")
+		}
+	}
+
+	if c.opts.SpectralAnalysis && strings.EqualFold(track.Format, "flac") {
+		c.checkSpectrum(track)
+	}
+}
+
+func (c *libraryHealthChecker) checkSpectrum(track *LibraryScanResult) {
+	result, err := analyzeFLACSpectralCutoff(track.FilePath, c.opts.SpectralMaxSeconds)
+	if err != nil {
+		var frameErr *flacFrameError
+		if errors.As(err, &frameErr) {
+			c.addFileIssue(track, healthIssueCorruptAudioStream, healthSeverityError, healthActionRedownload,
+				fmt.Sprintf("FLAC stream is damaged: %v", err))
+		}
+		return
+	}
+
+	switch {
+	case result.LikelyLossy:
+		c.addFileIssue(track, healthIssueLossyInLossless, healthSeverityWarning, healthActionRedownload,
+			fmt.Sprintf("Spectrum cuts off at %d Hz (%.0f dB drop); likely transcoded from a lossy source", result.CutoffHz, result.DropDb))
+	case result.LikelyUpsampled:
+		c.addFileIssue(track, healthIssueUpsampled, healthSeverityInfo, healthActionRedownload,
+			fmt.Sprintf("%d Hz file has no content above %d Hz; likely upsampled from CD quality", result.SampleRate, result.CutoffHz))
+	}
+}
+
+func libraryTrackQualityLabel(track LibraryScanResult) string {
+	format := strings.ToLower(track.Format)
+	if track.BitDepth > 0 && track.SampleRate > 0 {
+		return fmt.Sprintf("%s %d-bit/%.1fkHz", format, track.BitDepth, float64(track.SampleRate)/1000)
+	}
+	if track.SampleRate > 0 {
+		return fmt.Sprintf("%s %.1fkHz", format, float64(track.SampleRate)/1000)
+	}
+	return format
+}
+
+func (c *libraryHealthChecker) checkAlbums(tracks []LibraryScanResult) {
+	type albumGroup struct {
+		name   string
+		artist string
+		tracks []LibraryScanResult
+	}
+
+	groups := make(map[string]*albumGroup)
+	var keys []string
+	for _, track := range tracks {
+		if track.MetadataFromFilename || track.AlbumName == "" || track.AlbumName == "Unknown Album" {
+			continue
+		}
+		artist := libraryAlbumArtistOf(track)
+		key := strings.ToLower(artist) + "\x00" + strings.ToLower(track.AlbumName)
+		group, ok := groups[key]
+		if !ok {
+			group = &albumGroup{name: track.AlbumName, artist: artist}
+			groups[key] = group
+			keys = append(keys, key)
+		}
+		group.tracks = append(group.tracks, track)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		group := groups[key]
+
+		discTracks := make(map[int]map[int]bool)
+		discTotals := make(map[int]int)
+		qualities := make(map[string]int)
+		for _, track := range group.tracks {
+			disc := max(track.DiscNumber, 1)
+			if track.TrackNumber > 0 {
+				if discTracks[disc] == nil {
+					discTracks[disc] = make(map[int]bool)
+				}
+				discTracks[disc][track.TrackNumber] = true
+			}
+			discTotals[disc] = max(discTotals[disc], track.TotalTracks)
+			qualities[libraryTrackQualityLabel(track)]++
+		}
+
+		discs := make([]int, 0, len(discTracks))
+		for disc := range discTracks {
+			discs = append(discs, disc)
+		}
+		sort.Ints(discs)
+
+		var gaps []string
+		for _, disc := range discs {
+			numbers := discTracks[disc]
+			expected := discTotals[disc]
+			for n := range numbers {
+				expected = max(expected, n)
+			}
+			var missing []string
+			for n := 1; n <= expected; n++ {
+				if !numbers[n] {
+					missing = append(missing, fmt.Sprintf("%d", n))
+				}
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			if len(discs) > 1 {
+				gaps = append(gaps, fmt.Sprintf("disc %d: %s", disc, strings.Join(missing, ", ")))
+			} else {
+				gaps = append(gaps, strings.Join(missing, ", "))
+			}
+		}
+		if len(gaps) > 0 {
+			c.add(LibraryHealthIssue{
+				Type:            healthIssueAlbumTrackGap,
+				Severity:        healthSeverityWarning,
+				Album:           group.name,
+				AlbumArtist:     group.artist,
+				Detail:          "Missing track numbers: " + strings.Join(gaps, "; "),
+				SuggestedAction: healthActionDownloadMissing,
+			})
+		}
+
+		if len(qualities) > 1 {
+			labels := make([]string, 0, len(qualities))
+			for label, count := range qualities {
+				labels = append(labels, fmt.Sprintf("%s (%d)", label, count))
+			}
+			sort.Strings(labels)
+			c.add(LibraryHealthIssue{
+				Type:            healthIssueAlbumMixedQuality,
+				Severity:        healthSeverityInfo,
+				Album:           group.name,
+				AlbumArtist:     group.artist,
+				Detail:          "Tracks use different formats or resolutions: " + strings.Join(labels, ", "),
+				SuggestedAction: healthActionRedownloadAlbum,
+			})
+		}
+	}
+}
+
+// The health report keeps its own progress and cancel channel so it can run
+// alongside a library scan without disturbing it.
+var (
+	libraryHealthProgress   LibraryScanProgress
+	libraryHealthProgressMu sync.RWMutex
+	libraryHealthCancel     chan struct{}
+	libraryHealthCancelMu   sync.Mutex
+)
+
+func GenerateLibraryHealthReport(folderPath, optionsJSON string) (string, error) {
+	if folderPath == "" {
+		return "", fmt.Errorf("folder path is empty")
+	}
+	info, err := os.Stat(folderPath)
+	if err != nil {
+		return "", fmt.Errorf("folder not found: %w", err)
+	}
+	if !info.IsDir() {
+		return "", fmt.Errorf("path is not a folder: %s", folderPath)
+	}
+
+	opts := defaultLibraryHealthOptions()
+	if strings.TrimSpace(optionsJSON) != "" {
+		if err := json.Unmarshal([]byte(optionsJSON), &opts); err != nil {
+			return "", fmt.Errorf("invalid health report options: %w", err)
+		}
+	}
+
+	libraryHealthProgressMu.Lock()
+	libraryHealthProgress = LibraryScanProgress{}
+	libraryHealthProgressMu.Unlock()
+
+	libraryHealthCancelMu.Lock()
+	if libraryHealthCancel != nil {
+		close(libraryHealthCancel)
+	}
+	libraryHealthCancel = make(chan struct{})
+	cancelCh := libraryHealthCancel
+	libraryHealthCancelMu.Unlock()
+
+	files, err := collectLibraryAudioFiles(folderPath, cancelCh)
+	if err != nil {
+		return "", err
+	}
+
+	report := &LibraryHealthReport{
+		Folder:      folderPath,
+		GeneratedAt: time.Now().UTC().Format(time.RFC3339),
+		TotalFiles:  len(files),
+		Summary:     make(map[string]int),
+		Issues:      []LibraryHealthIssue{},
+	}
+	checker := &libraryHealthChecker{opts: opts, report: report}
+
+	libraryHealthProgressMu.Lock()
+	libraryHealthProgress.TotalFiles = len(files)
+	libraryHealthProgressMu.Unlock()
+
+	scanTime := time.Now().UTC().Format(time.RFC3339)
+	tracks := make([]LibraryScanResult, 0, len(files))
+	errorCount := 0
+	for i, file := range files {
+		select {
+		case <-cancelCh:
+			return "", fmt.Errorf("health report cancelled")
+		default:
+		}
+
+		libraryHealthProgressMu.Lock()
+		libraryHealthProgress.ScannedFiles = i + 1
+		libraryHealthProgress.CurrentFile = filepath.Base(file.path)
+		libraryHealthProgress.ProgressPct = float64(i+1) / float64(len(files)) * 100
+		libraryHealthProgressMu.Unlock()
+
+		if strings.ToLower(filepath.Ext(file.path)) == ".cue" {
+			continue
+		}
+
+		track, err := scanAudioFileWithKnownModTime(file.path, scanTime, file.modTime)
+		if err != nil {
+			errorCount++
+			checker.add(LibraryHealthIssue{
+				Type:            healthIssueUnreadable,
+				Severity:        healthSeverityError,
+				FilePath:        file.path,
+				Detail:          fmt.Sprintf("File could not be scanned: %v", err),
+				SuggestedAction: healthActionRemoveOrReplace,
+			})
+			continue
+		}
+
+		report.CheckedFiles++
+		checker.checkFile(track)
+		tracks = append(tracks, *track)
+	}
+
+	checker.checkAlbums(tracks)
+
+	libraryHealthProgressMu.Lock()
+	libraryHealthProgress.ErrorCount = errorCount
+	libraryHealthProgress.IsComplete = true
+	libraryHealthProgressMu.Unlock()
+
+	GoLog("[LibraryHealth] Checked %d files, found %d issues\n", report.CheckedFiles, len(report.Issues))
+
+	jsonBytes, err := json.Marshal(report)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal health report: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+func GetLibraryHealthReportProgress() string {
+	libraryHealthProgressMu.RLock()
+	defer libraryHealthProgressMu.RUnlock()
+
+	jsonBytes, _ := json.Marshal(libraryHealthProgress)
+	return string(jsonBytes)
+}
+
+func CancelLibraryHealthReport() {
+	libraryHealthCancelMu.Lock()
+	defer libraryHealthCancelMu.Unlock()
+
+	if libraryHealthCancel != nil {
+		close(libraryHealthCancel)
+		libraryHealthCancel = nil
+	}
+}
@@ -0,0 +1,99 @@
+package gobackend
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestLibraryHealthAlbumChecksFindGapsAndMixedQuality(t *testing.T) {
+	checker := &libraryHealthChecker{
+		opts:   defaultLibraryHealthOptions(),
+		report: &LibraryHealthReport{Summary: make(map[string]int)},
+	}
+
+	checker.checkAlbums([]LibraryScanResult{
+		{FilePath: "/a/1.flac", AlbumName: "Album", ArtistName: "Artist", TrackNumber: 1, TotalTracks: 5, Format: "flac", BitDepth: 16, SampleRate: 44100},
+		{FilePath: "/a/2.flac", AlbumName: "Album", ArtistName: "Artist", TrackNumber: 2, TotalTracks: 5, Format: "flac", BitDepth: 24, SampleRate: 96000},
+		{FilePath: "/a/4.flac", AlbumName: "Album", ArtistName: "Artist", TrackNumber: 4, TotalTracks: 5, Format: "flac", BitDepth: 16, SampleRate: 44100},
+		{FilePath: "/b/1.mp3", AlbumName: "Other", ArtistName: "Artist", TrackNumber: 1, TotalTracks: 1, Format: "mp3", SampleRate: 44100},
+	})
+
+	if checker.report.Summary[healthIssueAlbumTrackGap] != 1 {
+		t.Fatalf("expected one gap issue, got summary %+v", checker.report.Summary)
+	}
+	if checker.report.Summary[healthIssueAlbumMixedQuality] != 1 {
+		t.Fatalf("expected one mixed quality issue, got summary %+v", checker.report.Summary)
+	}
+	for _, issue := range checker.report.Issues {
+		if issue.Type == healthIssueAlbumTrackGap && !strings.Contains(issue.Detail, "3, 5") {
+			t.Fatalf("unexpected gap detail: %q", issue.Detail)
+		}
+		if issue.SuggestedAction == "" {
+			t.Fatalf("issue %s has no suggested action", issue.Type)
+		}
+	}
+}
+
+func TestDetectSpectralCutoff(t *testing.T) {
+	const bins = spectrumFFTSize / 2
+	build := func(cutoffHz float64) []float64 {
+		power := make([]float64, bins)
+		binHz := 44100.0 / float64(2*bins)
+		for i := range power {
+			hz := float64(i) * binHz
+			// Gentle 3 dB/kHz roll-off like a typical master.
+			level := -3 * hz / 1000
+			if hz > cutoffHz {
+				level -= 90
+			}
+			power[i] = math.Pow(10, level/10)
+		}
+		return power
+	}
+
+	lossy := detectSpectralCutoff(build(16000), 44100)
+	if !lossy.LikelyLossy || lossy.CutoffHz < 15500 || lossy.CutoffHz > 16500 {
+		t.Fatalf("expected lossy cutoff near 16 kHz, got %+v", lossy)
+	}
+
+	lossless := detectSpectralCutoff(build(30000), 44100)
+	if lossless.LikelyLossy || lossless.LikelyUpsampled {
+		t.Fatalf("expected full-band spectrum to pass, got %+v", lossless)
+	}
+}
+
+func TestLibraryHealthReportLeavesRunningScanAlone(t *testing.T) {
+	libraryScanCancelMu.Lock()
+	origCancel := libraryScanCancel
+	scanCancel := make(chan struct{})
+	libraryScanCancel = scanCancel
+	libraryScanCancelMu.Unlock()
+	libraryScanProgressMu.Lock()
+	origProgress := libraryScanProgress
+	libraryScanProgress = LibraryScanProgress{TotalFiles: 10, ScannedFiles: 4}
+	libraryScanProgressMu.Unlock()
+	t.Cleanup(func() {
+		libraryScanCancelMu.Lock()
+		libraryScanCancel = origCancel
+		libraryScanCancelMu.Unlock()
+		libraryScanProgressMu.Lock()
+		libraryScanProgress = origProgress
+		libraryScanProgressMu.Unlock()
+	})
+
+	if _, err := GenerateLibraryHealthReport(t.TempDir(), ""); err != nil {
+		t.Fatalf("GenerateLibraryHealthReport failed: %v", err)
+	}
+
+	select {
+	case <-scanCancel:
+		t.Fatal("health report cancelled the running library scan")
+	default:
+	}
+	libraryScanProgressMu.RLock()
+	defer libraryScanProgressMu.RUnlock()
+	if libraryScanProgress.ScannedFiles != 4 || libraryScanProgress.TotalFiles != 10 {
+		t.Fatalf("health report overwrote scan progress: %+v", libraryScanProgress)
+	}
+}
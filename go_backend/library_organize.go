@@ -0,0 +1,749 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Bulk library tools: parse tags out of existing paths and move files into a
+// new filename template layout. Both support a dry run and record a journal
+// next to the library so a run can be rolled back.
+
+const libraryJournalPrefix = ".spotiflac-journal-"
+
+var (
+	templatePlaceholderExpr = regexp.MustCompile(`\{([a-z_]+)(?::([^{}]+))?\}`)
+	libraryCoverFileNames   = []string{"cover.jpg", "cover.jpeg", "cover.png", "folder.jpg", "folder.png", "front.jpg", "front.png"}
+)
+
+type TagFromFilenameRequest struct {
+	FolderPath string   `json:"folder_path,omitempty"`
+	Files      []string `json:"files,omitempty"`
+	Template   string   `json:"template"`
+	Overwrite  bool     `json:"overwrite,omitempty"`
+	DryRun     bool     `json:"dry_run"`
+}
+
+type TagFromFilenameItem struct {
+	FilePath string                `json:"file_path"`
+	Status   string                `json:"status"`
+	Parsed   map[string]string     `json:"parsed,omitempty"`
+	Changes  []AlbumReEnrichChange `json:"changes,omitempty"`
+	Method   string                `json:"method,omitempty"`
+	Error    string                `json:"error,omitempty"`
+}
+
+type OrganizeLibraryRequest struct {
+	FolderPath string   `json:"folder_path,omitempty"`
+	Files      []string `json:"files,omitempty"`
+	Template   string   `json:"template"`
+	TargetRoot string   `json:"target_root,omitempty"`
+	DryRun     bool     `json:"dry_run"`
+}
+
+type LibraryFileMove struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+	Copy bool   `json:"copy,omitempty"`
+}
+
+type OrganizeLibraryItem struct {
+	SourcePath string            `json:"source_path"`
+	TargetPath string            `json:"target_path"`
+	Status     string            `json:"status"`
+	Sidecars   []LibraryFileMove `json:"sidecars,omitempty"`
+	Error      string            `json:"error,omitempty"`
+}
+
+type TagFromFilenameResult struct {
+	DryRun      bool                  `json:"dry_run"`
+	JournalPath string                `json:"journal_path,omitempty"`
+	Items       []TagFromFilenameItem `json:"items"`
+}
+
+type OrganizeLibraryResult struct {
+	DryRun      bool                  `json:"dry_run"`
+	JournalPath string                `json:"journal_path,omitempty"`
+	Items       []OrganizeLibraryItem `json:"items"`
+}
+
+type libraryJournalTagEntry struct {
+	FilePath string            `json:"file_path"`
+	Previous map[string]string `json:"previous"`
+}
+
+// libraryJournal records completed operations in order so RollbackLibraryJournal
+// can undo them in reverse.
+type libraryJournal struct {
+	ID        string                   `json:"id"`
+	Kind      string                   `json:"kind"`
+	CreatedAt int64                    `json:"created_at"`
+	Moves     []LibraryFileMove        `json:"moves,omitempty"`
+	Tags      []libraryJournalTagEntry `json:"tags,omitempty"`
+	path      string
+}
+
+func newLibraryJournal(kind, root string) *libraryJournal {
+	id := strconv.FormatInt(time.Now().UnixNano(), 36)
+	return &libraryJournal{
+		ID:        id,
+		Kind:      kind,
+		CreatedAt: time.Now().UnixMilli(),
+		path:      filepath.Join(root, libraryJournalPrefix+kind+"-"+id+".json"),
+	}
+}
+
+// save rewrites the journal after every operation so an interrupted run can
+// still be rolled back.
+func (j *libraryJournal) save() error {
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := j.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, j.path)
+}
+
+type templateCapture struct {
+	name   string
+	format string
+}
+
+// filenameTemplatePattern compiles a BuildFilename template into a regexp
+// matched against the tail of a slash-separated path without extension.
+// Fallback chains, functions and conditional sections cannot be reversed and
+// are rejected.
+func filenameTemplatePattern(template string) (*regexp.Regexp, []templateCapture, error) {
+	template = strings.Trim(strings.TrimSpace(filepath.ToSlash(template)), "/")
+	if template == "" {
+		return nil, nil, fmt.Errorf("template is required")
+	}
+	for i := 0; i < len(template); i++ {
+		if template[i] != '{' {
+			continue
+		}
+		end := matchingTemplateBrace(template, i)
+		if end < 0 {
+			break
+		}
+		if block := template[i : end+1]; templatePlaceholderExpr.FindString(block) != block {
+			return nil, nil, fmt.Errorf("%s cannot be read back from file names; use plain placeholders such as {artist} or {track:02}", block)
+		}
+		i = end
+	}
+
+	var pattern strings.Builder
+	var captures []templateCapture
+	pattern.WriteString(`(?:^|/)`)
+	last := 0
+	for _, loc := range templatePlaceholderExpr.FindAllStringSubmatchIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		last = loc[1]
+
+		name := template[loc[2]:loc[3]]
+		format := ""
+		if loc[4] >= 0 {
+			format = template[loc[4]:loc[5]]
+		}
+		switch name {
+		case "title", "artist", "album", "album_artist", "isrc", "genre", "label", "composer", "quality", "provider", "date":
+			if name == "date" && format != "" {
+				expr, err := strftimePattern(format)
+				if err != nil {
+					return nil, nil, err
+				}
+				pattern.WriteString("(" + expr + ")")
+			} else {
+				pattern.WriteString(`([^/]+?)`)
+			}
+		case "track", "track_raw", "disc", "disc_raw", "total_tracks", "total_discs", "bit_depth":
+			if format != "" && !isNumeric(format) {
+				return nil, nil, fmt.Errorf("invalid number format in {%s:%s}", name, format)
+			}
+			pattern.WriteString(`(\d+)`)
+		case "sample_rate":
+			pattern.WriteString(`(\d+(?:\.\d+)?)`)
+		case "year":
+			pattern.WriteString(`(\d{4})`)
+		default:
+			return nil, nil, fmt.Errorf("unsupported placeholder {%s}", name)
+		}
+		captures = append(captures, templateCapture{name: name, format: format})
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString(`$`)
+
+	if len(captures) == 0 {
+		return nil, nil, fmt.Errorf("template has no placeholders")
+	}
+	expr, err := regexp.Compile(pattern.String())
+	if err != nil {
+		return nil, nil, err
+	}
+	return expr, captures, nil
+}
+
+func strftimePattern(format string) (string, error) {
+	var builder strings.Builder
+	for i := 0; i < len(format); i++ {
+		if format[i] != '%' || i+1 >= len(format) {
+			builder.WriteString(regexp.QuoteMeta(string(format[i])))
+			continue
+		}
+		i++
+		switch format[i] {
+		case 'Y':
+			builder.WriteString(`\d{4}`)
+		case 'y', 'm', 'd':
+			builder.WriteString(`\d{2}`)
+		case 'b':
+			builder.WriteString(`[A-Za-z]{3}`)
+		case 'B':
+			builder.WriteString(`[A-Za-z]+`)
+		case '%':
+			builder.WriteString("%")
+		default:
+			return "", fmt.Errorf("unsupported date format %%%c", format[i])
+		}
+	}
+	return builder.String(), nil
+}
+
+// parseTagsFromPath extracts editor fields (title, artist, album,
+// album_artist, isrc, genre, label, composer, track_number, disc_number,
+// date) from a path using a compiled template. Quality, provider, totals and
+// audio format placeholders only have to match.
+func parseTagsFromPath(expr *regexp.Regexp, captures []templateCapture, filePath string) (map[string]string, bool) {
+	path := filepath.ToSlash(strings.TrimSuffix(filePath, filepath.Ext(filePath)))
+	match := expr.FindStringSubmatch(path)
+	if match == nil {
+		return nil, false
+	}
+
+	fields := make(map[string]string)
+	set := func(field, value string) {
+		if _, exists := fields[field]; !exists && value != "" {
+			fields[field] = value
+		}
+	}
+	for i, capture := range captures {
+		value := strings.TrimSpace(match[i+1])
+		switch capture.name {
+		case "title", "artist", "album", "album_artist", "isrc", "genre", "label", "composer":
+			set(capture.name, value)
+		case "track", "track_raw":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				set("track_number", strconv.Itoa(n))
+			}
+		case "disc", "disc_raw":
+			if n, err := strconv.Atoi(value); err == nil && n > 0 {
+				set("disc_number", strconv.Itoa(n))
+			}
+		case "year":
+			set("date", value)
+		case "date":
+			if capture.format == "" {
+				set("date", value)
+				continue
+			}
+			parsed, err := time.Parse(convertStrftimeToGoLayout(capture.format), value)
+			if err != nil {
+				continue
+			}
+			switch {
+			case strings.Contains(capture.format, "%d"):
+				set("date", parsed.Format("2006-01-02"))
+			case strings.Contains(capture.format, "%m"), strings.Contains(capture.format, "%b"), strings.Contains(capture.format, "%B"):
+				set("date", parsed.Format("2006-01"))
+			default:
+				set("date", parsed.Format("2006"))
+			}
+		}
+	}
+	return fields, true
+}
+
+// TagFromFilename parses tags from existing file and folder names using the
+// BuildFilename placeholder syntax. Existing tags are kept unless overwrite
+// is set; files that would end up with the same album, disc and track are
+// reported as conflicts and left untouched.
+func TagFromFilename(requestJSON string) (string, error) {
+	var req TagFromFilenameRequest
+	if err := json.Unmarshal([]byte(requestJSON), &req); err != nil {
+		return "", fmt.Errorf("failed to parse request: %w", err)
+	}
+	expr, captures, err := filenameTemplatePattern(req.Template)
+	if err != nil {
+		return "", err
+	}
+	paths, err := collectBulkAudioPaths(req.FolderPath, req.Files)
+	if err != nil {
+		return "", err
+	}
+
+	items := make([]TagFromFilenameItem, len(paths))
+	current := make([]map[string]string, len(paths))
+	positions := make(map[string][]int)
+	for i, path := range paths {
+		item := TagFromFilenameItem{FilePath: path}
+		parsed, ok := parseTagsFromPath(expr, captures, path)
+		if !ok {
+			item.Status = "unmatched"
+			items[i] = item
+			continue
+		}
+		file, err := readAlbumReEnrichFile(path)
+		if err != nil {
+			item.Status = "failed"
+			item.Error = err.Error()
+			items[i] = item
+			continue
+		}
+		current[i] = file.tags
+
+		proposed := make(map[string]string)
+		for field, value := range parsed {
+			if req.Overwrite || isPlaceholderReEnrichValue(file.tags[field]) {
+				proposed[field] = value
+			}
+		}
+		item.Parsed = parsed
+		item.Changes = diffAlbumReEnrichFields(file.tags, proposed)
+		item.Status = "unchanged"
+		if len(item.Changes) > 0 {
+			item.Status = "planned"
+		}
+		items[i] = item
+
+		if track := parsed["track_number"]; track != "" {
+			album := parsed["album"]
+			if album == "" {
+				album = file.tags["album"]
+			}
+			key := strings.ToLower(filepath.Dir(path) + "\x00" + album + "\x00" + parsed["disc_number"] + "\x00" + track)
+			positions[key] = append(positions[key], i)
+		}
+	}
+	for _, indexes := range positions {
+		if len(indexes) < 2 {
+			continue
+		}
+		for _, i := range indexes {
+			items[i].Status = "conflict"
+			items[i].Error = "another file parses to the same album, disc and track"
+		}
+	}
+
+	result := TagFromFilenameResult{DryRun: req.DryRun, Items: items}
+	if !req.DryRun {
+		journal := newLibraryJournal("tags", bulkJournalRoot(req.FolderPath, paths))
+		for i := range items {
+			item := &items[i]
+			if item.Status != "planned" {
+				continue
+			}
+			fields := make(map[string]string, len(item.Changes))
+			previous := make(map[string]string, len(item.Changes))
+			for _, change := range item.Changes {
+				fields[change.Field] = change.Proposed
+				previous[change.Field] = current[i][change.Field]
+			}
+			method, err := editFileFields(item.FilePath, fields)
+			if err != nil {
+				item.Status = "failed"
+				item.Error = err.Error()
+				continue
+			}
+			item.Status = "applied"
+			item.Method = method
+			if method == "ffmpeg" {
+				// Dart writes these; the journal still lets it restore them.
+				item.Status = "pending_ffmpeg"
+			}
+			journal.Tags = append(journal.Tags, libraryJournalTagEntry{FilePath: item.FilePath, Previous: previous})
+			if err := journal.save(); err != nil {
+				GoLog("[Organize] Failed to save journal: %v\n", err)
+			}
+		}
+		if len(journal.Tags) > 0 {
+			result.JournalPath = journal.path
+		}
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+func editFileFields(filePath string, fields map[string]string) (string, error) {
+	fieldsJSON, _ := json.Marshal(fields)
+	raw, err := EditFileMetadata(filePath, string(fieldsJSON))
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		Method string `json:"method"`
+	}
+	_ = json.Unmarshal([]byte(raw), &resp)
+	return resp.Method, nil
+}
+
+func bulkJournalRoot(folderPath string, paths []string) string {
+	if folderPath = strings.TrimSpace(folderPath); folderPath != "" {
+		return folderPath
+	}
+	return filepath.Dir(paths[0])
+}
+
+// organizeTargetPath renders the template for one file using its current
+// tags; buildFilePathFromTemplate keeps slashes in tag values out of the
+// folder structure.
+func organizeTargetPath(root, template string, file albumReEnrichFile) string {
+	metadata := make(map[string]interface{}, len(file.tags)+2)
+	for key, value := range file.tags {
+		metadata[key] = value
+	}
+	metadata["track"] = parsePositiveInt(file.tags["track_number"])
+	metadata["disc"] = parsePositiveInt(file.tags["disc_number"])
+	relative := buildFilePathFromTemplate(template, metadata)
+	return filepath.Join(root, relative) + strings.ToLower(filepath.Ext(file.path))
+}
+
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
+
+func sameFilePath(a, b string) bool {
+	if filepath.Clean(a) == filepath.Clean(b) {
+		return true
+	}
+	infoA, errA := os.Stat(a)
+	infoB, errB := os.Stat(b)
+	return errA == nil && errB == nil && os.SameFile(infoA, infoB)
+}
+
+// OrganizeLibrary moves existing files into a new filename template layout.
+// Lyrics sidecars follow their audio file; cover images follow the album
+// folder, or are copied when one folder is split across several targets.
+// Existing targets and duplicate targets are reported as collisions and
+// skipped.
+func OrganizeLibrary(requestJSON string) (string, error) {
+	var req OrganizeLibraryRequest
+	if err := json.Unmarshal([]byte(requestJSON), &req); err != nil {
+		return "", fmt.Errorf("failed to parse request: %w", err)
+	}
+	if strings.TrimSpace(req.Template) == "" {
+		return "", fmt.Errorf("template is required")
+	}
+	paths, err := collectBulkAudioPaths(req.FolderPath, req.Files)
+	if err != nil {
+		return "", err
+	}
+	root := strings.TrimSpace(req.TargetRoot)
+	if root == "" {
+		root = bulkJournalRoot(req.FolderPath, paths)
+	}
+
+	items := make([]OrganizeLibraryItem, len(paths))
+	targets := make(map[string][]int)
+	for i, path := range paths {
+		item := OrganizeLibraryItem{SourcePath: path}
+		file, err := readAlbumReEnrichFile(path)
+		if err != nil {
+			item.Status = "failed"
+			item.Error = err.Error()
+			items[i] = item
+			continue
+		}
+		item.TargetPath = organizeTargetPath(root, req.Template, file)
+		item.Status = "planned"
+		if sameFilePath(item.SourcePath, item.TargetPath) && filepath.Base(item.SourcePath) == filepath.Base(item.TargetPath) {
+			item.Status = "unchanged"
+		} else if pathExists(item.TargetPath) && !sameFilePath(item.SourcePath, item.TargetPath) {
+			item.Status = "collision"
+			item.Error = "target file already exists"
+		}
+
+		if item.Status == "planned" {
+			lrcFrom := strings.TrimSuffix(path, filepath.Ext(path)) + ".lrc"
+			if pathExists(lrcFrom) {
+				lrcTo := strings.TrimSuffix(item.TargetPath, filepath.Ext(item.TargetPath)) + ".lrc"
+				if pathExists(lrcTo) && !sameFilePath(lrcFrom, lrcTo) {
+					item.Status = "collision"
+					item.Error = "target lyrics file already exists"
+				} else {
+					item.Sidecars = append(item.Sidecars, LibraryFileMove{From: lrcFrom, To: lrcTo})
+				}
+			}
+		}
+		items[i] = item
+		key := strings.ToLower(item.TargetPath)
+		targets[key] = append(targets[key], i)
+	}
+	for _, indexes := range targets {
+		if len(indexes) < 2 {
+			continue
+		}
+		for _, i := range indexes {
+			items[i].Status = "collision"
+			items[i].Error = "several files map to the same target"
+			items[i].Sidecars = nil
+		}
+	}
+	covers := planCoverMoves(items)
+
+	result := OrganizeLibraryResult{DryRun: req.DryRun, Items: items}
+	if !req.DryRun {
+		journal := newLibraryJournal("organize", root)
+		if err := os.MkdirAll(root, 0755); err != nil {
+			return "", fmt.Errorf("failed to create target folder: %w", err)
+		}
+		record := func(move LibraryFileMove) {
+			journal.Moves = append(journal.Moves, move)
+			if err := journal.save(); err != nil {
+				GoLog("[Organize] Failed to save journal: %v\n", err)
+			}
+		}
+		sourceDirs := make(map[string]bool)
+		failedDirs := make(map[string]bool)
+		for i := range items {
+			item := &items[i]
+			if item.Status != "planned" {
+				continue
+			}
+			move := LibraryFileMove{From: item.SourcePath, To: item.TargetPath}
+			if err := moveLibraryFile(move); err != nil {
+				item.Status = "failed"
+				item.Error = err.Error()
+				failedDirs[filepath.Dir(item.SourcePath)] = true
+				continue
+			}
+			record(move)
+			item.Status = "moved"
+			sourceDirs[filepath.Dir(item.SourcePath)] = true
+			for _, sidecar := range item.Sidecars {
+				if err := moveLibraryFile(sidecar); err != nil {
+					GoLog("[Organize] Failed to move %s: %v\n", sidecar.From, err)
+					continue
+				}
+				record(sidecar)
+			}
+		}
+		for _, cover := range covers {
+			if !sourceDirs[filepath.Dir(cover.From)] {
+				continue
+			}
+			// A track that failed to move still needs its cover.
+			if failedDirs[filepath.Dir(cover.From)] {
+				cover.Copy = true
+			}
+			if err := moveLibraryFile(cover); err != nil {
+				GoLog("[Organize] Failed to move cover %s: %v\n", cover.From, err)
+				continue
+			}
+			record(cover)
+		}
+		for dir := range sourceDirs {
+			removeEmptyDirs(dir, req.FolderPath)
+		}
+		if len(journal.Moves) > 0 {
+			result.JournalPath = journal.path
+		}
+		GoLog("[Organize] Moved %d files into %s\n", len(journal.Moves), root)
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+// planCoverMoves moves folder cover art along with the audio. When files from
+// one folder land in several target folders the cover is copied into each,
+// and it stays put while any audio file in the folder is not moving.
+func planCoverMoves(items []OrganizeLibraryItem) []LibraryFileMove {
+	targetDirs := make(map[string]map[string]bool)
+	staying := make(map[string]bool)
+	for _, item := range items {
+		dir := filepath.Dir(item.SourcePath)
+		if item.Status != "planned" {
+			staying[dir] = true
+			continue
+		}
+		if targetDirs[dir] == nil {
+			targetDirs[dir] = make(map[string]bool)
+		}
+		targetDirs[dir][filepath.Dir(item.TargetPath)] = true
+	}
+
+	var moves []LibraryFileMove
+	for dir, dests := range targetDirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			continue
+		}
+		copyOnly := staying[dir] || len(dests) > 1
+		for _, entry := range entries {
+			if entry.IsDir() || !isLibraryCoverFile(entry.Name()) {
+				continue
+			}
+			for dest := range dests {
+				if dest == dir {
+					continue
+				}
+				to := filepath.Join(dest, entry.Name())
+				if pathExists(to) {
+					continue
+				}
+				moves = append(moves, LibraryFileMove{From: filepath.Join(dir, entry.Name()), To: to, Copy: copyOnly})
+			}
+		}
+	}
+	sort.Slice(moves, func(i, j int) bool { return moves[i].To < moves[j].To })
+	return moves
+}
+
+func isLibraryCoverFile(name string) bool {
+	for _, cover := range libraryCoverFileNames {
+		if strings.EqualFold(name, cover) {
+			return true
+		}
+	}
+	return false
+}
+
+func moveLibraryFile(move LibraryFileMove) error {
+	if err := os.MkdirAll(filepath.Dir(move.To), 0755); err != nil {
+		return fmt.Errorf("failed to create directory: %w", err)
+	}
+	if move.Copy {
+		return copyLibraryFile(move.From, move.To)
+	}
+	if err := os.Rename(move.From, move.To); err != nil {
+		// Rename fails across filesystems (e.g. internal storage to SD card).
+		if !errors.Is(err, syscall.EXDEV) {
+			return err
+		}
+		if err := copyLibraryFile(move.From, move.To); err != nil {
+			return err
+		}
+		if err := os.Remove(move.From); err != nil {
+			// Drop the copy so a failed move never leaves an unjournaled
+			// duplicate behind.
+			if removeErr := os.Remove(move.To); removeErr != nil {
+				GoLog("[Organize] Failed to remove copy %s: %v\n", move.To, removeErr)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func copyLibraryFile(from, to string) error {
+	src, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		os.Remove(to)
+		return err
+	}
+	return dst.Close()
+}
+
+// removeEmptyDirs deletes dir and its parents while they are empty. Only
+// folders below stopAt are touched, so the library root is never removed.
+func removeEmptyDirs(dir, stopAt string) {
+	if strings.TrimSpace(stopAt) == "" {
+		return
+	}
+	stopAt = filepath.Clean(stopAt)
+	for dir = filepath.Clean(dir); strings.HasPrefix(dir, stopAt+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+// RollbackLibraryJournal undoes a TagFromFilename or OrganizeLibrary run in
+// reverse order. The journal is deleted once every step was undone.
+func RollbackLibraryJournal(journalPath string) (string, error) {
+	data, err := os.ReadFile(journalPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read journal: %w", err)
+	}
+	var journal libraryJournal
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return "", fmt.Errorf("failed to parse journal: %w", err)
+	}
+
+	var failures []string
+	for i := len(journal.Moves) - 1; i >= 0; i-- {
+		move := journal.Moves[i]
+		var err error
+		if move.Copy {
+			err = os.Remove(move.To)
+		} else {
+			err = moveLibraryFile(LibraryFileMove{From: move.To, To: move.From})
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", move.To, err))
+			continue
+		}
+		removeEmptyDirs(filepath.Dir(move.To), filepath.Dir(journalPath))
+	}
+	var ffmpegFiles []map[string]interface{}
+	for i := len(journal.Tags) - 1; i >= 0; i-- {
+		entry := journal.Tags[i]
+		method, err := editFileFields(entry.FilePath, entry.Previous)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", entry.FilePath, err))
+			continue
+		}
+		if method == "ffmpeg" {
+			ffmpegFiles = append(ffmpegFiles, map[string]interface{}{"file_path": entry.FilePath, "fields": entry.Previous})
+		}
+	}
+
+	if len(failures) == 0 {
+		if err := os.Remove(journalPath); err != nil {
+			GoLog("[Organize] Failed to remove journal %s: %v\n", journalPath, err)
+		}
+	}
+	GoLog("[Organize] Rolled back %s journal %s (%d failures)\n", journal.Kind, journal.ID, len(failures))
+
+	jsonBytes, err := json.Marshal(map[string]interface{}{
+		"success":  len(failures) == 0,
+		"kind":     journal.Kind,
+		"failures": failures,
+		"ffmpeg":   ffmpegFiles,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(jsonBytes), nil
+}
@@ -0,0 +1,205 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseTagsFromPathWithTemplate(t *testing.T) {
+	expr, captures, err := filenameTemplatePattern("{artist}/{date:%Y} - {album}/{disc}-{track:02} {title}")
+	if err != nil {
+		t.Fatalf("filenameTemplatePattern failed: %v", err)
+	}
+
+	fields, ok := parseTagsFromPath(expr, captures, "/music/Some Band/2004 - Great Album/1-07 The Song.flac")
+	if !ok {
+		t.Fatalf("expected path to match template")
+	}
+	want := map[string]string{
+		"artist":       "Some Band",
+		"date":         "2004",
+		"album":        "Great Album",
+		"disc_number":  "1",
+		"track_number": "7",
+		"title":        "The Song",
+	}
+	for field, value := range want {
+		if fields[field] != value {
+			t.Fatalf("%s = %q, want %q (all: %v)", field, fields[field], value, fields)
+		}
+	}
+
+	if _, ok := parseTagsFromPath(expr, captures, "/music/loose file.flac"); ok {
+		t.Fatalf("expected non-matching path to be rejected")
+	}
+	if _, _, err := filenameTemplatePattern("{artist} - {unknown}"); err == nil {
+		t.Fatalf("expected unsupported placeholder to fail")
+	}
+}
+
+func TestParseTagsFromPathExtendedPlaceholders(t *testing.T) {
+	expr, captures, err := filenameTemplatePattern("{album_artist}/{album} [{quality}]/{track:02} {composer} - {title} ({isrc})")
+	if err != nil {
+		t.Fatalf("filenameTemplatePattern failed: %v", err)
+	}
+	fields, ok := parseTagsFromPath(expr, captures, "/music/Various/Hits [FLAC 24bit]/03 Someone - Tune (USABC1234567).flac")
+	if !ok {
+		t.Fatalf("expected path to match template")
+	}
+	want := map[string]string{
+		"album_artist": "Various",
+		"album":        "Hits",
+		"track_number": "3",
+		"composer":     "Someone",
+		"title":        "Tune",
+		"isrc":         "USABC1234567",
+	}
+	for field, value := range want {
+		if fields[field] != value {
+			t.Fatalf("%s = %q, want %q (all: %v)", field, fields[field], value, fields)
+		}
+	}
+	if _, exists := fields["quality"]; exists {
+		t.Fatalf("quality is not an editor field: %v", fields)
+	}
+
+	for _, template := range []string{
+		"{album_artist|artist}/{title}",
+		"{title|lower}",
+		"{?total_discs>1:Disc {disc}/}{title}",
+		"{artist} - {?:{album} - }{title}",
+	} {
+		if _, _, err := filenameTemplatePattern(template); err == nil {
+			t.Fatalf("expected %q to be rejected", template)
+		}
+	}
+}
+
+func TestTagFromFilenameAppliesAndRollsBack(t *testing.T) {
+	root := t.TempDir()
+	albumDir := filepath.Join(root, "Band", "Record")
+	if err := os.MkdirAll(albumDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	path := writeTestFLAC(t, albumDir, "03 - Third.flac", buildTestFLAC(t, 2, 64))
+	if _, err := EditFileMetadata(path, `{"title":"Keep Me"}`); err != nil {
+		t.Fatalf("EditFileMetadata failed: %v", err)
+	}
+
+	reqJSON, _ := json.Marshal(TagFromFilenameRequest{FolderPath: root, Template: "{artist}/{album}/{track} - {title}"})
+	raw, err := TagFromFilename(string(reqJSON))
+	if err != nil {
+		t.Fatalf("TagFromFilename failed: %v", err)
+	}
+	var result TagFromFilenameResult
+	if err := json.Unmarshal([]byte(raw), &result); err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Items) != 1 || result.Items[0].Status != "applied" || result.JournalPath == "" {
+		t.Fatalf("unexpected result: %s", raw)
+	}
+
+	file, err := readAlbumReEnrichFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.tags["title"] != "Keep Me" || file.tags["artist"] != "Band" || file.tags["album"] != "Record" || file.tags["track_number"] != "3" {
+		t.Fatalf("unexpected tags after apply: %v", file.tags)
+	}
+
+	if _, err := RollbackLibraryJournal(result.JournalPath); err != nil {
+		t.Fatalf("RollbackLibraryJournal failed: %v", err)
+	}
+	file, err = readAlbumReEnrichFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if file.tags["artist"] != "" || file.tags["track_number"] != "" || file.tags["title"] != "Keep Me" {
+		t.Fatalf("tags not restored: %v", file.tags)
+	}
+	if pathExists(result.JournalPath) {
+		t.Fatalf("journal should be removed after a clean rollback")
+	}
+}
+
+func TestOrganizeLibraryMovesSidecarsAndRollsBack(t *testing.T) {
+	root := t.TempDir()
+	srcDir := filepath.Join(root, "incoming")
+	if err := os.MkdirAll(srcDir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	tagged := func(name, fields string) string {
+		path := writeTestFLAC(t, srcDir, name, buildTestFLAC(t, 2, 64))
+		if _, err := EditFileMetadata(path, fields); err != nil {
+			t.Fatalf("EditFileMetadata(%s) failed: %v", name, err)
+		}
+		return path
+	}
+	first := tagged("a.flac", `{"title":"One","artist":"Band","album":"Record","track_number":"1"}`)
+	second := tagged("b.flac", `{"title":"Two","artist":"Band","album":"Record","track_number":"2"}`)
+	dupe := tagged("c.flac", `{"title":"Two","artist":"Band","album":"Record","track_number":"2"}`)
+	if err := os.WriteFile(filepath.Join(srcDir, "a.lrc"), []byte("[00:01.00]One"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(srcDir, "cover.jpg"), []byte("jpg"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	template := "{artist}/{album}/{track} {title}"
+	reqJSON, _ := json.Marshal(OrganizeLibraryRequest{FolderPath: root, Template: template, DryRun: true})
+	raw, err := OrganizeLibrary(string(reqJSON))
+	if err != nil {
+		t.Fatalf("OrganizeLibrary dry run failed: %v", err)
+	}
+	var preview OrganizeLibraryResult
+	if err := json.Unmarshal([]byte(raw), &preview); err != nil {
+		t.Fatal(err)
+	}
+	statuses := make(map[string]string)
+	for _, item := range preview.Items {
+		statuses[item.SourcePath] = item.Status
+	}
+	if statuses[first] != "planned" || statuses[second] != "collision" || statuses[dupe] != "collision" {
+		t.Fatalf("unexpected dry-run statuses: %v", statuses)
+	}
+	if !pathExists(first) {
+		t.Fatalf("dry run must not move files")
+	}
+
+	if err := os.Remove(dupe); err != nil {
+		t.Fatal(err)
+	}
+	reqJSON, _ = json.Marshal(OrganizeLibraryRequest{FolderPath: root, Template: template})
+	raw, err = OrganizeLibrary(string(reqJSON))
+	if err != nil {
+		t.Fatalf("OrganizeLibrary failed: %v", err)
+	}
+	var result OrganizeLibraryResult
+	if err := json.Unmarshal([]byte(raw), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	albumDir := filepath.Join(root, "Band", "Record")
+	for _, name := range []string{"01 One.flac", "01 One.lrc", "02 Two.flac", "cover.jpg"} {
+		if !pathExists(filepath.Join(albumDir, name)) {
+			t.Fatalf("expected %s in organized album folder", name)
+		}
+	}
+	if pathExists(srcDir) {
+		t.Fatalf("emptied source folder should be removed")
+	}
+
+	if _, err := RollbackLibraryJournal(result.JournalPath); err != nil {
+		t.Fatalf("RollbackLibraryJournal failed: %v", err)
+	}
+	for _, path := range []string{first, second, filepath.Join(srcDir, "a.lrc"), filepath.Join(srcDir, "cover.jpg")} {
+		if !pathExists(path) {
+			t.Fatalf("expected %s to be restored", path)
+		}
+	}
+	if pathExists(filepath.Join(root, "Band")) {
+		t.Fatalf("rollback should remove the folders it emptied")
+	}
+}
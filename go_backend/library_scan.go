@@ -22,6 +22,7 @@ type LibraryScanResult struct {
 	CoverPath            string `json:"coverPath,omitempty"`
 	ScannedAt            string `json:"scannedAt"`
 	FileModTime          int64  `json:"fileModTime,omitempty"` // Unix timestamp in milliseconds
+	FileSize             int64  `json:"fileSize,omitempty"`
 	ISRC                 string `json:"isrc,omitempty"`
 	TrackNumber          int    `json:"trackNumber,omitempty"`
 	TotalTracks          int    `json:"totalTracks,omitempty"`
@@ -38,6 +39,10 @@ type LibraryScanResult struct {
 	Copyright            string `json:"copyright,omitempty"`
 	Format               string `json:"format,omitempty"`
 	MetadataFromFilename bool   `json:"metadataFromFilename,omitempty"`
+
+	// Identifiers holds MusicBrainz and provider ID tags keyed by the same
+	// field names ReadFileMetadata uses.
+	Identifiers map[string]string `json:"identifiers,omitempty"`
 }
 
 type LibraryScanProgress struct {
@@ -166,6 +171,8 @@ func ScanLibraryFolder(folderPath string) (string, error) {
 	libraryScanProgressMu.Unlock()
 
 	if totalFiles == 0 {
+		// An emptied folder must still drop its rows from the store.
+		syncLibraryDatabaseWithFullScan(folderPath, nil)
 		libraryScanProgressMu.Lock()
 		libraryScanProgress.IsComplete = true
 		libraryScanProgressMu.Unlock()
@@ -263,6 +270,8 @@ func ScanLibraryFolder(folderPath string) (string, error) {
 
 	GoLog("[LibraryScan] Scan complete: %d tracks found, %d errors\n", len(results), errorCount)
 
+	syncLibraryDatabaseWithFullScan(folderPath, results)
+
 	jsonBytes, err := json.Marshal(results)
 	if err != nil {
 		return "[]", fmt.Errorf("failed to marshal results: %w", err)
@@ -295,8 +304,12 @@ func scanAudioFileWithKnownModTimeAndDisplayNameAndCoverCacheKey(filePath, displ
 
 	if knownModTime > 0 {
 		result.FileModTime = knownModTime
-	} else if info, err := os.Stat(filePath); err == nil {
-		result.FileModTime = info.ModTime().UnixMilli()
+	}
+	if info, err := os.Stat(filePath); err == nil {
+		result.FileSize = info.Size()
+		if result.FileModTime == 0 {
+			result.FileModTime = info.ModTime().UnixMilli()
+		}
 	}
 
 	libraryCoverCacheMu.RLock()
@@ -378,6 +391,7 @@ func scanFLACFile(filePath string, result *LibraryScanResult, displayNameHint st
 	result.Composer = metadata.Composer
 	result.Label = metadata.Label
 	result.Copyright = metadata.Copyright
+	result.Identifiers = libraryIdentifierFields(metadata.TrackIdentifiers)
 
 	quality, err := GetAudioQuality(filePath)
 	if err == nil {
@@ -418,6 +432,7 @@ func scanM4AFile(filePath string, result *LibraryScanResult, displayNameHint str
 		result.Composer = metadata.Composer
 		result.Label = metadata.Label
 		result.Copyright = metadata.Copyright
+		result.Identifiers = libraryIdentifierFields(metadata.TrackIdentifiers)
 	}
 
 	quality, err := GetM4AQuality(filePath)
@@ -455,6 +470,7 @@ func scanMP3File(filePath string, result *LibraryScanResult, displayNameHint str
 	result.Composer = metadata.Composer
 	result.Label = metadata.Label
 	result.Copyright = metadata.Copyright
+	result.Identifiers = libraryIdentifierFields(metadata.TrackIdentifiers)
 
 	quality, err := GetMP3Quality(filePath)
 	if err == nil {
@@ -492,6 +508,7 @@ func scanOggFile(filePath string, result *LibraryScanResult, displayNameHint str
 	result.Composer = metadata.Composer
 	result.Label = metadata.Label
 	result.Copyright = metadata.Copyright
+	result.Identifiers = libraryIdentifierFields(metadata.TrackIdentifiers)
 
 	quality, err := GetOggQuality(filePath)
 	if err == nil {
@@ -538,6 +555,7 @@ func scanAPEFile(filePath string, result *LibraryScanResult, displayNameHint str
 	result.Composer = metadata.Composer
 	result.Label = metadata.Label
 	result.Copyright = metadata.Copyright
+	result.Identifiers = libraryIdentifierFields(metadata.TrackIdentifiers)
 
 	applyDefaultLibraryMetadata(filePath, displayNameHint, result)
 
@@ -784,6 +802,7 @@ func scanLibraryFolderIncrementalWithExistingFiles(folderPath string, existingFi
 			SkippedCount: skippedCount,
 			TotalFiles:   totalFiles,
 		}
+		syncLibraryDatabaseWithIncrementalScan(result)
 		jsonBytes, _ := json.Marshal(result)
 		return string(jsonBytes), nil
 	}
@@ -881,6 +900,7 @@ func scanLibraryFolderIncrementalWithExistingFiles(folderPath string, existingFi
 		SkippedCount: skippedCount,
 		TotalFiles:   totalFiles,
 	}
+	syncLibraryDatabaseWithIncrementalScan(scanResult)
 
 	jsonBytes, err := json.Marshal(scanResult)
 	if err != nil {
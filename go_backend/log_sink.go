@@ -0,0 +1,300 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	logTimestampFormat = "2006-01-02T15:04:05.000Z07:00"
+
+	logFileName          = "spotiflac.log"
+	logFilePrefix        = "spotiflac-"
+	defaultLogFileMaxMB  = 2
+	defaultLogFileMaxAge = 7 * 24
+	defaultLogFileCount  = 5
+)
+
+var logLevelRanks = map[string]int{
+	"DEBUG": 0,
+	"INFO":  1,
+	"WARN":  2,
+	"ERROR": 3,
+	"FATAL": 4,
+}
+
+// LogLevelSettings sets the lowest level kept per tag. An empty Default
+// keeps the old behaviour: everything while logging is enabled, errors only
+// otherwise.
+type LogLevelSettings struct {
+	Default string            `json:"default,omitempty"`
+	Tags    map[string]string `json:"tags,omitempty"`
+}
+
+var (
+	logLevelsMu sync.RWMutex
+	logLevels   LogLevelSettings
+)
+
+func normalizeLogLevel(level string) (string, error) {
+	level = strings.ToUpper(strings.TrimSpace(level))
+	if level == "WARNING" {
+		level = "WARN"
+	}
+	if _, ok := logLevelRanks[level]; !ok {
+		return "", fmt.Errorf("unknown log level %q", level)
+	}
+	return level, nil
+}
+
+func logLevelAllowed(tag, level string, loggingEnabled bool) bool {
+	if level == "FATAL" {
+		return true
+	}
+	logLevelsMu.RLock()
+	threshold, ok := logLevels.Tags[tag]
+	if !ok {
+		threshold = logLevels.Default
+	}
+	logLevelsMu.RUnlock()
+
+	if threshold == "" {
+		if loggingEnabled {
+			return true
+		}
+		threshold = "ERROR"
+	}
+	return logLevelRanks[level] >= logLevelRanks[threshold]
+}
+
+func SetLogLevels(settingsJSON string) error {
+	var settings LogLevelSettings
+	if strings.TrimSpace(settingsJSON) != "" {
+		if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
+			return fmt.Errorf("invalid log level settings: %w", err)
+		}
+	}
+
+	normalized := LogLevelSettings{Tags: make(map[string]string, len(settings.Tags))}
+	if settings.Default != "" {
+		level, err := normalizeLogLevel(settings.Default)
+		if err != nil {
+			return err
+		}
+		normalized.Default = level
+	}
+	for tag, level := range settings.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			continue
+		}
+		level, err := normalizeLogLevel(level)
+		if err != nil {
+			return fmt.Errorf("tag %s: %w", tag, err)
+		}
+		normalized.Tags[tag] = level
+	}
+
+	logLevelsMu.Lock()
+	logLevels = normalized
+	logLevelsMu.Unlock()
+	return nil
+}
+
+func GetLogLevels() (string, error) {
+	logLevelsMu.RLock()
+	jsonBytes, err := json.Marshal(logLevels)
+	logLevelsMu.RUnlock()
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+// LogFileSettings configures the on-disk log. Entries are written as JSON
+// lines to spotiflac.log in Dir, which is rotated once it reaches
+// MaxSizeBytes; rotated files are pruned by age and count.
+type LogFileSettings struct {
+	Dir          string `json:"dir"`
+	MaxSizeBytes int64  `json:"max_size_bytes,omitempty"`
+	MaxAgeHours  int    `json:"max_age_hours,omitempty"`
+	MaxFiles     int    `json:"max_files,omitempty"`
+}
+
+func (s LogFileSettings) withDefaults() LogFileSettings {
+	if s.MaxSizeBytes <= 0 {
+		s.MaxSizeBytes = defaultLogFileMaxMB * 1024 * 1024
+	}
+	if s.MaxAgeHours <= 0 {
+		s.MaxAgeHours = defaultLogFileMaxAge
+	}
+	if s.MaxFiles <= 0 {
+		s.MaxFiles = defaultLogFileCount
+	}
+	return s
+}
+
+type logFileSink struct {
+	mu       sync.Mutex
+	settings LogFileSettings
+	file     *os.File
+	size     int64
+}
+
+var logSink = &logFileSink{}
+
+// SetLogFileSettings enables the file log, or disables it when dir is empty.
+func SetLogFileSettings(settingsJSON string) error {
+	var settings LogFileSettings
+	if strings.TrimSpace(settingsJSON) != "" {
+		if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
+			return fmt.Errorf("invalid log file settings: %w", err)
+		}
+	}
+	settings.Dir = strings.TrimSpace(settings.Dir)
+	return logSink.configure(settings)
+}
+
+func GetLogFileSettings() (string, error) {
+	logSink.mu.Lock()
+	settings := logSink.settings
+	logSink.mu.Unlock()
+
+	jsonBytes, err := json.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+func (s *logFileSink) configure(settings LogFileSettings) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closeLocked()
+	if settings.Dir == "" {
+		s.settings = LogFileSettings{}
+		return nil
+	}
+	settings = settings.withDefaults()
+	if err := os.MkdirAll(settings.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	s.settings = settings
+	if err := s.openLocked(); err != nil {
+		s.settings = LogFileSettings{}
+		return err
+	}
+	s.pruneLocked()
+	return nil
+}
+
+func (s *logFileSink) openLocked() error {
+	file, err := os.OpenFile(filepath.Join(s.settings.Dir, logFileName), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open log file: %w", err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	s.file = file
+	s.size = info.Size()
+	return nil
+}
+
+func (s *logFileSink) closeLocked() {
+	if s.file != nil {
+		s.file.Close()
+		s.file = nil
+	}
+	s.size = 0
+}
+
+func (s *logFileSink) write(entry LogEntry) {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.file == nil {
+		return
+	}
+	if s.size > 0 && s.size+int64(len(line)) > s.settings.MaxSizeBytes {
+		s.rotateLocked()
+		if s.file == nil {
+			return
+		}
+	}
+	n, err := s.file.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		fmt.Printf("[Log] Failed to write log file: %v\n", err)
+	}
+}
+
+func (s *logFileSink) rotateLocked() {
+	s.closeLocked()
+	current := filepath.Join(s.settings.Dir, logFileName)
+	rotated := filepath.Join(s.settings.Dir, logFilePrefix+time.Now().Format("20060102T150405.000")+".log")
+	if err := os.Rename(current, rotated); err != nil {
+		fmt.Printf("[Log] Failed to rotate log file: %v\n", err)
+	}
+	if err := s.openLocked(); err != nil {
+		fmt.Printf("[Log] Failed to reopen log file: %v\n", err)
+	}
+	s.pruneLocked()
+}
+
+// rotatedFilesLocked lists rotated log files, newest first.
+func (s *logFileSink) rotatedFilesLocked() []string {
+	entries, err := os.ReadDir(s.settings.Dir)
+	if err != nil {
+		return nil
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if !entry.IsDir() && strings.HasPrefix(name, logFilePrefix) && strings.HasSuffix(name, ".log") {
+			files = append(files, filepath.Join(s.settings.Dir, name))
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	return files
+}
+
+func (s *logFileSink) pruneLocked() {
+	cutoff := time.Now().Add(-time.Duration(s.settings.MaxAgeHours) * time.Hour)
+	// The active file counts towards MaxFiles.
+	for i, path := range s.rotatedFilesLocked() {
+		info, err := os.Stat(path)
+		if i+1 >= s.settings.MaxFiles || (err == nil && info.ModTime().Before(cutoff)) {
+			os.Remove(path)
+		}
+	}
+}
+
+// logFiles returns the active log file followed by the rotated ones, newest
+// first.
+func (s *logFileSink) logFiles() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.settings.Dir == "" {
+		return nil
+	}
+	if s.file != nil {
+		s.file.Sync()
+	}
+	files := []string{filepath.Join(s.settings.Dir, logFileName)}
+	return append(files, s.rotatedFilesLocked()...)
+}
@@ -69,18 +69,19 @@ func (lb *LogBuffer) IsLoggingEnabled() bool {
 	return lb.loggingEnabled
 }
 
+// Add records an entry in the ring buffer. The file sink is written after
+// lb.mu is released so slow disk I/O never blocks readers or other loggers.
 func (lb *LogBuffer) Add(level, tag, message string) {
 	lb.mu.Lock()
-	defer lb.mu.Unlock()
-
-	if !lb.loggingEnabled && level != "ERROR" && level != "FATAL" {
+	if !logLevelAllowed(tag, level, lb.loggingEnabled) {
+		lb.mu.Unlock()
 		return
 	}
 
 	message = sanitizeSensitiveLogText(message)
 
 	entry := LogEntry{
-		Timestamp: time.Now().Format("15:04:05.000"),
+		Timestamp: time.Now().Format(logTimestampFormat),
 		Level:     level,
 		Tag:       tag,
 		Message:   message,
@@ -90,6 +91,9 @@ func (lb *LogBuffer) Add(level, tag, message string) {
 		lb.entries = lb.entries[1:]
 	}
 	lb.entries = append(lb.entries, entry)
+	lb.mu.Unlock()
+
+	logSink.write(entry)
 
 	fmt.Printf("[%s] %s\n", tag, message)
 }
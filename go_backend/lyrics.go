@@ -1,6 +1,7 @@
 package gobackend
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"math"
@@ -72,6 +73,14 @@ type LyricsFetchOptions struct {
 	IncludeRomanizationNetease bool   `json:"include_romanization_netease"`
 	MultiPersonWordByWord      bool   `json:"multi_person_word_by_word"`
 	MusixmatchLanguage         string `json:"musixmatch_language,omitempty"`
+	// TimeBudgetMs bounds how long all providers are queried concurrently
+	// before the best candidate so far is picked.
+	TimeBudgetMs int `json:"time_budget_ms,omitempty"`
+	// Romanize attaches a romanization layer to lyrics in Japanese, Korean
+	// or Chinese; InlineLayers writes layers as secondary timed lines.
+	Romanize             bool   `json:"romanize"`
+	RomanizationLanguage string `json:"romanization_language,omitempty"`
+	InlineLayers         bool   `json:"inline_layers"`
 }
 
 var defaultLyricsFetchOptions = LyricsFetchOptions{
@@ -79,6 +88,7 @@ var defaultLyricsFetchOptions = LyricsFetchOptions{
 	IncludeRomanizationNetease: false,
 	MultiPersonWordByWord:      true,
 	MusixmatchLanguage:         "",
+	TimeBudgetMs:               defaultLyricsTimeBudgetMs,
 }
 
 var (
@@ -144,6 +154,12 @@ func normalizeLyricsFetchOptions(opts LyricsFetchOptions) LyricsFetchOptions {
 	if len(opts.MusixmatchLanguage) > 16 {
 		opts.MusixmatchLanguage = opts.MusixmatchLanguage[:16]
 	}
+	opts.RomanizationLanguage = strings.ToLower(strings.TrimSpace(opts.RomanizationLanguage))
+	if opts.TimeBudgetMs <= 0 {
+		opts.TimeBudgetMs = defaultLyricsTimeBudgetMs
+	} else if opts.TimeBudgetMs < minLyricsTimeBudgetMs {
+		opts.TimeBudgetMs = minLyricsTimeBudgetMs
+	}
 	return opts
 }
 
@@ -154,11 +170,14 @@ func SetLyricsFetchOptions(opts LyricsFetchOptions) {
 	defer lyricsFetchOptionsMu.Unlock()
 	lyricsFetchOptions = normalized
 
-	GoLog("[Lyrics] Fetch options set: translation=%v romanization=%v multi_person=%v musixmatch_lang=%q\n",
+	GoLog("[Lyrics] Fetch options set: translation=%v romanization=%v multi_person=%v musixmatch_lang=%q time_budget_ms=%d romanize=%v inline_layers=%v\n",
 		normalized.IncludeTranslationNetease,
 		normalized.IncludeRomanizationNetease,
 		normalized.MultiPersonWordByWord,
 		normalized.MusixmatchLanguage,
+		normalized.TimeBudgetMs,
+		normalized.Romanize,
+		normalized.InlineLayers,
 	)
 }
 
@@ -259,10 +278,24 @@ type LRCLibResponse struct {
 	SyncedLyrics string  `json:"syncedLyrics"`
 }
 
-type LyricsLine struct {
+// LyricsWord is one timed word or syllable. Text keeps its trailing space
+// when a word boundary follows, so concatenating Text rebuilds the line.
+type LyricsWord struct {
 	StartTimeMs int64  `json:"startTimeMs"`
-	Words       string `json:"words"`
 	EndTimeMs   int64  `json:"endTimeMs"`
+	Text        string `json:"text"`
+}
+
+// LyricsLine keeps Words in the LRC form providers return (including word
+// tags, agent prefix and [bg:] lines) for compatibility; the parsed details
+// are exposed alongside it.
+type LyricsLine struct {
+	StartTimeMs int64        `json:"startTimeMs"`
+	Words       string       `json:"words"`
+	EndTimeMs   int64        `json:"endTimeMs"`
+	Agent       string       `json:"agent,omitempty"`
+	WordTimings []LyricsWord `json:"wordTimings,omitempty"`
+	Background  []LyricsWord `json:"background,omitempty"`
 }
 
 type LyricsResponse struct {
@@ -272,6 +305,15 @@ type LyricsResponse struct {
 	PlainLyrics  string       `json:"plainLyrics"`
 	Provider     string       `json:"provider"`
 	Source       string       `json:"source"`
+
+	// Matched* describe the provider's song when it reports one; they feed
+	// candidate scoring in FetchLyricsAllSources.
+	MatchedTitle       string            `json:"matchedTitle,omitempty"`
+	MatchedArtist      string            `json:"matchedArtist,omitempty"`
+	MatchedDurationSec float64           `json:"matchedDurationSec,omitempty"`
+	Score              float64           `json:"score,omitempty"`
+	Alternates         []*LyricsResponse `json:"alternates,omitempty"`
+	Layers             []LyricsLayer     `json:"layers,omitempty"`
 }
 
 type LyricsClient struct {
@@ -284,7 +326,7 @@ func NewLyricsClient() *LyricsClient {
 	}
 }
 
-func (c *LyricsClient) FetchLyricsWithMetadata(artist, track string) (*LyricsResponse, error) {
+func (c *LyricsClient) FetchLyricsWithMetadata(ctx context.Context, artist, track string) (*LyricsResponse, error) {
 	baseURL := "https://lrclib.net/api/get"
 	params := url.Values{}
 	params.Set("artist_name", artist)
@@ -292,7 +334,7 @@ func (c *LyricsClient) FetchLyricsWithMetadata(artist, track string) (*LyricsRes
 
 	fullURL := baseURL + "?" + params.Encode()
 
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -320,14 +362,14 @@ func (c *LyricsClient) FetchLyricsWithMetadata(artist, track string) (*LyricsRes
 	return c.parseLRCLibResponse(&lrcResp), nil
 }
 
-func (c *LyricsClient) FetchLyricsFromLRCLibSearch(query string, durationSec float64) (*LyricsResponse, error) {
+func (c *LyricsClient) FetchLyricsFromLRCLibSearch(ctx context.Context, query string, durationSec float64) (*LyricsResponse, error) {
 	baseURL := "https://lrclib.net/api/search"
 	params := url.Values{}
 	params.Set("q", query)
 
 	fullURL := baseURL + "?" + params.Encode()
 
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -408,9 +450,38 @@ func (c *LyricsClient) durationMatches(lrcDuration, targetDuration float64) bool
 }
 
 func (c *LyricsClient) FetchLyricsAllSources(spotifyID, trackName, artistName string, durationSec float64) (*LyricsResponse, error) {
-	primaryArtist := normalizeArtistName(artistName)
-	fetchOptions := GetLyricsFetchOptions()
+	return c.FetchLyricsAllSourcesWithISRC(spotifyID, "", trackName, artistName, durationSec)
+}
 
+func (c *LyricsClient) FetchLyricsAllSourcesWithISRC(spotifyID, isrc, trackName, artistName string, durationSec float64) (*LyricsResponse, error) {
+	return c.FetchLyricsAllSourcesContext(context.Background(), spotifyID, isrc, trackName, artistName, durationSec)
+}
+
+// FetchLyricsAllSourcesContext consults the local lyrics store before any
+// provider; offset-only store entries are applied to the fetched lyrics.
+func (c *LyricsClient) FetchLyricsAllSourcesContext(ctx context.Context, spotifyID, isrc, trackName, artistName string, durationSec float64) (*LyricsResponse, error) {
+	entry, stored := findStoredLyrics(isrc, artistName, trackName, durationSec)
+	if stored {
+		if local := entry.response(); local != nil {
+			GoLog("[Lyrics] Using local lyrics store entry %s\n", entry.ID)
+			return applyLyricsLayerOptions(local, GetLyricsFetchOptions()), nil
+		}
+	}
+
+	lyrics, err := c.fetchLyricsFromSources(ctx, trackName, artistName, durationSec)
+	if err != nil {
+		return nil, err
+	}
+	if durationSec > 0 {
+		lyrics = autoFixLyricsTiming(lyrics, int64(math.Round(durationSec*1000)))
+	}
+	if stored {
+		lyrics = applyLyricsOffset(lyrics, entry.OffsetMs)
+	}
+	return applyLyricsLayerOptions(lyrics, GetLyricsFetchOptions()), nil
+}
+
+func (c *LyricsClient) fetchLyricsFromSources(ctx context.Context, trackName, artistName string, durationSec float64) (*LyricsResponse, error) {
 	extManager := getExtensionManager()
 	var extensionProviders []*extensionProviderWrapper
 	if extManager != nil {
@@ -433,137 +504,129 @@ func (c *LyricsClient) FetchLyricsAllSources(spotifyID, trackName, artistName st
 		GoLog("[Lyrics] Ignoring cached non-extension lyrics because extension providers are available\n")
 	}
 
-	isValidResult := func(l *LyricsResponse) bool {
-		return lyricsHasUsableText(l)
-	}
-
-	if len(extensionProviders) > 0 {
-		for _, provider := range extensionProviders {
-			GoLog("[Lyrics] Trying extension lyrics provider: %s\n", provider.extension.ID)
-			lyrics, err := provider.FetchLyrics(trackName, artistName, "", durationSec)
-			if err == nil && isValidResult(lyrics) {
-				GoLog("[Lyrics] Got lyrics from extension: %s\n", provider.extension.ID)
-				globalLyricsCache.Set(artistName, trackName, durationSec, lyrics)
-				return lyrics, nil
-			}
-			if err != nil {
-				GoLog("[Lyrics] Extension %s failed: %v\n", provider.extension.ID, err)
-			}
-		}
-	}
-
-	if cachedNonExtension != nil {
-		cachedCopy := *cachedNonExtension
-		cachedCopy.Source = cachedNonExtension.Source + " (cached fallback)"
-		GoLog("[Lyrics] Extension providers unavailable for this track, using cached built-in lyrics\n")
-		return &cachedCopy, nil
+	query := lyricsQuery{
+		trackName:       trackName,
+		artistName:      artistName,
+		primaryArtist:   normalizeArtistName(artistName),
+		simplifiedTrack: simplifyTrackName(trackName),
+		durationSec:     durationSec,
+		options:         GetLyricsFetchOptions(),
 	}
-
 	providerOrder := GetLyricsProviderOrder()
-	simplifiedTrack := simplifyTrackName(trackName)
-
 	GoLog("[Lyrics] Searching for: %s - %s (providers: %v)\n", artistName, trackName, providerOrder)
 
-	for _, providerName := range providerOrder {
-		GoLog("[Lyrics] Trying provider: %s\n", providerName)
-
-		var lyrics *LyricsResponse
-		var err error
+	best := c.fetchScoredLyrics(ctx, query, providerOrder, extensionProviders)
+	if best == nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
+	if best == nil {
+		if cachedNonExtension != nil {
+			cachedCopy := *cachedNonExtension
+			cachedCopy.Source = cachedNonExtension.Source + " (cached fallback)"
+			GoLog("[Lyrics] No provider returned lyrics, using cached built-in lyrics\n")
+			return &cachedCopy, nil
+		}
+		return nil, fmt.Errorf("lyrics not found from any source")
+	}
 
-		switch providerName {
-		case LyricsProviderLRCLIB:
-			lyrics, err = c.tryLRCLIB(primaryArtist, artistName, trackName, simplifiedTrack, durationSec)
+	GoLog("[Lyrics] Best lyrics from %s (score %.2f, %d alternates)\n", best.Source, best.Score, len(best.Alternates))
+	globalLyricsCache.Set(artistName, trackName, durationSec, best)
+	return best, nil
+}
 
-		case LyricsProviderNetease:
-			neteaseClient := NewNeteaseClient()
+func (c *LyricsClient) fetchFromBuiltInProvider(ctx context.Context, providerName string, q lyricsQuery) (*LyricsResponse, error) {
+	var lyrics *LyricsResponse
+	var err error
+	primaryArtist, artistName, trackName := q.primaryArtist, q.artistName, q.trackName
+	simplifiedTrack, durationSec, fetchOptions := q.simplifiedTrack, q.durationSec, q.options
+
+	switch providerName {
+	case LyricsProviderLRCLIB:
+		lyrics, err = c.tryLRCLIB(ctx, primaryArtist, artistName, trackName, simplifiedTrack, durationSec)
+
+	case LyricsProviderNetease:
+		neteaseClient := NewNeteaseClient()
+		lyrics, err = neteaseClient.FetchLyrics(
+			ctx,
+			trackName,
+			primaryArtist,
+			durationSec,
+			fetchOptions.IncludeTranslationNetease,
+			fetchOptions.IncludeRomanizationNetease,
+		)
+		if err != nil && primaryArtist != artistName {
 			lyrics, err = neteaseClient.FetchLyrics(
+				ctx,
 				trackName,
+				artistName,
+				durationSec,
+				fetchOptions.IncludeTranslationNetease,
+				fetchOptions.IncludeRomanizationNetease,
+			)
+		}
+		if err != nil && simplifiedTrack != trackName {
+			lyrics, err = neteaseClient.FetchLyrics(
+				ctx,
+				simplifiedTrack,
 				primaryArtist,
 				durationSec,
 				fetchOptions.IncludeTranslationNetease,
 				fetchOptions.IncludeRomanizationNetease,
 			)
-			if err != nil && primaryArtist != artistName {
-				lyrics, err = neteaseClient.FetchLyrics(
-					trackName,
-					artistName,
-					durationSec,
-					fetchOptions.IncludeTranslationNetease,
-					fetchOptions.IncludeRomanizationNetease,
-				)
-			}
-			if err != nil && simplifiedTrack != trackName {
-				lyrics, err = neteaseClient.FetchLyrics(
-					simplifiedTrack,
-					primaryArtist,
-					durationSec,
-					fetchOptions.IncludeTranslationNetease,
-					fetchOptions.IncludeRomanizationNetease,
-				)
-			}
+		}
 
-		case LyricsProviderMusixmatch:
-			musixmatchClient := NewMusixmatchClient()
+	case LyricsProviderMusixmatch:
+		musixmatchClient := NewMusixmatchClient()
+		lyrics, err = musixmatchClient.FetchLyrics(
+			ctx,
+			trackName,
+			primaryArtist,
+			durationSec,
+			fetchOptions.MusixmatchLanguage,
+		)
+		if err != nil && primaryArtist != artistName {
 			lyrics, err = musixmatchClient.FetchLyrics(
+				ctx,
 				trackName,
-				primaryArtist,
+				artistName,
 				durationSec,
 				fetchOptions.MusixmatchLanguage,
 			)
-			if err != nil && primaryArtist != artistName {
-				lyrics, err = musixmatchClient.FetchLyrics(
-					trackName,
-					artistName,
-					durationSec,
-					fetchOptions.MusixmatchLanguage,
-				)
-			}
-
-		case LyricsProviderAppleMusic:
-			appleClient := NewAppleMusicClient()
-			lyrics, err = appleClient.FetchLyrics(trackName, primaryArtist, durationSec, fetchOptions.MultiPersonWordByWord)
-			if err != nil && primaryArtist != artistName {
-				lyrics, err = appleClient.FetchLyrics(trackName, artistName, durationSec, fetchOptions.MultiPersonWordByWord)
-			}
-
-		case LyricsProviderQQMusic:
-			qqClient := NewQQMusicClient()
-			lyrics, err = qqClient.FetchLyrics(trackName, primaryArtist, durationSec, fetchOptions.MultiPersonWordByWord)
-			if err != nil && primaryArtist != artistName {
-				lyrics, err = qqClient.FetchLyrics(trackName, artistName, durationSec, fetchOptions.MultiPersonWordByWord)
-			}
-
-		default:
-			GoLog("[Lyrics] Unknown provider: %s, skipping\n", providerName)
-			continue
 		}
 
-		if err == nil && isValidResult(lyrics) {
-			GoLog("[Lyrics] Got lyrics from: %s\n", providerName)
-			globalLyricsCache.Set(artistName, trackName, durationSec, lyrics)
-			return lyrics, nil
+	case LyricsProviderAppleMusic:
+		appleClient := NewAppleMusicClient()
+		lyrics, err = appleClient.FetchLyrics(ctx, trackName, primaryArtist, durationSec, fetchOptions.MultiPersonWordByWord)
+		if err != nil && primaryArtist != artistName {
+			lyrics, err = appleClient.FetchLyrics(ctx, trackName, artistName, durationSec, fetchOptions.MultiPersonWordByWord)
 		}
 
-		if err != nil {
-			GoLog("[Lyrics] Provider %s failed: %v\n", providerName, err)
+	case LyricsProviderQQMusic:
+		qqClient := NewQQMusicClient()
+		lyrics, err = qqClient.FetchLyrics(ctx, trackName, primaryArtist, durationSec, fetchOptions.MultiPersonWordByWord)
+		if err != nil && primaryArtist != artistName {
+			lyrics, err = qqClient.FetchLyrics(ctx, trackName, artistName, durationSec, fetchOptions.MultiPersonWordByWord)
 		}
+
+	default:
+		return nil, fmt.Errorf("unknown provider: %s", providerName)
 	}
 
-	return nil, fmt.Errorf("lyrics not found from any source")
+	return lyrics, err
 }
 
-func (c *LyricsClient) tryLRCLIB(primaryArtist, artistName, trackName, simplifiedTrack string, durationSec float64) (*LyricsResponse, error) {
+func (c *LyricsClient) tryLRCLIB(ctx context.Context, primaryArtist, artistName, trackName, simplifiedTrack string, durationSec float64) (*LyricsResponse, error) {
 	var lyrics *LyricsResponse
 	var err error
 
-	lyrics, err = c.FetchLyricsWithMetadata(primaryArtist, trackName)
+	lyrics, err = c.FetchLyricsWithMetadata(ctx, primaryArtist, trackName)
 	if err == nil && lyrics != nil && (len(lyrics.Lines) > 0 || lyrics.Instrumental) {
 		lyrics.Source = "LRCLIB"
 		return lyrics, nil
 	}
 
 	if primaryArtist != artistName {
-		lyrics, err = c.FetchLyricsWithMetadata(artistName, trackName)
+		lyrics, err = c.FetchLyricsWithMetadata(ctx, artistName, trackName)
 		if err == nil && lyrics != nil && (len(lyrics.Lines) > 0 || lyrics.Instrumental) {
 			lyrics.Source = "LRCLIB"
 			return lyrics, nil
@@ -571,7 +634,7 @@ func (c *LyricsClient) tryLRCLIB(primaryArtist, artistName, trackName, simplifie
 	}
 
 	if simplifiedTrack != trackName {
-		lyrics, err = c.FetchLyricsWithMetadata(primaryArtist, simplifiedTrack)
+		lyrics, err = c.FetchLyricsWithMetadata(ctx, primaryArtist, simplifiedTrack)
 		if err == nil && lyrics != nil && (len(lyrics.Lines) > 0 || lyrics.Instrumental) {
 			lyrics.Source = "LRCLIB (simplified)"
 			return lyrics, nil
@@ -579,7 +642,7 @@ func (c *LyricsClient) tryLRCLIB(primaryArtist, artistName, trackName, simplifie
 	}
 
 	query := primaryArtist + " " + trackName
-	lyrics, err = c.FetchLyricsFromLRCLibSearch(query, durationSec)
+	lyrics, err = c.FetchLyricsFromLRCLibSearch(ctx, query, durationSec)
 	if err == nil && lyrics != nil && (len(lyrics.Lines) > 0 || lyrics.Instrumental) {
 		lyrics.Source = "LRCLIB Search"
 		return lyrics, nil
@@ -587,7 +650,7 @@ func (c *LyricsClient) tryLRCLIB(primaryArtist, artistName, trackName, simplifie
 
 	if simplifiedTrack != trackName {
 		query = primaryArtist + " " + simplifiedTrack
-		lyrics, err = c.FetchLyricsFromLRCLibSearch(query, durationSec)
+		lyrics, err = c.FetchLyricsFromLRCLibSearch(ctx, query, durationSec)
 		if err == nil && lyrics != nil && (len(lyrics.Lines) > 0 || lyrics.Instrumental) {
 			lyrics.Source = "LRCLIB Search (simplified)"
 			return lyrics, nil
@@ -599,9 +662,12 @@ func (c *LyricsClient) tryLRCLIB(primaryArtist, artistName, trackName, simplifie
 
 func (c *LyricsClient) parseLRCLibResponse(resp *LRCLibResponse) *LyricsResponse {
 	result := &LyricsResponse{
-		Instrumental: resp.Instrumental,
-		PlainLyrics:  resp.PlainLyrics,
-		Provider:     "LRCLIB",
+		Instrumental:       resp.Instrumental,
+		PlainLyrics:        resp.PlainLyrics,
+		Provider:           "LRCLIB",
+		MatchedTitle:       resp.TrackName,
+		MatchedArtist:      resp.ArtistName,
+		MatchedDurationSec: resp.Duration,
 	}
 
 	if resp.SyncedLyrics != "" {
@@ -665,6 +731,14 @@ func parseSyncedLyrics(syncedLyrics string) []LyricsLine {
 		lines[len(lines)-1].EndTimeMs = lines[len(lines)-1].StartTimeMs + 5000
 	}
 
+	for i := range lines {
+		applyLRCLineDetail(&lines[i])
+	}
+
+	if offset, ok := lrcOffsetTagMs(syncedLyrics); ok {
+		lines = mapLyricsTimestamps(lines, func(ms int64) int64 { return max(ms-offset, 0) })
+	}
+
 	return lines
 }
 
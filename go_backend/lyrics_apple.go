@@ -1,6 +1,7 @@
 package gobackend
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -100,18 +101,26 @@ func selectBestAppleMusicSearchResult(results []appleMusicSearchResult, trackNam
 	return &results[bestIndex]
 }
 
-func (c *AppleMusicClient) SearchSong(trackName, artistName string, durationSec float64) (string, error) {
+func (c *AppleMusicClient) SearchSong(ctx context.Context, trackName, artistName string, durationSec float64) (string, error) {
+	best, err := c.searchBestSong(ctx, trackName, artistName, durationSec)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(best.ID), nil
+}
+
+func (c *AppleMusicClient) searchBestSong(ctx context.Context, trackName, artistName string, durationSec float64) (*appleMusicSearchResult, error) {
 	query := trackName + " " + artistName
 	if strings.TrimSpace(query) == "" {
-		return "", fmt.Errorf("empty search query")
+		return nil, fmt.Errorf("empty search query")
 	}
 
 	encodedQuery := url.QueryEscape(query)
 	searchURL := fmt.Sprintf("https://lyrics.paxsenix.org/apple-music/search?q=%s", encodedQuery)
 
-	req, err := http.NewRequest("GET", searchURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	req.Header.Set("User-Agent", appUserAgent())
@@ -119,31 +128,31 @@ func (c *AppleMusicClient) SearchSong(trackName, artistName string, durationSec
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("apple music search failed: %w", err)
+		return nil, fmt.Errorf("apple music search failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("apple music search returned HTTP %d", resp.StatusCode)
+		return nil, fmt.Errorf("apple music search returned HTTP %d", resp.StatusCode)
 	}
 
 	var searchResp []appleMusicSearchResult
 	if err := json.NewDecoder(resp.Body).Decode(&searchResp); err != nil {
-		return "", fmt.Errorf("failed to decode apple music response: %w", err)
+		return nil, fmt.Errorf("failed to decode apple music response: %w", err)
 	}
 
 	best := selectBestAppleMusicSearchResult(searchResp, trackName, artistName, durationSec)
 	if best == nil || strings.TrimSpace(best.ID) == "" {
-		return "", fmt.Errorf("no songs found on apple music")
+		return nil, fmt.Errorf("no songs found on apple music")
 	}
 
-	return strings.TrimSpace(best.ID), nil
+	return best, nil
 }
 
-func (c *AppleMusicClient) FetchLyricsByID(songID string) (string, error) {
+func (c *AppleMusicClient) FetchLyricsByID(ctx context.Context, songID string) (string, error) {
 	lyricsURL := fmt.Sprintf("https://lyrics.paxsenix.org/apple-music/lyrics?id=%s", songID)
 
-	req, err := http.NewRequest("GET", lyricsURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", lyricsURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -249,17 +258,18 @@ func formatPaxContent(lyricsType string, content []paxLyrics, multiPersonWordByW
 }
 
 func (c *AppleMusicClient) FetchLyrics(
+	ctx context.Context,
 	trackName,
 	artistName string,
 	durationSec float64,
 	multiPersonWordByWord bool,
 ) (*LyricsResponse, error) {
-	songID, err := c.SearchSong(trackName, artistName, durationSec)
+	song, err := c.searchBestSong(ctx, trackName, artistName, durationSec)
 	if err != nil {
 		return nil, err
 	}
 
-	rawLyrics, err := c.FetchLyricsByID(songID)
+	rawLyrics, err := c.FetchLyricsByID(ctx, strings.TrimSpace(song.ID))
 	if err != nil {
 		return nil, err
 	}
@@ -273,12 +283,22 @@ func (c *AppleMusicClient) FetchLyrics(
 	}
 
 	lines := parseSyncedLyrics(lrcText)
+	if len(lines) > 0 && !multiPersonWordByWord {
+		// Agents and background vocals are only written to the LRC text in
+		// multi-person mode; keep them in the model either way.
+		if detailed, detailErr := formatPaxLyricsToLRC(rawLyrics, true); detailErr == nil {
+			attachLyricsLineDetail(lines, parseSyncedLyrics(detailed))
+		}
+	}
 	if len(lines) > 0 {
 		return &LyricsResponse{
-			Lines:    lines,
-			SyncType: "LINE_SYNCED",
-			Provider: "Apple Music",
-			Source:   "Apple Music",
+			Lines:              lines,
+			SyncType:           "LINE_SYNCED",
+			Provider:           "Apple Music",
+			Source:             "Apple Music",
+			MatchedTitle:       song.SongName,
+			MatchedArtist:      song.ArtistName,
+			MatchedDurationSec: float64(song.Duration) / 1000.0,
 		}, nil
 	}
 
@@ -286,10 +306,13 @@ func (c *AppleMusicClient) FetchLyrics(
 
 	if len(resultLines) > 0 {
 		return &LyricsResponse{
-			Lines:    resultLines,
-			SyncType: "UNSYNCED",
-			Provider: "Apple Music",
-			Source:   "Apple Music",
+			Lines:              resultLines,
+			SyncType:           "UNSYNCED",
+			Provider:           "Apple Music",
+			Source:             "Apple Music",
+			MatchedTitle:       song.SongName,
+			MatchedArtist:      song.ArtistName,
+			MatchedDurationSec: float64(song.Duration) / 1000.0,
 		}, nil
 	}
 
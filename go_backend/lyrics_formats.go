@@ -0,0 +1,585 @@
+package gobackend
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	lyricsFormatLRC         = "lrc"
+	lyricsFormatEnhancedLRC = "enhanced_lrc"
+	lyricsFormatTTML        = "ttml"
+	lyricsFormatSRT         = "srt"
+	lyricsFormatPlain       = "plain"
+)
+
+var (
+	lrcWordTimestampPattern = regexp.MustCompile(`<(\d{2}):(\d{2})\.(\d{2,3})>`)
+	lrcAgentPrefixPattern   = regexp.MustCompile(`^(v\d+):\s*`)
+	lrcHeaderTagPattern     = regexp.MustCompile(`^\[(ti|ar|al|by|length|offset):(.*)\]$`)
+)
+
+// parseLRCWords splits enhanced LRC text ("<00:01.00>Hel<00:01.20>lo ") into
+// timed words. Each chunk starts at the tag before it and ends at the next
+// tag, or at lineEndMs for the last chunk.
+func parseLRCWords(text string, lineEndMs int64) []LyricsWord {
+	tags := lrcWordTimestampPattern.FindAllStringSubmatchIndex(text, -1)
+	if len(tags) == 0 {
+		return nil
+	}
+
+	var words []LyricsWord
+	for i, tag := range tags {
+		end := len(text)
+		endMs := lineEndMs
+		if i+1 < len(tags) {
+			end = tags[i+1][0]
+			endMs = lrcTimestampToMs(text[tags[i+1][2]:tags[i+1][3]], text[tags[i+1][4]:tags[i+1][5]], text[tags[i+1][6]:tags[i+1][7]])
+		}
+		chunk := text[tag[1]:end]
+		if strings.TrimSpace(chunk) == "" {
+			if chunk != "" && len(words) > 0 && !strings.HasSuffix(words[len(words)-1].Text, " ") {
+				words[len(words)-1].Text += " "
+			}
+			continue
+		}
+		startMs := lrcTimestampToMs(text[tag[2]:tag[3]], text[tag[4]:tag[5]], text[tag[6]:tag[7]])
+		words = append(words, LyricsWord{StartTimeMs: startMs, EndTimeMs: max(endMs, startMs), Text: chunk})
+	}
+	return words
+}
+
+// applyLRCLineDetail fills the agent, word timings and background vocals of
+// a line whose Words hold enhanced LRC, as produced by formatPaxContent.
+func applyLRCLineDetail(line *LyricsLine) {
+	parts := strings.Split(line.Words, "\n")
+	main := parts[0]
+	if match := lrcAgentPrefixPattern.FindStringSubmatch(main); match != nil {
+		line.Agent = match[1]
+		main = main[len(match[0]):]
+	}
+	line.WordTimings = parseLRCWords(main, line.EndTimeMs)
+
+	for _, part := range parts[1:] {
+		if bg, ok := strings.CutPrefix(strings.TrimSpace(part), "[bg:"); ok {
+			line.Background = append(line.Background, parseLRCWords(strings.TrimSuffix(bg, "]"), line.EndTimeMs)...)
+		}
+	}
+}
+
+// attachLyricsLineDetail copies word-level details from a richer parse of
+// the same lyrics onto lines built with different display options.
+func attachLyricsLineDetail(lines, detailed []LyricsLine) {
+	if len(lines) != len(detailed) {
+		return
+	}
+	for i := range lines {
+		if lines[i].StartTimeMs != detailed[i].StartTimeMs {
+			return
+		}
+	}
+	for i := range lines {
+		lines[i].Agent = detailed[i].Agent
+		lines[i].WordTimings = detailed[i].WordTimings
+		lines[i].Background = detailed[i].Background
+	}
+}
+
+func lyricsWordsText(words []LyricsWord) string {
+	var builder strings.Builder
+	for _, word := range words {
+		builder.WriteString(word.Text)
+	}
+	return strings.Join(strings.Fields(builder.String()), " ")
+}
+
+// lyricsLineText returns the display text of a line without timing tags,
+// agent prefixes or background vocals.
+func lyricsLineText(line LyricsLine) string {
+	if len(line.WordTimings) > 0 {
+		return lyricsWordsText(line.WordTimings)
+	}
+	main := strings.Split(line.Words, "\n")[0]
+	main = lrcAgentPrefixPattern.ReplaceAllString(main, "")
+	main = lrcWordTimestampPattern.ReplaceAllString(main, "")
+	return strings.Join(strings.Fields(main), " ")
+}
+
+func lyricsLineDisplayText(line LyricsLine) string {
+	text := lyricsLineText(line)
+	if bg := lyricsWordsText(line.Background); bg != "" {
+		text += " (" + bg + ")"
+	}
+	return text
+}
+
+func writeLRCHeader(builder *strings.Builder, trackName, artistName string) {
+	builder.WriteString(fmt.Sprintf("[ti:%s]\n", trackName))
+	builder.WriteString(fmt.Sprintf("[ar:%s]\n", artistName))
+	builder.WriteString("[by:Implemented by SpotiFLAC-Mobile using Paxsenix API]\n")
+	builder.WriteString("\n")
+}
+
+func appendEnhancedLRCWords(builder *strings.Builder, words []LyricsWord) {
+	for i, word := range words {
+		builder.WriteString(fmt.Sprintf("<%s>", msToLRCTimestampInline(word.StartTimeMs)))
+		if i == len(words)-1 {
+			builder.WriteString(strings.TrimRight(word.Text, " "))
+		} else {
+			builder.WriteString(word.Text)
+		}
+		if i == len(words)-1 || words[i+1].StartTimeMs != word.EndTimeMs {
+			builder.WriteString(fmt.Sprintf("<%s>", msToLRCTimestampInline(word.EndTimeMs)))
+		}
+	}
+}
+
+func convertToTimedLRC(lyrics *LyricsResponse, trackName, artistName string, enhanced bool) string {
+	var builder strings.Builder
+	writeLRCHeader(&builder, trackName, artistName)
+	synced := lyrics.SyncType != "UNSYNCED"
+	for _, line := range lyrics.Lines {
+		if !synced {
+			if text := lyricsLineDisplayText(line); text != "" {
+				builder.WriteString(text + "\n")
+			}
+			continue
+		}
+		if !enhanced || len(line.WordTimings) == 0 {
+			if text := lyricsLineDisplayText(line); text != "" {
+				builder.WriteString(msToLRCTimestamp(line.StartTimeMs) + text + "\n")
+			}
+			continue
+		}
+		builder.WriteString(msToLRCTimestamp(line.StartTimeMs))
+		if line.Agent != "" {
+			builder.WriteString(line.Agent + ":")
+		}
+		appendEnhancedLRCWords(&builder, line.WordTimings)
+		builder.WriteString("\n")
+		if len(line.Background) > 0 {
+			builder.WriteString("[bg:")
+			appendEnhancedLRCWords(&builder, line.Background)
+			builder.WriteString("]\n")
+		}
+	}
+	return builder.String()
+}
+
+func formatTTMLTime(ms int64) string {
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+func xmlEscape(text string) string {
+	var builder strings.Builder
+	_ = xml.EscapeText(&builder, []byte(text))
+	return builder.String()
+}
+
+func appendTTMLSpans(builder *strings.Builder, words []LyricsWord) {
+	for _, word := range words {
+		text := strings.TrimRight(word.Text, " ")
+		builder.WriteString(fmt.Sprintf(`<span begin="%s" end="%s">%s</span>`,
+			formatTTMLTime(word.StartTimeMs), formatTTMLTime(word.EndTimeMs), xmlEscape(text)))
+		if len(text) < len(word.Text) {
+			builder.WriteString(" ")
+		}
+	}
+}
+
+// convertToTTML writes Apple-style TTML: one <p> per line, word spans when
+// word timings are known and background vocals in an x-bg span.
+func convertToTTML(lyrics *LyricsResponse, trackName, artistName string) string {
+	timing := "None"
+	agents := make(map[string]bool)
+	var agentOrder []string
+	if lyrics.SyncType != "UNSYNCED" {
+		timing = "Line"
+	}
+	for _, line := range lyrics.Lines {
+		if len(line.WordTimings) > 0 && timing == "Line" {
+			timing = "Word"
+		}
+		if line.Agent != "" && !agents[line.Agent] {
+			agents[line.Agent] = true
+			agentOrder = append(agentOrder, line.Agent)
+		}
+	}
+
+	var builder strings.Builder
+	builder.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	builder.WriteString(`<tt xmlns="http://www.w3.org/ns/ttml" xmlns:ttm="http://www.w3.org/ns/ttml#metadata" xmlns:itunes="http://music.apple.com/lyric-ttml-internal" itunes:timing="` + timing + `">` + "\n")
+	builder.WriteString("<head><metadata>")
+	builder.WriteString("<ttm:title>" + xmlEscape(trackName) + "</ttm:title>")
+	builder.WriteString("<ttm:name type=\"full\">" + xmlEscape(artistName) + "</ttm:name>")
+	for _, agent := range agentOrder {
+		builder.WriteString(`<ttm:agent type="person" xml:id="` + xmlEscape(agent) + `"/>`)
+	}
+	builder.WriteString("</metadata></head>\n<body><div>\n")
+
+	for _, line := range lyrics.Lines {
+		text := lyricsLineText(line)
+		if text == "" {
+			continue
+		}
+		builder.WriteString("<p")
+		if timing != "None" {
+			builder.WriteString(fmt.Sprintf(` begin="%s" end="%s"`, formatTTMLTime(line.StartTimeMs), formatTTMLTime(max(line.EndTimeMs, line.StartTimeMs))))
+		}
+		if line.Agent != "" {
+			builder.WriteString(` ttm:agent="` + xmlEscape(line.Agent) + `"`)
+		}
+		builder.WriteString(">")
+		if len(line.WordTimings) > 0 {
+			appendTTMLSpans(&builder, line.WordTimings)
+		} else {
+			builder.WriteString(xmlEscape(text))
+		}
+		if len(line.Background) > 0 {
+			builder.WriteString(`<span ttm:role="x-bg">`)
+			appendTTMLSpans(&builder, line.Background)
+			builder.WriteString("</span>")
+		}
+		builder.WriteString("</p>\n")
+	}
+	builder.WriteString("</div></body>\n</tt>\n")
+	return builder.String()
+}
+
+// parseTTMLTime accepts clock times ("01:02.345", "00:01:02.345") and
+// offset times ("62.345s", "62345ms").
+func parseTTMLTime(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if ms, ok := strings.CutSuffix(value, "ms"); ok {
+		parsed, err := strconv.ParseFloat(ms, 64)
+		return int64(parsed), err == nil
+	}
+	if sec, ok := strings.CutSuffix(value, "s"); ok {
+		parsed, err := strconv.ParseFloat(sec, 64)
+		return int64(math.Round(parsed * 1000)), err == nil
+	}
+
+	var total float64
+	for _, part := range strings.Split(value, ":") {
+		parsed, err := strconv.ParseFloat(part, 64)
+		if err != nil {
+			return 0, false
+		}
+		total = total*60 + parsed
+	}
+	return int64(math.Round(total * 1000)), true
+}
+
+func ttmlAttr(element xml.StartElement, name string) string {
+	for _, attr := range element.Attr {
+		if attr.Name.Local == name {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// parseTTMLContent reads TTML as written by convertToTTML or Apple Music:
+// <p> lines with optional word <span>s and an x-bg background span.
+func parseTTMLContent(content string) (*LyricsResponse, string, string, error) {
+	decoder := xml.NewDecoder(strings.NewReader(content))
+	lyrics := &LyricsResponse{SyncType: "UNSYNCED"}
+	var trackName, artistName string
+
+	var (
+		line     *LyricsLine
+		lineText strings.Builder
+		word     *LyricsWord
+		spans    []bool // true for background spans
+		metaText *string
+	)
+	inBackground := func() bool {
+		for _, bg := range spans {
+			if bg {
+				return true
+			}
+		}
+		return false
+	}
+	appendSpace := func() {
+		words := &line.WordTimings
+		if inBackground() {
+			words = &line.Background
+		}
+		if n := len(*words); n > 0 && !strings.HasSuffix((*words)[n-1].Text, " ") {
+			(*words)[n-1].Text += " "
+		}
+	}
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, "", "", fmt.Errorf("invalid ttml: %w", err)
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "title":
+				metaText = &trackName
+			case "name":
+				metaText = &artistName
+			case "p":
+				line = &LyricsLine{Agent: ttmlAttr(t, "agent")}
+				lineText.Reset()
+				if start, ok := parseTTMLTime(ttmlAttr(t, "begin")); ok {
+					line.StartTimeMs = start
+					lyrics.SyncType = "LINE_SYNCED"
+				}
+				if end, ok := parseTTMLTime(ttmlAttr(t, "end")); ok {
+					line.EndTimeMs = end
+				}
+			case "span":
+				if line == nil {
+					continue
+				}
+				background := ttmlAttr(t, "role") == "x-bg"
+				spans = append(spans, background)
+				if start, ok := parseTTMLTime(ttmlAttr(t, "begin")); ok && !background {
+					word = &LyricsWord{StartTimeMs: start}
+					if end, ok := parseTTMLTime(ttmlAttr(t, "end")); ok {
+						word.EndTimeMs = end
+					}
+				}
+			case "br":
+				if line != nil {
+					lineText.WriteString(" ")
+				}
+			}
+		case xml.CharData:
+			text := string(t)
+			switch {
+			case metaText != nil:
+				*metaText += strings.TrimSpace(text)
+			case word != nil:
+				word.Text += text
+			case line != nil:
+				if strings.TrimSpace(text) == "" {
+					if text != "" {
+						appendSpace()
+					}
+				} else if !inBackground() {
+					lineText.WriteString(text)
+				}
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "title", "name":
+				metaText = nil
+			case "span":
+				if len(spans) == 0 {
+					continue
+				}
+				if word != nil {
+					word.EndTimeMs = max(word.EndTimeMs, word.StartTimeMs)
+					if inBackground() {
+						line.Background = append(line.Background, *word)
+					} else {
+						line.WordTimings = append(line.WordTimings, *word)
+					}
+					word = nil
+				}
+				spans = spans[:len(spans)-1]
+			case "p":
+				if line == nil {
+					continue
+				}
+				if len(line.WordTimings) > 0 {
+					line.Words = lyricsWordsText(line.WordTimings)
+				} else {
+					line.Words = strings.Join(strings.Fields(lineText.String()), " ")
+				}
+				if line.Words != "" || len(line.Background) > 0 {
+					line.EndTimeMs = max(line.EndTimeMs, line.StartTimeMs)
+					lyrics.Lines = append(lyrics.Lines, *line)
+				}
+				line, spans, word = nil, nil, nil
+			}
+		}
+	}
+
+	if len(lyrics.Lines) == 0 {
+		return nil, "", "", fmt.Errorf("ttml contains no lyrics")
+	}
+	return lyrics, trackName, artistName, nil
+}
+
+func isTTMLContent(content string) bool {
+	trimmed := strings.TrimSpace(content)
+	return strings.HasPrefix(trimmed, "<") && strings.Contains(trimmed, "<tt")
+}
+
+// parseLyricsContent reads LRC or TTML text into the lyrics model.
+func parseLyricsContent(content string) (*LyricsResponse, string, string, error) {
+	if isTTMLContent(content) {
+		return parseTTMLContent(content)
+	}
+	lyrics, trackName, artistName := parseLRCContent(content)
+	if len(lyrics.Lines) == 0 {
+		return nil, "", "", fmt.Errorf("no lyrics found in content")
+	}
+	return lyrics, trackName, artistName, nil
+}
+
+func formatSRTTime(ms int64) string {
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+func convertToSRT(lyrics *LyricsResponse) (string, error) {
+	if lyrics.SyncType == "UNSYNCED" {
+		return "", fmt.Errorf("srt requires synced lyrics")
+	}
+	var builder strings.Builder
+	cue := 0
+	for _, line := range lyrics.Lines {
+		text := lyricsLineText(line)
+		if text == "" {
+			continue
+		}
+		cue++
+		builder.WriteString(fmt.Sprintf("%d\n%s --> %s\n%s\n", cue,
+			formatSRTTime(line.StartTimeMs), formatSRTTime(max(line.EndTimeMs, line.StartTimeMs)), text))
+		if bg := lyricsWordsText(line.Background); bg != "" {
+			builder.WriteString("(" + bg + ")\n")
+		}
+		builder.WriteString("\n")
+	}
+	return builder.String(), nil
+}
+
+// formatLyrics serializes lyrics in the requested format. An empty format
+// keeps the historical LRC output of convertToLRCWithMetadata.
+func formatLyrics(lyrics *LyricsResponse, format, trackName, artistName string) (string, error) {
+	if lyrics == nil || len(lyrics.Lines) == 0 {
+		return "", fmt.Errorf("no lyrics to format")
+	}
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case "":
+		return convertToLRCWithMetadata(lyrics, trackName, artistName), nil
+	case lyricsFormatLRC:
+		return convertToTimedLRC(lyrics, trackName, artistName, false), nil
+	case lyricsFormatEnhancedLRC:
+		return convertToTimedLRC(lyrics, trackName, artistName, true), nil
+	case lyricsFormatTTML:
+		return convertToTTML(lyrics, trackName, artistName), nil
+	case lyricsFormatSRT:
+		return convertToSRT(lyrics)
+	case lyricsFormatPlain:
+		var lines []string
+		for _, line := range lyrics.Lines {
+			if text := lyricsLineDisplayText(line); text != "" {
+				lines = append(lines, text)
+			}
+		}
+		return strings.Join(lines, "\n") + "\n", nil
+	default:
+		return "", fmt.Errorf("unsupported lyrics format: %s", format)
+	}
+}
+
+func lyricsFileExtension(format string) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case lyricsFormatTTML:
+		return ".ttml"
+	case lyricsFormatSRT:
+		return ".srt"
+	case lyricsFormatPlain:
+		return ".txt"
+	default:
+		return ".lrc"
+	}
+}
+
+// parseLRCContent rebuilds the lyrics model from LRC text, keeping the
+// [ti:] and [ar:] header values for re-serialization.
+func parseLRCContent(content string) (*LyricsResponse, string, string) {
+	var trackName, artistName string
+	var body []string
+	for _, line := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if match := lrcHeaderTagPattern.FindStringSubmatch(trimmed); match != nil {
+			switch match[1] {
+			case "ti":
+				trackName = strings.TrimSpace(match[2])
+			case "ar":
+				artistName = strings.TrimSpace(match[2])
+			}
+			continue
+		}
+		body = append(body, line)
+	}
+
+	lyrics := &LyricsResponse{Lines: parseSyncedLyrics(strings.Join(body, "\n")), SyncType: "LINE_SYNCED"}
+	if offset, ok := lrcOffsetTagMs(content); ok {
+		lyrics = applyLyricsOffset(lyrics, -offset)
+	}
+	if len(lyrics.Lines) == 0 {
+		lyrics.Lines = plainTextLyricsLines(strings.Join(body, "\n"))
+		lyrics.SyncType = "UNSYNCED"
+	}
+	return lyrics, trackName, artistName
+}
+
+// convertLRCContent re-serializes LRC text; an empty format returns it as is.
+func convertLRCContent(content, format string) (string, error) {
+	if strings.TrimSpace(format) == "" {
+		return content, nil
+	}
+	lyrics, trackName, artistName := parseLRCContent(content)
+	return formatLyrics(lyrics, format, trackName, artistName)
+}
+
+// SaveLyricsFile writes LRC content next to the audio file in the given
+// format (lrc, enhanced_lrc, ttml, srt or plain); the extension follows the
+// format.
+func SaveLyricsFile(audioFilePath, lrcContent, format string) (string, error) {
+	if strings.TrimSpace(format) == "" {
+		return SaveLRCFile(audioFilePath, lrcContent)
+	}
+	if lrcContent == "" {
+		return "", fmt.Errorf("empty LRC content")
+	}
+	content, err := convertLRCContent(lrcContent, format)
+	if err != nil {
+		return "", err
+	}
+
+	ext := filepath.Ext(audioFilePath)
+	lyricsPath := strings.TrimSuffix(audioFilePath, ext) + lyricsFileExtension(format)
+	if err := os.WriteFile(lyricsPath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write lyrics file: %w", err)
+	}
+
+	GoLog("[Lyrics] Saved %s lyrics file: %s\n", format, lyricsPath)
+	return lyricsPath, nil
+}
+
+// lyricsForEmbedding converts LRC content for the LYRICS tag. TTML and SRT
+// are file formats only, so they keep the LRC content.
+func lyricsForEmbedding(lrcContent, format string) string {
+	switch strings.ToLower(strings.TrimSpace(format)) {
+	case lyricsFormatLRC, lyricsFormatEnhancedLRC, lyricsFormatPlain:
+		if converted, err := convertLRCContent(lrcContent, format); err == nil {
+			return converted
+		}
+	}
+	return lrcContent
+}
@@ -0,0 +1,184 @@
+package gobackend
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func intPtr(v int) *int { return &v }
+
+func testPaxContent() []paxLyrics {
+	return []paxLyrics{
+		{
+			Timestamp:    1000,
+			EndTime:      2000,
+			OppositeTurn: true,
+			Text: []paxLyricDetail{
+				{Text: "Hel", Part: true, Timestamp: intPtr(1000), EndTime: intPtr(1200)},
+				{Text: "lo", Timestamp: intPtr(1200), EndTime: intPtr(1500)},
+				{Text: "world", Timestamp: intPtr(1500), EndTime: intPtr(2000)},
+			},
+			Background: true,
+			BackgroundText: []paxLyricDetail{
+				{Text: "ooh", Timestamp: intPtr(1600), EndTime: intPtr(1900)},
+			},
+		},
+		{
+			Timestamp: 3000,
+			EndTime:   4000,
+			Text: []paxLyricDetail{
+				{Text: "Bye", Timestamp: intPtr(3000), EndTime: intPtr(4000)},
+			},
+		},
+	}
+}
+
+func TestParseSyncedLyricsKeepsWordTimingsAgentsAndBackground(t *testing.T) {
+	lines := parseSyncedLyrics(formatPaxContent("Syllable", testPaxContent(), true))
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lines))
+	}
+
+	first := lines[0]
+	if first.Agent != "v2" {
+		t.Fatalf("agent = %q, want v2", first.Agent)
+	}
+	if len(first.WordTimings) != 3 || first.WordTimings[1].StartTimeMs != 1200 || first.WordTimings[1].EndTimeMs != 1500 {
+		t.Fatalf("unexpected word timings: %+v", first.WordTimings)
+	}
+	if got := lyricsLineText(first); got != "Hello world" {
+		t.Fatalf("line text = %q", got)
+	}
+	if len(first.Background) != 1 || first.Background[0].StartTimeMs != 1600 {
+		t.Fatalf("unexpected background: %+v", first.Background)
+	}
+
+	plain := parseSyncedLyrics(formatPaxContent("Syllable", testPaxContent(), false))
+	attachLyricsLineDetail(plain, lines)
+	if plain[0].Agent != "v2" || len(plain[0].Background) != 1 {
+		t.Fatalf("details were not attached: %+v", plain[0])
+	}
+}
+
+func TestFormatLyricsOutputs(t *testing.T) {
+	lyrics := &LyricsResponse{
+		Lines:    parseSyncedLyrics(formatPaxContent("Syllable", testPaxContent(), true)),
+		SyncType: "LINE_SYNCED",
+	}
+
+	lrc, err := formatLyrics(lyrics, lyricsFormatLRC, "Song", "Artist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(lrc, "[00:01.00]Hello world (ooh)\n") || strings.Contains(lrc, "<00:") {
+		t.Fatalf("unexpected line-level LRC:\n%s", lrc)
+	}
+
+	enhanced, err := formatLyrics(lyrics, lyricsFormatEnhancedLRC, "Song", "Artist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(enhanced, "[00:01.00]v2:<00:01.00>Hel<00:01.20>lo <00:01.50>world<00:02.00>\n[bg:<00:01.60>ooh<00:01.90>]") {
+		t.Fatalf("unexpected enhanced LRC:\n%s", enhanced)
+	}
+	reparsed, _, _ := parseLRCContent(enhanced)
+	if len(reparsed.Lines) != 2 || len(reparsed.Lines[0].WordTimings) != 3 {
+		t.Fatalf("enhanced LRC did not round-trip: %+v", reparsed.Lines)
+	}
+
+	ttml, err := formatLyrics(lyrics, lyricsFormatTTML, "Song & Co", "Artist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`itunes:timing="Word"`,
+		`<ttm:title>Song &amp; Co</ttm:title>`,
+		`<p begin="00:00:01.000" end="00:00:03.000" ttm:agent="v2">`,
+		`<span begin="00:00:01.200" end="00:00:01.500">lo</span> `,
+		`<span ttm:role="x-bg">`,
+	} {
+		if !strings.Contains(ttml, want) {
+			t.Fatalf("TTML missing %q:\n%s", want, ttml)
+		}
+	}
+
+	srt, err := formatLyrics(lyrics, lyricsFormatSRT, "", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(srt, "1\n00:00:01,000 --> 00:00:03,000\nHello world\n(ooh)\n\n2\n") {
+		t.Fatalf("unexpected SRT:\n%s", srt)
+	}
+
+	if _, err := formatLyrics(&LyricsResponse{Lines: plainTextLyricsLines("a"), SyncType: "UNSYNCED"}, lyricsFormatSRT, "", ""); err == nil {
+		t.Fatalf("expected SRT of unsynced lyrics to fail")
+	}
+}
+
+func TestSaveLyricsFileUsesFormatExtension(t *testing.T) {
+	dir := t.TempDir()
+	audioPath := filepath.Join(dir, "song.flac")
+	lrc := "[ti:Song]\n[ar:Artist]\n\n[00:01.00]First\n[00:03.50]Second\n"
+
+	path, err := SaveLyricsFile(audioPath, lrc, lyricsFormatSRT)
+	if err != nil {
+		t.Fatalf("SaveLyricsFile failed: %v", err)
+	}
+	if filepath.Ext(path) != ".srt" {
+		t.Fatalf("expected .srt file, got %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "00:00:01,000 --> 00:00:03,500\nFirst") {
+		t.Fatalf("unexpected SRT content:\n%s", data)
+	}
+}
+
+func TestParseTTMLContentRoundTrip(t *testing.T) {
+	source := &LyricsResponse{
+		SyncType: "LINE_SYNCED",
+		Lines: []LyricsLine{
+			{
+				StartTimeMs: 1000,
+				EndTimeMs:   2000,
+				Words:       "Hello world",
+				Agent:       "v1",
+				WordTimings: []LyricsWord{
+					{StartTimeMs: 1000, EndTimeMs: 1500, Text: "Hello "},
+					{StartTimeMs: 1500, EndTimeMs: 2000, Text: "world"},
+				},
+				Background: []LyricsWord{{StartTimeMs: 1600, EndTimeMs: 1900, Text: "ooh"}},
+			},
+			{StartTimeMs: 2500, EndTimeMs: 3000, Words: "Plain & simple"},
+		},
+	}
+
+	parsed, trackName, artistName, err := parseTTMLContent(convertToTTML(source, "Song", "Artist"))
+	if err != nil {
+		t.Fatalf("parseTTMLContent failed: %v", err)
+	}
+	if trackName != "Song" || artistName != "Artist" {
+		t.Fatalf("metadata = %q/%q", trackName, artistName)
+	}
+	if parsed.SyncType != "LINE_SYNCED" || len(parsed.Lines) != 2 {
+		t.Fatalf("unexpected lyrics: %+v", parsed)
+	}
+	first := parsed.Lines[0]
+	if first.Words != "Hello world" || first.Agent != "v1" || len(first.WordTimings) != 2 || first.WordTimings[0].Text != "Hello " {
+		t.Fatalf("unexpected first line: %+v", first)
+	}
+	if len(first.Background) != 1 || first.Background[0].StartTimeMs != 1600 {
+		t.Fatalf("background not parsed: %+v", first.Background)
+	}
+	if parsed.Lines[1].Words != "Plain & simple" || parsed.Lines[1].StartTimeMs != 2500 {
+		t.Fatalf("unexpected second line: %+v", parsed.Lines[1])
+	}
+
+	if ms, ok := parseTTMLTime("12.5s"); !ok || ms != 12500 {
+		t.Fatalf("parseTTMLTime(12.5s) = %d, %v", ms, ok)
+	}
+}
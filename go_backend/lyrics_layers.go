@@ -0,0 +1,331 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+const (
+	lyricsLayerTranslation  = "translation"
+	lyricsLayerRomanization = "romanization"
+
+	// Layer lines within this distance of a main line are treated as its
+	// counterpart; providers round timestamps differently.
+	lyricsLayerAlignToleranceMs = 1000
+
+	// Han-only lines within this many lines of a line with kana are read as
+	// Japanese.
+	lyricsKanaNeighbourLines = 2
+)
+
+// LyricsLayer is a secondary text track (translation or romanization) whose
+// lines share the timestamps of the main lines they belong to.
+type LyricsLayer struct {
+	Kind     string `json:"kind"`
+	Language string `json:"language,omitempty"`
+	Source   string `json:"source,omitempty"`
+	// Partial is set when some characters had no known reading and were
+	// kept as written.
+	Partial bool         `json:"partial,omitempty"`
+	Lines   []LyricsLine `json:"lines"`
+}
+
+type LyricsLayerRequest struct {
+	Content             string `json:"content"`
+	Romanize            bool   `json:"romanize,omitempty"`
+	Language            string `json:"language,omitempty"`
+	TranslationContent  string `json:"translationContent,omitempty"`
+	TranslationLanguage string `json:"translationLanguage,omitempty"`
+	Inline              bool   `json:"inline,omitempty"`
+	Format              string `json:"format,omitempty"`
+}
+
+type LyricsLayerResult struct {
+	Lyrics              string            `json:"lyrics"`
+	Language            string            `json:"language,omitempty"`
+	Layers              map[string]string `json:"layers,omitempty"`
+	RomanizationPartial bool              `json:"romanizationPartial,omitempty"`
+}
+
+func (l *LyricsResponse) layer(kind string) *LyricsLayer {
+	for i := range l.Layers {
+		if l.Layers[i].Kind == kind {
+			return &l.Layers[i]
+		}
+	}
+	return nil
+}
+
+// withLyricsLayer returns a copy of lyrics with the layer added or replacing
+// the existing layer of the same kind; cached responses are never mutated.
+func withLyricsLayer(lyrics *LyricsResponse, layer LyricsLayer) *LyricsResponse {
+	result := *lyrics
+	result.Layers = make([]LyricsLayer, 0, len(lyrics.Layers)+1)
+	for _, existing := range lyrics.Layers {
+		if existing.Kind != layer.Kind {
+			result.Layers = append(result.Layers, existing)
+		}
+	}
+	result.Layers = append(result.Layers, layer)
+	return &result
+}
+
+func detectLyricsLanguage(lyrics *LyricsResponse) string {
+	var builder strings.Builder
+	for _, line := range lyrics.Lines {
+		builder.WriteString(lyricsLineText(line))
+		builder.WriteString("\n")
+	}
+	return detectRomanizationLanguage(builder.String())
+}
+
+// lineRomanizationLanguages detects the language of each line. Han-only
+// lines are ambiguous, and Japanese lyrics often have kanji-only lines, so
+// they are read as Japanese when a nearby line has kana.
+func lineRomanizationLanguages(texts []string) []string {
+	languages := make([]string, len(texts))
+	for i, text := range texts {
+		languages[i] = detectRomanizationLanguage(text)
+	}
+	for i := range languages {
+		if languages[i] != "zh" {
+			continue
+		}
+		for j := max(0, i-lyricsKanaNeighbourLines); j <= min(len(texts)-1, i+lyricsKanaNeighbourLines); j++ {
+			if containsKana(texts[j]) {
+				languages[i] = "ja"
+				break
+			}
+		}
+	}
+	return languages
+}
+
+// buildRomanizationLayer romanizes every main line; language "" detects it
+// per line from the script. It returns nil when the lyrics need no
+// romanization.
+func buildRomanizationLayer(lyrics *LyricsResponse, language string) *LyricsLayer {
+	language = strings.ToLower(strings.TrimSpace(language))
+	layerLanguage := language
+	if layerLanguage == "" {
+		layerLanguage = detectLyricsLanguage(lyrics)
+	}
+	if layerLanguage != "ja" && layerLanguage != "ko" && layerLanguage != "zh" {
+		return nil
+	}
+
+	var lines []LyricsLine
+	var texts []string
+	for _, line := range lyrics.Lines {
+		if text := lyricsLineText(line); text != "" {
+			lines = append(lines, line)
+			texts = append(texts, text)
+		}
+	}
+	languages := make([]string, len(texts))
+	if language == "" {
+		languages = lineRomanizationLanguages(texts)
+	} else {
+		for i := range languages {
+			languages[i] = language
+		}
+	}
+
+	layer := &LyricsLayer{Kind: lyricsLayerRomanization, Language: layerLanguage, Source: "Built-in"}
+	changed := false
+	for i, line := range lines {
+		romanized := romanizeText(texts[i], languages[i])
+		if romanized != texts[i] {
+			changed = true
+		}
+		if containsHan(romanized) || containsKana(romanized) || containsHangul(romanized) {
+			layer.Partial = true
+		}
+		layer.Lines = append(layer.Lines, LyricsLine{StartTimeMs: line.StartTimeMs, EndTimeMs: line.EndTimeMs, Words: romanized})
+	}
+	if !changed {
+		return nil
+	}
+	return layer
+}
+
+// alignLyricsLayer pins layer lines to the timestamps of the closest main
+// lines; unsynced lyrics are paired by position.
+func alignLyricsLayer(lyrics *LyricsResponse, layerLines []LyricsLine, unsynced bool) []LyricsLine {
+	var main []LyricsLine
+	for _, line := range lyrics.Lines {
+		if lyricsLineText(line) != "" {
+			main = append(main, line)
+		}
+	}
+
+	var aligned []LyricsLine
+	if unsynced || lyrics.SyncType == "UNSYNCED" {
+		index := 0
+		for _, line := range layerLines {
+			text := lyricsLineText(line)
+			if text == "" || index >= len(main) {
+				continue
+			}
+			aligned = append(aligned, LyricsLine{StartTimeMs: main[index].StartTimeMs, EndTimeMs: main[index].EndTimeMs, Words: text})
+			index++
+		}
+		return aligned
+	}
+
+	used := make(map[int]bool)
+	for _, line := range layerLines {
+		text := lyricsLineText(line)
+		if text == "" {
+			continue
+		}
+		best, bestDiff := -1, int64(lyricsLayerAlignToleranceMs+1)
+		for i, candidate := range main {
+			diff := candidate.StartTimeMs - line.StartTimeMs
+			if diff < 0 {
+				diff = -diff
+			}
+			if !used[i] && diff < bestDiff {
+				best, bestDiff = i, diff
+			}
+		}
+		if best < 0 {
+			continue
+		}
+		used[best] = true
+		aligned = append(aligned, LyricsLine{StartTimeMs: main[best].StartTimeMs, EndTimeMs: main[best].EndTimeMs, Words: text})
+	}
+	sort.SliceStable(aligned, func(i, j int) bool {
+		return aligned[i].StartTimeMs < aligned[j].StartTimeMs
+	})
+	return aligned
+}
+
+// lyricsLayerFromContent parses LRC/TTML layer text and aligns it to lyrics.
+func lyricsLayerFromContent(lyrics *LyricsResponse, kind, language, source, content string) (*LyricsLayer, error) {
+	parsed, _, _, err := parseLyricsContent(content)
+	if err != nil {
+		return nil, err
+	}
+	lines := alignLyricsLayer(lyrics, parsed.Lines, parsed.SyncType == "UNSYNCED")
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%s does not line up with the lyrics", kind)
+	}
+	return &LyricsLayer{Kind: kind, Language: language, Source: source, Lines: lines}, nil
+}
+
+// mergeLyricsLayersInline writes layers as secondary lines that repeat the
+// timestamp of their main line, the layout Netease uses for translations.
+func mergeLyricsLayersInline(lyrics *LyricsResponse) *LyricsResponse {
+	if len(lyrics.Layers) == 0 {
+		return lyrics
+	}
+	result := *lyrics
+	result.Lines = make([]LyricsLine, 0, len(lyrics.Lines)*(len(lyrics.Layers)+1))
+	result.Layers = nil
+	unsynced := lyrics.SyncType == "UNSYNCED"
+	next := make([]int, len(lyrics.Layers))
+	for _, line := range lyrics.Lines {
+		result.Lines = append(result.Lines, line)
+		if lyricsLineText(line) == "" {
+			continue
+		}
+		for i, layer := range lyrics.Layers {
+			for !unsynced && next[i] < len(layer.Lines) && layer.Lines[next[i]].StartTimeMs < line.StartTimeMs {
+				next[i]++
+			}
+			if next[i] >= len(layer.Lines) {
+				continue
+			}
+			layerLine := layer.Lines[next[i]]
+			if unsynced || layerLine.StartTimeMs == line.StartTimeMs {
+				result.Lines = append(result.Lines, LyricsLine{StartTimeMs: line.StartTimeMs, EndTimeMs: line.EndTimeMs, Words: layerLine.Words})
+				next[i]++
+			}
+		}
+	}
+	return &result
+}
+
+// formatLyricsLayer serializes one layer as standalone lyrics.
+func formatLyricsLayer(lyrics *LyricsResponse, kind, format, trackName, artistName string) (string, error) {
+	layer := lyrics.layer(kind)
+	if layer == nil {
+		return "", fmt.Errorf("lyrics have no %s layer", kind)
+	}
+	return formatLyrics(&LyricsResponse{Lines: layer.Lines, SyncType: lyrics.SyncType}, format, trackName, artistName)
+}
+
+// applyLyricsLayerOptions adds the layers requested in the fetch options.
+func applyLyricsLayerOptions(lyrics *LyricsResponse, opts LyricsFetchOptions) *LyricsResponse {
+	if lyrics == nil || lyrics.Instrumental {
+		return lyrics
+	}
+	if opts.Romanize && lyrics.layer(lyricsLayerRomanization) == nil {
+		if layer := buildRomanizationLayer(lyrics, opts.RomanizationLanguage); layer != nil {
+			lyrics = withLyricsLayer(lyrics, *layer)
+		}
+	}
+	if opts.InlineLayers {
+		lyrics = mergeLyricsLayersInline(lyrics)
+	}
+	return lyrics
+}
+
+// BuildLyricsLayers adds romanization and/or a translation to LRC or TTML
+// content and returns the main lyrics plus each layer as separate lyrics,
+// or everything in one file when Inline is set.
+func BuildLyricsLayers(requestJSON string) (string, error) {
+	var req LyricsLayerRequest
+	if err := json.Unmarshal([]byte(requestJSON), &req); err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+	lyrics, trackName, artistName, err := parseLyricsContent(req.Content)
+	if err != nil {
+		return "", err
+	}
+
+	result := LyricsLayerResult{Language: strings.ToLower(strings.TrimSpace(req.Language))}
+	if result.Language == "" {
+		result.Language = detectLyricsLanguage(lyrics)
+	}
+	if req.Romanize {
+		if layer := buildRomanizationLayer(lyrics, req.Language); layer != nil {
+			lyrics = withLyricsLayer(lyrics, *layer)
+			result.RomanizationPartial = layer.Partial
+		}
+	}
+	if strings.TrimSpace(req.TranslationContent) != "" {
+		layer, err := lyricsLayerFromContent(lyrics, lyricsLayerTranslation, req.TranslationLanguage, "User", req.TranslationContent)
+		if err != nil {
+			return "", err
+		}
+		lyrics = withLyricsLayer(lyrics, *layer)
+	}
+
+	if req.Inline {
+		lyrics = mergeLyricsLayersInline(lyrics)
+	} else {
+		for _, layer := range lyrics.Layers {
+			content, err := formatLyricsLayer(lyrics, layer.Kind, req.Format, trackName, artistName)
+			if err != nil {
+				return "", err
+			}
+			if result.Layers == nil {
+				result.Layers = make(map[string]string)
+			}
+			result.Layers[layer.Kind] = content
+		}
+	}
+	if result.Lyrics, err = formatLyrics(lyrics, req.Format, trackName, artistName); err != nil {
+		return "", err
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
@@ -1,6 +1,7 @@
 package gobackend
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -49,7 +50,7 @@ func NewMusixmatchClient() *MusixmatchClient {
 	}
 }
 
-func (c *MusixmatchClient) fetchLyricsPayload(trackName, artistName string, durationSec float64, lyricsType, language string) (string, error) {
+func (c *MusixmatchClient) fetchLyricsPayload(ctx context.Context, trackName, artistName string, durationSec float64, lyricsType, language string) (string, error) {
 	if strings.TrimSpace(trackName) == "" || strings.TrimSpace(artistName) == "" {
 		return "", fmt.Errorf("empty track or artist name")
 	}
@@ -67,7 +68,7 @@ func (c *MusixmatchClient) fetchLyricsPayload(trackName, artistName string, dura
 	}
 	fullURL := c.baseURL + "?" + params.Encode()
 
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -112,13 +113,13 @@ func (c *MusixmatchClient) fetchLyricsPayload(trackName, artistName string, dura
 	return "", fmt.Errorf("failed to decode musixmatch response")
 }
 
-func (c *MusixmatchClient) FetchLyricsInLanguage(trackName, artistName string, durationSec float64, language string) (*LyricsResponse, error) {
+func (c *MusixmatchClient) FetchLyricsInLanguage(ctx context.Context, trackName, artistName string, durationSec float64, language string) (*LyricsResponse, error) {
 	lang := strings.ToLower(strings.TrimSpace(language))
 	if lang == "" {
 		return nil, fmt.Errorf("invalid language")
 	}
 
-	lrcText, err := c.fetchLyricsPayload(trackName, artistName, durationSec, "translate", lang)
+	lrcText, err := c.fetchLyricsPayload(ctx, trackName, artistName, durationSec, "translate", lang)
 	if err != nil {
 		return nil, err
 	}
@@ -148,16 +149,16 @@ func (c *MusixmatchClient) FetchLyricsInLanguage(trackName, artistName string, d
 	return nil, fmt.Errorf("no lyrics found on musixmatch for language %s", lang)
 }
 
-func (c *MusixmatchClient) FetchLyrics(trackName, artistName string, durationSec float64, preferredLanguage string) (*LyricsResponse, error) {
+func (c *MusixmatchClient) FetchLyrics(ctx context.Context, trackName, artistName string, durationSec float64, preferredLanguage string) (*LyricsResponse, error) {
 	if preferred := strings.ToLower(strings.TrimSpace(preferredLanguage)); preferred != "" {
-		localized, localizedErr := c.FetchLyricsInLanguage(trackName, artistName, durationSec, preferred)
+		localized, localizedErr := c.FetchLyricsInLanguage(ctx, trackName, artistName, durationSec, preferred)
 		if localizedErr == nil {
 			return localized, nil
 		}
 		GoLog("[Musixmatch] Language override '%s' failed: %v\n", preferred, localizedErr)
 	}
 
-	lrcText, err := c.fetchLyricsPayload(trackName, artistName, durationSec, "word", "")
+	lrcText, err := c.fetchLyricsPayload(ctx, trackName, artistName, durationSec, "word", "")
 	if err != nil {
 		return nil, err
 	}
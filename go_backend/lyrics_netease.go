@@ -1,6 +1,7 @@
 package gobackend
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
@@ -50,7 +51,7 @@ func NewNeteaseClient() *NeteaseClient {
 	}
 }
 
-func (c *NeteaseClient) SearchSong(trackName, artistName string) (int64, error) {
+func (c *NeteaseClient) SearchSong(ctx context.Context, trackName, artistName string) (int64, error) {
 	query := trackName + " " + artistName
 	if strings.TrimSpace(query) == "" {
 		return 0, fmt.Errorf("empty search query")
@@ -62,7 +63,7 @@ func (c *NeteaseClient) SearchSong(trackName, artistName string) (int64, error)
 
 	fullURL := searchURL + "?" + params.Encode()
 
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
 		return 0, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -94,16 +95,24 @@ func (c *NeteaseClient) SearchSong(trackName, artistName string) (int64, error)
 	return searchResp.Result.Songs[0].ID, nil
 }
 
-func (c *NeteaseClient) FetchLyricsByID(songID int64, includeTranslation, includeRomanization bool) (string, error) {
+func (c *NeteaseClient) FetchLyricsByID(ctx context.Context, songID int64, includeTranslation, includeRomanization bool) (string, error) {
+	lyricsResp, err := c.fetchLyricsPayload(ctx, songID)
+	if err != nil {
+		return "", err
+	}
+	return mergeNeteaseLyrics(lyricsResp, includeTranslation, includeRomanization), nil
+}
+
+func (c *NeteaseClient) fetchLyricsPayload(ctx context.Context, songID int64) (*neteaseLyricsResponse, error) {
 	lyricsURL := "https://lyrics.paxsenix.org/netease/lyrics"
 	params := url.Values{}
 	params.Set("id", fmt.Sprintf("%d", songID))
 
 	fullURL := lyricsURL + "?" + params.Encode()
 
-	req, err := http.NewRequest("GET", fullURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", fullURL, nil)
 	if err != nil {
-		return "", fmt.Errorf("failed to create request: %w", err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	for k, v := range neteaseHeaders {
@@ -113,23 +122,26 @@ func (c *NeteaseClient) FetchLyricsByID(songID int64, includeTranslation, includ
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return "", fmt.Errorf("netease lyrics fetch failed: %w", err)
+		return nil, fmt.Errorf("netease lyrics fetch failed: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("netease lyrics returned HTTP %d", resp.StatusCode)
+		return nil, fmt.Errorf("netease lyrics returned HTTP %d", resp.StatusCode)
 	}
 
 	var lyricsResp neteaseLyricsResponse
 	if err := json.NewDecoder(resp.Body).Decode(&lyricsResp); err != nil {
-		return "", fmt.Errorf("failed to decode netease lyrics: %w", err)
+		return nil, fmt.Errorf("failed to decode netease lyrics: %w", err)
 	}
 
 	if lyricsResp.LRC == nil || strings.TrimSpace(lyricsResp.LRC.Lyric) == "" {
-		return "", fmt.Errorf("no lyrics available on netease")
+		return nil, fmt.Errorf("no lyrics available on netease")
 	}
+	return &lyricsResp, nil
+}
 
+func mergeNeteaseLyrics(lyricsResp *neteaseLyricsResponse, includeTranslation, includeRomanization bool) string {
 	lyric := lyricsResp.LRC.Lyric
 
 	if includeTranslation && lyricsResp.TLyric != nil && strings.TrimSpace(lyricsResp.TLyric.Lyric) != "" {
@@ -140,25 +152,27 @@ func (c *NeteaseClient) FetchLyricsByID(songID int64, includeTranslation, includ
 		lyric += "\n\n" + lyricsResp.RomaLRC.Lyric
 	}
 
-	return lyric, nil
+	return lyric
 }
 
 func (c *NeteaseClient) FetchLyrics(
+	ctx context.Context,
 	trackName,
 	artistName string,
 	durationSec float64,
 	includeTranslation,
 	includeRomanization bool,
 ) (*LyricsResponse, error) {
-	songID, err := c.SearchSong(trackName, artistName)
+	songID, err := c.SearchSong(ctx, trackName, artistName)
 	if err != nil {
 		return nil, err
 	}
 
-	lrcText, err := c.FetchLyricsByID(songID, includeTranslation, includeRomanization)
+	lyricsResp, err := c.fetchLyricsPayload(ctx, songID)
 	if err != nil {
 		return nil, err
 	}
+	lrcText := mergeNeteaseLyrics(lyricsResp, includeTranslation, includeRomanization)
 
 	lines := parseSyncedLyrics(lrcText)
 	if len(lines) == 0 {
@@ -186,10 +200,30 @@ func (c *NeteaseClient) FetchLyrics(
 		}, nil
 	}
 
-	return &LyricsResponse{
+	lyrics := &LyricsResponse{
 		Lines:    lines,
 		SyncType: "LINE_SYNCED",
 		Provider: "Netease",
 		Source:   "Netease",
-	}, nil
+	}
+	// Translation and romanization that are not merged into the text are
+	// kept as layers.
+	if !includeTranslation {
+		lyrics = attachNeteaseLayer(lyrics, lyricsLayerTranslation, "zh", lyricsResp.TLyric)
+	}
+	if !includeRomanization {
+		lyrics = attachNeteaseLayer(lyrics, lyricsLayerRomanization, "", lyricsResp.RomaLRC)
+	}
+	return lyrics, nil
+}
+
+func attachNeteaseLayer(lyrics *LyricsResponse, kind, language string, field *neteaseLyricField) *LyricsResponse {
+	if field == nil || strings.TrimSpace(field.Lyric) == "" {
+		return lyrics
+	}
+	layer, err := lyricsLayerFromContent(lyrics, kind, language, "Netease", field.Lyric)
+	if err != nil {
+		return lyrics
+	}
+	return withLyricsLayer(lyrics, *layer)
 }
@@ -1,6 +1,7 @@
 package gobackend
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -32,7 +33,7 @@ func NewQQMusicClient() *QQMusicClient {
 	}
 }
 
-func (c *QQMusicClient) fetchLyricsByMetadata(trackName, artistName string, durationSec float64) (string, error) {
+func (c *QQMusicClient) fetchLyricsByMetadata(ctx context.Context, trackName, artistName string, durationSec float64) (string, error) {
 	payload := qqLyricsMetadataRequest{
 		Artist: []string{artistName},
 		Title:  trackName,
@@ -48,7 +49,7 @@ func (c *QQMusicClient) fetchLyricsByMetadata(trackName, artistName string, dura
 		return "", fmt.Errorf("failed to marshal payload: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", lyricsURL, strings.NewReader(string(payloadBytes)))
+	req, err := http.NewRequestWithContext(ctx, "POST", lyricsURL, strings.NewReader(string(payloadBytes)))
 	if err != nil {
 		return "", fmt.Errorf("failed to create request: %w", err)
 	}
@@ -91,12 +92,13 @@ func formatQQLyricsMetadataToLRC(rawJSON string, multiPersonWordByWord bool) (st
 }
 
 func (c *QQMusicClient) FetchLyrics(
+	ctx context.Context,
 	trackName,
 	artistName string,
 	durationSec float64,
 	multiPersonWordByWord bool,
 ) (*LyricsResponse, error) {
-	rawLyrics, err := c.fetchLyricsByMetadata(trackName, artistName, durationSec)
+	rawLyrics, err := c.fetchLyricsByMetadata(ctx, trackName, artistName, durationSec)
 	if err != nil {
 		return nil, err
 	}
@@ -114,6 +116,17 @@ func (c *QQMusicClient) FetchLyrics(
 	}
 
 	lines := parseSyncedLyrics(lrcText)
+	if len(lines) > 0 && !multiPersonWordByWord {
+		// Agents and background vocals are only written to the LRC text in
+		// multi-person mode; keep them in the model either way.
+		detailed, detailErr := formatQQLyricsMetadataToLRC(rawLyrics, true)
+		if detailErr != nil {
+			detailed, detailErr = formatPaxLyricsToLRC(rawLyrics, true)
+		}
+		if detailErr == nil {
+			attachLyricsLineDetail(lines, parseSyncedLyrics(detailed))
+		}
+	}
 	if len(lines) > 0 {
 		return &LyricsResponse{
 			Lines:    lines,
@@ -0,0 +1,487 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+var japaneseExtraRomaji = map[rune]string{
+	'ゐ': "i", 'ゑ': "e", 'ゔ': "vu", 'ゎ': "wa", 'ゕ': "ka", 'ゖ': "ke",
+	'を': "o",
+}
+
+// japaneseComboRomaji covers yōon and the extended katakana combinations,
+// keyed by hiragana (katakana is folded to hiragana first).
+var japaneseComboRomaji = map[string]string{
+	"ぢゃ": "ja", "ぢゅ": "ju", "ぢょ": "jo",
+	"てぃ": "ti", "でぃ": "di", "とぅ": "tu", "どぅ": "du", "でゅ": "dyu",
+	"ふぁ": "fa", "ふぃ": "fi", "ふぇ": "fe", "ふぉ": "fo", "ふゅ": "fyu",
+	"うぃ": "wi", "うぇ": "we", "うぉ": "wo", "いぇ": "ye",
+	"しぇ": "she", "ちぇ": "che", "じぇ": "je",
+	"つぁ": "tsa", "つぃ": "tsi", "つぇ": "tse", "つぉ": "tso",
+	"ゔぁ": "va", "ゔぃ": "vi", "ゔぇ": "ve", "ゔぉ": "vo",
+	"くぁ": "kwa", "ぐぁ": "gwa",
+}
+
+var cjkPunctuation = map[rune]string{
+	'、': ", ", '。': ". ", '，': ", ", '．': ". ", '！': "! ", '？': "? ",
+	'「': "\"", '」': "\"", '『': "\"", '』': "\"", '（': " (", '）': ") ",
+	'・': " ", '　': " ", '〜': "~", '～': "~", '…': "...", '：': ": ", '；': "; ",
+}
+
+var (
+	lyricsReadingsMu     sync.RWMutex
+	lyricsReadings       = map[string]map[string]string{}
+	lyricsReadingsMaxLen = map[string]int{}
+)
+
+// LoadLyricsReadingDictionary merges a word -> reading dictionary used for
+// kanji ("ja", readings in kana) or hanzi ("zh", readings in pinyin). It is
+// the plug-in point for readings the built-in tables cannot provide.
+func LoadLyricsReadingDictionary(language, dictJSON string) (int, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if language != "ja" && language != "zh" {
+		return 0, fmt.Errorf("unsupported reading dictionary language: %s", language)
+	}
+	var entries map[string]string
+	if err := json.Unmarshal([]byte(dictJSON), &entries); err != nil {
+		return 0, fmt.Errorf("invalid reading dictionary: %w", err)
+	}
+
+	lyricsReadingsMu.Lock()
+	defer lyricsReadingsMu.Unlock()
+	if lyricsReadings[language] == nil {
+		lyricsReadings[language] = make(map[string]string)
+	}
+	count := 0
+	for word, reading := range entries {
+		word, reading = strings.TrimSpace(word), strings.TrimSpace(reading)
+		if word == "" || reading == "" {
+			continue
+		}
+		lyricsReadings[language][word] = reading
+		lyricsReadingsMaxLen[language] = max(lyricsReadingsMaxLen[language], utf8.RuneCountInString(word))
+		count++
+	}
+	GoLog("[Lyrics] Loaded %d %s readings\n", count, language)
+	return count, nil
+}
+
+// lookupLyricsReading finds the longest dictionary word starting at runes[i].
+func lookupLyricsReading(language string, runes []rune, i int) (string, int) {
+	lyricsReadingsMu.RLock()
+	defer lyricsReadingsMu.RUnlock()
+	dict := lyricsReadings[language]
+	if len(dict) == 0 {
+		return "", 0
+	}
+	for n := min(lyricsReadingsMaxLen[language], len(runes)-i); n > 0; n-- {
+		if reading, ok := dict[string(runes[i:i+n])]; ok {
+			return reading, n
+		}
+	}
+	return "", 0
+}
+
+func foldKatakana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - 0x60
+	}
+	return r
+}
+
+func japaneseKanaRomaji(r rune) (string, bool) {
+	if romaji, ok := japaneseExtraRomaji[r]; ok {
+		return romaji, true
+	}
+	romaji, ok := hiraganaToRomaji[r]
+	return romaji, ok
+}
+
+func writeCJKPunctuation(builder *strings.Builder, r rune) bool {
+	if text, ok := cjkPunctuation[r]; ok {
+		builder.WriteString(text)
+		return true
+	}
+	if r >= 0xFF01 && r <= 0xFF5E {
+		builder.WriteRune(r - 0xFEE0)
+		return true
+	}
+	return false
+}
+
+func collapseRomanizedSpaces(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	for _, p := range []string{",", ".", "!", "?", ")", ":", ";"} {
+		text = strings.ReplaceAll(text, " "+p, p)
+	}
+	return strings.ReplaceAll(text, "( ", "(")
+}
+
+// romanizeJapanese writes modified Hepburn in plain ASCII: sokuon doubles
+// the next consonant (tch before ch), ー repeats the previous vowel, ん
+// before a vowel or y becomes n', and kanji use the reading dictionary.
+func romanizeJapanese(text string) string {
+	runes := []rune(text)
+	folded := make([]rune, len(runes))
+	for i, r := range runes {
+		folded[i] = foldKatakana(r)
+	}
+
+	var syllables []string
+	sokuon := false
+	emit := func(romaji string) {
+		if sokuon && romaji != "" && !strings.ContainsRune("aiueo", rune(romaji[0])) {
+			if strings.HasPrefix(romaji, "ch") {
+				romaji = "t" + romaji
+			} else {
+				romaji = romaji[:1] + romaji
+			}
+		}
+		sokuon = false
+		syllables = append(syllables, romaji)
+	}
+
+	for i := 0; i < len(folded); {
+		r := folded[i]
+		switch {
+		case r == 'っ':
+			sokuon = true
+			i++
+			continue
+		case r == 'ー':
+			for j := len(syllables) - 1; j >= 0; j-- {
+				if last := syllables[j]; last != "" {
+					if vowel := last[len(last)-1]; strings.IndexByte("aiueo", vowel) >= 0 {
+						syllables = append(syllables, string(vowel))
+					}
+					break
+				}
+			}
+			i++
+			continue
+		case isKanji(r):
+			if reading, n := lookupLyricsReading("ja", runes, i); n > 0 {
+				emit(romanizeJapanese(reading))
+				i += n
+				continue
+			}
+			sokuon = false
+			syllables = append(syllables, string(r))
+			i++
+			continue
+		}
+
+		if i+1 < len(folded) {
+			combo := string(folded[i : i+2])
+			if romaji, ok := japaneseComboRomaji[combo]; ok {
+				emit(romaji)
+				i += 2
+				continue
+			}
+			if romaji, ok := combinationHiragana[combo]; ok {
+				emit(romaji)
+				i += 2
+				continue
+			}
+		}
+		if romaji, ok := japaneseKanaRomaji(r); ok {
+			emit(romaji)
+			i++
+			continue
+		}
+
+		var builder strings.Builder
+		if !writeCJKPunctuation(&builder, r) {
+			builder.WriteRune(r)
+		}
+		sokuon = false
+		syllables = append(syllables, builder.String())
+		i++
+	}
+
+	var builder strings.Builder
+	for i, syllable := range syllables {
+		builder.WriteString(syllable)
+		if syllable == "n" && i+1 < len(syllables) {
+			if next := syllables[i+1]; next != "" && strings.IndexByte("aiueoy", next[0]) >= 0 {
+				builder.WriteString("'")
+			}
+		}
+	}
+	return collapseRomanizedSpaces(builder.String())
+}
+
+var (
+	hangulInitials = []string{"g", "kk", "n", "d", "tt", "r", "m", "b", "pp", "s", "ss", "", "j", "jj", "ch", "k", "t", "p", "h"}
+	hangulMedials  = []string{"a", "ae", "ya", "yae", "eo", "e", "yeo", "ye", "o", "wa", "wae", "oe", "yo", "u", "wo", "we", "wi", "yu", "eu", "ui", "i"}
+	// hangulCodas is the final consonant as pronounced before a consonant or
+	// at the end of a word.
+	hangulCodas = []string{"", "k", "k", "k", "n", "n", "n", "t", "l", "k", "m", "l", "l", "l", "p", "l", "m", "p", "p", "t", "t", "ng", "t", "t", "k", "t", "p", "t"}
+	// hangulLiaison splits a final consonant across a following silent ㅇ.
+	hangulLiaison = [][2]string{
+		{"", ""}, {"", "g"}, {"", "kk"}, {"k", "s"}, {"", "n"}, {"n", "j"}, {"", "n"}, {"", "d"},
+		{"", "r"}, {"l", "g"}, {"l", "m"}, {"l", "b"}, {"l", "s"}, {"l", "t"}, {"l", "p"}, {"", "r"},
+		{"", "m"}, {"", "b"}, {"b", "s"}, {"", "s"}, {"", "ss"}, {"ng", ""}, {"", "j"}, {"", "ch"},
+		{"", "k"}, {"", "t"}, {"", "p"}, {"", ""},
+	}
+)
+
+const (
+	hangulInitialG  = 0
+	hangulInitialN  = 2
+	hangulInitialD  = 3
+	hangulInitialR  = 5
+	hangulInitialM  = 6
+	hangulInitialNG = 11
+	hangulInitialJ  = 12
+	hangulInitialH  = 18
+	hangulMedialI   = 20
+	hangulFinalNH   = 6
+	hangulFinalD    = 7
+	hangulFinalLT   = 13
+	hangulFinalLH   = 15
+	hangulFinalT    = 25
+	hangulFinalH    = 27
+)
+
+// hangulBoundary romanizes the coda of one syllable and the onset of the
+// next, applying liaison, palatalization, aspiration and the nasal/liquid
+// assimilations of the Revised Romanization. medial is the vowel of the next
+// syllable.
+func hangulBoundary(final, initial, medial int) (string, string) {
+	if final == 0 {
+		return "", hangulInitials[initial]
+	}
+	// ㄷ and ㅌ before 이 are pronounced ㅈ and ㅊ (같이 gachi, 굳이 guji),
+	// as is ㄷ before 히 (굳히다 guchida).
+	if medial == hangulMedialI {
+		switch {
+		case initial == hangulInitialNG && final == hangulFinalD:
+			return "", "j"
+		case initial == hangulInitialNG && final == hangulFinalT:
+			return "", "ch"
+		case initial == hangulInitialNG && final == hangulFinalLT:
+			return "l", "ch"
+		case initial == hangulInitialH && final == hangulFinalD:
+			return "", "ch"
+		}
+	}
+	if initial == hangulInitialNG {
+		return hangulLiaison[final][0], hangulLiaison[final][1]
+	}
+	if final == hangulFinalH || final == hangulFinalNH || final == hangulFinalLH {
+		aspirated := map[int]string{hangulInitialG: "k", hangulInitialD: "t", hangulInitialJ: "ch"}
+		if onset, ok := aspirated[initial]; ok {
+			return map[int]string{hangulFinalH: "", hangulFinalNH: "n", hangulFinalLH: "l"}[final], onset
+		}
+	}
+
+	coda := hangulCodas[final]
+	nasal := map[string]string{"k": "ng", "t": "n", "p": "m"}
+	switch initial {
+	case hangulInitialN, hangulInitialM:
+		if coda == "l" && initial == hangulInitialN {
+			return "l", "l"
+		}
+		if n, ok := nasal[coda]; ok {
+			coda = n
+		}
+	case hangulInitialR:
+		switch coda {
+		case "l", "n":
+			return "l", "l"
+		case "ng", "m":
+			return coda, "n"
+		default:
+			if n, ok := nasal[coda]; ok {
+				return n, "n"
+			}
+		}
+	}
+	return coda, hangulInitials[initial]
+}
+
+// romanizeKorean applies the Revised Romanization of Korean; sound changes
+// are applied within words, not across spaces.
+func romanizeKorean(text string) string {
+	var builder strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		if runes[i] < 0xAC00 || runes[i] > 0xD7A3 {
+			if !writeCJKPunctuation(&builder, runes[i]) {
+				builder.WriteRune(runes[i])
+			}
+			i++
+			continue
+		}
+
+		start := i
+		for i < len(runes) && runes[i] >= 0xAC00 && runes[i] <= 0xD7A3 {
+			i++
+		}
+		word := runes[start:i]
+		onset := hangulInitials[int(word[0]-0xAC00)/588]
+		for j, syllable := range word {
+			index := int(syllable - 0xAC00)
+			medial, final := index%588/28, index%28
+			builder.WriteString(onset + hangulMedials[medial])
+			if j+1 < len(word) {
+				nextIndex := int(word[j+1] - 0xAC00)
+				coda, next := hangulBoundary(final, nextIndex/588, nextIndex%588/28)
+				builder.WriteString(coda)
+				onset = next
+			} else {
+				builder.WriteString(hangulCodas[final])
+			}
+		}
+	}
+	return collapseRomanizedSpaces(builder.String())
+}
+
+// romanizeChinese writes toneless pinyin, one syllable per character.
+// Dictionary words win over the built-in per-character table; characters
+// found in neither are kept.
+func romanizeChinese(text string) string {
+	var builder strings.Builder
+	runes := []rune(text)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		if !isKanji(r) {
+			if !writeCJKPunctuation(&builder, r) {
+				builder.WriteRune(r)
+			}
+			i++
+			continue
+		}
+		if reading, n := lookupLyricsReading("zh", runes, i); n > 0 {
+			builder.WriteString(" " + reading + " ")
+			i += n
+			continue
+		}
+		if syllable, ok := pinyinTable()[r]; ok {
+			builder.WriteString(" " + syllable + " ")
+		} else {
+			builder.WriteRune(r)
+		}
+		i++
+	}
+	return collapseRomanizedSpaces(builder.String())
+}
+
+func containsHangul(text string) bool {
+	for _, r := range text {
+		if r >= 0xAC00 && r <= 0xD7A3 || r >= 0x1100 && r <= 0x11FF || r >= 0x3130 && r <= 0x318F {
+			return true
+		}
+	}
+	return false
+}
+
+func containsKana(text string) bool {
+	for _, r := range text {
+		if isHiragana(r) || isKatakana(r) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsHan(text string) bool {
+	for _, r := range text {
+		if isKanji(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// detectRomanizationLanguage guesses ja, ko or zh from the script in use;
+// Han without any kana is treated as Chinese.
+func detectRomanizationLanguage(text string) string {
+	switch {
+	case containsKana(text):
+		return "ja"
+	case containsHangul(text):
+		return "ko"
+	case containsHan(text):
+		return "zh"
+	default:
+		return ""
+	}
+}
+
+func romanizeText(text, language string) string {
+	switch language {
+	case "ja":
+		return romanizeJapanese(text)
+	case "ko":
+		return romanizeKorean(text)
+	case "zh":
+		return romanizeChinese(text)
+	default:
+		return text
+	}
+}
+
+var (
+	pinyinTableOnce sync.Once
+	pinyinTableMap  map[rune]string
+)
+
+func pinyinTable() map[rune]string {
+	pinyinTableOnce.Do(func() {
+		pinyinTableMap = make(map[rune]string)
+		for _, entry := range strings.Fields(pinyinTableSource) {
+			r, size := utf8.DecodeRuneInString(entry)
+			if size < len(entry) && unicode.Is(unicode.Han, r) {
+				pinyinTableMap[r] = entry[size:]
+			}
+		}
+	})
+	return pinyinTableMap
+}
+
+// pinyinTableSource lists about 500 common characters (simplified and
+// traditional) with their most frequent reading in lyrics. It is far from
+// complete: other characters are kept as written and the layer is marked
+// partial, and LoadLyricsReadingDictionary fills the gaps.
+const pinyinTableSource = `
+的de 一yi 是shi 不bu 了le 我wo 你ni 他ta 她ta 它ta 们men 們men 在zai 有you 这zhe 這zhe 那na 个ge 個ge
+人ren 来lai 來lai 到dao 说shuo 說shuo 要yao 就jiu 去qu 会hui 會hui 着zhe 著zhe 没mei 沒mei 看kan 好hao 也ye 还hai 還hai
+想xiang 爱ai 愛ai 心xin 天tian 时shi 時shi 里li 裡li 裏li 和he 与yu 與yu 对dui 對dui 过guo 過guo 后hou 後hou 让rang 讓rang
+见jian 見jian 听ting 聽ting 梦meng 夢meng 泪lei 淚lei 恋lian 戀lian 情qing 温wen 溫wen 远yuan 遠yuan 风feng 風feng
+云yun 雲yun 雨yu 雪xue 花hua 月yue 星xing 夜ye 光guang 日ri 年nian 生sheng 死si 活huo 命ming 世shi 界jie
+开kai 開kai 门men 門men 问wen 問wen 间jian 間jian 为wei 為wei 无wu 無wu 么me 麼me 什shen 边bian 邊bian
+记ji 記ji 忆yi 憶yi 离li 離li 别bie 別bie 欢huan 歡huan 乐le 樂le 难nan 難nan 经jing 經jing 变bian 變bian
+阳yang 陽yang 灯deng 燈deng 够gou 夠gou 吗ma 嗎ma 声sheng 聲sheng 话hua 話hua 请qing 請qing 谁shui 誰shui
+从cong 從cong 写xie 寫xie 轻qing 輕qing 岁sui 歲sui 歌ge 唱chang 曲qu 音yin 美mei 丽li 麗li 笑xiao 哭ku
+等deng 走zou 跑pao 飞fei 飛fei 回hui 家jia 路lu 街jie 城cheng 海hai 山shan 水shui 河he 江jiang 湖hu
+手shou 眼yan 脸lian 臉lian 头tou 頭tou 身shen 口kou 嘴zui 唇chun 发fa 髮fa 肩jian 背bei 怀huai 懷huai 抱bao
+吻wen 牵qian 牽qian 拥yong 擁yong 给gei 給gei 能neng 可ke 以yi 都dou 很hen 太tai 最zui 更geng 再zai
+又you 才cai 只zhi 自zi 己ji 已yi 如ru 果guo 因yin 所suo 但dan 却que 卻que 而er 或huo 虽sui 雖sui 然ran
+知zhi 道dao 觉jue 覺jue 得de 忘wang 怕pa 懂dong 相xiang 信xin 望wang 期qi 待dai 找zhao 寻xun 尋xun
+留liu 停ting 放fang 拿na 带dai 帶dai 陪pei 跟gen 同tong 起qi 二er 三san 四si 五wu 六liu 七qi
+八ba 九jiu 十shi 百bai 千qian 万wan 萬wan 多duo 少shao 大da 小xiao 长chang 長chang 短duan 高gao 低di 深shen
+新xin 旧jiu 舊jiu 老lao 青qing 春chun 夏xia 秋qiu 冬dong 今jin 明ming 昨zuo 早zao 晚wan 前qian 上shang
+下xia 左zuo 右you 中zhong 外wai 内nei 內nei 东dong 東dong 西xi 南nan 北bei 地di 方fang 处chu 處chu
+哪na 怎zen 样yang 樣yang 此ci 每mei 些xie 全quan 真zhen 假jia 错cuo 錯cuo
+快kuai 慢man 冷leng 热re 熱re 暖nuan 痛tong 伤shang 傷shang 苦ku 甜tian 寂ji 寞mo 孤gu 单dan 單dan 独du 獨du
+永yong 恒heng 恆heng 久jiu 直zhi 曾ceng 刚gang 剛gang 正zheng 将jiang 將jiang 未wei
+朋peng 友you 宝bao 寶bao 贝bei 貝bei 亲qin 親qin 妈ma 媽ma 爸ba 孩hai 子zi 女nü 男nan 儿er 兒er
+王wang 李li 张zhang 張zhang 刘liu 劉liu 陈chen 陳chen 杨yang 楊yang 周zhou 吴wu 吳wu 林lin 黄huang 黃huang
+红hong 紅hong 白bai 黑hei 蓝lan 藍lan 绿lü 綠lü 色se 彩cai 纯chun 純chun 静jing 靜jing 安an 平ping
+火huo 烟yan 煙yan 雾wu 霧wu 空kong 宇yu 宙zhou 魂hun 灵ling 靈ling 神shen 仙xian
+历li 歷li 故gu 事shi 书shu 書shu 字zi 封feng 纸zhi 紙zhi 画hua 畫hua 图tu 圖tu
+醒xing 睡shui 醉zui 酒jiu 茶cha 杯bei 饭fan 飯fan 吃chi 喝he 玩wan 做zuo 作zuo 用yong 学xue 學xue
+思si 念nian 愿yuan 願yuan 意yi 感gan 动dong 動dong 受shou 恨hen 怨yuan 悲bei 喜xi 怒nu 哀ai
+名ming 影ying 像xiang 形xing 模mo 候hou 分fen 秒miao 钟zhong 鐘zhong 点dian 點dian
+场chang 場chang 次ci 遍bian 片pian 条tiao 條tiao 双shuang 雙shuang 颗ke 顆ke 朵duo 首shou 句ju 段duan
+始shi 结jie 結jie 束shu 完wan 成cheng 功gong 失shi 败bai 敗bai 胜sheng 勝sheng 输shu 輸shu 赢ying 贏ying
+谢xie 謝xie
+转zhuan 轉zhuan 窗chuang 墙qiang 牆qiang 桥qiao 橋qiao 船chuan 车che 車che
+`
@@ -0,0 +1,130 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestRomanizeJapanese(t *testing.T) {
+	cases := map[string]string{
+		"きって":      "kitte",
+		"まっちゃ":     "matcha",
+		"ラーメン":     "raamen",
+		"きんえん":     "kin'en",
+		"しんよう":     "shin'you",
+		"とうきょう":    "toukyou",
+		"ティーを、のむ。": "tiio, nomu.",
+		"ヴァイオリン":   "vaiorin",
+	}
+	for input, want := range cases {
+		if got := romanizeJapanese(input); got != want {
+			t.Errorf("romanizeJapanese(%q) = %q, want %q", input, got, want)
+		}
+	}
+
+	if _, err := LoadLyricsReadingDictionary("ja", `{"東京":"とうきょう"}`); err != nil {
+		t.Fatalf("LoadLyricsReadingDictionary failed: %v", err)
+	}
+	if got := romanizeJapanese("東京へ"); got != "toukyouhe" {
+		t.Errorf("kanji reading = %q, want toukyouhe", got)
+	}
+}
+
+func TestRomanizeKorean(t *testing.T) {
+	cases := map[string]string{
+		"한국어":    "hangugeo",
+		"안녕하세요":  "annyeonghaseyo",
+		"사랑해":    "saranghae",
+		"종로":     "jongno",
+		"신라":     "silla",
+		"백마":     "baengma",
+		"좋다":     "jota",
+		"너를 사랑해": "neoreul saranghae",
+		"같이":     "gachi",
+		"굳이":     "guji",
+		"해돋이":    "haedoji",
+		"굳히다":    "guchida",
+		"마디":     "madi",
+	}
+	for input, want := range cases {
+		if got := romanizeKorean(input); got != want {
+			t.Errorf("romanizeKorean(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+func TestRomanizeChinese(t *testing.T) {
+	if got := romanizeChinese("我爱你，永远"); got != "wo ai ni, yong yuan" {
+		t.Errorf("romanizeChinese = %q", got)
+	}
+}
+
+func TestRomanizationLayerDetectsLanguagePerLine(t *testing.T) {
+	lyrics := &LyricsResponse{Lines: []LyricsLine{
+		{StartTimeMs: 1000, Words: "永远"},
+		{StartTimeMs: 2000, Words: "きって"},
+		{StartTimeMs: 3000, Words: "사랑해"},
+		{StartTimeMs: 4000, Words: "我爱你"},
+		{StartTimeMs: 5000, Words: "hello"},
+		{StartTimeMs: 6000, Words: "我爱你"},
+		{StartTimeMs: 7000, Words: "永远"},
+	}}
+	layer := buildRomanizationLayer(lyrics, "")
+	if layer == nil || layer.Language != "ja" {
+		t.Fatalf("expected a ja layer, got %+v", layer)
+	}
+	// The kanji-only first line sits next to kana, so it is not given pinyin
+	// and, with no reading dictionary, stays as written.
+	want := []string{"永远", "kitte", "saranghae", "我爱你", "hello", "wo ai ni", "yong yuan"}
+	for i, line := range layer.Lines {
+		if line.Words != want[i] {
+			t.Errorf("line %d = %q, want %q", i, line.Words, want[i])
+		}
+	}
+	if !layer.Partial {
+		t.Fatal("expected layer with unread kanji to be marked partial")
+	}
+
+	chinese := &LyricsResponse{Lines: []LyricsLine{{StartTimeMs: 1000, Words: "我爱你"}}}
+	if layer := buildRomanizationLayer(chinese, ""); layer == nil || layer.Partial {
+		t.Fatalf("expected a complete zh layer, got %+v", layer)
+	}
+}
+
+func TestBuildLyricsLayers(t *testing.T) {
+	reqJSON, _ := json.Marshal(LyricsLayerRequest{
+		Content:            "[00:01.00]사랑해\n[00:05.00]안녕\n",
+		Romanize:           true,
+		TranslationContent: "[00:01.20]I love you\n[00:05.10]Hello\n",
+		Format:             lyricsFormatLRC,
+	})
+	resultJSON, err := BuildLyricsLayers(string(reqJSON))
+	if err != nil {
+		t.Fatalf("BuildLyricsLayers failed: %v", err)
+	}
+	var result LyricsLayerResult
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if result.Language != "ko" {
+		t.Fatalf("language = %q, want ko", result.Language)
+	}
+	if !strings.Contains(result.Layers[lyricsLayerRomanization], "[00:01.00]saranghae") {
+		t.Fatalf("romanization layer = %q", result.Layers[lyricsLayerRomanization])
+	}
+	if !strings.Contains(result.Layers[lyricsLayerTranslation], "[00:05.00]Hello") {
+		t.Fatalf("translation layer should be aligned to main timestamps: %q", result.Layers[lyricsLayerTranslation])
+	}
+
+	var inline LyricsLayerResult
+	reqJSON, _ = json.Marshal(LyricsLayerRequest{Content: "[00:01.00]사랑해\n", Romanize: true, Inline: true, Format: lyricsFormatLRC})
+	resultJSON, err = BuildLyricsLayers(string(reqJSON))
+	if err != nil {
+		t.Fatalf("BuildLyricsLayers inline failed: %v", err)
+	}
+	_ = json.Unmarshal([]byte(resultJSON), &inline)
+	if !strings.Contains(inline.Lyrics, "[00:01.00]사랑해\n[00:01.00]saranghae\n") || len(inline.Layers) != 0 {
+		t.Fatalf("unexpected inline output: %+v", inline)
+	}
+}
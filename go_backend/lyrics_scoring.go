@@ -0,0 +1,194 @@
+package gobackend
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	defaultLyricsTimeBudgetMs = 12000
+	minLyricsTimeBudgetMs     = 2000
+
+	// A line starting this far past the end of the track means the lyrics
+	// belong to a different (usually longer) version.
+	lyricsOverrunToleranceSec = 5.0
+)
+
+var lyricsProviderTrust = map[string]float64{
+	LyricsProviderAppleMusic: 1.0,
+	LyricsProviderMusixmatch: 0.9,
+	LyricsProviderQQMusic:    0.85,
+	LyricsProviderNetease:    0.8,
+	LyricsProviderLRCLIB:     0.75,
+}
+
+const extensionLyricsTrust = 0.8
+
+type lyricsQuery struct {
+	trackName       string
+	artistName      string
+	primaryArtist   string
+	simplifiedTrack string
+	durationSec     float64
+	options         LyricsFetchOptions
+}
+
+type lyricsCandidate struct {
+	lyrics *LyricsResponse
+	source string
+	trust  float64
+	rank   int
+}
+
+// fetchBuiltInLyrics is a variable so tests can stub provider calls.
+var fetchBuiltInLyrics = func(ctx context.Context, c *LyricsClient, provider string, q lyricsQuery) (*LyricsResponse, error) {
+	return c.fetchFromBuiltInProvider(ctx, provider, q)
+}
+
+// fetchScoredLyrics queries every provider concurrently within the configured
+// time budget and returns the best scoring result, with the remaining usable
+// results attached as alternates. Providers still running when it returns are
+// cancelled.
+func (c *LyricsClient) fetchScoredLyrics(ctx context.Context, q lyricsQuery, providerOrder []string, extensionProviders []*extensionProviderWrapper) *LyricsResponse {
+	total := len(extensionProviders) + len(providerOrder)
+	if total == 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan lyricsCandidate, total)
+	rank := 0
+	for _, provider := range extensionProviders {
+		go func(provider *extensionProviderWrapper, rank int) {
+			lyrics, err := provider.FetchLyrics(q.trackName, q.artistName, "", q.durationSec)
+			if err != nil {
+				GoLog("[Lyrics] Extension %s failed: %v\n", provider.extension.ID, err)
+				lyrics = nil
+			}
+			results <- lyricsCandidate{lyrics: lyrics, source: provider.extension.ID, trust: extensionLyricsTrust, rank: rank}
+		}(provider, rank)
+		rank++
+	}
+	for _, providerName := range providerOrder {
+		go func(providerName string, rank int) {
+			lyrics, err := fetchBuiltInLyrics(ctx, c, providerName, q)
+			if err != nil {
+				GoLog("[Lyrics] Provider %s failed: %v\n", providerName, err)
+				lyrics = nil
+			}
+			results <- lyricsCandidate{lyrics: lyrics, source: providerName, trust: lyricsProviderTrust[providerName], rank: rank}
+		}(providerName, rank)
+		rank++
+	}
+
+	budget := time.Duration(q.options.TimeBudgetMs) * time.Millisecond
+	if budget <= 0 {
+		budget = defaultLyricsTimeBudgetMs * time.Millisecond
+	}
+	timer := time.NewTimer(budget)
+	defer timer.Stop()
+
+	var candidates []*LyricsResponse
+collect:
+	for pending := total; pending > 0; pending-- {
+		select {
+		case result := <-results:
+			if !lyricsHasUsableText(result.lyrics) {
+				continue
+			}
+			score, ok := scoreLyricsCandidate(result.lyrics, q, result.trust, result.rank, total)
+			if !ok {
+				GoLog("[Lyrics] Discarding %s result: duration mismatch\n", result.source)
+				continue
+			}
+			scored := *result.lyrics
+			scored.Score = score
+			scored.Alternates = nil
+			candidates = append(candidates, &scored)
+		case <-timer.C:
+			GoLog("[Lyrics] Time budget of %v reached with %d providers pending\n", budget, pending)
+			break collect
+		case <-ctx.Done():
+			return nil
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	best := candidates[0]
+	if len(candidates) > 1 {
+		best.Alternates = candidates[1:]
+	}
+	return best
+}
+
+// scoreLyricsCandidate rates lyrics (roughly 0-1) from sync granularity,
+// duration agreement, title/artist similarity and provider trust. It returns
+// false when the match is far enough off to be the wrong recording.
+func scoreLyricsCandidate(lyrics *LyricsResponse, q lyricsQuery, trust float64, rank, total int) (float64, bool) {
+	syncScore := 0.35
+	if lyrics.SyncType == "LINE_SYNCED" {
+		syncScore = 0.75
+		for _, line := range lyrics.Lines {
+			if len(line.WordTimings) > 0 {
+				syncScore = 1
+				break
+			}
+		}
+	}
+
+	durationScore := 0.6
+	if q.durationSec > 0 && lyrics.MatchedDurationSec > 0 {
+		diff := math.Abs(lyrics.MatchedDurationSec - q.durationSec)
+		switch {
+		case diff > 2*durationToleranceSec:
+			return 0, false
+		case diff <= 2:
+			durationScore = 1
+		case diff <= 5:
+			durationScore = 0.8
+		case diff <= durationToleranceSec:
+			durationScore = 0.4
+		default:
+			durationScore = 0
+		}
+	}
+	if q.durationSec > 0 && lyrics.SyncType == "LINE_SYNCED" && len(lyrics.Lines) > 0 {
+		lastStartSec := float64(lyrics.Lines[len(lyrics.Lines)-1].StartTimeMs) / 1000.0
+		if lastStartSec > q.durationSec+lyricsOverrunToleranceSec {
+			durationScore = 0
+		}
+	}
+
+	titleScore := 0.7
+	if strings.TrimSpace(lyrics.MatchedTitle) != "" {
+		titleScore = math.Max(
+			calculateStringSimilarity(normalizeStringForMatching(lyrics.MatchedTitle), normalizeStringForMatching(q.trackName)),
+			calculateStringSimilarity(normalizeStringForMatching(simplifyTrackName(lyrics.MatchedTitle)), normalizeStringForMatching(q.simplifiedTrack)),
+		)
+	}
+
+	artistScore := 0.7
+	if strings.TrimSpace(lyrics.MatchedArtist) != "" {
+		artistScore = math.Max(
+			calculateStringSimilarity(normalizeStringForMatching(lyrics.MatchedArtist), normalizeStringForMatching(q.artistName)),
+			calculateStringSimilarity(normalizeStringForMatching(normalizeArtistName(lyrics.MatchedArtist)), normalizeStringForMatching(q.primaryArtist)),
+		)
+	}
+
+	score := 0.35*syncScore + 0.25*durationScore + 0.15*titleScore + 0.1*artistScore + 0.15*trust
+	if total > 1 {
+		// Small tie-breaker so the user's provider order still matters.
+		score += 0.02 * float64(total-1-rank) / float64(total-1)
+	}
+	return score, true
+}
@@ -0,0 +1,102 @@
+package gobackend
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+func TestFetchLyricsAllSourcesPicksBestScoredCandidate(t *testing.T) {
+	origFetch := fetchBuiltInLyrics
+	origOrder := GetLyricsProviderOrder()
+	defer func() {
+		fetchBuiltInLyrics = origFetch
+		SetLyricsProviderOrder(origOrder)
+	}()
+	SetLyricsProviderOrder([]string{LyricsProviderLRCLIB, LyricsProviderNetease, LyricsProviderAppleMusic})
+
+	fetchBuiltInLyrics = func(_ context.Context, c *LyricsClient, provider string, q lyricsQuery) (*LyricsResponse, error) {
+		switch provider {
+		case LyricsProviderLRCLIB:
+			return &LyricsResponse{
+				Lines:              []LyricsLine{{Words: "plain words"}},
+				SyncType:           "UNSYNCED",
+				Provider:           "LRCLIB",
+				Source:             "LRCLIB",
+				MatchedTitle:       "Scoring Song",
+				MatchedArtist:      "Scoring Artist",
+				MatchedDurationSec: 200,
+			}, nil
+		case LyricsProviderNetease:
+			// Synced, but for a much longer edit of the song.
+			return &LyricsResponse{
+				Lines:              []LyricsLine{{StartTimeMs: 1000, Words: "long"}},
+				SyncType:           "LINE_SYNCED",
+				Provider:           "Netease",
+				Source:             "Netease",
+				MatchedTitle:       "Scoring Song",
+				MatchedArtist:      "Scoring Artist",
+				MatchedDurationSec: 260,
+			}, nil
+		case LyricsProviderAppleMusic:
+			return &LyricsResponse{
+				Lines: []LyricsLine{{
+					StartTimeMs: 1000,
+					Words:       "synced words",
+					WordTimings: []LyricsWord{{StartTimeMs: 1000, EndTimeMs: 1500, Text: "synced"}},
+				}},
+				SyncType:           "LINE_SYNCED",
+				Provider:           "Apple Music",
+				Source:             "Apple Music",
+				MatchedTitle:       "Scoring Song",
+				MatchedArtist:      "Scoring Artist",
+				MatchedDurationSec: 201,
+			}, nil
+		}
+		return nil, fmt.Errorf("not found")
+	}
+
+	lyrics, err := NewLyricsClient().FetchLyricsAllSources("", "Scoring Song", "Scoring Artist", 200)
+	if err != nil {
+		t.Fatalf("FetchLyricsAllSources failed: %v", err)
+	}
+	if lyrics.Provider != "Apple Music" {
+		t.Fatalf("expected Apple Music to win, got %s", lyrics.Provider)
+	}
+	if len(lyrics.Alternates) != 1 || lyrics.Alternates[0].Provider != "LRCLIB" {
+		t.Fatalf("expected only LRCLIB as alternate, got %+v", lyrics.Alternates)
+	}
+	if lyrics.Score <= lyrics.Alternates[0].Score {
+		t.Fatalf("best score %.3f should exceed alternate %.3f", lyrics.Score, lyrics.Alternates[0].Score)
+	}
+}
+
+func TestScoreLyricsCandidatePrefersCloserDuration(t *testing.T) {
+	q := lyricsQuery{
+		trackName:       "Song",
+		artistName:      "Artist",
+		primaryArtist:   "Artist",
+		simplifiedTrack: "Song",
+		durationSec:     180,
+	}
+	lines := []LyricsLine{{StartTimeMs: 1000, Words: "a"}}
+
+	near, ok := scoreLyricsCandidate(&LyricsResponse{Lines: lines, SyncType: "LINE_SYNCED", MatchedDurationSec: 181}, q, 0.75, 1, 2)
+	if !ok {
+		t.Fatalf("close duration should be accepted")
+	}
+	far, ok := scoreLyricsCandidate(&LyricsResponse{Lines: lines, SyncType: "LINE_SYNCED", MatchedDurationSec: 189}, q, 1.0, 0, 2)
+	if !ok {
+		t.Fatalf("duration within tolerance should be accepted")
+	}
+	if near <= far {
+		t.Fatalf("closer duration should score higher: %.3f <= %.3f", near, far)
+	}
+
+	overrun := []LyricsLine{{StartTimeMs: 1000, Words: "a"}, {StartTimeMs: 240000, Words: "b"}}
+	late, _ := scoreLyricsCandidate(&LyricsResponse{Lines: overrun, SyncType: "LINE_SYNCED"}, q, 0.75, 1, 2)
+	unknown, _ := scoreLyricsCandidate(&LyricsResponse{Lines: lines, SyncType: "LINE_SYNCED"}, q, 0.75, 1, 2)
+	if late >= unknown {
+		t.Fatalf("lines past the track end should lower the score: %.3f >= %.3f", late, unknown)
+	}
+}
@@ -0,0 +1,492 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const lyricsStoreVersion = 1
+
+// LyricsStoreEntry is a user-pinned lyric or override. Content holds LRC or
+// TTML text; an entry without content only adjusts fetched lyrics through
+// OffsetMs (added to every timestamp) or marks the track instrumental.
+type LyricsStoreEntry struct {
+	ID           string  `json:"id"`
+	ISRC         string  `json:"isrc,omitempty"`
+	Artist       string  `json:"artist,omitempty"`
+	Title        string  `json:"title,omitempty"`
+	DurationSec  float64 `json:"durationSec,omitempty"`
+	Content      string  `json:"content,omitempty"`
+	OffsetMs     int64   `json:"offsetMs,omitempty"`
+	Instrumental bool    `json:"instrumental,omitempty"`
+	SourcePath   string  `json:"sourcePath,omitempty"`
+	UpdatedAt    string  `json:"updatedAt"`
+}
+
+type lyricsStoreFile struct {
+	Version   int                `json:"version"`
+	UpdatedAt string             `json:"updatedAt"`
+	Entries   []LyricsStoreEntry `json:"entries"`
+}
+
+type lyricsStore struct {
+	mu      sync.RWMutex
+	writeMu sync.Mutex
+	path    string
+	entries map[string]LyricsStoreEntry
+}
+
+// LocalLyricsRequest pins lyrics for a track. FilePath is read when Content
+// is empty; with neither, an existing entry keeps its content and only the
+// offset and instrumental flag are updated.
+type LocalLyricsRequest struct {
+	ISRC         string  `json:"isrc,omitempty"`
+	Artist       string  `json:"artist,omitempty"`
+	Title        string  `json:"title,omitempty"`
+	DurationSec  float64 `json:"durationSec,omitempty"`
+	Content      string  `json:"content,omitempty"`
+	FilePath     string  `json:"filePath,omitempty"`
+	OffsetMs     int64   `json:"offsetMs,omitempty"`
+	Instrumental bool    `json:"instrumental,omitempty"`
+}
+
+type LyricsSidecarImportRequest struct {
+	FolderPath string   `json:"folderPath,omitempty"`
+	Files      []string `json:"files,omitempty"`
+	Overwrite  bool     `json:"overwrite,omitempty"`
+}
+
+type LyricsSidecarImportError struct {
+	FilePath string `json:"filePath"`
+	Error    string `json:"error"`
+}
+
+type LyricsSidecarImportResult struct {
+	Imported int                        `json:"imported"`
+	Skipped  int                        `json:"skipped"`
+	Errors   []LyricsSidecarImportError `json:"errors,omitempty"`
+}
+
+var (
+	lyricsStoreInstance *lyricsStore
+	lyricsStoreMu       sync.RWMutex
+)
+
+func getLyricsStore() *lyricsStore {
+	lyricsStoreMu.RLock()
+	defer lyricsStoreMu.RUnlock()
+	return lyricsStoreInstance
+}
+
+func requireLyricsStore() (*lyricsStore, error) {
+	store := getLyricsStore()
+	if store == nil {
+		return nil, fmt.Errorf("lyrics store is not open")
+	}
+	return store, nil
+}
+
+func loadLyricsStore(storePath string) (*lyricsStore, error) {
+	store := &lyricsStore{
+		path:    storePath,
+		entries: make(map[string]LyricsStoreEntry),
+	}
+
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return store, nil
+		}
+		return nil, err
+	}
+
+	var file lyricsStoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse lyrics store: %w", err)
+	}
+	if file.Version > lyricsStoreVersion {
+		return nil, fmt.Errorf("lyrics store version %d is newer than supported %d", file.Version, lyricsStoreVersion)
+	}
+
+	for _, entry := range file.Entries {
+		if entry.ID == "" {
+			continue
+		}
+		store.entries[entry.ID] = entry
+	}
+	return store, nil
+}
+
+// persist holds writeMu from the snapshot to the rename so a slower writer
+// can never replace a newer snapshot.
+func (s *lyricsStore) persist() error {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.mu.RLock()
+	file := lyricsStoreFile{
+		Version:   lyricsStoreVersion,
+		UpdatedAt: time.Now().UTC().Format(time.RFC3339),
+		Entries:   make([]LyricsStoreEntry, 0, len(s.entries)),
+	}
+	for _, entry := range s.entries {
+		file.Entries = append(file.Entries, entry)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(file.Entries, func(i, j int) bool {
+		return file.Entries[i].ID < file.Entries[j].ID
+	})
+	return writeJSONFileAtomic(s.path, file)
+}
+
+func normalizeLyricsStoreISRC(isrc string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(isrc), "-", ""))
+}
+
+func lyricsStoreTrackKey(artist, title string) string {
+	return normalizeStringForMatching(normalizeArtistName(artist)) + "|" + normalizeStringForMatching(title)
+}
+
+// lyricsStoreID keys entries by ISRC when known, otherwise by normalized
+// artist/title and the duration rounded like the lyrics cache.
+func lyricsStoreID(isrc, artist, title string, durationSec float64) string {
+	if normalized := normalizeLyricsStoreISRC(isrc); normalized != "" {
+		return "isrc:" + normalized
+	}
+	return fmt.Sprintf("track:%s|%.0f", lyricsStoreTrackKey(artist, title), math.Round(durationSec/10)*10)
+}
+
+// find prefers an ISRC match and falls back to artist/title, where the
+// durations must agree within durationToleranceSec when both are known.
+func (s *lyricsStore) find(isrc, artist, title string, durationSec float64) (LyricsStoreEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	normalizedISRC := normalizeLyricsStoreISRC(isrc)
+	if normalizedISRC != "" {
+		if entry, ok := s.entries["isrc:"+normalizedISRC]; ok {
+			return entry, true
+		}
+	}
+	if strings.TrimSpace(title) == "" {
+		return LyricsStoreEntry{}, false
+	}
+
+	key := lyricsStoreTrackKey(artist, title)
+	var best LyricsStoreEntry
+	bestDiff := math.MaxFloat64
+	for _, entry := range s.entries {
+		if entry.ISRC != "" && normalizedISRC != "" && entry.ISRC != normalizedISRC {
+			continue
+		}
+		if lyricsStoreTrackKey(entry.Artist, entry.Title) != key {
+			continue
+		}
+		diff := 0.0
+		if durationSec > 0 && entry.DurationSec > 0 {
+			diff = math.Abs(entry.DurationSec - durationSec)
+			if diff > durationToleranceSec {
+				continue
+			}
+		}
+		if diff < bestDiff || (diff == bestDiff && entry.ID < best.ID) {
+			best, bestDiff = entry, diff
+		}
+	}
+	return best, bestDiff != math.MaxFloat64
+}
+
+func (s *lyricsStore) put(entry LyricsStoreEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries[entry.ID] = entry
+}
+
+func (s *lyricsStore) get(id string) (LyricsStoreEntry, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	entry, ok := s.entries[id]
+	return entry, ok
+}
+
+func (s *lyricsStore) remove(id string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.entries[id]; !ok {
+		return false
+	}
+	delete(s.entries, id)
+	return true
+}
+
+// findStoredLyrics returns the store entry for a track, if the store is open.
+func findStoredLyrics(isrc, artist, title string, durationSec float64) (LyricsStoreEntry, bool) {
+	store := getLyricsStore()
+	if store == nil {
+		return LyricsStoreEntry{}, false
+	}
+	return store.find(isrc, artist, title, durationSec)
+}
+
+// response builds lyrics from the entry's own content or instrumental flag;
+// it returns nil for offset-only entries.
+func (e LyricsStoreEntry) response() *LyricsResponse {
+	if e.Instrumental {
+		return &LyricsResponse{Instrumental: true, SyncType: "UNSYNCED", Provider: "Local", Source: "Local Library"}
+	}
+	if strings.TrimSpace(e.Content) == "" {
+		return nil
+	}
+	lyrics, _, _, err := parseLyricsContent(e.Content)
+	if err != nil {
+		GoLog("[LyricsStore] Stored lyrics %s are unreadable: %v\n", e.ID, err)
+		return nil
+	}
+	lyrics.Provider = "Local"
+	lyrics.Source = "Local Library"
+	return applyLyricsOffset(lyrics, e.OffsetMs)
+}
+
+// applyLyricsOffset returns a copy of synced lyrics with every timestamp
+// shifted by offsetMs, clamped at zero.
+func applyLyricsOffset(lyrics *LyricsResponse, offsetMs int64) *LyricsResponse {
+	if lyrics == nil || offsetMs == 0 || lyrics.SyncType == "UNSYNCED" {
+		return lyrics
+	}
+	result := *lyrics
+	result.Lines = mapLyricsTimestamps(lyrics.Lines, func(ms int64) int64 {
+		return max(ms+offsetMs, 0)
+	})
+	return &result
+}
+
+// storedLyricsOverride returns pinned lyrics for the track, or nil when the
+// store has none (offset-only entries are applied after fetching instead).
+func storedLyricsOverride(isrc, artist, title string, durationSec float64) *LyricsResponse {
+	entry, ok := findStoredLyrics(isrc, artist, title, durationSec)
+	if !ok {
+		return nil
+	}
+	return entry.response()
+}
+
+func OpenLyricsStore(storePath string) error {
+	if strings.TrimSpace(storePath) == "" {
+		return fmt.Errorf("lyrics store path is empty")
+	}
+
+	store, err := loadLyricsStore(storePath)
+	if err != nil {
+		return err
+	}
+
+	lyricsStoreMu.Lock()
+	lyricsStoreInstance = store
+	lyricsStoreMu.Unlock()
+
+	GoLog("[LyricsStore] Opened %s with %d entries\n", storePath, len(store.entries))
+	return nil
+}
+
+func CloseLyricsStore() error {
+	lyricsStoreMu.Lock()
+	store := lyricsStoreInstance
+	lyricsStoreInstance = nil
+	lyricsStoreMu.Unlock()
+
+	if store == nil {
+		return nil
+	}
+	return store.persist()
+}
+
+// SaveLocalLyrics pins lyrics or an override for a track and returns the
+// stored entry as JSON.
+func SaveLocalLyrics(requestJSON string) (string, error) {
+	store, err := requireLyricsStore()
+	if err != nil {
+		return "", err
+	}
+
+	var req LocalLyricsRequest
+	if err := json.Unmarshal([]byte(requestJSON), &req); err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+	if normalizeLyricsStoreISRC(req.ISRC) == "" && strings.TrimSpace(req.Title) == "" {
+		return "", fmt.Errorf("isrc or title is required")
+	}
+
+	content := req.Content
+	if strings.TrimSpace(content) == "" && req.FilePath != "" {
+		data, err := os.ReadFile(req.FilePath)
+		if err != nil {
+			return "", fmt.Errorf("failed to read lyrics file: %w", err)
+		}
+		content = string(data)
+	}
+	if strings.TrimSpace(content) != "" {
+		if _, _, _, err := parseLyricsContent(content); err != nil {
+			return "", err
+		}
+	}
+
+	entry := LyricsStoreEntry{
+		ID:           lyricsStoreID(req.ISRC, req.Artist, req.Title, req.DurationSec),
+		ISRC:         normalizeLyricsStoreISRC(req.ISRC),
+		Artist:       strings.TrimSpace(req.Artist),
+		Title:        strings.TrimSpace(req.Title),
+		DurationSec:  req.DurationSec,
+		Content:      content,
+		OffsetMs:     req.OffsetMs,
+		Instrumental: req.Instrumental,
+		SourcePath:   req.FilePath,
+		UpdatedAt:    time.Now().UTC().Format(time.RFC3339),
+	}
+	if existing, ok := store.get(entry.ID); ok && strings.TrimSpace(content) == "" {
+		entry.Content = existing.Content
+		entry.SourcePath = existing.SourcePath
+	}
+
+	store.put(entry)
+	if err := store.persist(); err != nil {
+		return "", err
+	}
+	GoLog("[LyricsStore] Saved %s\n", entry.ID)
+
+	jsonBytes, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+func RemoveLocalLyrics(id string) (bool, error) {
+	store, err := requireLyricsStore()
+	if err != nil {
+		return false, err
+	}
+	if !store.remove(id) {
+		return false, nil
+	}
+	return true, store.persist()
+}
+
+func ListLocalLyrics() (string, error) {
+	store, err := requireLyricsStore()
+	if err != nil {
+		return "", err
+	}
+
+	store.mu.RLock()
+	entries := make([]LyricsStoreEntry, 0, len(store.entries))
+	for _, entry := range store.entries {
+		entries = append(entries, entry)
+	}
+	store.mu.RUnlock()
+
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].ID < entries[j].ID
+	})
+	jsonBytes, err := json.Marshal(entries)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+func findLyricsSidecar(audioPath string) string {
+	base := strings.TrimSuffix(audioPath, filepath.Ext(audioPath))
+	for _, ext := range []string{".lrc", ".ttml"} {
+		if info, err := os.Stat(base + ext); err == nil && !info.IsDir() {
+			return base + ext
+		}
+	}
+	return ""
+}
+
+// ImportSidecarLyrics stores the .lrc/.ttml files that sit next to audio
+// files, keyed by the audio file's ISRC or artist/title/duration tags.
+func ImportSidecarLyrics(requestJSON string) (string, error) {
+	store, err := requireLyricsStore()
+	if err != nil {
+		return "", err
+	}
+
+	var req LyricsSidecarImportRequest
+	if err := json.Unmarshal([]byte(requestJSON), &req); err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+	paths, err := collectBulkAudioPaths(req.FolderPath, req.Files)
+	if err != nil {
+		return "", err
+	}
+
+	result := LyricsSidecarImportResult{}
+	for _, path := range paths {
+		sidecar := findLyricsSidecar(path)
+		if sidecar == "" {
+			continue
+		}
+		fail := func(err error) {
+			result.Errors = append(result.Errors, LyricsSidecarImportError{FilePath: sidecar, Error: err.Error()})
+		}
+
+		data, err := os.ReadFile(sidecar)
+		if err != nil {
+			fail(err)
+			continue
+		}
+		if _, _, _, err := parseLyricsContent(string(data)); err != nil {
+			fail(err)
+			continue
+		}
+		file, err := readAlbumReEnrichFile(path)
+		if err != nil {
+			fail(fmt.Errorf("failed to read tags: %w", err))
+			continue
+		}
+		title := file.tags["title"]
+		if title == "" {
+			title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+		}
+		durationSec := float64(file.durationMS) / 1000.0
+
+		id := lyricsStoreID(file.tags["isrc"], file.tags["artist"], title, durationSec)
+		if _, exists := store.get(id); exists && !req.Overwrite {
+			result.Skipped++
+			continue
+		}
+		store.put(LyricsStoreEntry{
+			ID:          id,
+			ISRC:        normalizeLyricsStoreISRC(file.tags["isrc"]),
+			Artist:      file.tags["artist"],
+			Title:       title,
+			DurationSec: durationSec,
+			Content:     string(data),
+			SourcePath:  sidecar,
+			UpdatedAt:   time.Now().UTC().Format(time.RFC3339),
+		})
+		result.Imported++
+	}
+
+	if result.Imported > 0 {
+		if err := store.persist(); err != nil {
+			return "", err
+		}
+	}
+	GoLog("[LyricsStore] Imported %d sidecar lyrics (%d skipped, %d failed)\n", result.Imported, result.Skipped, len(result.Errors))
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
@@ -0,0 +1,147 @@
+package gobackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func openTestLyricsStore(t *testing.T) string {
+	t.Helper()
+	storePath := filepath.Join(t.TempDir(), "lyrics_store.json")
+	if err := OpenLyricsStore(storePath); err != nil {
+		t.Fatalf("OpenLyricsStore failed: %v", err)
+	}
+	t.Cleanup(func() { _ = CloseLyricsStore() })
+	return storePath
+}
+
+func TestLocalLyricsOverrideFetchedLyrics(t *testing.T) {
+	storePath := openTestLyricsStore(t)
+
+	origFetch := fetchBuiltInLyrics
+	defer func() { fetchBuiltInLyrics = origFetch }()
+	fetchBuiltInLyrics = func(_ context.Context, c *LyricsClient, provider string, q lyricsQuery) (*LyricsResponse, error) {
+		return &LyricsResponse{
+			Lines:    []LyricsLine{{StartTimeMs: 1000, EndTimeMs: 2000, Words: "fetched"}},
+			SyncType: "LINE_SYNCED",
+			Provider: provider,
+			Source:   provider,
+		}, nil
+	}
+
+	pinned, _ := json.Marshal(LocalLyricsRequest{
+		Artist:      "Store Artist feat. Guest",
+		Title:       "Pinned Song",
+		DurationSec: 200,
+		Content:     "[00:05.00]pinned line\n",
+	})
+	if _, err := SaveLocalLyrics(string(pinned)); err != nil {
+		t.Fatalf("SaveLocalLyrics failed: %v", err)
+	}
+	lyrics, err := NewLyricsClient().FetchLyricsAllSources("", "Pinned Song", "Store Artist", 203)
+	if err != nil {
+		t.Fatalf("FetchLyricsAllSources failed: %v", err)
+	}
+	if lyrics.Provider != "Local" || len(lyrics.Lines) != 1 || lyrics.Lines[0].Words != "pinned line" {
+		t.Fatalf("expected pinned lyrics, got %+v", lyrics)
+	}
+
+	offset, _ := json.Marshal(LocalLyricsRequest{ISRC: "us-abc-12-00001", OffsetMs: 500})
+	if _, err := SaveLocalLyrics(string(offset)); err != nil {
+		t.Fatalf("SaveLocalLyrics offset failed: %v", err)
+	}
+	lyrics, err = NewLyricsClient().FetchLyricsAllSourcesWithISRC("", "USABC1200001", "Offset Song", "Offset Artist", 180)
+	if err != nil {
+		t.Fatalf("FetchLyricsAllSourcesWithISRC failed: %v", err)
+	}
+	if lyrics.Lines[0].StartTimeMs != 1500 || lyrics.Lines[0].EndTimeMs != 2500 {
+		t.Fatalf("offset not applied: %+v", lyrics.Lines[0])
+	}
+
+	// Entries survive reopening the store.
+	if err := CloseLyricsStore(); err != nil {
+		t.Fatalf("CloseLyricsStore failed: %v", err)
+	}
+	if err := OpenLyricsStore(storePath); err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	if entry, ok := findStoredLyrics("", "Store Artist", "Pinned Song", 0); !ok || entry.Content == "" {
+		t.Fatalf("pinned entry missing after reopen: %+v", entry)
+	}
+	if _, ok := findStoredLyrics("", "Store Artist", "Pinned Song", 240); ok {
+		t.Fatalf("entry should not match a different duration")
+	}
+}
+
+func TestImportSidecarLyrics(t *testing.T) {
+	openTestLyricsStore(t)
+
+	dir := t.TempDir()
+	path := writeTestFLAC(t, dir, "song.flac", buildTestFLAC(t, 2, 64))
+	fields, _ := json.Marshal(map[string]string{"title": "Sidecar Song", "artist": "Sidecar Artist", "isrc": "GBAAA0000001"})
+	if _, err := EditFileMetadata(path, string(fields)); err != nil {
+		t.Fatalf("EditFileMetadata failed: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "song.lrc"), []byte("[00:01.00]from sidecar\n"), 0644); err != nil {
+		t.Fatalf("write lrc: %v", err)
+	}
+	writeTestFLAC(t, dir, "no_lyrics.flac", buildTestFLAC(t, 2, 64))
+
+	reqJSON, _ := json.Marshal(LyricsSidecarImportRequest{FolderPath: dir})
+	resultJSON, err := ImportSidecarLyrics(string(reqJSON))
+	if err != nil {
+		t.Fatalf("ImportSidecarLyrics failed: %v", err)
+	}
+	var result LyricsSidecarImportResult
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("unmarshal result: %v", err)
+	}
+	if result.Imported != 1 || len(result.Errors) != 0 {
+		t.Fatalf("unexpected import result: %+v", result)
+	}
+
+	local := storedLyricsOverride("GBAAA0000001", "", "", 0)
+	if local == nil || local.Lines[0].Words != "from sidecar" {
+		t.Fatalf("sidecar lyrics not stored by isrc: %+v", local)
+	}
+
+	resultJSON, _ = ImportSidecarLyrics(string(reqJSON))
+	_ = json.Unmarshal([]byte(resultJSON), &result)
+	if result.Imported != 0 || result.Skipped != 1 {
+		t.Fatalf("re-import should skip existing entry: %+v", result)
+	}
+}
+
+func TestLyricsStoreConcurrentSavesKeepEveryEntry(t *testing.T) {
+	storePath := openTestLyricsStore(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			req, _ := json.Marshal(LocalLyricsRequest{ISRC: fmt.Sprintf("USABC12%05d", i), Content: "[00:01.00]line\n"})
+			if _, err := SaveLocalLyrics(string(req)); err != nil {
+				t.Errorf("SaveLocalLyrics failed: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(storePath)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	var file lyricsStoreFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		t.Fatalf("invalid store file: %v", err)
+	}
+	if len(file.Entries) != 20 {
+		t.Fatalf("expected the last write to hold all 20 entries, got %d", len(file.Entries))
+	}
+}
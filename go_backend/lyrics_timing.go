@@ -0,0 +1,309 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	lyricsTimingNonMonotonic = "non_monotonic"
+	lyricsTimingOutOfRange   = "out_of_range"
+	lyricsTimingDuplicate    = "duplicate"
+
+	// Automatic rescaling only runs for small mismatches; bigger ones mean a
+	// different edit, which a linear stretch cannot fix.
+	lyricsRescaleMinDiffMs = 1500
+	lyricsRescaleMaxRatio  = 0.1
+)
+
+var lrcOffsetTagPattern = regexp.MustCompile(`(?m)^\s*\[offset:\s*([+-]?\d+)\s*\]\s*$`)
+
+type LyricsTimingIssue struct {
+	Type        string `json:"type"`
+	LineIndex   int    `json:"lineIndex"`
+	StartTimeMs int64  `json:"startTimeMs"`
+	Message     string `json:"message"`
+}
+
+type LyricsTimingRequest struct {
+	Content          string `json:"content"`
+	OffsetMs         int64  `json:"offsetMs,omitempty"`
+	LyricsDurationMs int64  `json:"lyricsDurationMs,omitempty"`
+	TargetDurationMs int64  `json:"targetDurationMs,omitempty"`
+	AudioFilePath    string `json:"audioFilePath,omitempty"`
+	MergeDuplicates  bool   `json:"mergeDuplicates,omitempty"`
+	SortLines        bool   `json:"sortLines,omitempty"`
+	Format           string `json:"format,omitempty"`
+}
+
+type LyricsTimingResult struct {
+	Content string              `json:"content"`
+	Applied []string            `json:"applied,omitempty"`
+	Issues  []LyricsTimingIssue `json:"issues,omitempty"`
+}
+
+// mapLyricsTimestamps copies lines with every line and word timestamp
+// passed through fn.
+func mapLyricsTimestamps(lines []LyricsLine, fn func(int64) int64) []LyricsLine {
+	mapWords := func(words []LyricsWord) []LyricsWord {
+		if len(words) == 0 {
+			return words
+		}
+		mapped := make([]LyricsWord, len(words))
+		for i, word := range words {
+			word.StartTimeMs = fn(word.StartTimeMs)
+			word.EndTimeMs = fn(word.EndTimeMs)
+			mapped[i] = word
+		}
+		return mapped
+	}
+
+	mapped := make([]LyricsLine, len(lines))
+	for i, line := range lines {
+		line.StartTimeMs = fn(line.StartTimeMs)
+		line.EndTimeMs = fn(line.EndTimeMs)
+		line.WordTimings = mapWords(line.WordTimings)
+		line.Background = mapWords(line.Background)
+		mapped[i] = line
+	}
+	return mapped
+}
+
+// lrcOffsetTagMs reads the [offset:] tag. A positive offset makes lyrics
+// appear sooner, so it is subtracted from the timestamps.
+func lrcOffsetTagMs(content string) (int64, bool) {
+	match := lrcOffsetTagPattern.FindStringSubmatch(content)
+	if match == nil {
+		return 0, false
+	}
+	offset, err := strconv.ParseInt(match[1], 10, 64)
+	return offset, err == nil && offset != 0
+}
+
+// rescaleLyricsTimings stretches timestamps linearly from the duration the
+// lyrics were timed against to the duration of the local track.
+func rescaleLyricsTimings(lyrics *LyricsResponse, fromDurationMs, toDurationMs int64) *LyricsResponse {
+	if lyrics == nil || lyrics.SyncType == "UNSYNCED" || fromDurationMs <= 0 || toDurationMs <= 0 || fromDurationMs == toDurationMs {
+		return lyrics
+	}
+	ratio := float64(toDurationMs) / float64(fromDurationMs)
+	result := *lyrics
+	result.Lines = mapLyricsTimestamps(lyrics.Lines, func(ms int64) int64 {
+		return int64(math.Round(float64(ms) * ratio))
+	})
+	return &result
+}
+
+// sortLyricsLines orders lines by start time and recomputes the end times
+// derived from the next line.
+func sortLyricsLines(lyrics *LyricsResponse) *LyricsResponse {
+	if lyrics == nil || lyrics.SyncType == "UNSYNCED" {
+		return lyrics
+	}
+	result := *lyrics
+	result.Lines = append([]LyricsLine(nil), lyrics.Lines...)
+	sort.SliceStable(result.Lines, func(i, j int) bool {
+		return result.Lines[i].StartTimeMs < result.Lines[j].StartTimeMs
+	})
+	for i := 0; i < len(result.Lines)-1; i++ {
+		if next := result.Lines[i+1].StartTimeMs; next > result.Lines[i].StartTimeMs && len(result.Lines[i].WordTimings) == 0 {
+			result.Lines[i].EndTimeMs = next
+		}
+	}
+	return &result
+}
+
+func isDuplicateLyricsLine(a, b LyricsLine) bool {
+	diff := a.StartTimeMs - b.StartTimeMs
+	return diff >= -10 && diff <= 10 && lyricsLineText(a) == lyricsLineText(b)
+}
+
+// mergeDuplicateLyricsLines drops lines repeating the previous line's text at
+// the same timestamp. Lines sharing a timestamp with different text are
+// translations and are kept.
+func mergeDuplicateLyricsLines(lyrics *LyricsResponse) *LyricsResponse {
+	if lyrics == nil || lyrics.SyncType == "UNSYNCED" {
+		return lyrics
+	}
+	result := *lyrics
+	result.Lines = make([]LyricsLine, 0, len(lyrics.Lines))
+	for _, line := range lyrics.Lines {
+		if n := len(result.Lines); n > 0 && isDuplicateLyricsLine(result.Lines[n-1], line) {
+			result.Lines[n-1].EndTimeMs = max(result.Lines[n-1].EndTimeMs, line.EndTimeMs)
+			continue
+		}
+		result.Lines = append(result.Lines, line)
+	}
+	return &result
+}
+
+// validateLyricsTimings reports lines that go back in time, start after the
+// end of the track (when durationMs is known) or duplicate the line before.
+func validateLyricsTimings(lyrics *LyricsResponse, durationMs int64) []LyricsTimingIssue {
+	if lyrics == nil || lyrics.SyncType == "UNSYNCED" {
+		return nil
+	}
+	var issues []LyricsTimingIssue
+	for i, line := range lyrics.Lines {
+		if i > 0 && line.StartTimeMs < lyrics.Lines[i-1].StartTimeMs {
+			issues = append(issues, LyricsTimingIssue{
+				Type: lyricsTimingNonMonotonic, LineIndex: i, StartTimeMs: line.StartTimeMs,
+				Message: fmt.Sprintf("starts before the previous line (%s)", msToLRCTimestampInline(lyrics.Lines[i-1].StartTimeMs)),
+			})
+		}
+		if durationMs > 0 && line.StartTimeMs > durationMs {
+			issues = append(issues, LyricsTimingIssue{
+				Type: lyricsTimingOutOfRange, LineIndex: i, StartTimeMs: line.StartTimeMs,
+				Message: fmt.Sprintf("starts after the end of the track (%s)", msToLRCTimestampInline(durationMs)),
+			})
+		}
+		if i > 0 && isDuplicateLyricsLine(lyrics.Lines[i-1], line) {
+			issues = append(issues, LyricsTimingIssue{
+				Type: lyricsTimingDuplicate, LineIndex: i, StartTimeMs: line.StartTimeMs,
+				Message: "repeats the previous line",
+			})
+		}
+	}
+	return issues
+}
+
+// autoFixLyricsTiming repairs provider lyrics for a track of durationMs: it
+// sorts and de-duplicates lines, and rescales the timings when the provider
+// reports a slightly different duration for its recording.
+func autoFixLyricsTiming(lyrics *LyricsResponse, durationMs int64) *LyricsResponse {
+	if lyrics == nil || lyrics.SyncType == "UNSYNCED" || len(lyrics.Lines) == 0 {
+		return lyrics
+	}
+	found := make(map[string]bool)
+	for _, issue := range validateLyricsTimings(lyrics, durationMs) {
+		found[issue.Type] = true
+	}
+	if found[lyricsTimingNonMonotonic] {
+		lyrics = sortLyricsLines(lyrics)
+	}
+	if found[lyricsTimingDuplicate] || found[lyricsTimingNonMonotonic] {
+		lyrics = mergeDuplicateLyricsLines(lyrics)
+	}
+
+	sourceMs := int64(math.Round(lyrics.MatchedDurationSec * 1000))
+	if durationMs > 0 && sourceMs > 0 {
+		diff := sourceMs - durationMs
+		if diff < 0 {
+			diff = -diff
+		}
+		if diff >= lyricsRescaleMinDiffMs && float64(diff) <= lyricsRescaleMaxRatio*float64(durationMs) {
+			GoLog("[Lyrics] Rescaling %s lyrics from %dms to %dms\n", lyrics.Source, sourceMs, durationMs)
+			lyrics = rescaleLyricsTimings(lyrics, sourceMs, durationMs)
+			lyrics.MatchedDurationSec = float64(durationMs) / 1000.0
+		}
+	}
+	return lyrics
+}
+
+// audioFileDurationMs prefers the exact FLAC sample count and falls back to
+// the duration in the file's tags.
+func audioFileDurationMs(path string) (int64, error) {
+	if quality, err := GetAudioQuality(path); err == nil && quality.TotalSamples > 0 && quality.SampleRate > 0 {
+		return quality.TotalSamples * 1000 / int64(quality.SampleRate), nil
+	}
+	file, err := readAlbumReEnrichFile(path)
+	if err != nil {
+		return 0, err
+	}
+	if file.durationMS <= 0 {
+		return 0, fmt.Errorf("duration unknown for %s", path)
+	}
+	return int64(file.durationMS), nil
+}
+
+func formatFixedLyrics(lyrics *LyricsResponse, format, trackName, artistName string) (string, error) {
+	if strings.TrimSpace(format) != "" {
+		return formatLyrics(lyrics, format, trackName, artistName)
+	}
+	for _, line := range lyrics.Lines {
+		if len(line.WordTimings) > 0 {
+			return convertToTimedLRC(lyrics, trackName, artistName, true), nil
+		}
+	}
+	return convertToTimedLRC(lyrics, trackName, artistName, false), nil
+}
+
+// FixLyricsTiming applies the [offset:] tag and a fixed shift, rescales to
+// the target duration (or the audio file's), optionally sorts and merges
+// duplicate lines, and reports the timing issues that remain.
+func FixLyricsTiming(requestJSON string) (string, error) {
+	var req LyricsTimingRequest
+	if err := json.Unmarshal([]byte(requestJSON), &req); err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+	lyrics, trackName, artistName, err := parseLyricsContent(req.Content)
+	if err != nil {
+		return "", err
+	}
+	if lyrics.SyncType == "UNSYNCED" {
+		return "", fmt.Errorf("lyrics are not synced")
+	}
+
+	result := LyricsTimingResult{}
+	if offset, ok := lrcOffsetTagMs(req.Content); ok {
+		result.Applied = append(result.Applied, fmt.Sprintf("offset tag %+dms", offset))
+	}
+	if req.OffsetMs != 0 {
+		lyrics = applyLyricsOffset(lyrics, req.OffsetMs)
+		result.Applied = append(result.Applied, fmt.Sprintf("shift %+dms", req.OffsetMs))
+	}
+
+	targetMs := req.TargetDurationMs
+	if targetMs <= 0 && req.AudioFilePath != "" {
+		if targetMs, err = audioFileDurationMs(req.AudioFilePath); err != nil {
+			return "", err
+		}
+	}
+	if req.LyricsDurationMs > 0 && targetMs > 0 && req.LyricsDurationMs != targetMs {
+		lyrics = rescaleLyricsTimings(lyrics, req.LyricsDurationMs, targetMs)
+		result.Applied = append(result.Applied, fmt.Sprintf("rescale %dms to %dms", req.LyricsDurationMs, targetMs))
+	}
+	if req.SortLines {
+		lyrics = sortLyricsLines(lyrics)
+		result.Applied = append(result.Applied, "sort")
+	}
+	if req.MergeDuplicates {
+		before := len(lyrics.Lines)
+		lyrics = mergeDuplicateLyricsLines(lyrics)
+		result.Applied = append(result.Applied, fmt.Sprintf("merged %d duplicate lines", before-len(lyrics.Lines)))
+	}
+
+	result.Issues = validateLyricsTimings(lyrics, targetMs)
+	if result.Content, err = formatFixedLyrics(lyrics, req.Format, trackName, artistName); err != nil {
+		return "", err
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+// ValidateLyricsTiming lists timing issues in LRC or TTML content; a
+// durationMs of 0 skips the out-of-range check.
+func ValidateLyricsTiming(content string, durationMs int64) (string, error) {
+	lyrics, _, _, err := parseLyricsContent(content)
+	if err != nil {
+		return "", err
+	}
+	issues := validateLyricsTimings(lyrics, durationMs)
+	if issues == nil {
+		issues = []LyricsTimingIssue{}
+	}
+	jsonBytes, err := json.Marshal(issues)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
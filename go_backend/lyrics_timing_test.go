@@ -0,0 +1,78 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseLRCContentAppliesOffsetTag(t *testing.T) {
+	lyrics, _, _ := parseLRCContent("[offset:+500]\n[00:10.00]first\n[00:12.00]second\n")
+	if len(lyrics.Lines) != 2 {
+		t.Fatalf("expected 2 lines, got %d", len(lyrics.Lines))
+	}
+	if lyrics.Lines[0].StartTimeMs != 9500 || lyrics.Lines[1].StartTimeMs != 11500 {
+		t.Fatalf("offset not applied: %+v", lyrics.Lines)
+	}
+}
+
+func TestFixLyricsTimingRescalesAndMerges(t *testing.T) {
+	req, _ := json.Marshal(LyricsTimingRequest{
+		Content:          "[00:20.00]b\n[00:10.00]a\n[00:10.00]a\n[00:30.00]c\n",
+		LyricsDurationMs: 100000,
+		TargetDurationMs: 110000,
+		SortLines:        true,
+		MergeDuplicates:  true,
+	})
+	resultJSON, err := FixLyricsTiming(string(req))
+	if err != nil {
+		t.Fatalf("FixLyricsTiming failed: %v", err)
+	}
+	var result LyricsTimingResult
+	if err := json.Unmarshal([]byte(resultJSON), &result); err != nil {
+		t.Fatalf("invalid result: %v", err)
+	}
+	for _, want := range []string{"[00:11.00]a", "[00:22.00]b", "[00:33.00]c"} {
+		if !strings.Contains(result.Content, want) {
+			t.Fatalf("expected %q in:\n%s", want, result.Content)
+		}
+	}
+	if strings.Count(result.Content, "]a") != 1 {
+		t.Fatalf("duplicate line not merged:\n%s", result.Content)
+	}
+	if len(result.Issues) != 0 {
+		t.Fatalf("expected no remaining issues, got %+v", result.Issues)
+	}
+}
+
+func TestValidateLyricsTimingsReportsIssues(t *testing.T) {
+	lyrics, _, _ := parseLRCContent("[00:10.00]a\n[00:05.00]b\n[00:05.00]b\n[03:30.00]late\n")
+	issues := validateLyricsTimings(lyrics, 200000)
+	found := map[string]int{}
+	for _, issue := range issues {
+		found[issue.Type] = issue.LineIndex
+	}
+	if found[lyricsTimingNonMonotonic] != 1 || found[lyricsTimingDuplicate] != 2 || found[lyricsTimingOutOfRange] != 3 {
+		t.Fatalf("unexpected issues: %+v", issues)
+	}
+}
+
+func TestAutoFixLyricsTimingRescalesSmallDurationMismatch(t *testing.T) {
+	lyrics := &LyricsResponse{
+		Lines:              []LyricsLine{{StartTimeMs: 60000, EndTimeMs: 65000, Words: "a"}},
+		SyncType:           "LINE_SYNCED",
+		MatchedDurationSec: 200,
+	}
+	fixed := autoFixLyricsTiming(lyrics, 205000)
+	if fixed.Lines[0].StartTimeMs != 61500 {
+		t.Fatalf("expected rescaled start 61500, got %d", fixed.Lines[0].StartTimeMs)
+	}
+	if lyrics.Lines[0].StartTimeMs != 60000 {
+		t.Fatal("input lyrics were mutated")
+	}
+
+	unrelated := autoFixLyricsTiming(lyrics, 300000)
+	if unrelated.Lines[0].StartTimeMs != 60000 {
+		t.Fatalf("large mismatch should not rescale, got %d", unrelated.Lines[0].StartTimeMs)
+	}
+}
@@ -126,6 +126,8 @@ type Metadata struct {
 	ReplayGainTrackPeak string // e.g. "0.988831"
 	ReplayGainAlbumGain string // e.g. "-7.20 dB"
 	ReplayGainAlbumPeak string // e.g. "1.000000"
+
+	TrackIdentifiers
 }
 
 func EmbedMetadata(filePath string, metadata Metadata, coverPath string) error {
@@ -316,6 +318,12 @@ func ReadMetadata(filePath string) (*Metadata, error) {
 			metadata.ReplayGainAlbumGain = getComment(cmt, "REPLAYGAIN_ALBUM_GAIN")
 			metadata.ReplayGainAlbumPeak = getComment(cmt, "REPLAYGAIN_ALBUM_PEAK")
 
+			for _, comment := range cmt.Comments {
+				if key, value, ok := strings.Cut(comment, "="); ok {
+					metadata.TrackIdentifiers.setTag(key, value)
+				}
+			}
+
 			break
 		}
 	}
@@ -455,6 +463,19 @@ func EditFlacFields(filePath string, fields map[string]string) error {
 		}
 	}
 
+	for _, tag := range identifierTags {
+		v, ok := fields[tag.field]
+		if !ok {
+			continue
+		}
+		removeCommentKey(cmt, tag.vorbis)
+		if tag.multi {
+			setMultiValueComment(cmt, tag.vorbis, strings.TrimSpace(v))
+		} else {
+			setComment(cmt, tag.vorbis, strings.TrimSpace(v))
+		}
+	}
+
 	cmtBlock := cmt.Marshal()
 	if cmtIdx >= 0 {
 		f.Meta[cmtIdx] = &cmtBlock
@@ -537,6 +558,29 @@ func writeVorbisMetadata(cmt *flacvorbis.MetaDataBlockVorbisComment, metadata Me
 	setComment(cmt, "REPLAYGAIN_TRACK_PEAK", metadata.ReplayGainTrackPeak)
 	setComment(cmt, "REPLAYGAIN_ALBUM_GAIN", metadata.ReplayGainAlbumGain)
 	setComment(cmt, "REPLAYGAIN_ALBUM_PEAK", metadata.ReplayGainAlbumPeak)
+
+	writeVorbisIdentifiers(cmt, metadata.TrackIdentifiers)
+}
+
+func writeVorbisIdentifiers(cmt *flacvorbis.MetaDataBlockVorbisComment, ids TrackIdentifiers) {
+	for _, tag := range identifierTags {
+		value := *tag.value(&ids)
+		if tag.multi {
+			setMultiValueComment(cmt, tag.vorbis, value)
+		} else {
+			setComment(cmt, tag.vorbis, value)
+		}
+	}
+}
+
+func setMultiValueComment(cmt *flacvorbis.MetaDataBlockVorbisComment, key, value string) {
+	if value == "" {
+		return
+	}
+	removeCommentKey(cmt, key)
+	for _, part := range splitIdentifierValues(value) {
+		cmt.Comments = append(cmt.Comments, key+"="+part)
+	}
 }
 
 func setComment(cmt *flacvorbis.MetaDataBlockVorbisComment, key, value string) {
@@ -999,6 +1043,8 @@ func ReadM4ATags(filePath string) (*AudioMetadata, error) {
 					metadata.ReplayGainAlbumGain = value
 				case "REPLAYGAIN_ALBUM_PEAK":
 					metadata.ReplayGainAlbumPeak = value
+				default:
+					metadata.TrackIdentifiers.setTag(name, value)
 				}
 			}
 		}
@@ -1229,9 +1275,14 @@ func readM4AFreeformValue(f *os.File, parent atomHeader, fileSize int64) (string
 				}
 			}
 		case "data":
+			// Multi-valued tags (e.g. MusicBrainz artist IDs) carry one data
+			// atom per value.
 			payload, payloadErr := readM4ADataAtomPayload(f, header)
-			if payloadErr == nil {
-				dataValue = strings.TrimSpace(strings.TrimRight(string(payload), "\x00"))
+			if value := strings.TrimSpace(strings.TrimRight(string(payload), "\x00")); payloadErr == nil && value != "" {
+				if dataValue != "" {
+					dataValue += "; "
+				}
+				dataValue += value
 			}
 		}
 
@@ -1303,16 +1354,20 @@ func buildM4AAtom(typ string, payload []byte) []byte {
 	return buf
 }
 
-func buildM4AFreeformAtom(name, value string) []byte {
+// buildM4AFreeformAtom writes one data atom per value, which is how
+// multi-valued freeform tags are stored.
+func buildM4AFreeformAtom(name string, values ...string) []byte {
 	meanPayload := append([]byte{0, 0, 0, 0}, []byte("com.apple.iTunes")...)
 	namePayload := append([]byte{0, 0, 0, 0}, []byte(name)...)
-	dataPayload := make([]byte, 8+len(value))
-	binary.BigEndian.PutUint32(dataPayload[0:4], 1) // UTF-8 text
-	copy(dataPayload[8:], []byte(value))
 
 	payload := append([]byte{}, buildM4AAtom("mean", meanPayload)...)
 	payload = append(payload, buildM4AAtom("name", namePayload)...)
-	payload = append(payload, buildM4AAtom("data", dataPayload)...)
+	for _, value := range values {
+		dataPayload := make([]byte, 8+len(value))
+		binary.BigEndian.PutUint32(dataPayload[0:4], 1) // UTF-8 text
+		copy(dataPayload[8:], []byte(value))
+		payload = append(payload, buildM4AAtom("data", dataPayload)...)
+	}
 	return buildM4AAtom("----", payload)
 }
 
@@ -1417,6 +1472,66 @@ func EditM4AReplayGain(filePath string, fields map[string]string) error {
 		return nil
 	}
 
+	targets := map[string]struct{}{
+		"REPLAYGAIN_TRACK_GAIN": {},
+		"REPLAYGAIN_TRACK_PEAK": {},
+		"REPLAYGAIN_ALBUM_GAIN": {},
+		"REPLAYGAIN_ALBUM_PEAK": {},
+		"ITUNNORM":              {},
+	}
+
+	order := []string{
+		"replaygain_track_gain",
+		"replaygain_track_peak",
+		"replaygain_album_gain",
+		"replaygain_album_peak",
+		"iTunNORM",
+	}
+	var atoms [][]byte
+	for _, key := range order {
+		value := strings.TrimSpace(replayGainFields[key])
+		if value == "" {
+			continue
+		}
+		name := key
+		if key != "iTunNORM" {
+			name = strings.ToLower(key)
+		}
+		atoms = append(atoms, buildM4AFreeformAtom(name, value))
+	}
+
+	return replaceM4AFreeformAtoms(filePath, targets, atoms)
+}
+
+// EditM4AIdentifiers writes the identifier fields present in fields as
+// freeform atoms; present-but-empty fields remove the tag.
+func EditM4AIdentifiers(filePath string, fields map[string]string) error {
+	targets := map[string]struct{}{}
+	var atoms [][]byte
+	for _, tag := range identifierTags {
+		value, ok := fields[tag.field]
+		if !ok {
+			continue
+		}
+		targets[strings.ToUpper(tag.freeform)] = struct{}{}
+		values := []string{strings.TrimSpace(value)}
+		if tag.multi {
+			values = splitIdentifierValues(value)
+		}
+		if len(values) > 0 && values[0] != "" {
+			atoms = append(atoms, buildM4AFreeformAtom(tag.freeform, values...))
+		}
+	}
+	if len(targets) == 0 {
+		return nil
+	}
+
+	return replaceM4AFreeformAtoms(filePath, targets, atoms)
+}
+
+// replaceM4AFreeformAtoms drops the freeform atoms whose upper-cased names
+// are in targets and appends atoms to ilst, fixing up the parent sizes.
+func replaceM4AFreeformAtoms(filePath string, targets map[string]struct{}, atoms [][]byte) error {
 	f, err := os.Open(filePath)
 	if err != nil {
 		return err
@@ -1441,13 +1556,6 @@ func EditM4AReplayGain(filePath string, fields map[string]string) error {
 	bodyStart := path.ilst.offset + path.ilst.headerSize
 	bodyEnd := path.ilst.offset + path.ilst.size
 	newBody := make([]byte, 0, int(path.ilst.size))
-	targets := map[string]struct{}{
-		"REPLAYGAIN_TRACK_GAIN": {},
-		"REPLAYGAIN_TRACK_PEAK": {},
-		"REPLAYGAIN_ALBUM_GAIN": {},
-		"REPLAYGAIN_ALBUM_PEAK": {},
-		"ITUNNORM":              {},
-	}
 
 	for pos := bodyStart; pos+8 <= bodyEnd; {
 		header, readErr := readAtomHeaderAt(f, pos, info.Size())
@@ -1477,23 +1585,8 @@ func EditM4AReplayGain(filePath string, fields map[string]string) error {
 		pos += header.size
 	}
 
-	order := []string{
-		"replaygain_track_gain",
-		"replaygain_track_peak",
-		"replaygain_album_gain",
-		"replaygain_album_peak",
-		"iTunNORM",
-	}
-	for _, key := range order {
-		value := strings.TrimSpace(replayGainFields[key])
-		if value == "" {
-			continue
-		}
-		name := key
-		if key != "iTunNORM" {
-			name = strings.ToLower(key)
-		}
-		newBody = append(newBody, buildM4AFreeformAtom(name, value)...)
+	for _, atom := range atoms {
+		newBody = append(newBody, atom...)
 	}
 
 	newIlst := buildM4AAtom("ilst", newBody)
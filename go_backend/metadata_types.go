@@ -30,6 +30,8 @@ type TrackMetadata struct {
 	ArtistID    string `json:"artist_id,omitempty"`
 	AlbumType   string `json:"album_type,omitempty"`
 	Composer    string `json:"composer,omitempty"`
+
+	ReleaseIdentifiers
 }
 
 type AlbumTrackMetadata struct {
@@ -0,0 +1,589 @@
+package gobackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	musicBrainzAPITimeout = 15 * time.Second
+	musicBrainzCacheTTL   = 30 * time.Minute
+	musicBrainzMaxRetries = 2
+)
+
+// MusicBrainz allows one request per second per client.
+var musicBrainzRateLimiter = NewRateLimiter(1, time.Second)
+
+// ReleaseIdentifiers carries MusicBrainz IDs and release details that are
+// written as tags. Multiple artist IDs are separated by "; ".
+type ReleaseIdentifiers struct {
+	MusicBrainzRecordingID    string `json:"musicbrainz_recording_id,omitempty"`
+	MusicBrainzReleaseID      string `json:"musicbrainz_release_id,omitempty"`
+	MusicBrainzReleaseGroupID string `json:"musicbrainz_release_group_id,omitempty"`
+	MusicBrainzArtistID       string `json:"musicbrainz_artist_id,omitempty"`
+	MusicBrainzAlbumArtistID  string `json:"musicbrainz_album_artist_id,omitempty"`
+	MusicBrainzTrackID        string `json:"musicbrainz_track_id,omitempty"`
+	OriginalDate              string `json:"original_date,omitempty"`
+	Barcode                   string `json:"barcode,omitempty"`
+	CatalogNumber             string `json:"catalog_number,omitempty"`
+	Media                     string `json:"media,omitempty"`
+	ReleaseCountry            string `json:"release_country,omitempty"`
+}
+
+func (r ReleaseIdentifiers) IsEmpty() bool {
+	return r == ReleaseIdentifiers{}
+}
+
+// fields returns the non-empty identifiers keyed by their JSON names.
+func (r ReleaseIdentifiers) fields() map[string]string {
+	fields := make(map[string]string)
+	raw, _ := json.Marshal(r)
+	_ = json.Unmarshal(raw, &fields)
+	return fields
+}
+
+// mergeMissing fills empty fields of r from other.
+func (r *ReleaseIdentifiers) mergeMissing(other ReleaseIdentifiers) {
+	fill := func(dst *string, src string) {
+		if *dst == "" {
+			*dst = src
+		}
+	}
+	fill(&r.MusicBrainzRecordingID, other.MusicBrainzRecordingID)
+	fill(&r.MusicBrainzReleaseID, other.MusicBrainzReleaseID)
+	fill(&r.MusicBrainzReleaseGroupID, other.MusicBrainzReleaseGroupID)
+	fill(&r.MusicBrainzArtistID, other.MusicBrainzArtistID)
+	fill(&r.MusicBrainzAlbumArtistID, other.MusicBrainzAlbumArtistID)
+	fill(&r.MusicBrainzTrackID, other.MusicBrainzTrackID)
+	fill(&r.OriginalDate, other.OriginalDate)
+	fill(&r.Barcode, other.Barcode)
+	fill(&r.CatalogNumber, other.CatalogNumber)
+	fill(&r.Media, other.Media)
+	fill(&r.ReleaseCountry, other.ReleaseCountry)
+}
+
+type musicBrainzArtistCredit struct {
+	Name       string `json:"name"`
+	JoinPhrase string `json:"joinphrase"`
+	Artist     struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"artist"`
+}
+
+type musicBrainzTrack struct {
+	ID        string `json:"id"`
+	Number    string `json:"number"`
+	Position  int    `json:"position"`
+	Title     string `json:"title"`
+	Length    int    `json:"length"`
+	Recording struct {
+		ID    string   `json:"id"`
+		ISRCs []string `json:"isrcs"`
+	} `json:"recording"`
+	ArtistCredit []musicBrainzArtistCredit `json:"artist-credit"`
+}
+
+type musicBrainzMedium struct {
+	Position   int                `json:"position"`
+	Format     string             `json:"format"`
+	TrackCount int                `json:"track-count"`
+	Tracks     []musicBrainzTrack `json:"tracks"`
+	// Search results use "track" instead of "tracks".
+	Track []musicBrainzTrack `json:"track"`
+}
+
+func (m musicBrainzMedium) allTracks() []musicBrainzTrack {
+	if len(m.Tracks) > 0 {
+		return m.Tracks
+	}
+	return m.Track
+}
+
+type musicBrainzRelease struct {
+	ID           string                    `json:"id"`
+	Title        string                    `json:"title"`
+	Status       string                    `json:"status"`
+	Date         string                    `json:"date"`
+	Country      string                    `json:"country"`
+	Barcode      string                    `json:"barcode"`
+	TrackCount   int                       `json:"track-count"`
+	ArtistCredit []musicBrainzArtistCredit `json:"artist-credit"`
+	ReleaseGroup struct {
+		ID               string `json:"id"`
+		PrimaryType      string `json:"primary-type"`
+		FirstReleaseDate string `json:"first-release-date"`
+	} `json:"release-group"`
+	LabelInfo []struct {
+		CatalogNumber string `json:"catalog-number"`
+		Label         *struct {
+			Name string `json:"name"`
+		} `json:"label"`
+	} `json:"label-info"`
+	Media           []musicBrainzMedium `json:"media"`
+	CoverArtArchive struct {
+		Front bool `json:"front"`
+	} `json:"cover-art-archive"`
+}
+
+type musicBrainzRecording struct {
+	ID           string                    `json:"id"`
+	Title        string                    `json:"title"`
+	Length       int                       `json:"length"`
+	ISRCs        []string                  `json:"isrcs"`
+	ArtistCredit []musicBrainzArtistCredit `json:"artist-credit"`
+	Releases     []musicBrainzRelease      `json:"releases"`
+	Tags         []musicBrainzTag          `json:"tags"`
+}
+
+type musicBrainzRecordingSearchResponse struct {
+	Recordings []musicBrainzRecording `json:"recordings"`
+}
+
+type MusicBrainzClient struct {
+	httpClient  *http.Client
+	baseURL     string
+	rateLimiter *RateLimiter
+	cache       map[string]*cacheEntry
+	cacheMu     sync.Mutex
+}
+
+var (
+	musicBrainzClient     *MusicBrainzClient
+	musicBrainzClientOnce sync.Once
+)
+
+func GetMusicBrainzClient() *MusicBrainzClient {
+	musicBrainzClientOnce.Do(func() {
+		musicBrainzClient = &MusicBrainzClient{
+			httpClient:  NewMetadataHTTPClient(musicBrainzAPITimeout),
+			baseURL:     musicBrainzAPIBase,
+			rateLimiter: musicBrainzRateLimiter,
+			cache:       make(map[string]*cacheEntry),
+		}
+	})
+	return musicBrainzClient
+}
+
+func formatMusicBrainzArtistCredit(credits []musicBrainzArtistCredit) (string, string) {
+	var name strings.Builder
+	ids := make([]string, 0, len(credits))
+	for _, credit := range credits {
+		creditName := credit.Name
+		if creditName == "" {
+			creditName = credit.Artist.Name
+		}
+		name.WriteString(creditName)
+		name.WriteString(credit.JoinPhrase)
+		if credit.Artist.ID != "" {
+			ids = append(ids, credit.Artist.ID)
+		}
+	}
+	return strings.TrimSpace(name.String()), strings.Join(ids, "; ")
+}
+
+// musicBrainzMatchHints describes the track being tagged. An ISRC is often
+// shared by several recordings and releases, so the hints decide which one
+// is meant. Zero values are ignored.
+type musicBrainzMatchHints struct {
+	AlbumName   string
+	UPC         string
+	TotalTracks int
+	DurationMS  int
+}
+
+func (h musicBrainzMatchHints) cacheKey() string {
+	return fmt.Sprintf("%s|%s|%d|%d", normalizeLooseTitle(h.AlbumName), normalizeMusicBrainzBarcode(h.UPC), h.TotalTracks, h.DurationMS/1000)
+}
+
+func normalizeMusicBrainzBarcode(barcode string) string {
+	digits := strings.Map(func(r rune) rune {
+		if r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, barcode)
+	// A UPC-A is an EAN-13 with a leading zero.
+	return strings.TrimLeft(digits, "0")
+}
+
+func (r musicBrainzRelease) trackCount() int {
+	if r.TrackCount > 0 {
+		return r.TrackCount
+	}
+	total := 0
+	for _, medium := range r.Media {
+		total += medium.TrackCount
+	}
+	return total
+}
+
+// scoreMusicBrainzRelease ranks a release against the hints. A barcode match
+// outweighs everything else; album title and track count come next, then
+// official album releases.
+func scoreMusicBrainzRelease(r musicBrainzRelease, hints musicBrainzMatchHints) int {
+	score := 0
+	if upc := normalizeMusicBrainzBarcode(hints.UPC); upc != "" && upc == normalizeMusicBrainzBarcode(r.Barcode) {
+		score += 100
+	}
+	if album := normalizeLooseTitle(hints.AlbumName); album != "" {
+		title := normalizeLooseTitle(r.Title)
+		switch {
+		case title == album:
+			score += 20
+		case title != "" && (strings.Contains(title, album) || strings.Contains(album, title)):
+			score += 10
+		}
+	}
+	if hints.TotalTracks > 0 {
+		if count := r.trackCount(); count == hints.TotalTracks {
+			score += 5
+		} else {
+			for _, medium := range r.Media {
+				if medium.TrackCount == hints.TotalTracks {
+					score += 5
+					break
+				}
+			}
+		}
+	}
+	if strings.EqualFold(r.Status, "official") {
+		score += 2
+	}
+	if strings.EqualFold(r.ReleaseGroup.PrimaryType, "album") {
+		score++
+	}
+	return score
+}
+
+// selectMusicBrainzRelease picks the release that best fits the hints,
+// falling back to official album releases and then the earliest dated one,
+// so tags point at the edition being tagged or else the canonical one.
+func selectMusicBrainzRelease(releases []musicBrainzRelease, hints musicBrainzMatchHints) *musicBrainzRelease {
+	if len(releases) == 0 {
+		return nil
+	}
+	sorted := append([]musicBrainzRelease(nil), releases...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if si, sj := scoreMusicBrainzRelease(sorted[i], hints), scoreMusicBrainzRelease(sorted[j], hints); si != sj {
+			return si > sj
+		}
+		di, dj := sorted[i].Date, sorted[j].Date
+		if di == "" || dj == "" {
+			return di != ""
+		}
+		return di < dj
+	})
+	return &sorted[0]
+}
+
+// scoreMusicBrainzRecording ranks a recording by how close its length is to
+// the hinted duration plus the score of its best release.
+func scoreMusicBrainzRecording(recording musicBrainzRecording, hints musicBrainzMatchHints) int {
+	score := 0
+	if hints.DurationMS > 0 && recording.Length > 0 {
+		diff := recording.Length - hints.DurationMS
+		if diff < 0 {
+			diff = -diff
+		}
+		switch {
+		case diff <= 2000:
+			score += 8
+		case diff <= 5000:
+			score += 4
+		case diff > 15000:
+			score -= 8
+		}
+	}
+	if release := selectMusicBrainzRelease(recording.Releases, hints); release != nil {
+		score += scoreMusicBrainzRelease(*release, hints)
+	}
+	return score
+}
+
+// selectMusicBrainzRecording picks the best scoring recording, keeping the
+// search order on ties.
+func selectMusicBrainzRecording(recordings []musicBrainzRecording, hints musicBrainzMatchHints) musicBrainzRecording {
+	best, bestScore := 0, scoreMusicBrainzRecording(recordings[0], hints)
+	for i := 1; i < len(recordings); i++ {
+		if score := scoreMusicBrainzRecording(recordings[i], hints); score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return recordings[best]
+}
+
+// findMusicBrainzTrack locates the recording inside a release's media.
+func findMusicBrainzTrack(release *musicBrainzRelease, recordingID string) (*musicBrainzMedium, *musicBrainzTrack) {
+	for i := range release.Media {
+		medium := &release.Media[i]
+		tracks := medium.allTracks()
+		for j := range tracks {
+			if tracks[j].Recording.ID == recordingID || (tracks[j].Recording.ID == "" && len(release.Media) == 1 && len(tracks) == 1) {
+				return medium, &tracks[j]
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (c *MusicBrainzClient) buildTrackMetadata(recording musicBrainzRecording, release *musicBrainzRelease) TrackMetadata {
+	artists, artistIDs := formatMusicBrainzArtistCredit(recording.ArtistCredit)
+	track := TrackMetadata{
+		SpotifyID:  "musicbrainz:" + recording.ID,
+		Name:       recording.Title,
+		Artists:    artists,
+		DurationMS: recording.Length,
+	}
+	track.MusicBrainzRecordingID = recording.ID
+	track.MusicBrainzArtistID = artistIDs
+	if len(recording.ISRCs) > 0 {
+		track.ISRC = recording.ISRCs[0]
+	}
+	if release == nil {
+		return track
+	}
+
+	albumArtist, albumArtistIDs := formatMusicBrainzArtistCredit(release.ArtistCredit)
+	track.AlbumName = release.Title
+	track.AlbumArtist = albumArtist
+	track.AlbumID = release.ID
+	track.AlbumType = strings.ToLower(release.ReleaseGroup.PrimaryType)
+	track.ReleaseDate = release.Date
+	track.TotalDiscs = len(release.Media)
+	track.MusicBrainzReleaseID = release.ID
+	track.MusicBrainzReleaseGroupID = release.ReleaseGroup.ID
+	track.MusicBrainzAlbumArtistID = albumArtistIDs
+	track.OriginalDate = release.ReleaseGroup.FirstReleaseDate
+	track.Barcode = release.Barcode
+	track.ReleaseCountry = release.Country
+	for _, info := range release.LabelInfo {
+		if info.CatalogNumber != "" && track.CatalogNumber == "" {
+			track.CatalogNumber = info.CatalogNumber
+		}
+	}
+	if release.CoverArtArchive.Front {
+		track.Images = "https://coverartarchive.org/release/" + release.ID + "/front-1200"
+	}
+
+	if medium, mbTrack := findMusicBrainzTrack(release, recording.ID); medium != nil {
+		track.DiscNumber = medium.Position
+		track.TotalTracks = medium.TrackCount
+		track.Media = medium.Format
+		track.MusicBrainzTrackID = mbTrack.ID
+		track.TrackNumber = mbTrack.Position
+		if track.TrackNumber == 0 {
+			track.TrackNumber, _ = strconv.Atoi(mbTrack.Number)
+		}
+	}
+	return track
+}
+
+// LookupByISRC resolves the recording and release that best fit hints, then
+// fetches the release for barcode, catalog number, label, media and album
+// artist data.
+func (c *MusicBrainzClient) LookupByISRC(ctx context.Context, isrc string, hints musicBrainzMatchHints) (*TrackMetadata, error) {
+	normalizedISRC := strings.ToUpper(strings.TrimSpace(isrc))
+	if normalizedISRC == "" {
+		return nil, fmt.Errorf("no ISRC provided")
+	}
+
+	cacheKey := "isrc:" + normalizedISRC + "|" + hints.cacheKey()
+	c.cacheMu.Lock()
+	if entry, ok := c.cache[cacheKey]; ok && !entry.isExpired() {
+		c.cacheMu.Unlock()
+		track := entry.data.(TrackMetadata)
+		return &track, nil
+	}
+	c.cacheMu.Unlock()
+
+	var search musicBrainzRecordingSearchResponse
+	endpoint := fmt.Sprintf("%s/recording?query=%s&fmt=json&limit=5", c.baseURL, url.QueryEscape("isrc:"+normalizedISRC))
+	if err := c.getJSON(ctx, endpoint, &search); err != nil {
+		return nil, err
+	}
+	if len(search.Recordings) == 0 {
+		return nil, fmt.Errorf("no recordings found for ISRC: %s", normalizedISRC)
+	}
+
+	recording := selectMusicBrainzRecording(search.Recordings, hints)
+	if len(recording.ISRCs) == 0 {
+		recording.ISRCs = []string{normalizedISRC}
+	}
+	selected := selectMusicBrainzRelease(recording.Releases, hints)
+	if selected != nil {
+		var release musicBrainzRelease
+		endpoint := fmt.Sprintf("%s/release/%s?inc=artist-credits+labels+recordings+release-groups&fmt=json", c.baseURL, url.PathEscape(selected.ID))
+		if err := c.getJSON(ctx, endpoint, &release); err != nil {
+			GoLog("[MusicBrainz] Release lookup failed for %s: %v\n", selected.ID, err)
+		} else {
+			selected = &release
+		}
+	}
+
+	track := c.buildTrackMetadata(recording, selected)
+	c.cacheMu.Lock()
+	c.cache[cacheKey] = &cacheEntry{data: track, expiresAt: time.Now().Add(musicBrainzCacheTTL)}
+	c.cacheMu.Unlock()
+
+	GoLog("[MusicBrainz] ISRC %s -> recording %s, release %s\n", normalizedISRC, track.MusicBrainzRecordingID, track.MusicBrainzReleaseID)
+	return &track, nil
+}
+
+// GetRelease returns every track of a release, in medium order.
+func (c *MusicBrainzClient) GetRelease(ctx context.Context, releaseID string) ([]TrackMetadata, error) {
+	releaseID = strings.TrimSpace(releaseID)
+	if releaseID == "" {
+		return nil, fmt.Errorf("no release ID provided")
+	}
+
+	var release musicBrainzRelease
+	endpoint := fmt.Sprintf("%s/release/%s?inc=artist-credits+labels+recordings+release-groups+isrcs&fmt=json", c.baseURL, url.PathEscape(releaseID))
+	if err := c.getJSON(ctx, endpoint, &release); err != nil {
+		return nil, err
+	}
+
+	var tracks []TrackMetadata
+	for _, medium := range release.Media {
+		for _, mbTrack := range medium.allTracks() {
+			recording := musicBrainzRecording{
+				ID:           mbTrack.Recording.ID,
+				Title:        mbTrack.Title,
+				Length:       mbTrack.Length,
+				ISRCs:        mbTrack.Recording.ISRCs,
+				ArtistCredit: mbTrack.ArtistCredit,
+			}
+			if len(recording.ArtistCredit) == 0 {
+				recording.ArtistCredit = release.ArtistCredit
+			}
+			tracks = append(tracks, c.buildTrackMetadata(recording, &release))
+		}
+	}
+	return tracks, nil
+}
+
+func (c *MusicBrainzClient) SearchTracks(query string, limit int) ([]ExtTrackMetadata, error) {
+	if limit <= 0 {
+		limit = 10
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), musicBrainzAPITimeout)
+	defer cancel()
+
+	var search musicBrainzRecordingSearchResponse
+	endpoint := fmt.Sprintf("%s/recording?query=%s&fmt=json&limit=%d", c.baseURL, url.QueryEscape(query), limit)
+	if err := c.getJSON(ctx, endpoint, &search); err != nil {
+		return nil, err
+	}
+
+	tracks := make([]ExtTrackMetadata, 0, len(search.Recordings))
+	for _, recording := range search.Recordings {
+		track := c.buildTrackMetadata(recording, selectMusicBrainzRelease(recording.Releases, musicBrainzMatchHints{}))
+		tracks = append(tracks, normalizeBuiltInMetadataTrack(track, "musicbrainz"))
+	}
+	return tracks, nil
+}
+
+func (c *MusicBrainzClient) getJSON(ctx context.Context, endpoint string, dst interface{}) error {
+	var lastErr error
+	for attempt := 0; attempt <= musicBrainzMaxRetries; attempt++ {
+		if err := c.rateLimiter.Wait(ctx); err != nil {
+			return err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("User-Agent", getRandomUserAgent())
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusTooManyRequests {
+			lastErr = fmt.Errorf("MusicBrainz API returned status: %d", resp.StatusCode)
+			if wait := getRetryAfterDuration(resp); wait > 0 {
+				if err := sleepWithContext(ctx, wait); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("MusicBrainz API returned status: %d", resp.StatusCode)
+		}
+		return json.Unmarshal(body, dst)
+	}
+	return lastErr
+}
+
+// applyMusicBrainzReleaseIdentifiers fills missing release identifiers on a
+// download request when MusicBrainz is in the metadata provider priority.
+func applyMusicBrainzReleaseIdentifiers(ctx context.Context, req *DownloadRequest) {
+	if req == nil || req.ISRC == "" || req.MusicBrainzRecordingID != "" || !isMetadataProviderEnabled("musicbrainz") {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, musicBrainzAPITimeout)
+	defer cancel()
+
+	track, err := GetMusicBrainzClient().LookupByISRC(ctx, req.ISRC, musicBrainzMatchHints{
+		AlbumName:   req.AlbumName,
+		UPC:         req.UPC,
+		TotalTracks: req.TotalTracks,
+		DurationMS:  req.DurationMS,
+	})
+	if err != nil {
+		GoLog("[MusicBrainz] Lookup failed for ISRC %s: %v\n", req.ISRC, err)
+		return
+	}
+	req.ReleaseIdentifiers.mergeMissing(track.ReleaseIdentifiers)
+}
+
+func isMetadataProviderEnabled(providerID string) bool {
+	for _, id := range GetMetadataProviderPriority() {
+		if id == providerID {
+			return true
+		}
+	}
+	return false
+}
+
+func LookupMusicBrainzByISRC(isrc string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*musicBrainzAPITimeout)
+	defer cancel()
+
+	track, err := GetMusicBrainzClient().LookupByISRC(ctx, isrc, musicBrainzMatchHints{})
+	if err != nil {
+		return "", err
+	}
+	jsonBytes, err := json.Marshal(TrackResponse{Track: *track})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal track: %w", err)
+	}
+	return string(jsonBytes), nil
+}
@@ -0,0 +1,191 @@
+package gobackend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-flac/flacvorbis/v2"
+)
+
+const testMusicBrainzRecording = `{"recordings":[{"id":"rec-1","title":"Song","length":201000,
+	"artist-credit":[{"name":"Alpha","joinphrase":" feat. ","artist":{"id":"art-a","name":"Alpha"}},{"name":"Beta","artist":{"id":"art-b","name":"Beta"}}],
+	"releases":[
+		{"id":"rel-bootleg","title":"Live","status":"Bootleg","date":"1999","release-group":{"id":"rg-2","primary-type":"Album"}},
+		{"id":"rel-1","title":"First","status":"Official","date":"2001-05-01","release-group":{"id":"rg-1","primary-type":"Album"},
+		 "media":[{"position":1,"format":"CD","track-count":10,"track":[{"id":"trk-1","number":"3","title":"Song"}]}]}
+	]}]}`
+
+const testMusicBrainzRelease = `{"id":"rel-1","title":"First","status":"Official","date":"2001-05-01","country":"GB","barcode":"0123456789012",
+	"artist-credit":[{"name":"Alpha","artist":{"id":"art-a","name":"Alpha"}}],
+	"release-group":{"id":"rg-1","primary-type":"Album","first-release-date":"2000-11-20"},
+	"label-info":[{"catalog-number":"CAT-001","label":{"name":"Label"}}],
+	"cover-art-archive":{"front":true},
+	"media":[
+		{"position":1,"format":"CD","track-count":10,"tracks":[{"id":"trk-0","position":1,"recording":{"id":"rec-0"}}]},
+		{"position":2,"format":"CD","track-count":8,"tracks":[{"id":"trk-1","position":3,"recording":{"id":"rec-1"}}]}
+	]}`
+
+func newTestMusicBrainzClient(t *testing.T, requests *[]string) *MusicBrainzClient {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r.URL.Path)
+		switch {
+		case r.URL.Path == "/recording":
+			fmt.Fprint(w, testMusicBrainzRecording)
+		case strings.HasPrefix(r.URL.Path, "/release/rel-1"):
+			if !strings.Contains(r.URL.Query().Get("inc"), "labels") {
+				t.Errorf("release lookup missing labels include: %s", r.URL.RawQuery)
+			}
+			fmt.Fprint(w, testMusicBrainzRelease)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	return &MusicBrainzClient{
+		httpClient:  server.Client(),
+		baseURL:     server.URL,
+		rateLimiter: NewRateLimiter(100, time.Second),
+		cache:       make(map[string]*cacheEntry),
+	}
+}
+
+func TestMusicBrainzLookupByISRCResolvesRelease(t *testing.T) {
+	var requests []string
+	client := newTestMusicBrainzClient(t, &requests)
+
+	track, err := client.LookupByISRC(context.Background(), "gbabc0100001", musicBrainzMatchHints{})
+	if err != nil {
+		t.Fatalf("LookupByISRC failed: %v", err)
+	}
+	if track.Artists != "Alpha feat. Beta" || track.AlbumArtist != "Alpha" {
+		t.Fatalf("unexpected artist credits: %q / %q", track.Artists, track.AlbumArtist)
+	}
+	want := ReleaseIdentifiers{
+		MusicBrainzRecordingID:    "rec-1",
+		MusicBrainzReleaseID:      "rel-1",
+		MusicBrainzReleaseGroupID: "rg-1",
+		MusicBrainzArtistID:       "art-a; art-b",
+		MusicBrainzAlbumArtistID:  "art-a",
+		MusicBrainzTrackID:        "trk-1",
+		OriginalDate:              "2000-11-20",
+		Barcode:                   "0123456789012",
+		CatalogNumber:             "CAT-001",
+		Media:                     "CD",
+		ReleaseCountry:            "GB",
+	}
+	if track.ReleaseIdentifiers != want {
+		t.Fatalf("ReleaseIdentifiers = %+v, want %+v", track.ReleaseIdentifiers, want)
+	}
+	if track.DiscNumber != 2 || track.TrackNumber != 3 || track.TotalTracks != 8 || track.ISRC != "GBABC0100001" {
+		t.Fatalf("unexpected position data: %+v", track)
+	}
+
+	if _, err := client.LookupByISRC(context.Background(), "GBABC0100001", musicBrainzMatchHints{}); err != nil {
+		t.Fatalf("cached lookup failed: %v", err)
+	}
+	if len(requests) != 2 {
+		t.Fatalf("expected cached second lookup, got requests %v", requests)
+	}
+
+	cmt := flacvorbis.New()
+	writeVorbisMetadata(cmt, Metadata{Title: "Song", TrackIdentifiers: TrackIdentifiers{ReleaseIdentifiers: track.ReleaseIdentifiers}})
+	if got := getCommentValues(cmt, "MUSICBRAINZ_ARTISTID"); !slices.Equal(got, []string{"art-a", "art-b"}) {
+		t.Fatalf("MUSICBRAINZ_ARTISTID = %#v", got)
+	}
+	if got := getCommentValues(cmt, "MUSICBRAINZ_TRACKID"); !slices.Equal(got, []string{"rec-1"}) {
+		t.Fatalf("MUSICBRAINZ_TRACKID = %#v", got)
+	}
+	if got := getCommentValues(cmt, "CATALOGNUMBER"); !slices.Equal(got, []string{"CAT-001"}) {
+		t.Fatalf("CATALOGNUMBER = %#v", got)
+	}
+}
+
+func TestMusicBrainzSearchTracksUsesPreferredRelease(t *testing.T) {
+	var requests []string
+	client := newTestMusicBrainzClient(t, &requests)
+
+	tracks, err := client.SearchTracks("song alpha", 5)
+	if err != nil {
+		t.Fatalf("SearchTracks failed: %v", err)
+	}
+	if len(tracks) != 1 {
+		t.Fatalf("expected one track, got %d", len(tracks))
+	}
+	track := tracks[0]
+	if track.ProviderID != "musicbrainz" || track.AlbumName != "First" || track.TrackNumber != 3 || track.MusicBrainzTrackID != "trk-1" {
+		t.Fatalf("unexpected search result: %+v", track)
+	}
+}
+
+func TestMusicBrainzGetJSONStopsOnCancel(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	t.Cleanup(server.Close)
+	client := &MusicBrainzClient{
+		httpClient:  server.Client(),
+		baseURL:     server.URL,
+		rateLimiter: NewRateLimiter(100, time.Second),
+		cache:       make(map[string]*cacheEntry),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	var dst map[string]any
+	err := client.getJSON(ctx, server.URL+"/recording", &dst)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Retry-After sleep ignored the context, took %v", elapsed)
+	}
+	if calls != 1 {
+		t.Fatalf("expected no retry after cancel, got %d calls", calls)
+	}
+}
+
+func TestSelectMusicBrainzRecordingUsesHints(t *testing.T) {
+	album := func(id, title, date, barcode string, tracks int) musicBrainzRelease {
+		r := musicBrainzRelease{ID: id, Title: title, Status: "Official", Date: date, Barcode: barcode, TrackCount: tracks}
+		r.ReleaseGroup.PrimaryType = "Album"
+		return r
+	}
+	recordings := []musicBrainzRecording{
+		{ID: "rec-live", Length: 245000, Releases: []musicBrainzRelease{album("rel-hits", "Greatest Hits", "1998", "", 20)}},
+		{ID: "rec-studio", Length: 201000, Releases: []musicBrainzRelease{
+			album("rel-first", "First", "2001-05-01", "0123456789012", 10),
+			album("rel-deluxe", "First (Deluxe Edition)", "2011", "0600000000001", 14),
+		}},
+	}
+
+	cases := []struct {
+		name      string
+		hints     musicBrainzMatchHints
+		recording string
+		release   string
+	}{
+		{"no hints", musicBrainzMatchHints{}, "rec-live", "rel-hits"},
+		{"upc", musicBrainzMatchHints{UPC: "600000000001"}, "rec-studio", "rel-deluxe"},
+		{"album and tracks", musicBrainzMatchHints{AlbumName: "First", TotalTracks: 10, DurationMS: 200500}, "rec-studio", "rel-first"},
+		{"duration", musicBrainzMatchHints{AlbumName: "Greatest Hits", DurationMS: 244000}, "rec-live", "rel-hits"},
+	}
+	for _, tc := range cases {
+		recording := selectMusicBrainzRecording(recordings, tc.hints)
+		release := selectMusicBrainzRelease(recording.Releases, tc.hints)
+		if recording.ID != tc.recording || release.ID != tc.release {
+			t.Fatalf("%s: got %s / %s, want %s / %s", tc.name, recording.ID, release.ID, tc.recording, tc.release)
+		}
+	}
+}
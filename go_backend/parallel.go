@@ -1,6 +1,7 @@
 package gobackend
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"sync"
@@ -127,9 +128,11 @@ type ParallelDownloadResult struct {
 }
 
 func FetchCoverAndLyricsParallel(
+	ctx context.Context,
 	coverURL string,
 	maxQualityCover bool,
 	spotifyID string,
+	isrc string,
 	trackName string,
 	artistName string,
 	embedLyrics bool,
@@ -143,7 +146,9 @@ func FetchCoverAndLyricsParallel(
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			data, err := downloadCoverToMemory(coverURL, maxQualityCover)
+			span := startTraceSpanContext(ctx, "cover", "")
+			data, err := downloadCoverToMemory(ctx, coverURL, maxQualityCover)
+			span.set("bytes", len(data)).end(err)
 			resultMu.Lock()
 			if err != nil {
 				result.CoverErr = err
@@ -160,7 +165,12 @@ func FetchCoverAndLyricsParallel(
 			defer wg.Done()
 			client := NewLyricsClient()
 			durationSec := float64(durationMs) / 1000.0
-			lyrics, err := client.FetchLyricsAllSources(spotifyID, trackName, artistName, durationSec)
+			span := startTraceSpanContext(ctx, "lyrics", "")
+			lyrics, err := client.FetchLyricsAllSourcesContext(ctx, spotifyID, isrc, trackName, artistName, durationSec)
+			if lyrics != nil {
+				span.set("source", lyrics.Source).set("lines", len(lyrics.Lines))
+			}
+			span.end(err)
 			resultMu.Lock()
 			if err != nil {
 				result.LyricsErr = err
@@ -218,6 +218,7 @@ type ItemProgressWriter struct {
 	startTime    time.Time
 	lastTime     time.Time
 	lastBytes    int64
+	limiter      byteRateLimiter
 }
 
 const progressUpdateThreshold = 64 * 1024
@@ -239,6 +240,9 @@ func (pw *ItemProgressWriter) Write(p []byte) (int, error) {
 	if pw.itemID != "" && isDownloadCancelled(pw.itemID) {
 		return 0, ErrDownloadCancelled
 	}
+	if err := throttleDownload(pw.itemID, &pw.limiter, len(p)); err != nil {
+		return 0, err
+	}
 	n, err := pw.writer.Write(p)
 	if err != nil {
 		return n, err
@@ -0,0 +1,339 @@
+package gobackend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half_open"
+
+	circuitFailureThreshold = 5
+	circuitBaseCooldown     = 30 * time.Second
+	circuitMaxCooldown      = 10 * time.Minute
+
+	healthLatencyAlpha = 0.3
+
+	// Mirrors are raced in waves; the next wave starts when the current one
+	// has failed or has not answered within the hedge delay.
+	mirrorRaceWaveSize   = 3
+	mirrorRaceHedgeDelay = 4 * time.Second
+)
+
+// endpointHealth tracks one mirror of a provider.
+type endpointHealth struct {
+	Provider            string  `json:"provider"`
+	Endpoint            string  `json:"endpoint"`
+	State               string  `json:"state"`
+	Successes           int     `json:"successes"`
+	Failures            int     `json:"failures"`
+	ConsecutiveFailures int     `json:"consecutive_failures"`
+	LatencyMs           float64 `json:"latency_ms"`
+	SuccessRate         float64 `json:"success_rate"`
+	Score               float64 `json:"score"`
+	LastError           string  `json:"last_error,omitempty"`
+	LastSuccessAt       int64   `json:"last_success_at,omitempty"`
+	LastFailureAt       int64   `json:"last_failure_at,omitempty"`
+	OpenForMs           int64   `json:"open_for_ms,omitempty"`
+
+	openUntil     time.Time
+	cooldown      time.Duration
+	probeInFlight bool
+}
+
+func (h *endpointHealth) successRate() float64 {
+	// Laplace smoothing keeps new mirrors at 0.5 instead of 0 or 1.
+	return float64(h.Successes+1) / float64(h.Successes+h.Failures+2)
+}
+
+func (h *endpointHealth) score() float64 {
+	latency := h.LatencyMs
+	if latency == 0 {
+		latency = 2000
+	}
+	return h.successRate() / (1 + latency/5000) / float64(1+h.ConsecutiveFailures)
+}
+
+type providerHealthRegistry struct {
+	mu        sync.Mutex
+	endpoints map[string]*endpointHealth
+}
+
+var providerHealth = newProviderHealthRegistry()
+
+func newProviderHealthRegistry() *providerHealthRegistry {
+	return &providerHealthRegistry{endpoints: make(map[string]*endpointHealth)}
+}
+
+func (r *providerHealthRegistry) entry(provider, endpoint string) *endpointHealth {
+	key := provider + "|" + endpoint
+	h, ok := r.endpoints[key]
+	if !ok {
+		h = &endpointHealth{Provider: provider, Endpoint: endpoint, State: circuitClosed}
+		r.endpoints[key] = h
+	}
+	return h
+}
+
+// allow reports whether a request may go to the endpoint. An open circuit
+// turns half-open after its cooldown and lets exactly one probe through.
+func (r *providerHealthRegistry) allow(provider, endpoint string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := r.entry(provider, endpoint)
+	switch h.State {
+	case circuitOpen:
+		if time.Now().Before(h.openUntil) {
+			return false
+		}
+		h.State = circuitHalfOpen
+		h.probeInFlight = true
+		return true
+	case circuitHalfOpen:
+		if h.probeInFlight {
+			return false
+		}
+		h.probeInFlight = true
+		return true
+	}
+	return true
+}
+
+// record updates the endpoint after a request. Neutral outcomes (the mirror
+// answered but lacks the track) only update latency; cancelled requests say
+// nothing about the mirror and are not recorded at all.
+func (r *providerHealthRegistry) record(provider, endpoint string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	h := r.entry(provider, endpoint)
+	if errors.Is(err, context.Canceled) || errors.Is(err, ErrDownloadCancelled) {
+		h.probeInFlight = false
+		return
+	}
+	now := time.Now()
+	ms := float64(latency.Milliseconds())
+	if h.LatencyMs == 0 {
+		h.LatencyMs = ms
+	} else {
+		h.LatencyMs = healthLatencyAlpha*ms + (1-healthLatencyAlpha)*h.LatencyMs
+	}
+	h.probeInFlight = false
+
+	if err == nil || isNeutralMirrorError(err) {
+		if err == nil {
+			h.Successes++
+			h.LastSuccessAt = now.UnixMilli()
+		}
+		h.ConsecutiveFailures = 0
+		h.cooldown = 0
+		h.State = circuitClosed
+		h.openUntil = time.Time{}
+		return
+	}
+
+	h.Failures++
+	h.ConsecutiveFailures++
+	h.LastFailureAt = now.UnixMilli()
+	h.LastError = err.Error()
+	if h.State == circuitHalfOpen || h.ConsecutiveFailures >= circuitFailureThreshold {
+		if h.cooldown == 0 {
+			h.cooldown = circuitBaseCooldown
+		} else {
+			h.cooldown = min(h.cooldown*2, circuitMaxCooldown)
+		}
+		h.State = circuitOpen
+		h.openUntil = now.Add(h.cooldown)
+		GoLog("[Health] %s mirror %s circuit open for %v after %d failures: %s\n",
+			provider, endpoint, h.cooldown, h.ConsecutiveFailures, h.LastError)
+	}
+}
+
+// isNeutralMirrorError matches answers that say nothing about the mirror's
+// health, such as a 404 for a track it does not carry.
+func isNeutralMirrorError(err error) bool {
+	return errors.Is(err, ErrTrackNotFound) ||
+		errors.Is(err, ErrRegionLocked) ||
+		errors.Is(err, ErrQualityUnavailable)
+}
+
+// mirrorStatusError types a mirror's non-OK status so that a track the
+// mirror cannot serve is told apart from a broken mirror. A 403 usually
+// means the mirror is banned or its credentials expired, so it counts
+// against the mirror like any other error.
+func mirrorStatusError(provider string, status int) error {
+	err := fmt.Errorf("HTTP %d", status)
+	switch status {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusGone:
+		return newDownloadError(provider, ErrTrackNotFound, err)
+	}
+	return err
+}
+
+// release hands back a half-open probe slot that was reserved but not used.
+func (r *providerHealthRegistry) release(provider, endpoint string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if h := r.entry(provider, endpoint); h.State == circuitHalfOpen {
+		h.probeInFlight = false
+	}
+}
+
+// orderByHealth returns the endpoints the circuit breaker allows, best score
+// first, and those it skipped. Ties keep the configured order.
+func (r *providerHealthRegistry) orderByHealth(provider string, endpoints []string) ([]string, []string) {
+	var allowed, skipped []string
+	for _, endpoint := range endpoints {
+		if r.allow(provider, endpoint) {
+			allowed = append(allowed, endpoint)
+		} else {
+			skipped = append(skipped, endpoint)
+		}
+	}
+
+	r.mu.Lock()
+	scores := make(map[string]float64, len(allowed))
+	for _, endpoint := range allowed {
+		scores[endpoint] = r.entry(provider, endpoint).score()
+	}
+	r.mu.Unlock()
+
+	sort.SliceStable(allowed, func(i, j int) bool {
+		return scores[allowed[i]] > scores[allowed[j]]
+	})
+	return allowed, skipped
+}
+
+// raceMirrors calls fetch for the endpoints in health order, a wave at a
+// time, and returns the index (into endpoints) of the first success. fetch
+// must only write state belonging to its own index and must honour the
+// context it is given, which is cancelled as soon as a winner is chosen. No
+// further waves are launched once ctx is done, and every launched fetch has
+// returned and been recorded before raceMirrors does.
+func (r *providerHealthRegistry) raceMirrors(ctx context.Context, provider string, endpoints []string, fetch func(ctx context.Context, index int) error) (int, error) {
+	ordered, skipped := r.orderByHealth(provider, endpoints)
+	if len(ordered) == 0 {
+		GoLog("[Health] All %d %s mirrors have open circuits, trying them anyway\n", len(endpoints), provider)
+		ordered = skipped
+		skipped = nil
+	} else if len(skipped) > 0 {
+		GoLog("[Health] Skipping %d %s mirrors with open circuits\n", len(skipped), provider)
+	}
+
+	indexOf := make(map[string]int, len(endpoints))
+	for i, endpoint := range endpoints {
+		indexOf[endpoint] = i
+	}
+
+	type mirrorResult struct {
+		index int
+		err   error
+	}
+	raceCtx, cancelRace := context.WithCancel(ctx)
+	results := make(chan mirrorResult, len(ordered))
+	var fetches sync.WaitGroup
+	launched, pending := 0, 0
+	launchWave := func() {
+		for end := min(launched+mirrorRaceWaveSize, len(ordered)); launched < end; launched++ {
+			endpoint := ordered[launched]
+			pending++
+			fetches.Add(1)
+			go func(index int) {
+				defer fetches.Done()
+				start := time.Now()
+				err := fetch(raceCtx, index)
+				r.record(provider, endpoint, time.Since(start), err)
+				results <- mirrorResult{index: index, err: err}
+			}(indexOf[endpoint])
+		}
+	}
+
+	defer func() {
+		cancelRace()
+		fetches.Wait()
+		for _, endpoint := range ordered[launched:] {
+			r.release(provider, endpoint)
+		}
+	}()
+
+	var errs []string
+	launchWave()
+	hedge := time.NewTimer(mirrorRaceHedgeDelay)
+	defer hedge.Stop()
+	for pending > 0 {
+		select {
+		case result := <-results:
+			pending--
+			if result.err == nil {
+				return result.index, nil
+			}
+			errMsg := result.err.Error()
+			if len(errMsg) > 50 {
+				errMsg = errMsg[:50] + "..."
+			}
+			errs = append(errs, fmt.Sprintf("%s: %s", endpoints[result.index], errMsg))
+			if pending == 0 && launched < len(ordered) && ctx.Err() == nil {
+				launchWave()
+				hedge.Reset(mirrorRaceHedgeDelay)
+			}
+		case <-hedge.C:
+			if launched < len(ordered) && ctx.Err() == nil {
+				launchWave()
+				hedge.Reset(mirrorRaceHedgeDelay)
+			}
+		case <-ctx.Done():
+			return -1, ctx.Err()
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return -1, err
+	}
+	for _, endpoint := range skipped {
+		errs = append(errs, fmt.Sprintf("%s: circuit open", endpoint))
+	}
+	return -1, fmt.Errorf("all %d %s APIs failed. Errors: %v", len(endpoints), provider, errs)
+}
+
+func (r *providerHealthRegistry) snapshot() []endpointHealth {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := time.Now()
+	list := make([]endpointHealth, 0, len(r.endpoints))
+	for _, h := range r.endpoints {
+		entry := *h
+		if entry.State == circuitOpen {
+			if now.Before(entry.openUntil) {
+				entry.OpenForMs = entry.openUntil.Sub(now).Milliseconds()
+			} else {
+				entry.State = circuitHalfOpen
+			}
+		}
+		entry.SuccessRate = h.successRate()
+		entry.Score = h.score()
+		list = append(list, entry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Provider != list[j].Provider {
+			return list[i].Provider < list[j].Provider
+		}
+		return list[i].Score > list[j].Score
+	})
+	return list
+}
+
+// GetProviderHealth lists the health and circuit state of every mirror that
+// has been used so far.
+func GetProviderHealth() (string, error) {
+	jsonBytes, err := json.Marshal(providerHealth.snapshot())
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
@@ -0,0 +1,142 @@
+package gobackend
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOpensAndProbes(t *testing.T) {
+	health := newProviderHealthRegistry()
+	for i := 0; i < circuitFailureThreshold; i++ {
+		health.record("Tidal", "dead", 100*time.Millisecond, fmt.Errorf("connection refused"))
+	}
+	if health.allow("Tidal", "dead") {
+		t.Fatal("circuit should be open after repeated failures")
+	}
+
+	health.endpoints["Tidal|dead"].openUntil = time.Now().Add(-time.Second)
+	if !health.allow("Tidal", "dead") {
+		t.Fatal("expired circuit should allow a half-open probe")
+	}
+	if health.allow("Tidal", "dead") {
+		t.Fatal("only one half-open probe may be in flight")
+	}
+	health.record("Tidal", "dead", 100*time.Millisecond, fmt.Errorf("HTTP 502"))
+	h := health.endpoints["Tidal|dead"]
+	if h.State != circuitOpen || h.cooldown != 2*circuitBaseCooldown {
+		t.Fatalf("failed probe should reopen with a longer cooldown, got %s %v", h.State, h.cooldown)
+	}
+
+	health.record("Tidal", "flaky", time.Second, mirrorStatusError("tidal", 404))
+	health.record("Tidal", "flaky", time.Second,
+		newDownloadError("tidal", ErrRegionLocked, fmt.Errorf("returned PREVIEW instead of FULL")))
+	if health.endpoints["Tidal|flaky"].Failures != 0 {
+		t.Fatal("a missing or region-locked track should not count against the mirror")
+	}
+	health.record("Tidal", "flaky", time.Second, mirrorStatusError("tidal", 408))
+	if health.endpoints["Tidal|flaky"].Failures != 1 {
+		t.Fatal("a request timeout should count against the mirror")
+	}
+	health.record("Tidal", "flaky", time.Second, mirrorStatusError("tidal", 403))
+	if health.endpoints["Tidal|flaky"].Failures != 2 {
+		t.Fatal("a forbidden answer should count against the mirror")
+	}
+}
+
+func TestRaceMirrorsPrefersHealthyAndSkipsOpen(t *testing.T) {
+	health := newProviderHealthRegistry()
+	endpoints := []string{"dead", "slow", "fast", "unknown"}
+	for i := 0; i < circuitFailureThreshold; i++ {
+		health.record("Qobuz", "dead", time.Second, fmt.Errorf("timeout"))
+	}
+	health.record("Qobuz", "slow", 8*time.Second, nil)
+	health.record("Qobuz", "fast", 200*time.Millisecond, nil)
+
+	ordered, skipped := health.orderByHealth("Qobuz", endpoints)
+	if len(skipped) != 1 || skipped[0] != "dead" {
+		t.Fatalf("expected dead mirror to be skipped, got %v", skipped)
+	}
+	if ordered[0] != "fast" {
+		t.Fatalf("expected fast mirror first, got %v", ordered)
+	}
+
+	var calls int32
+	index, err := health.raceMirrors(context.Background(), "Qobuz", endpoints, func(_ context.Context, i int) error {
+		atomic.AddInt32(&calls, 1)
+		if endpoints[i] == "dead" {
+			t.Error("open circuit should not be called")
+		}
+		if endpoints[i] != "unknown" {
+			return fmt.Errorf("HTTP 500")
+		}
+		return nil
+	})
+	if err != nil || endpoints[index] != "unknown" {
+		t.Fatalf("expected unknown mirror to win, got %d %v", index, err)
+	}
+	if n := atomic.LoadInt32(&calls); n != 3 {
+		t.Fatalf("expected one wave of 3 calls, got %d", n)
+	}
+}
+
+func TestRaceMirrorsStopsOnCancel(t *testing.T) {
+	health := newProviderHealthRegistry()
+	endpoints := []string{"a", "b", "c", "d", "e", "f"}
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var calls int32
+	_, err := health.raceMirrors(ctx, "Tidal", endpoints, func(_ context.Context, i int) error {
+		atomic.AddInt32(&calls, 1)
+		cancel()
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if err != context.Canceled {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if n := atomic.LoadInt32(&calls); n > mirrorRaceWaveSize {
+		t.Fatalf("no wave should start after cancel, got %d calls", n)
+	}
+
+	health.mu.Lock()
+	defer health.mu.Unlock()
+	for key, h := range health.endpoints {
+		if h.Failures != 0 {
+			t.Fatalf("cancelled fetch counted against %s", key)
+		}
+	}
+}
+
+func TestRaceMirrorsCancelsLosers(t *testing.T) {
+	health := newProviderHealthRegistry()
+	endpoints := []string{"winner", "slow-a", "slow-b"}
+	health.record("Qobuz", "winner", 100*time.Millisecond, nil)
+
+	var cancelled int32
+	index, err := health.raceMirrors(context.Background(), "Qobuz", endpoints, func(ctx context.Context, i int) error {
+		if endpoints[i] == "winner" {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			atomic.AddInt32(&cancelled, 1)
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return fmt.Errorf("loser was not cancelled")
+		}
+	})
+	if err != nil || endpoints[index] != "winner" {
+		t.Fatalf("expected winner, got %d %v", index, err)
+	}
+	if n := atomic.LoadInt32(&cancelled); n != 2 {
+		t.Fatalf("expected both losing fetches to be cancelled, got %d", n)
+	}
+	for _, endpoint := range endpoints[1:] {
+		if h := health.endpoints["Qobuz|"+endpoint]; h.Failures != 0 {
+			t.Fatalf("cancelled loser %s counted as a failure", endpoint)
+		}
+	}
+}
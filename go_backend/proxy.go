@@ -0,0 +1,318 @@
+package gobackend
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	xproxy "golang.org/x/net/proxy"
+)
+
+// proxyDirect in a rule sends matching domains around the global proxy.
+const proxyDirect = "direct"
+
+// ProxyRule routes requests for Domains (exact host or any subdomain; a
+// leading "*." is accepted) through ProxyURL.
+type ProxyRule struct {
+	Domains  []string `json:"domains"`
+	ProxyURL string   `json:"proxy_url"`
+}
+
+// ProxySettings configures the proxy for all backend traffic. ProxyURL is
+// the global proxy (http://, https://, socks5:// or socks5h://, with
+// optional user:password); rules are checked first, in order.
+type ProxySettings struct {
+	ProxyURL string      `json:"proxy_url"`
+	Rules    []ProxyRule `json:"rules,omitempty"`
+}
+
+type proxyRoute struct {
+	domains []string
+	proxy   *url.URL // nil means direct
+}
+
+var (
+	proxyMu       sync.RWMutex
+	proxySettings ProxySettings
+	proxyRoutes   []proxyRoute
+	proxyGlobal   *url.URL
+)
+
+func parseProxyURL(raw string) (*url.URL, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.EqualFold(raw, proxyDirect) {
+		return nil, nil
+	}
+	if !strings.Contains(raw, "://") {
+		raw = "http://" + raw
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	switch u.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q", u.Scheme)
+	}
+	if u.Hostname() == "" {
+		return nil, fmt.Errorf("proxy URL has no host")
+	}
+	return u, nil
+}
+
+func normalizeProxyDomain(domain string) string {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	domain = strings.TrimPrefix(domain, "*.")
+	return strings.TrimPrefix(domain, ".")
+}
+
+func proxyDomainMatches(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// SetProxySettings replaces the proxy configuration; an empty object turns
+// the proxy off. Idle connections are dropped so the change applies at once.
+func SetProxySettings(settingsJSON string) error {
+	var settings ProxySettings
+	if strings.TrimSpace(settingsJSON) != "" {
+		if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
+			return fmt.Errorf("invalid proxy settings: %w", err)
+		}
+	}
+
+	global, err := parseProxyURL(settings.ProxyURL)
+	if err != nil {
+		return err
+	}
+	routes := make([]proxyRoute, 0, len(settings.Rules))
+	for _, rule := range settings.Rules {
+		proxyURL, err := parseProxyURL(rule.ProxyURL)
+		if err != nil {
+			return fmt.Errorf("rule for %s: %w", strings.Join(rule.Domains, ", "), err)
+		}
+		route := proxyRoute{proxy: proxyURL}
+		for _, domain := range rule.Domains {
+			if domain = normalizeProxyDomain(domain); domain != "" {
+				route.domains = append(route.domains, domain)
+			}
+		}
+		if len(route.domains) > 0 {
+			routes = append(routes, route)
+		}
+	}
+
+	proxyMu.Lock()
+	proxySettings = settings
+	proxyRoutes = routes
+	proxyGlobal = global
+	proxyMu.Unlock()
+
+	CloseIdleConnections()
+	if global != nil {
+		GoLog("[Proxy] Global proxy set to %s (%d rules)\n", global.Redacted(), len(routes))
+	} else {
+		GoLog("[Proxy] Global proxy disabled (%d rules)\n", len(routes))
+	}
+	return nil
+}
+
+func GetProxySettings() (string, error) {
+	proxyMu.RLock()
+	settings := proxySettings
+	proxyMu.RUnlock()
+
+	jsonBytes, err := json.Marshal(settings)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+// proxyForHost returns the proxy for a destination host, or nil to dial
+// directly.
+func proxyForHost(host string) *url.URL {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+
+	proxyMu.RLock()
+	defer proxyMu.RUnlock()
+	for _, route := range proxyRoutes {
+		for _, domain := range route.domains {
+			if proxyDomainMatches(host, domain) {
+				return route.proxy
+			}
+		}
+	}
+	if host == "localhost" || net.ParseIP(host).IsLoopback() {
+		return nil
+	}
+	return proxyGlobal
+}
+
+// proxyForRequest is the http.Transport Proxy hook.
+func proxyForRequest(req *http.Request) (*url.URL, error) {
+	if req == nil || req.URL == nil {
+		return nil, nil
+	}
+	return proxyForHost(req.URL.Hostname()), nil
+}
+
+func hasProxyConfigured() bool {
+	proxyMu.RLock()
+	defer proxyMu.RUnlock()
+	if proxyGlobal != nil {
+		return true
+	}
+	for _, route := range proxyRoutes {
+		if route.proxy != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func proxyDefaultPort(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Port()
+	}
+	switch u.Scheme {
+	case "https":
+		return "443"
+	case "socks5", "socks5h":
+		return "1080"
+	}
+	return "80"
+}
+
+// dialThroughProxy opens a tunnel to addr for transports that dial
+// themselves (the uTLS transport). SOCKS5 always resolves names remotely.
+func dialThroughProxy(ctx context.Context, dialer *net.Dialer, proxyURL *url.URL, addr string) (net.Conn, error) {
+	if proxyURL.Scheme == "socks5" || proxyURL.Scheme == "socks5h" {
+		socksDialer, err := xproxy.FromURL(proxyURL, dialer)
+		if err != nil {
+			return nil, err
+		}
+		contextDialer, ok := socksDialer.(xproxy.ContextDialer)
+		if !ok {
+			return socksDialer.Dial("tcp", addr)
+		}
+		return contextDialer.DialContext(ctx, "tcp", addr)
+	}
+
+	proxyAddr := net.JoinHostPort(proxyURL.Hostname(), proxyDefaultPort(proxyURL))
+	conn, err := resolvingDial(ctx, dialer, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("proxy %s: %w", proxyAddr, err)
+	}
+	if proxyURL.Scheme == "https" {
+		tlsConn := tls.Client(conn, &tls.Config{ServerName: proxyURL.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("proxy %s: %w", proxyAddr, err)
+		}
+		conn = tlsConn
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+	connectReq := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+	if proxyURL.User != nil {
+		password, _ := proxyURL.User.Password()
+		credentials := proxyURL.User.Username() + ":" + password
+		connectReq.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte(credentials)))
+	}
+	if err := connectReq.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT: %w", err)
+	}
+	resp, err := http.ReadResponse(bufio.NewReader(conn), connectReq)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy CONNECT to %s failed: %s", addr, resp.Status)
+	}
+	return conn, nil
+}
+
+// dialWithProxy dials addr directly or through the proxy configured for it.
+func dialWithProxy(ctx context.Context, dialer *net.Dialer, addr string) (net.Conn, error) {
+	if proxyURL := proxyForHost(addr); proxyURL != nil {
+		return dialThroughProxy(ctx, dialer, proxyURL, addr)
+	}
+	return resolvingDial(ctx, dialer, "tcp", addr)
+}
+
+type ProxyCheckResult struct {
+	Reachable  bool   `json:"reachable"`
+	StatusCode int    `json:"status_code,omitempty"`
+	LatencyMs  int64  `json:"latency_ms"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CheckProxy requests targetURL through proxyURL (or the configured proxy
+// when proxyURL is empty) without changing the settings.
+func CheckProxy(proxyURL, targetURL string) (string, error) {
+	target, err := url.Parse(strings.TrimSpace(targetURL))
+	if err != nil || target.Hostname() == "" {
+		return "", fmt.Errorf("invalid target URL: %s", targetURL)
+	}
+	proxy := proxyForHost(target.Hostname())
+	if strings.TrimSpace(proxyURL) != "" {
+		if proxy, err = parseProxyURL(proxyURL); err != nil {
+			return "", err
+		}
+	}
+
+	transport := sharedTransport.Clone()
+	transport.Proxy = http.ProxyURL(proxy)
+	defer transport.CloseIdleConnections()
+	client := &http.Client{Transport: transport, Timeout: 15 * time.Second}
+
+	req, err := http.NewRequest(http.MethodHead, target.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("User-Agent", userAgentForURL(req.URL))
+
+	result := ProxyCheckResult{}
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		resp.Body.Close()
+		result.Reachable = true
+		result.StatusCode = resp.StatusCode
+	}
+
+	jsonBytes, err := json.Marshal(result)
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
@@ -29,17 +29,17 @@ type QobuzDownloader struct {
 var (
 	globalQobuzDownloader *QobuzDownloader
 	qobuzDownloaderOnce   sync.Once
-	qobuzGetTrackByIDFunc = func(q *QobuzDownloader, trackID int64) (*QobuzTrack, error) {
-		return q.GetTrackByID(trackID)
+	qobuzGetTrackByIDFunc = func(ctx context.Context, q *QobuzDownloader, trackID int64) (*QobuzTrack, error) {
+		return q.GetTrackByID(ctx, trackID)
 	}
-	qobuzSearchTrackByISRCWithDurationFunc = func(q *QobuzDownloader, isrc string, expectedDurationSec int) (*QobuzTrack, error) {
-		return q.SearchTrackByISRCWithDuration(isrc, expectedDurationSec)
+	qobuzSearchTrackByISRCWithDurationFunc = func(ctx context.Context, q *QobuzDownloader, isrc string, expectedDurationSec int) (*QobuzTrack, error) {
+		return q.SearchTrackByISRCWithDuration(ctx, isrc, expectedDurationSec)
 	}
-	qobuzSearchTrackByMetadataWithDurationFunc = func(q *QobuzDownloader, trackName, artistName string, expectedDurationSec int) (*QobuzTrack, error) {
-		return q.SearchTrackByMetadataWithDuration(trackName, artistName, expectedDurationSec)
+	qobuzSearchTrackByMetadataWithDurationFunc = func(ctx context.Context, q *QobuzDownloader, trackName, artistName string, expectedDurationSec int) (*QobuzTrack, error) {
+		return q.SearchTrackByMetadataWithDuration(ctx, trackName, artistName, expectedDurationSec)
 	}
-	songLinkCheckTrackAvailabilityFunc = func(client *SongLinkClient, spotifyTrackID string, isrc string) (*TrackAvailability, error) {
-		return client.CheckTrackAvailability(spotifyTrackID, isrc)
+	songLinkCheckTrackAvailabilityFunc = func(ctx context.Context, client *SongLinkClient, spotifyTrackID string, isrc string) (*TrackAvailability, error) {
+		return client.CheckTrackAvailabilityContext(ctx, spotifyTrackID, isrc)
 	}
 )
 
@@ -91,6 +91,7 @@ type QobuzTrack struct {
 		ReleaseDate string `json:"release_date_original"`
 		ProductType string `json:"product_type"`
 		ReleaseType string `json:"release_type"`
+		UPC         string `json:"upc"`
 		Artist      struct {
 			ID   int64  `json:"id"`
 			Name string `json:"name"`
@@ -782,10 +783,10 @@ func NewQobuzDownloader() *QobuzDownloader {
 	return globalQobuzDownloader
 }
 
-func (q *QobuzDownloader) GetTrackByID(trackID int64) (*QobuzTrack, error) {
+func (q *QobuzDownloader) GetTrackByID(ctx context.Context, trackID int64) (*QobuzTrack, error) {
 	trackURL := fmt.Sprintf("%s%d&app_id=%s", qobuzTrackGetBaseURL, trackID, q.appID)
 
-	req, err := http.NewRequest("GET", trackURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", trackURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -794,7 +795,7 @@ func (q *QobuzDownloader) GetTrackByID(trackID int64) (*QobuzTrack, error) {
 	if err != nil {
 		if isQobuzPrimaryUnavailable(err) {
 			GoLog("[Qobuz] Primary API unavailable for track %d, trying qbz2 fallback: %v\n", trackID, err)
-			return q.getTrackByIDViaMusicDL(trackID)
+			return q.getTrackByIDViaMusicDL(ctx, trackID)
 		}
 		return nil, err
 	}
@@ -804,7 +805,7 @@ func (q *QobuzDownloader) GetTrackByID(trackID int64) (*QobuzTrack, error) {
 		primaryErr := fmt.Errorf("get track failed: HTTP %d", resp.StatusCode)
 		if isQobuzPrimaryUnavailable(primaryErr) {
 			GoLog("[Qobuz] Primary API unavailable for track %d, trying qbz2 fallback: %v\n", trackID, primaryErr)
-			return q.getTrackByIDViaMusicDL(trackID)
+			return q.getTrackByIDViaMusicDL(ctx, trackID)
 		}
 		return nil, primaryErr
 	}
@@ -817,18 +818,18 @@ func (q *QobuzDownloader) GetTrackByID(trackID int64) (*QobuzTrack, error) {
 	return &track, nil
 }
 
-func (q *QobuzDownloader) getTrackByIDViaMusicDL(trackID int64) (*QobuzTrack, error) {
+func (q *QobuzDownloader) getTrackByIDViaMusicDL(ctx context.Context, trackID int64) (*QobuzTrack, error) {
 	requestURL := fmt.Sprintf("%s%d", qobuzFallbackTrackGetBaseURL, trackID)
 	var track QobuzTrack
-	if err := q.getQobuzJSON(requestURL, &track); err != nil {
+	if err := q.getQobuzJSON(ctx, requestURL, &track); err != nil {
 		return nil, fmt.Errorf("qbz2 fallback also failed for track %d: %w", trackID, err)
 	}
 	GoLog("[Qobuz] qbz2 fallback succeeded for track %d\n", trackID)
 	return &track, nil
 }
 
-func (q *QobuzDownloader) getQobuzJSON(requestURL string, target interface{}) error {
-	req, err := http.NewRequest("GET", requestURL, nil)
+func (q *QobuzDownloader) getQobuzJSON(ctx context.Context, requestURL string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
 		return err
 	}
@@ -847,8 +848,8 @@ func (q *QobuzDownloader) getQobuzJSON(requestURL string, target interface{}) er
 	return json.NewDecoder(resp.Body).Decode(target)
 }
 
-func (q *QobuzDownloader) getQobuzBody(requestURL string) ([]byte, error) {
-	req, err := http.NewRequest("GET", requestURL, nil)
+func (q *QobuzDownloader) getQobuzBody(ctx context.Context, requestURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -914,7 +915,7 @@ func extractQobuzAlbumIDsFromArtistHTML(body []byte) []string {
 func (q *QobuzDownloader) getAlbumDetails(albumID string) (*qobuzAlbumDetails, error) {
 	requestURL := fmt.Sprintf("%s%s&app_id=%s", qobuzAlbumGetBaseURL, url.QueryEscape(strings.TrimSpace(albumID)), q.appID)
 	var album qobuzAlbumDetails
-	if err := q.getQobuzJSON(requestURL, &album); err != nil {
+	if err := q.getQobuzJSON(context.Background(), requestURL, &album); err != nil {
 		if isQobuzPrimaryUnavailable(err) {
 			GoLog("[Qobuz] Primary API unavailable for album %s, trying qbz2 fallback: %v\n", albumID, err)
 			return q.getAlbumDetailsViaMusicDL(albumID)
@@ -927,7 +928,7 @@ func (q *QobuzDownloader) getAlbumDetails(albumID string) (*qobuzAlbumDetails, e
 func (q *QobuzDownloader) getAlbumDetailsViaMusicDL(albumID string) (*qobuzAlbumDetails, error) {
 	requestURL := fmt.Sprintf("%s%s", qobuzFallbackAlbumGetBaseURL, url.QueryEscape(strings.TrimSpace(albumID)))
 	var album qobuzAlbumDetails
-	if err := q.getQobuzJSON(requestURL, &album); err != nil {
+	if err := q.getQobuzJSON(context.Background(), requestURL, &album); err != nil {
 		return nil, fmt.Errorf("qbz2 fallback also failed for album %s: %w", albumID, err)
 	}
 	GoLog("[Qobuz] qbz2 fallback succeeded for album %s\n", albumID)
@@ -937,7 +938,7 @@ func (q *QobuzDownloader) getAlbumDetailsViaMusicDL(albumID string) (*qobuzAlbum
 func (q *QobuzDownloader) getArtistDetails(artistID string) (*qobuzArtistDetails, error) {
 	requestURL := fmt.Sprintf("%s%s&app_id=%s", qobuzArtistGetBaseURL, url.QueryEscape(strings.TrimSpace(artistID)), q.appID)
 	var artist qobuzArtistDetails
-	if err := q.getQobuzJSON(requestURL, &artist); err != nil {
+	if err := q.getQobuzJSON(context.Background(), requestURL, &artist); err != nil {
 		if isQobuzPrimaryUnavailable(err) {
 			GoLog("[Qobuz] Primary API unavailable for artist %s, trying qbz2 fallback: %v\n", artistID, err)
 			return q.getArtistDetailsViaMusicDL(artistID)
@@ -950,7 +951,7 @@ func (q *QobuzDownloader) getArtistDetails(artistID string) (*qobuzArtistDetails
 func (q *QobuzDownloader) getArtistDetailsViaMusicDL(artistID string) (*qobuzArtistDetails, error) {
 	requestURL := fmt.Sprintf("%s%s", qobuzFallbackArtistGetBaseURL, url.QueryEscape(strings.TrimSpace(artistID)))
 	var artist qobuzArtistDetails
-	if err := q.getQobuzJSON(requestURL, &artist); err != nil {
+	if err := q.getQobuzJSON(context.Background(), requestURL, &artist); err != nil {
 		return nil, fmt.Errorf("qbz2 fallback also failed for artist %s: %w", artistID, err)
 	}
 	GoLog("[Qobuz] qbz2 fallback succeeded for artist %s\n", artistID)
@@ -967,7 +968,7 @@ func (q *QobuzDownloader) getPlaylistDetailsPage(playlistID string, limit, offse
 		q.appID,
 	)
 	var playlist qobuzPlaylistDetails
-	if err := q.getQobuzJSON(requestURL, &playlist); err != nil {
+	if err := q.getQobuzJSON(context.Background(), requestURL, &playlist); err != nil {
 		if isQobuzPrimaryUnavailable(err) {
 			GoLog("[Qobuz] Primary API unavailable for playlist %s, trying qbz2 fallback: %v\n", playlistID, err)
 			return q.getPlaylistDetailsPageViaMusicDL(playlistID, limit, offset)
@@ -986,7 +987,7 @@ func (q *QobuzDownloader) getPlaylistDetailsPageViaMusicDL(playlistID string, li
 		offset,
 	)
 	var playlist qobuzPlaylistDetails
-	if err := q.getQobuzJSON(requestURL, &playlist); err != nil {
+	if err := q.getQobuzJSON(context.Background(), requestURL, &playlist); err != nil {
 		return nil, fmt.Errorf("qbz2 fallback also failed for playlist %s: %w", playlistID, err)
 	}
 	GoLog("[Qobuz] qbz2 fallback succeeded for playlist %s (offset=%d)\n", playlistID, offset)
@@ -1004,7 +1005,7 @@ func (q *QobuzDownloader) getArtistAlbumIDs(artistID string) ([]string, error) {
 		slug = "artist"
 	}
 	requestURL := fmt.Sprintf("%s/interpreter/%s/%d", qobuzStoreBaseURL, url.PathEscape(slug), artist.ID)
-	body, err := q.getQobuzBody(requestURL)
+	body, err := q.getQobuzBody(context.Background(), requestURL)
 	if err != nil {
 		return nil, err
 	}
@@ -1022,7 +1023,7 @@ func (q *QobuzDownloader) GetTrackMetadata(resourceID string) (*TrackResponse, e
 		return nil, fmt.Errorf("invalid Qobuz track ID: %s", resourceID)
 	}
 
-	track, err := q.GetTrackByID(trackID)
+	track, err := q.GetTrackByID(context.Background(), trackID)
 	if err != nil {
 		return nil, err
 	}
@@ -1234,7 +1235,7 @@ func extractQobuzDownloadInfoFromBody(body []byte) (qobuzDownloadInfo, error) {
 		}
 	}
 
-	return qobuzDownloadInfo{}, fmt.Errorf("no download URL in response")
+	return qobuzDownloadInfo{}, newDownloadError("qobuz", ErrQualityUnavailable, fmt.Errorf("no download URL in response"))
 }
 
 func extractQobuzDownloadURLFromBody(body []byte) (string, error) {
@@ -1316,7 +1317,7 @@ func mapQobuzQualityCodeToAPI(qualityCode string) string {
 }
 
 func (q *QobuzDownloader) SearchTrackByISRC(isrc string) (*QobuzTrack, error) {
-	candidates, err := q.searchQobuzTracksWithFallback(isrc, 50)
+	candidates, err := q.searchQobuzTracksWithFallback(context.Background(), isrc, 50)
 	if err != nil {
 		return nil, err
 	}
@@ -1334,10 +1335,10 @@ func (q *QobuzDownloader) SearchTrackByISRC(isrc string) (*QobuzTrack, error) {
 	return nil, fmt.Errorf("no exact ISRC match found for: %s", isrc)
 }
 
-func (q *QobuzDownloader) SearchTrackByISRCWithDuration(isrc string, expectedDurationSec int) (*QobuzTrack, error) {
+func (q *QobuzDownloader) SearchTrackByISRCWithDuration(ctx context.Context, isrc string, expectedDurationSec int) (*QobuzTrack, error) {
 	GoLog("[Qobuz] Searching by ISRC: %s\n", isrc)
 
-	candidates, err := q.searchQobuzTracksWithFallback(isrc, 50)
+	candidates, err := q.searchQobuzTracksWithFallback(ctx, isrc, 50)
 	if err != nil {
 		return nil, err
 	}
@@ -1390,11 +1391,11 @@ func (q *QobuzDownloader) SearchTrackByISRCWithDuration(isrc string, expectedDur
 }
 
 func (q *QobuzDownloader) SearchTrackByISRCWithTitle(isrc, expectedTitle string) (*QobuzTrack, error) {
-	return q.SearchTrackByISRCWithDuration(isrc, 0)
+	return q.SearchTrackByISRCWithDuration(context.Background(), isrc, 0)
 }
 
 func (q *QobuzDownloader) SearchTrackByMetadata(trackName, artistName string) (*QobuzTrack, error) {
-	return q.SearchTrackByMetadataWithDuration(trackName, artistName, 0)
+	return q.SearchTrackByMetadataWithDuration(context.Background(), trackName, artistName, 0)
 }
 
 func (q *QobuzDownloader) SearchTracks(query string, limit int) ([]ExtTrackMetadata, error) {
@@ -1406,7 +1407,7 @@ func (q *QobuzDownloader) SearchTracks(query string, limit int) ([]ExtTrackMetad
 		limit = 20
 	}
 
-	tracks, err := q.searchQobuzTracksWithFallback(cleanQuery, limit)
+	tracks, err := q.searchQobuzTracksWithFallback(context.Background(), cleanQuery, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -1455,7 +1456,7 @@ func (q *QobuzDownloader) SearchAll(query string, trackLimit, artistLimit int, f
 	}
 
 	if trackLimit > 0 {
-		tracks, err := q.searchQobuzTracksWithFallback(cleanQuery, trackLimit)
+		tracks, err := q.searchQobuzTracksWithFallback(context.Background(), cleanQuery, trackLimit)
 		if err != nil {
 			GoLog("[Qobuz] Track search failed: %v\n", err)
 			return nil, fmt.Errorf("qobuz track search failed: %w", err)
@@ -1577,7 +1578,7 @@ func (q *QobuzDownloader) searchAllArtistsViaMusicDL(query string, limit int, re
 			} `json:"items"`
 		} `json:"artists"`
 	}
-	if err := q.getQobuzJSON(requestURL, &searchResp); err != nil {
+	if err := q.getQobuzJSON(context.Background(), requestURL, &searchResp); err != nil {
 		GoLog("[Qobuz] qbz2 fallback artist search also failed: %v\n", err)
 		return
 	}
@@ -1599,7 +1600,7 @@ func (q *QobuzDownloader) searchAllAlbumsViaMusicDL(query string, limit int, res
 			Items []qobuzAlbumDetails `json:"items"`
 		} `json:"albums"`
 	}
-	if err := q.getQobuzJSON(requestURL, &searchResp); err != nil {
+	if err := q.getQobuzJSON(context.Background(), requestURL, &searchResp); err != nil {
 		GoLog("[Qobuz] qbz2 fallback album search also failed: %v\n", err)
 		return
 	}
@@ -1618,7 +1619,7 @@ func (q *QobuzDownloader) searchAllAlbumsViaMusicDL(query string, limit int, res
 	}
 }
 
-func (q *QobuzDownloader) SearchTrackByMetadataWithDuration(trackName, artistName string, expectedDurationSec int) (*QobuzTrack, error) {
+func (q *QobuzDownloader) SearchTrackByMetadataWithDuration(ctx context.Context, trackName, artistName string, expectedDurationSec int) (*QobuzTrack, error) {
 	queries := []string{}
 
 	if artistName != "" && trackName != "" {
@@ -1671,7 +1672,7 @@ func (q *QobuzDownloader) SearchTrackByMetadataWithDuration(trackName, artistNam
 
 		GoLog("[Qobuz] Searching for: %s\n", cleanQuery)
 
-		result, err := q.searchQobuzTracksWithFallback(cleanQuery, 50)
+		result, err := q.searchQobuzTracksWithFallback(ctx, cleanQuery, 50)
 		if err != nil {
 			GoLog("[Qobuz] Search error for '%s': %v\n", cleanQuery, err)
 			continue
@@ -1774,12 +1775,14 @@ func qobuzTrackMatchesRequest(req DownloadRequest, track *QobuzTrack, logPrefix,
 		if req.ArtistName != "" && !qobuzArtistsMatch(req.ArtistName, track.Performer.Name) {
 			GoLog("[%s] Artist mismatch from %s: expected '%s', got '%s'. Rejecting.\n",
 				logPrefix, source, req.ArtistName, track.Performer.Name)
+			traceMatchDecision(req, source, track.Title, track.Performer.Name, track.ISRC, track.Duration, "artist_mismatch")
 			return false
 		}
 
 		if req.TrackName != "" && !qobuzTitlesMatch(req.TrackName, track.Title) {
 			GoLog("[%s] Title mismatch from %s: expected '%s', got '%s'. Rejecting.\n",
 				logPrefix, source, req.TrackName, track.Title)
+			traceMatchDecision(req, source, track.Title, track.Performer.Name, track.ISRC, track.Duration, "title_mismatch")
 			return false
 		}
 	}
@@ -1793,16 +1796,18 @@ func qobuzTrackMatchesRequest(req DownloadRequest, track *QobuzTrack, logPrefix,
 		if durationDiff > 10 {
 			GoLog("[%s] Duration mismatch from %s: expected %ds, got %ds. Rejecting.\n",
 				logPrefix, source, expectedDurationSec, track.Duration)
+			traceMatchDecision(req, source, track.Title, track.Performer.Name, track.ISRC, track.Duration, "duration_mismatch")
 			return false
 		}
 	}
 
+	traceMatchDecision(req, source, track.Title, track.Performer.Name, track.ISRC, track.Duration, "")
 	return true
 }
 
-func (q *QobuzDownloader) searchQobuzTracksViaAPI(query string, limit int) ([]QobuzTrack, error) {
+func (q *QobuzDownloader) searchQobuzTracksViaAPI(ctx context.Context, query string, limit int) ([]QobuzTrack, error) {
 	searchURL := fmt.Sprintf("%s%s&limit=%d&app_id=%s", qobuzTrackSearchBaseURL, url.QueryEscape(query), limit, q.appID)
-	req, err := http.NewRequest("GET", searchURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1811,7 +1816,7 @@ func (q *QobuzDownloader) searchQobuzTracksViaAPI(query string, limit int) ([]Qo
 	if err != nil {
 		if isQobuzPrimaryUnavailable(err) {
 			GoLog("[Qobuz] Primary API unavailable for track search, trying qbz2 fallback: %v\n", err)
-			return q.searchQobuzTracksViaMusicDL(query, limit)
+			return q.searchQobuzTracksViaMusicDL(ctx, query, limit)
 		}
 		return nil, err
 	}
@@ -1822,7 +1827,7 @@ func (q *QobuzDownloader) searchQobuzTracksViaAPI(query string, limit int) ([]Qo
 		primaryErr := fmt.Errorf("search failed: HTTP %d (%s)", resp.StatusCode, strings.TrimSpace(string(body)))
 		if isQobuzPrimaryUnavailable(primaryErr) {
 			GoLog("[Qobuz] Primary API unavailable for track search, trying qbz2 fallback: %v\n", primaryErr)
-			return q.searchQobuzTracksViaMusicDL(query, limit)
+			return q.searchQobuzTracksViaMusicDL(ctx, query, limit)
 		}
 		return nil, primaryErr
 	}
@@ -1838,14 +1843,14 @@ func (q *QobuzDownloader) searchQobuzTracksViaAPI(query string, limit int) ([]Qo
 	return result.Tracks.Items, nil
 }
 
-func (q *QobuzDownloader) searchQobuzTracksViaMusicDL(query string, limit int) ([]QobuzTrack, error) {
+func (q *QobuzDownloader) searchQobuzTracksViaMusicDL(ctx context.Context, query string, limit int) ([]QobuzTrack, error) {
 	requestURL := fmt.Sprintf("%s%s&limit=%d", qobuzFallbackTrackSearchBaseURL, url.QueryEscape(query), limit)
 	var result struct {
 		Tracks struct {
 			Items []QobuzTrack `json:"items"`
 		} `json:"tracks"`
 	}
-	if err := q.getQobuzJSON(requestURL, &result); err != nil {
+	if err := q.getQobuzJSON(ctx, requestURL, &result); err != nil {
 		return nil, fmt.Errorf("qbz2 fallback search also failed: %w", err)
 	}
 	GoLog("[Qobuz] qbz2 fallback search succeeded: %d tracks for '%s'\n", len(result.Tracks.Items), query)
@@ -2031,7 +2036,7 @@ func selectQobuzTracksFromAlbumSearchResults(
 	return tracks, nil
 }
 
-func (q *QobuzDownloader) searchQobuzTracksViaAlbumSearch(query string, limit int) ([]QobuzTrack, error) {
+func (q *QobuzDownloader) searchQobuzTracksViaAlbumSearch(ctx context.Context, query string, limit int) ([]QobuzTrack, error) {
 	albumLimit := limit
 	if albumLimit < 3 {
 		albumLimit = 3
@@ -2048,7 +2053,7 @@ func (q *QobuzDownloader) searchQobuzTracksViaAlbumSearch(query string, limit in
 		q.appID,
 	)
 
-	req, err := http.NewRequest("GET", searchURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -2057,7 +2062,7 @@ func (q *QobuzDownloader) searchQobuzTracksViaAlbumSearch(query string, limit in
 	if err != nil {
 		if isQobuzPrimaryUnavailable(err) {
 			GoLog("[Qobuz] Primary API unavailable for album search fallback, trying qbz2: %v\n", err)
-			return q.searchQobuzTracksViaAlbumSearchMusicDL(query, limit, albumLimit)
+			return q.searchQobuzTracksViaAlbumSearchMusicDL(ctx, query, limit, albumLimit)
 		}
 		return nil, err
 	}
@@ -2068,7 +2073,7 @@ func (q *QobuzDownloader) searchQobuzTracksViaAlbumSearch(query string, limit in
 		primaryErr := fmt.Errorf("album search failed: HTTP %d (%s)", resp.StatusCode, strings.TrimSpace(string(body)))
 		if isQobuzPrimaryUnavailable(primaryErr) {
 			GoLog("[Qobuz] Primary API unavailable for album search fallback, trying qbz2: %v\n", primaryErr)
-			return q.searchQobuzTracksViaAlbumSearchMusicDL(query, limit, albumLimit)
+			return q.searchQobuzTracksViaAlbumSearchMusicDL(ctx, query, limit, albumLimit)
 		}
 		return nil, primaryErr
 	}
@@ -2090,14 +2095,14 @@ func (q *QobuzDownloader) searchQobuzTracksViaAlbumSearch(query string, limit in
 	)
 }
 
-func (q *QobuzDownloader) searchQobuzTracksViaAlbumSearchMusicDL(query string, limit, albumLimit int) ([]QobuzTrack, error) {
+func (q *QobuzDownloader) searchQobuzTracksViaAlbumSearchMusicDL(ctx context.Context, query string, limit, albumLimit int) ([]QobuzTrack, error) {
 	requestURL := fmt.Sprintf("%salbum/search?query=%s&limit=%d", qobuzFallbackAPIBaseURL, url.QueryEscape(strings.TrimSpace(query)), albumLimit)
 	var searchResp struct {
 		Albums struct {
 			Items []qobuzAlbumDetails `json:"items"`
 		} `json:"albums"`
 	}
-	if err := q.getQobuzJSON(requestURL, &searchResp); err != nil {
+	if err := q.getQobuzJSON(ctx, requestURL, &searchResp); err != nil {
 		return nil, fmt.Errorf("qbz2 fallback album search also failed: %w", err)
 	}
 	GoLog("[Qobuz] qbz2 fallback album search returned %d albums\n", len(searchResp.Albums.Items))
@@ -2134,9 +2139,9 @@ func extractQobuzTrackIDsFromStoreSearchHTML(body []byte) []int64 {
 	return trackIDs
 }
 
-func (q *QobuzDownloader) searchQobuzTracksViaStore(query string, limit int) ([]QobuzTrack, error) {
+func (q *QobuzDownloader) searchQobuzTracksViaStore(ctx context.Context, query string, limit int) ([]QobuzTrack, error) {
 	searchURL := qobuzStoreSearchBaseURL + url.PathEscape(query)
-	req, err := http.NewRequest("GET", searchURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", searchURL, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -2167,7 +2172,7 @@ func (q *QobuzDownloader) searchQobuzTracksViaStore(query string, limit int) ([]
 
 	tracks := make([]QobuzTrack, 0, len(trackIDs))
 	for _, id := range trackIDs {
-		track, trackErr := q.GetTrackByID(id)
+		track, trackErr := q.GetTrackByID(ctx, id)
 		if trackErr != nil || track == nil {
 			continue
 		}
@@ -2180,8 +2185,8 @@ func (q *QobuzDownloader) searchQobuzTracksViaStore(query string, limit int) ([]
 	return tracks, nil
 }
 
-func (q *QobuzDownloader) searchQobuzTracksWithFallback(query string, limit int) ([]QobuzTrack, error) {
-	apiTracks, apiErr := q.searchQobuzTracksViaAPI(query, limit)
+func (q *QobuzDownloader) searchQobuzTracksWithFallback(ctx context.Context, query string, limit int) ([]QobuzTrack, error) {
+	apiTracks, apiErr := q.searchQobuzTracksViaAPI(ctx, query, limit)
 	if apiErr == nil {
 		if len(apiTracks) > 0 {
 			return apiTracks, nil
@@ -2191,7 +2196,7 @@ func (q *QobuzDownloader) searchQobuzTracksWithFallback(query string, limit int)
 		GoLog("[Qobuz] API search failed for '%s': %v. Trying album-search fallback.\n", query, apiErr)
 	}
 
-	albumTracks, albumErr := q.searchQobuzTracksViaAlbumSearch(query, limit)
+	albumTracks, albumErr := q.searchQobuzTracksViaAlbumSearch(ctx, query, limit)
 	if albumErr == nil && len(albumTracks) > 0 {
 		GoLog("[Qobuz] Album-search fallback returned %d candidate tracks for '%s'\n", len(albumTracks), query)
 		return albumTracks, nil
@@ -2200,7 +2205,7 @@ func (q *QobuzDownloader) searchQobuzTracksWithFallback(query string, limit int)
 		GoLog("[Qobuz] Album-search fallback failed for '%s': %v. Trying store fallback.\n", query, albumErr)
 	}
 
-	storeTracks, storeErr := q.searchQobuzTracksViaStore(query, limit)
+	storeTracks, storeErr := q.searchQobuzTracksViaStore(ctx, query, limit)
 	if storeErr == nil && len(storeTracks) > 0 {
 		GoLog("[Qobuz] Store fallback returned %d candidate tracks for '%s'\n", len(storeTracks), query)
 		return storeTracks, nil
@@ -2226,13 +2231,6 @@ func (q *QobuzDownloader) searchQobuzTracksWithFallback(query string, limit int)
 	return nil, fmt.Errorf("no tracks found for query: %s", query)
 }
 
-type qobuzAPIResult struct {
-	provider qobuzAPIProvider
-	info     qobuzDownloadInfo
-	err      error
-	duration time.Duration
-}
-
 // Mobile networks are more unstable, so we use longer timeouts
 const (
 	qobuzAPITimeoutMobile = 25 * time.Second
@@ -2246,8 +2244,8 @@ func getQobuzAPITimeout() time.Duration {
 }
 
 // fetchQobuzURLWithRetry fetches download URL from a single Qobuz API with retry logic
-func fetchQobuzURLWithRetry(provider qobuzAPIProvider, trackID int64, quality string, timeout time.Duration) (qobuzDownloadInfo, error) {
-	return fetchQobuzURLSingleAttempt(provider, trackID, quality, timeout, "")
+func fetchQobuzURLWithRetry(ctx context.Context, provider qobuzAPIProvider, trackID int64, quality string, timeout time.Duration) (qobuzDownloadInfo, error) {
+	return fetchQobuzURLSingleAttempt(ctx, provider, trackID, quality, timeout, "")
 }
 
 func buildQobuzMusicDLPayload(trackID int64, quality string) ([]byte, error) {
@@ -2260,7 +2258,7 @@ func buildQobuzMusicDLPayload(trackID int64, quality string) ([]byte, error) {
 	return json.Marshal(payload)
 }
 
-func fetchQobuzURLSingleAttempt(provider qobuzAPIProvider, trackID int64, quality string, timeout time.Duration, country string) (qobuzDownloadInfo, error) {
+func fetchQobuzURLSingleAttempt(ctx context.Context, provider qobuzAPIProvider, trackID int64, quality string, timeout time.Duration, country string) (qobuzDownloadInfo, error) {
 	var lastErr error
 	retryDelay := qobuzRetryDelay
 	var payloadBytes []byte
@@ -2275,7 +2273,9 @@ func fetchQobuzURLSingleAttempt(provider qobuzAPIProvider, trackID int64, qualit
 	for attempt := 0; attempt <= qobuzMaxRetries; attempt++ {
 		if attempt > 0 {
 			GoLog("[Qobuz] Retry %d/%d for %s after %v\n", attempt, qobuzMaxRetries, provider.Name, retryDelay)
-			time.Sleep(retryDelay)
+			if err := sleepWithContext(ctx, retryDelay); err != nil {
+				return qobuzDownloadInfo{}, err
+			}
 			retryDelay *= 2
 		}
 
@@ -2301,9 +2301,9 @@ func fetchQobuzURLSingleAttempt(provider qobuzAPIProvider, trackID int64, qualit
 				separator,
 				url.QueryEscape(normalizeQobuzQualityCode(quality)),
 			)
-			req, err = http.NewRequest("GET", reqURL, nil)
+			req, err = http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 		} else {
-			req, err = http.NewRequest("POST", reqURL, bytes.NewReader(payloadBytes))
+			req, err = http.NewRequestWithContext(ctx, "POST", reqURL, bytes.NewReader(payloadBytes))
 		}
 		if err != nil {
 			lastErr = err
@@ -2316,6 +2316,9 @@ func fetchQobuzURLSingleAttempt(provider qobuzAPIProvider, trackID int64, qualit
 		resp, err := DoRequestWithUserAgent(client, req)
 		if err != nil {
 			lastErr = err
+			if ctx.Err() != nil {
+				return qobuzDownloadInfo{}, ctx.Err()
+			}
 			// Check for retryable errors (timeout, connection reset)
 			errStr := strings.ToLower(err.Error())
 			if strings.Contains(errStr, "timeout") ||
@@ -2337,7 +2340,7 @@ func fetchQobuzURLSingleAttempt(provider qobuzAPIProvider, trackID int64, qualit
 		if resp.StatusCode == 429 {
 			io.Copy(io.Discard, resp.Body)
 			resp.Body.Close()
-			lastErr = fmt.Errorf("rate limited")
+			lastErr = newDownloadError("qobuz", ErrRateLimited, fmt.Errorf("rate limited"))
 			retryDelay = 2 * time.Second
 			continue
 		}
@@ -2345,7 +2348,7 @@ func fetchQobuzURLSingleAttempt(provider qobuzAPIProvider, trackID int64, qualit
 		if resp.StatusCode != 200 {
 			io.Copy(io.Discard, resp.Body)
 			resp.Body.Close()
-			return qobuzDownloadInfo{}, fmt.Errorf("HTTP %d", resp.StatusCode)
+			return qobuzDownloadInfo{}, mirrorStatusError("qobuz", resp.StatusCode)
 		}
 
 		body, err := io.ReadAll(resp.Body)
@@ -2373,58 +2376,35 @@ func fetchQobuzURLSingleAttempt(provider qobuzAPIProvider, trackID int64, qualit
 	return qobuzDownloadInfo{}, fmt.Errorf("all retries failed")
 }
 
-func getQobuzDownloadURLParallel(providers []qobuzAPIProvider, trackID int64, quality string) (qobuzAPIProvider, qobuzDownloadInfo, error) {
+func getQobuzDownloadURLParallel(ctx context.Context, providers []qobuzAPIProvider, trackID int64, quality string) (qobuzAPIProvider, qobuzDownloadInfo, error) {
 	if len(providers) == 0 {
 		return qobuzAPIProvider{}, qobuzDownloadInfo{}, fmt.Errorf("no APIs available")
 	}
 
-	GoLog("[Qobuz] Requesting download URL from %d APIs in parallel (with retry)...\n", len(providers))
+	GoLog("[Qobuz] Requesting download URL from %d APIs by health (with retry)...\n", len(providers))
 
-	resultChan := make(chan qobuzAPIResult, len(providers))
+	names := make([]string, len(providers))
+	for i, provider := range providers {
+		names[i] = provider.Name
+	}
+	infos := make([]qobuzDownloadInfo, len(providers))
 	startTime := time.Now()
 	timeout := getQobuzAPITimeout()
-
-	for _, provider := range providers {
-		go func(provider qobuzAPIProvider) {
-			reqStart := time.Now()
-			info, err := fetchQobuzURLWithRetry(provider, trackID, quality, timeout)
-			resultChan <- qobuzAPIResult{
-				provider: provider,
-				info:     info,
-				err:      err,
-				duration: time.Since(reqStart),
-			}
-		}(provider)
-	}
-
-	var errors []string
-
-	for i := 0; i < len(providers); i++ {
-		result := <-resultChan
-		if result.err == nil {
-			GoLog("[Qobuz] [Parallel] Got response from %s in %v\n", result.provider.Name, result.duration)
-
-			go func(remaining int) {
-				for j := 0; j < remaining; j++ {
-					<-resultChan
-				}
-			}(len(providers) - i - 1)
-
-			GoLog("[Qobuz] [Parallel] Total time: %v (first success)\n", time.Since(startTime))
-			return result.provider, result.info, nil
-		}
-		errMsg := result.err.Error()
-		if len(errMsg) > 50 {
-			errMsg = errMsg[:50] + "..."
-		}
-		errors = append(errors, fmt.Sprintf("%s: %s", result.provider.Name, errMsg))
+	index, err := providerHealth.raceMirrors(ctx, "Qobuz", names, func(raceCtx context.Context, i int) error {
+		info, err := fetchQobuzURLWithRetry(raceCtx, providers[i], trackID, quality, timeout)
+		infos[i] = info
+		return err
+	})
+	if err != nil {
+		GoLog("[Qobuz] [Parallel] All %d APIs failed in %v\n", len(providers), time.Since(startTime))
+		return qobuzAPIProvider{}, qobuzDownloadInfo{}, err
 	}
 
-	GoLog("[Qobuz] [Parallel] All %d APIs failed in %v\n", len(providers), time.Since(startTime))
-	return qobuzAPIProvider{}, qobuzDownloadInfo{}, fmt.Errorf("all %d Qobuz APIs failed. Errors: %v", len(providers), errors)
+	GoLog("[Qobuz] [Parallel] Got response from %s in %v\n", providers[index].Name, time.Since(startTime))
+	return providers[index], infos[index], nil
 }
 
-func (q *QobuzDownloader) GetDownloadURL(trackID int64, quality string) (qobuzDownloadInfo, error) {
+func (q *QobuzDownloader) GetDownloadURL(ctx context.Context, trackID int64, quality string) (qobuzDownloadInfo, error) {
 	providers := q.GetAvailableProviders()
 	if len(providers) == 0 {
 		return qobuzDownloadInfo{}, fmt.Errorf("no Qobuz API available")
@@ -2433,10 +2413,13 @@ func (q *QobuzDownloader) GetDownloadURL(trackID int64, quality string) (qobuzDo
 	qualityCode := normalizeQobuzQualityCode(quality)
 
 	downloadFunc := func(qual string) (qobuzDownloadInfo, error) {
-		provider, info, err := getQobuzDownloadURLParallel(providers, trackID, qual)
+		span := startTraceSpanContext(ctx, "quality_attempt", "qobuz").set("quality", qual)
+		provider, info, err := getQobuzDownloadURLParallel(ctx, providers, trackID, qual)
 		if err != nil {
+			span.end(err)
 			return qobuzDownloadInfo{}, err
 		}
+		span.set("mirror", provider.Name).end(nil)
 		GoLog("[Qobuz] Download URL resolved via %s\n", provider.Name)
 		return info, nil
 	}
@@ -2445,6 +2428,9 @@ func (q *QobuzDownloader) GetDownloadURL(trackID int64, quality string) (qobuzDo
 	if err == nil {
 		return downloadInfo, nil
 	}
+	if ctx.Err() != nil {
+		return qobuzDownloadInfo{}, err
+	}
 
 	currentQuality := qualityCode
 	if currentQuality == "27" {
@@ -2453,6 +2439,9 @@ func (q *QobuzDownloader) GetDownloadURL(trackID int64, quality string) (qobuzDo
 		if err == nil {
 			return downloadInfo, nil
 		}
+		if ctx.Err() != nil {
+			return qobuzDownloadInfo{}, err
+		}
 		currentQuality = "7"
 	}
 
@@ -2559,6 +2548,7 @@ type QobuzDownloadResult struct {
 	ISRC        string
 	CoverURL    string
 	LyricsLRC   string
+	Quality     string
 }
 
 func parseQobuzRequestTrackID(raw string) int64 {
@@ -2574,7 +2564,7 @@ func parseQobuzRequestTrackID(raw string) int64 {
 	return trackID
 }
 
-func resolveQobuzTrackForRequest(req DownloadRequest, downloader *QobuzDownloader, logPrefix string) (*QobuzTrack, error) {
+func resolveQobuzTrackForRequest(ctx context.Context, req DownloadRequest, downloader *QobuzDownloader, logPrefix string) (*QobuzTrack, error) {
 	if downloader == nil {
 		downloader = NewQobuzDownloader()
 	}
@@ -2591,7 +2581,7 @@ func resolveQobuzTrackForRequest(req DownloadRequest, downloader *QobuzDownloade
 	if req.QobuzID != "" {
 		GoLog("[%s] Using Qobuz ID from request payload: %s\n", logPrefix, req.QobuzID)
 		if trackID := parseQobuzRequestTrackID(req.QobuzID); trackID > 0 {
-			track, err = qobuzGetTrackByIDFunc(downloader, trackID)
+			track, err = qobuzGetTrackByIDFunc(ctx, downloader, trackID)
 			if err != nil {
 				GoLog("[%s] Failed to get track by request Qobuz ID %d: %v\n", logPrefix, trackID, err)
 				track = nil
@@ -2609,7 +2599,7 @@ func resolveQobuzTrackForRequest(req DownloadRequest, downloader *QobuzDownloade
 	if track == nil && req.ISRC != "" {
 		if cached := GetTrackIDCache().Get(req.ISRC); cached != nil && cached.QobuzTrackID > 0 {
 			GoLog("[%s] Cache hit! Using cached track ID: %d\n", logPrefix, cached.QobuzTrackID)
-			track, err = qobuzGetTrackByIDFunc(downloader, cached.QobuzTrackID)
+			track, err = qobuzGetTrackByIDFunc(ctx, downloader, cached.QobuzTrackID)
 			if err != nil {
 				GoLog("[%s] Cache hit but GetTrackByID failed: %v\n", logPrefix, err)
 				track = nil
@@ -2623,12 +2613,12 @@ func resolveQobuzTrackForRequest(req DownloadRequest, downloader *QobuzDownloade
 	if track == nil && req.SpotifyID != "" && req.QobuzID == "" && req.ISRC == "" {
 		GoLog("[%s] Trying to get Qobuz ID from SongLink for Spotify ID: %s\n", logPrefix, req.SpotifyID)
 		songLinkClient := NewSongLinkClient()
-		availability, slErr := songLinkCheckTrackAvailabilityFunc(songLinkClient, req.SpotifyID, req.ISRC)
+		availability, slErr := songLinkCheckTrackAvailabilityFunc(ctx, songLinkClient, req.SpotifyID, req.ISRC)
 		if slErr == nil && availability != nil && availability.QobuzID != "" {
 			var trackID int64
 			if _, parseErr := fmt.Sscanf(availability.QobuzID, "%d", &trackID); parseErr == nil && trackID > 0 {
 				GoLog("[%s] Got Qobuz ID %d from SongLink\n", logPrefix, trackID)
-				track, err = qobuzGetTrackByIDFunc(downloader, trackID)
+				track, err = qobuzGetTrackByIDFunc(ctx, downloader, trackID)
 				if err != nil {
 					GoLog("[%s] Failed to get track by SongLink ID %d: %v\n", logPrefix, trackID, err)
 					track = nil
@@ -2649,12 +2639,15 @@ func resolveQobuzTrackForRequest(req DownloadRequest, downloader *QobuzDownloade
 	// Strategy 4: ISRC search with duration verification
 	if track == nil && req.ISRC != "" {
 		GoLog("[%s] Trying ISRC search: %s\n", logPrefix, req.ISRC)
-		track, err = qobuzSearchTrackByISRCWithDurationFunc(downloader, req.ISRC, expectedDurationSec)
+		track, err = qobuzSearchTrackByISRCWithDurationFunc(ctx, downloader, req.ISRC, expectedDurationSec)
 		if track != nil && !qobuzTrackMatchesRequest(req, track, logPrefix, "ISRC search", false) {
 			track = nil
 		}
 	}
 
+	if track == nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	if track == nil {
 		errMsg := "could not find matching track on Qobuz without identifier match"
 		if err != nil {
@@ -2671,7 +2664,21 @@ func resolveQobuzTrackForRequest(req DownloadRequest, downloader *QobuzDownloade
 	return track, nil
 }
 
-func downloadFromQobuz(req DownloadRequest) (QobuzDownloadResult, error) {
+// qobuzExpectedQuality caps the track's maximum quality at what the
+// requested format ID delivers (6 = CD, 7 = 24/96, 27 = full hi-res).
+func qobuzExpectedQuality(track *QobuzTrack, qobuzQuality string) (int, float64) {
+	bitDepth := track.MaximumBitDepth
+	sampleRate := track.MaximumSamplingRate
+	switch qobuzQuality {
+	case "6":
+		bitDepth, sampleRate = 16, min(sampleRate, 44.1)
+	case "7":
+		sampleRate = min(sampleRate, 96)
+	}
+	return bitDepth, sampleRate
+}
+
+func downloadFromQobuz(ctx context.Context, req DownloadRequest) (QobuzDownloadResult, error) {
 	downloader := NewQobuzDownloader()
 
 	isSafOutput := isFDOutput(req.OutputFD) || strings.TrimSpace(req.OutputPath) != ""
@@ -2681,20 +2688,37 @@ func downloadFromQobuz(req DownloadRequest) (QobuzDownloadResult, error) {
 		}
 	}
 
-	track, err := resolveQobuzTrackForRequest(req, downloader, "Qobuz")
+	quality, err := meteredQualityForBuiltIn("qobuz", req.Quality)
 	if err != nil {
+		traceDecision(req.ItemID, "metered_policy", "qobuz", "skipped", nil)
 		return QobuzDownloadResult{}, err
 	}
 
-	filename := buildFilenameFromTemplate(req.FilenameFormat, map[string]interface{}{
-		"title":  req.TrackName,
-		"artist": req.ArtistName,
-		"album":  req.AlbumName,
-		"track":  req.TrackNumber,
-		"year":   extractYear(req.ReleaseDate),
-		"date":   req.ReleaseDate,
-		"disc":   req.DiscNumber,
-	})
+	resolveSpan := startTraceSpan(req.ItemID, "resolve", "qobuz")
+	track, err := resolveQobuzTrackForRequest(ctx, req, downloader, "Qobuz")
+	if track != nil {
+		resolveSpan.set("track_id", track.ID)
+	}
+	resolveSpan.end(err)
+	if err != nil {
+		return QobuzDownloadResult{}, classifyDownloadErrorOr("qobuz", ErrTrackNotFound,
+			asDownloadCancelled(ctx, req.ItemID, "track resolution", err))
+	}
+
+	qobuzQuality := "27"
+	switch quality {
+	case "LOSSLESS":
+		qobuzQuality = "6"
+	case "HI_RES":
+		qobuzQuality = "7"
+	case "HI_RES_LOSSLESS", "", "DEFAULT":
+		qobuzQuality = "27"
+	}
+	GoLog("[Qobuz] Using quality: %s (mapped from %s)\n", qobuzQuality, quality)
+
+	templateMetadata := filenameTemplateMetadata(req, "qobuz")
+	templateMetadata["bit_depth"], templateMetadata["sample_rate"] = qobuzExpectedQuality(track, qobuzQuality)
+	filename := buildFilePathFromTemplate(req.FilenameFormat, templateMetadata)
 	var outputPath string
 	if isSafOutput {
 		outputPath = strings.TrimSpace(req.OutputPath)
@@ -2702,31 +2726,27 @@ func downloadFromQobuz(req DownloadRequest) (QobuzDownloadResult, error) {
 			outputPath = fmt.Sprintf("/proc/self/fd/%d", req.OutputFD)
 		}
 	} else {
-		filename = sanitizeFilename(filename) + ".flac"
-		outputPath = filepath.Join(req.OutputDir, filename)
+		outputPath = filepath.Join(req.OutputDir, filename+".flac")
 		if fileInfo, statErr := os.Stat(outputPath); statErr == nil && fileInfo.Size() > 0 {
 			return QobuzDownloadResult{FilePath: "EXISTS:" + outputPath}, nil
 		}
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return QobuzDownloadResult{}, fmt.Errorf("failed to create output folder: %w", err)
+		}
 	}
 
-	qobuzQuality := "27"
-	switch req.Quality {
-	case "LOSSLESS":
-		qobuzQuality = "6"
-	case "HI_RES":
-		qobuzQuality = "7"
-	case "HI_RES_LOSSLESS", "", "DEFAULT":
-		qobuzQuality = "27"
-	}
-	GoLog("[Qobuz] Using quality: %s (mapped from %s)\n", qobuzQuality, req.Quality)
-
 	actualBitDepth := track.MaximumBitDepth
 	actualSampleRate := int(track.MaximumSamplingRate * 1000)
 	GoLog("[Qobuz] Actual quality: %d-bit/%.1fkHz\n", actualBitDepth, track.MaximumSamplingRate)
 
-	downloadInfo, err := downloader.GetDownloadURL(track.ID, qobuzQuality)
+	urlSpan := startTraceSpan(req.ItemID, "url_fetch", "qobuz").set("quality", qobuzQuality)
+	downloadInfo, err := downloader.GetDownloadURL(ctx, track.ID, qobuzQuality)
+	urlSpan.set("bit_depth", downloadInfo.BitDepth).set("sample_rate", downloadInfo.SampleRate).end(err)
 	if err != nil {
-		return QobuzDownloadResult{}, fmt.Errorf("failed to get download URL: %w", err)
+		if ctx.Err() != nil {
+			return QobuzDownloadResult{}, asDownloadCancelled(ctx, req.ItemID, "download URL lookup", err)
+		}
+		return QobuzDownloadResult{}, classifyDownloadError("qobuz", fmt.Errorf("failed to get download URL: %w", err))
 	}
 	if downloadInfo.BitDepth > 0 {
 		actualBitDepth = downloadInfo.BitDepth
@@ -2752,9 +2772,11 @@ func downloadFromQobuz(req DownloadRequest) (QobuzDownloadResult, error) {
 			embedLyrics = false
 		}
 		parallelResult = FetchCoverAndLyricsParallel(
+			ctx,
 			coverURL,
 			req.EmbedMaxQualityCover,
 			req.SpotifyID,
+			req.ISRC,
 			req.TrackName,
 			req.ArtistName,
 			embedLyrics,
@@ -2762,9 +2784,12 @@ func downloadFromQobuz(req DownloadRequest) (QobuzDownloadResult, error) {
 		)
 	}()
 
-	if err := downloader.DownloadFile(downloadInfo.DownloadURL, outputPath, req.OutputFD, req.ItemID); err != nil {
-		if errors.Is(err, ErrDownloadCancelled) {
-			return QobuzDownloadResult{}, ErrDownloadCancelled
+	downloadSpan := startTraceSpan(req.ItemID, "download", "qobuz")
+	err = downloader.DownloadFile(downloadInfo.DownloadURL, outputPath, req.OutputFD, req.ItemID)
+	downloadSpan.end(err)
+	if err != nil {
+		if errors.Is(err, ErrDownloadCancelled) || ctx.Err() != nil {
+			return QobuzDownloadResult{}, asDownloadCancelled(ctx, req.ItemID, "audio download", err)
 		}
 		return QobuzDownloadResult{}, fmt.Errorf("download failed: %w", err)
 	}
@@ -2806,6 +2831,12 @@ func downloadFromQobuz(req DownloadRequest) (QobuzDownloadResult, error) {
 		Label:         req.Label,
 		Copyright:     req.Copyright,
 		Composer:      req.Composer,
+
+		TrackIdentifiers: trackIdentifiersForDownload(req, ProviderIdentifiers{
+			QobuzTrackID: formatProviderTrackID(track.ID),
+			SourceURL:    fmt.Sprintf("%s%d", qobuzTrackPlayBaseURL, track.ID),
+			UPC:          track.Album.UPC,
+		}),
 	}
 
 	var coverData []byte
@@ -2821,7 +2852,10 @@ func downloadFromQobuz(req DownloadRequest) (QobuzDownloadResult, error) {
 			GoLog("[Qobuz] SAF output detected - skipping in-backend metadata/lyrics embedding (handled in Flutter)\n")
 		}
 	} else {
-		if err := EmbedMetadataWithCoverData(outputPath, metadata, coverData); err != nil {
+		tagSpan := startTraceSpan(req.ItemID, "tag", "qobuz")
+		err := EmbedMetadataWithCoverData(outputPath, metadata, coverData)
+		tagSpan.end(err)
+		if err != nil {
 			fmt.Printf("Warning: failed to embed metadata: %v\n", err)
 		}
 
@@ -2833,7 +2867,7 @@ func downloadFromQobuz(req DownloadRequest) (QobuzDownloadResult, error) {
 
 			if lyricsMode == "external" || lyricsMode == "both" {
 				GoLog("[Qobuz] Saving external LRC file...\n")
-				if lrcPath, lrcErr := SaveLRCFile(outputPath, parallelResult.LyricsLRC); lrcErr != nil {
+				if lrcPath, lrcErr := SaveLyricsFile(outputPath, parallelResult.LyricsLRC, req.LyricsFormat); lrcErr != nil {
 					GoLog("[Qobuz] Warning: failed to save LRC file: %v\n", lrcErr)
 				} else {
 					GoLog("[Qobuz] LRC file saved: %s\n", lrcPath)
@@ -2842,7 +2876,7 @@ func downloadFromQobuz(req DownloadRequest) (QobuzDownloadResult, error) {
 
 			if lyricsMode == "embed" || lyricsMode == "both" {
 				GoLog("[Qobuz] Embedding parallel-fetched lyrics (%d lines)...\n", len(parallelResult.LyricsData.Lines))
-				if embedErr := EmbedLyrics(outputPath, parallelResult.LyricsLRC); embedErr != nil {
+				if embedErr := EmbedLyrics(outputPath, lyricsForEmbedding(parallelResult.LyricsLRC, req.LyricsFormat)); embedErr != nil {
 					GoLog("[Qobuz] Warning: failed to embed lyrics: %v\n", embedErr)
 				} else {
 					fmt.Println("[Qobuz] Lyrics embedded successfully")
@@ -2892,5 +2926,6 @@ func downloadFromQobuz(req DownloadRequest) (QobuzDownloadResult, error) {
 		ISRC:        track.ISRC,
 		CoverURL:    resultCoverURL,
 		LyricsLRC:   lyricsLRC,
+		Quality:     quality,
 	}, nil
 }
@@ -1,6 +1,7 @@
 package gobackend
 
 import (
+	"context"
 	"encoding/json"
 	"testing"
 )
@@ -356,13 +357,13 @@ func TestResolveQobuzTrackForRequestRejectsSongLinkMismatch(t *testing.T) {
 	})
 	GetTrackIDCache().Clear()
 
-	qobuzGetTrackByIDFunc = func(_ *QobuzDownloader, trackID int64) (*QobuzTrack, error) {
+	qobuzGetTrackByIDFunc = func(_ context.Context, _ *QobuzDownloader, trackID int64) (*QobuzTrack, error) {
 		if trackID != 111 {
 			t.Fatalf("unexpected track ID lookup: %d", trackID)
 		}
 		return testQobuzTrack(111, "Aperture", "Harry Styles", 180), nil
 	}
-	qobuzSearchTrackByISRCWithDurationFunc = func(_ *QobuzDownloader, isrc string, expectedDurationSec int) (*QobuzTrack, error) {
+	qobuzSearchTrackByISRCWithDurationFunc = func(_ context.Context, _ *QobuzDownloader, isrc string, expectedDurationSec int) (*QobuzTrack, error) {
 		if isrc != "TESTISRC1" {
 			t.Fatalf("unexpected ISRC lookup: %q", isrc)
 		}
@@ -371,11 +372,11 @@ func TestResolveQobuzTrackForRequestRejectsSongLinkMismatch(t *testing.T) {
 		}
 		return testQobuzTrack(222, "Taste Back", "Harry Styles", 180), nil
 	}
-	qobuzSearchTrackByMetadataWithDurationFunc = func(_ *QobuzDownloader, _, _ string, _ int) (*QobuzTrack, error) {
+	qobuzSearchTrackByMetadataWithDurationFunc = func(_ context.Context, _ *QobuzDownloader, _, _ string, _ int) (*QobuzTrack, error) {
 		t.Fatal("metadata fallback should not run when ISRC fallback succeeds")
 		return nil, nil
 	}
-	songLinkCheckTrackAvailabilityFunc = func(_ *SongLinkClient, spotifyTrackID string, isrc string) (*TrackAvailability, error) {
+	songLinkCheckTrackAvailabilityFunc = func(_ context.Context, _ *SongLinkClient, spotifyTrackID string, isrc string) (*TrackAvailability, error) {
 		if spotifyTrackID != "spotify-track-id" {
 			t.Fatalf("unexpected spotify ID: %q", spotifyTrackID)
 		}
@@ -393,7 +394,7 @@ func TestResolveQobuzTrackForRequestRejectsSongLinkMismatch(t *testing.T) {
 		DurationMS: 180000,
 	}
 
-	track, err := resolveQobuzTrackForRequest(req, &QobuzDownloader{}, "Test")
+	track, err := resolveQobuzTrackForRequest(context.Background(), req, &QobuzDownloader{}, "Test")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -419,21 +420,21 @@ func TestResolveQobuzTrackForRequestRejectsOdesliMismatch(t *testing.T) {
 		songLinkCheckTrackAvailabilityFunc = origSongLinkCheck
 	})
 
-	qobuzGetTrackByIDFunc = func(_ *QobuzDownloader, trackID int64) (*QobuzTrack, error) {
+	qobuzGetTrackByIDFunc = func(_ context.Context, _ *QobuzDownloader, trackID int64) (*QobuzTrack, error) {
 		if trackID != 333 {
 			t.Fatalf("unexpected track ID lookup: %d", trackID)
 		}
 		return testQobuzTrack(333, "American Girls", "Harry Styles", 181), nil
 	}
-	qobuzSearchTrackByISRCWithDurationFunc = func(_ *QobuzDownloader, _ string, _ int) (*QobuzTrack, error) {
+	qobuzSearchTrackByISRCWithDurationFunc = func(_ context.Context, _ *QobuzDownloader, _ string, _ int) (*QobuzTrack, error) {
 		t.Fatal("ISRC fallback should not run without an ISRC")
 		return nil, nil
 	}
-	qobuzSearchTrackByMetadataWithDurationFunc = func(_ *QobuzDownloader, _, _ string, _ int) (*QobuzTrack, error) {
+	qobuzSearchTrackByMetadataWithDurationFunc = func(_ context.Context, _ *QobuzDownloader, _, _ string, _ int) (*QobuzTrack, error) {
 		t.Fatal("metadata fallback should not run")
 		return nil, nil
 	}
-	songLinkCheckTrackAvailabilityFunc = func(_ *SongLinkClient, _, _ string) (*TrackAvailability, error) {
+	songLinkCheckTrackAvailabilityFunc = func(_ context.Context, _ *SongLinkClient, _, _ string) (*TrackAvailability, error) {
 		t.Fatal("SongLink should not run when Odesli QobuzID is provided")
 		return nil, nil
 	}
@@ -445,7 +446,7 @@ func TestResolveQobuzTrackForRequestRejectsOdesliMismatch(t *testing.T) {
 		DurationMS: 181000,
 	}
 
-	track, err := resolveQobuzTrackForRequest(req, &QobuzDownloader{}, "Test")
+	track, err := resolveQobuzTrackForRequest(context.Background(), req, &QobuzDownloader{}, "Test")
 	if err == nil {
 		t.Fatalf("expected error, got track %+v", track)
 	}
@@ -466,21 +467,21 @@ func TestResolveQobuzTrackForRequestUsesPrefixedQobuzIDWithoutSongLink(t *testin
 		songLinkCheckTrackAvailabilityFunc = origSongLinkCheck
 	})
 
-	qobuzGetTrackByIDFunc = func(_ *QobuzDownloader, trackID int64) (*QobuzTrack, error) {
+	qobuzGetTrackByIDFunc = func(_ context.Context, _ *QobuzDownloader, trackID int64) (*QobuzTrack, error) {
 		if trackID != 40681594 {
 			t.Fatalf("unexpected track ID lookup: %d", trackID)
 		}
 		return testQobuzTrack(40681594, "Sign of the Times", "Harry Styles", 341), nil
 	}
-	qobuzSearchTrackByISRCWithDurationFunc = func(_ *QobuzDownloader, _ string, _ int) (*QobuzTrack, error) {
+	qobuzSearchTrackByISRCWithDurationFunc = func(_ context.Context, _ *QobuzDownloader, _ string, _ int) (*QobuzTrack, error) {
 		t.Fatal("ISRC fallback should not run when request qobuz id succeeds")
 		return nil, nil
 	}
-	qobuzSearchTrackByMetadataWithDurationFunc = func(_ *QobuzDownloader, _, _ string, _ int) (*QobuzTrack, error) {
+	qobuzSearchTrackByMetadataWithDurationFunc = func(_ context.Context, _ *QobuzDownloader, _, _ string, _ int) (*QobuzTrack, error) {
 		t.Fatal("metadata fallback should not run when request qobuz id succeeds")
 		return nil, nil
 	}
-	songLinkCheckTrackAvailabilityFunc = func(_ *SongLinkClient, _, _ string) (*TrackAvailability, error) {
+	songLinkCheckTrackAvailabilityFunc = func(_ context.Context, _ *SongLinkClient, _, _ string) (*TrackAvailability, error) {
 		t.Fatal("SongLink should not run when request qobuz id is provided")
 		return nil, nil
 	}
@@ -492,7 +493,7 @@ func TestResolveQobuzTrackForRequestUsesPrefixedQobuzIDWithoutSongLink(t *testin
 		DurationMS: 341000,
 	}
 
-	track, err := resolveQobuzTrackForRequest(req, &QobuzDownloader{}, "Test")
+	track, err := resolveQobuzTrackForRequest(context.Background(), req, &QobuzDownloader{}, "Test")
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -1,6 +1,11 @@
 package gobackend
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 )
@@ -21,31 +26,28 @@ func NewRateLimiter(maxRequests int, window time.Duration) *RateLimiter {
 }
 
 func (r *RateLimiter) WaitForSlot() {
-	r.mu.Lock()
-	defer r.mu.Unlock()
-
-	now := time.Now()
-
-	r.cleanOldTimestamps(now)
-
-	if len(r.timestamps) < r.maxRequests {
-		r.timestamps = append(r.timestamps, now)
-		return
-	}
-
-	oldestTimestamp := r.timestamps[0]
-	waitUntil := oldestTimestamp.Add(r.window)
-	waitDuration := waitUntil.Sub(now)
+	_ = r.Wait(context.Background())
+}
 
-	if waitDuration > 0 {
-		r.mu.Unlock()
-		time.Sleep(waitDuration)
+// Wait blocks until a slot in the window is free or ctx is done. The mutex
+// is released while sleeping so other callers can check the window.
+func (r *RateLimiter) Wait(ctx context.Context) error {
+	for {
 		r.mu.Lock()
+		now := time.Now()
+		r.cleanOldTimestamps(now)
+		if len(r.timestamps) < r.maxRequests {
+			r.timestamps = append(r.timestamps, now)
+			r.mu.Unlock()
+			return nil
+		}
+		waitDuration := r.timestamps[0].Add(r.window).Sub(now)
+		r.mu.Unlock()
 
-		r.cleanOldTimestamps(time.Now())
+		if err := sleepWithContext(ctx, waitDuration); err != nil {
+			return err
+		}
 	}
-
-	r.timestamps = append(r.timestamps, time.Now())
 }
 
 func (r *RateLimiter) cleanOldTimestamps(now time.Time) {
@@ -94,3 +96,230 @@ var songLinkRateLimiter = NewRateLimiter(9, time.Minute)
 func GetSongLinkRateLimiter() *RateLimiter {
 	return songLinkRateLimiter
 }
+
+func sleepWithContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type hostRateLimit struct {
+	perSecond float64
+	burst     float64
+}
+
+// Limits stay below what each service documents or tolerates; 429s lower
+// them further at runtime.
+var defaultHostRateLimits = map[string]hostRateLimit{
+	"api.deezer.com":      {perSecond: 8, burst: 10},
+	"tidal.com":           {perSecond: 5, burst: 10},
+	"www.qobuz.com":       {perSecond: 5, burst: 10},
+	"musicbrainz.org":     {perSecond: 1, burst: 1},
+	"lrclib.net":          {perSecond: 5, burst: 5},
+	"lyrics.paxsenix.org": {perSecond: 2, burst: 4},
+}
+
+// extensionHostRateLimit applies to every host an extension talks to that
+// has no limit of its own.
+var extensionHostRateLimit = hostRateLimit{perSecond: 10, burst: 20}
+
+// hostRateLimiter is a token bucket that slows down after 429 responses and
+// recovers gradually on successful ones.
+type hostRateLimiter struct {
+	mu           sync.Mutex
+	host         string
+	baseRate     float64
+	rate         float64
+	burst        float64
+	tokens       float64
+	last         time.Time
+	blockedUntil time.Time
+	throttled    int
+	waiting      int
+}
+
+func newHostRateLimiter(host string, limit hostRateLimit) *hostRateLimiter {
+	return &hostRateLimiter{
+		host:     host,
+		baseRate: limit.perSecond,
+		rate:     limit.perSecond,
+		burst:    limit.burst,
+		tokens:   limit.burst,
+		last:     time.Now(),
+	}
+}
+
+func (l *hostRateLimiter) refill(now time.Time) {
+	if elapsed := now.Sub(l.last).Seconds(); elapsed > 0 {
+		l.tokens = min(l.burst, l.tokens+elapsed*l.rate)
+		l.last = now
+	}
+}
+
+// Wait takes a token, sleeping until it is available. A cancelled wait gives
+// its token back.
+func (l *hostRateLimiter) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.refill(now)
+	l.tokens--
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if blocked := l.blockedUntil.Sub(now); blocked > wait {
+		wait = blocked
+	}
+	if wait <= 0 {
+		l.mu.Unlock()
+		return nil
+	}
+	l.waiting++
+	l.mu.Unlock()
+
+	err := sleepWithContext(ctx, wait)
+
+	l.mu.Lock()
+	l.waiting--
+	if err != nil {
+		l.tokens = min(l.burst, l.tokens+1)
+	}
+	l.mu.Unlock()
+	return err
+}
+
+// observe adapts the rate to a response: a 429 halves it and honours
+// Retry-After, anything below 400 brings it back towards the base rate.
+func (l *hostRateLimiter) observe(statusCode int, retryAfter time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		l.refill(now)
+		l.throttled++
+		l.rate = max(l.rate/2, l.baseRate/16)
+		l.tokens = min(l.tokens, 0)
+		if retryAfter <= 0 {
+			retryAfter = time.Duration(float64(time.Second) / l.rate)
+		}
+		if until := now.Add(retryAfter); until.After(l.blockedUntil) {
+			l.blockedUntil = until
+		}
+		GoLog("[RateLimit] %s returned 429, slowing to %.2f req/s for %v\n", l.host, l.rate, retryAfter)
+	case statusCode < 400 && l.rate < l.baseRate:
+		l.refill(now)
+		l.rate = min(l.baseRate, l.rate+l.baseRate/20)
+	}
+}
+
+type hostRateLimiterRegistry struct {
+	mu       sync.Mutex
+	limiters map[string]*hostRateLimiter
+}
+
+var hostRateLimiters = &hostRateLimiterRegistry{limiters: make(map[string]*hostRateLimiter)}
+
+// get returns the limiter for host, creating it from the defaults or from
+// fallback; it returns nil for hosts without any limit.
+func (r *hostRateLimiterRegistry) get(host string, fallback *hostRateLimit) *hostRateLimiter {
+	host = strings.ToLower(host)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if limiter, ok := r.limiters[host]; ok {
+		return limiter
+	}
+	limit, ok := defaultHostRateLimits[host]
+	if !ok {
+		if fallback == nil {
+			return nil
+		}
+		limit = *fallback
+	}
+	limiter := newHostRateLimiter(host, limit)
+	r.limiters[host] = limiter
+	return limiter
+}
+
+type hostRateLimiterState struct {
+	Host          string  `json:"host"`
+	RatePerSecond float64 `json:"rate_per_second"`
+	BaseRate      float64 `json:"base_rate_per_second"`
+	Burst         float64 `json:"burst"`
+	Tokens        float64 `json:"tokens"`
+	BlockedForMs  int64   `json:"blocked_for_ms"`
+	Throttled     int     `json:"throttled_count"`
+	Waiting       int     `json:"waiting"`
+}
+
+func (r *hostRateLimiterRegistry) snapshot() []hostRateLimiterState {
+	r.mu.Lock()
+	limiters := make([]*hostRateLimiter, 0, len(r.limiters))
+	for _, limiter := range r.limiters {
+		limiters = append(limiters, limiter)
+	}
+	r.mu.Unlock()
+
+	now := time.Now()
+	states := make([]hostRateLimiterState, 0, len(limiters))
+	for _, l := range limiters {
+		l.mu.Lock()
+		l.refill(now)
+		states = append(states, hostRateLimiterState{
+			Host:          l.host,
+			RatePerSecond: l.rate,
+			BaseRate:      l.baseRate,
+			Burst:         l.burst,
+			Tokens:        l.tokens,
+			BlockedForMs:  max(l.blockedUntil.Sub(now).Milliseconds(), 0),
+			Throttled:     l.throttled,
+			Waiting:       l.waiting,
+		})
+		l.mu.Unlock()
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Host < states[j].Host })
+	return states
+}
+
+// GetRateLimiterState reports every per-host limiter created so far.
+func GetRateLimiterState() (string, error) {
+	jsonBytes, err := json.Marshal(hostRateLimiters.snapshot())
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+// rateLimitedTransport waits for the destination host's limiter before each
+// request and feeds the response status back into it.
+type rateLimitedTransport struct {
+	base     http.RoundTripper
+	fallback *hostRateLimit
+}
+
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req == nil || req.URL == nil {
+		return t.base.RoundTrip(req)
+	}
+	limiter := hostRateLimiters.get(req.URL.Hostname(), t.fallback)
+	if limiter == nil {
+		return t.base.RoundTrip(req)
+	}
+	if err := limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err == nil {
+		limiter.observe(resp.StatusCode, getRetryAfterDuration(resp))
+	}
+	return resp, err
+}
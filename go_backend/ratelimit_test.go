@@ -0,0 +1,83 @@
+package gobackend
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestHostRateLimiterWaitIsCancellable(t *testing.T) {
+	limiter := newHostRateLimiter("slow.example", hostRateLimit{perSecond: 0.1, burst: 1})
+	if err := limiter.Wait(context.Background()); err != nil {
+		t.Fatalf("first token should be free: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := limiter.Wait(ctx); err == nil {
+		t.Fatal("expected the wait to be cancelled")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("cancelled wait took %v", elapsed)
+	}
+	if limiter.waiting != 0 || limiter.tokens < -0.01 {
+		t.Fatalf("cancelled wait should return its token, tokens=%.2f waiting=%d", limiter.tokens, limiter.waiting)
+	}
+}
+
+func TestHostRateLimiterLearnsFrom429(t *testing.T) {
+	limiter := newHostRateLimiter("busy.example", hostRateLimit{perSecond: 8, burst: 8})
+	limiter.observe(http.StatusTooManyRequests, 2*time.Second)
+	if limiter.rate != 4 {
+		t.Fatalf("expected rate to halve to 4, got %.2f", limiter.rate)
+	}
+	if blocked := time.Until(limiter.blockedUntil); blocked < time.Second {
+		t.Fatalf("expected Retry-After to block the host, got %v", blocked)
+	}
+
+	limiter.observe(http.StatusOK, 0)
+	if limiter.rate <= 4 || limiter.rate > 8 {
+		t.Fatalf("expected rate to recover towards 8, got %.2f", limiter.rate)
+	}
+}
+
+func TestRateLimitedTransportUsesExtensionFallback(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+	serverURL, _ := url.Parse(server.URL)
+	host := serverURL.Hostname()
+
+	registry := hostRateLimiters
+	hostRateLimiters = &hostRateLimiterRegistry{limiters: make(map[string]*hostRateLimiter)}
+	defer func() { hostRateLimiters = registry }()
+
+	plain := &http.Client{Transport: &rateLimitedTransport{base: http.DefaultTransport}}
+	resp, err := plain.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if hostRateLimiters.get(host, nil) != nil {
+		t.Fatal("hosts without a limit should not get a limiter")
+	}
+
+	fallback := hostRateLimit{perSecond: 100, burst: 100}
+	extension := &http.Client{Transport: &rateLimitedTransport{base: http.DefaultTransport, fallback: &fallback}}
+	resp, err = extension.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	states := hostRateLimiters.snapshot()
+	if len(states) != 1 || states[0].Host != host || states[0].Throttled != 1 || states[0].BlockedForMs <= 0 {
+		t.Fatalf("unexpected limiter state %+v", states)
+	}
+}
@@ -0,0 +1,701 @@
+package gobackend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	albumReEnrichMinMatchScore  = 0.55
+	albumReEnrichMaxCandidates  = 3
+	albumReEnrichSearchLimit    = 10
+	albumReEnrichReleaseTimeout = 30 * time.Second
+)
+
+type AlbumReEnrichRequest struct {
+	FolderPath   string   `json:"folder_path,omitempty"`
+	Files        []string `json:"files,omitempty"`
+	UpdateFields []string `json:"update_fields,omitempty"`
+}
+
+type AlbumReEnrichChange struct {
+	Field    string `json:"field"`
+	Current  string `json:"current"`
+	Proposed string `json:"proposed"`
+}
+
+type AlbumReEnrichFilePlan struct {
+	FilePath     string                `json:"file_path"`
+	Matched      bool                  `json:"matched"`
+	MatchScore   float64               `json:"match_score"`
+	MatchedTrack *ExtTrackMetadata     `json:"matched_track,omitempty"`
+	Changes      []AlbumReEnrichChange `json:"changes"`
+}
+
+type AlbumReEnrichGroup struct {
+	Album             string                  `json:"album"`
+	AlbumArtist       string                  `json:"album_artist"`
+	ProviderID        string                  `json:"provider_id,omitempty"`
+	ReleaseID         string                  `json:"release_id,omitempty"`
+	ReleaseName       string                  `json:"release_name,omitempty"`
+	ReleaseArtist     string                  `json:"release_artist,omitempty"`
+	ReleaseTrackCount int                     `json:"release_track_count,omitempty"`
+	Error             string                  `json:"error,omitempty"`
+	Files             []AlbumReEnrichFilePlan `json:"files"`
+}
+
+type AlbumReEnrichPlan struct {
+	PlanID    string               `json:"plan_id"`
+	CreatedAt int64                `json:"created_at"`
+	Groups    []AlbumReEnrichGroup `json:"groups"`
+}
+
+type AlbumReEnrichApproval struct {
+	FilePath string   `json:"file_path"`
+	Fields   []string `json:"fields,omitempty"`
+}
+
+type AlbumReEnrichApplyRequest struct {
+	PlanID   string                  `json:"plan_id"`
+	Approved []AlbumReEnrichApproval `json:"approved"`
+}
+
+type AlbumReEnrichApplyResult struct {
+	FilePath string            `json:"file_path"`
+	Success  bool              `json:"success"`
+	Method   string            `json:"method,omitempty"`
+	Fields   map[string]string `json:"fields,omitempty"`
+	Error    string            `json:"error,omitempty"`
+}
+
+// albumReEnrichFile is the current state of one file, keyed by
+// EditFileMetadata field names.
+type albumReEnrichFile struct {
+	path       string
+	tags       map[string]string
+	durationMS int
+}
+
+var (
+	albumReEnrichPlans   = make(map[string]*AlbumReEnrichPlan)
+	albumReEnrichPlansMu sync.Mutex
+
+	searchAlbumReEnrichCandidates = func(query string, limit int) ([]ExtTrackMetadata, error) {
+		return getExtensionManager().SearchTracksWithMetadataProviders(query, limit, true)
+	}
+	fetchAlbumReEnrichRelease = fetchReleaseTracks
+)
+
+// albumReEnrichFieldGroups maps ReEnrichFile update groups to the fields a
+// batch plan may change. Cover and lyrics stay with ReEnrichFile.
+var albumReEnrichFieldGroups = map[string][]string{
+	"basic_tags":   {"title", "artist", "album", "album_artist"},
+	"track_info":   {"track_number", "track_total", "disc_number", "disc_total"},
+	"release_info": {"date", "isrc"},
+	"extra":        {"genre", "label", "copyright", "composer"},
+}
+
+func albumReEnrichGroupEnabled(groups []string, group string) bool {
+	if len(groups) == 0 {
+		return true
+	}
+	for _, g := range groups {
+		if g == group {
+			return true
+		}
+	}
+	return false
+}
+
+func readAlbumReEnrichFile(path string) (albumReEnrichFile, error) {
+	raw, err := ReadFileMetadata(path)
+	if err != nil {
+		return albumReEnrichFile{}, err
+	}
+	var values map[string]any
+	if err := json.Unmarshal([]byte(raw), &values); err != nil {
+		return albumReEnrichFile{}, err
+	}
+
+	// ReadFileMetadata reports totals as total_tracks/total_discs while
+	// EditFileMetadata expects track_total/disc_total.
+	rename := map[string]string{"total_tracks": "track_total", "total_discs": "disc_total"}
+	file := albumReEnrichFile{path: path, tags: make(map[string]string)}
+	for key, value := range values {
+		if renamed, ok := rename[key]; ok {
+			key = renamed
+		}
+		switch v := value.(type) {
+		case string:
+			file.tags[key] = strings.TrimSpace(v)
+		case float64:
+			if key == "duration" {
+				file.durationMS = int(v) * 1000
+			} else if v > 0 {
+				file.tags[key] = strconv.Itoa(int(v))
+			}
+		}
+	}
+	return file, nil
+}
+
+func albumReEnrichGroupKey(file albumReEnrichFile) (string, string, string) {
+	album := file.tags["album"]
+	artist := file.tags["album_artist"]
+	if artist == "" {
+		artist = file.tags["artist"]
+	}
+	if isPlaceholderReEnrichValue(album) {
+		// Untagged files are grouped by folder, which usually holds one album.
+		dir := filepath.Dir(file.path)
+		return "dir:" + dir, filepath.Base(dir), artist
+	}
+	return strings.ToLower(artist + "\x00" + album), album, artist
+}
+
+func albumReEnrichTrackNumber(file albumReEnrichFile) (int, int) {
+	return parsePositiveInt(file.tags["disc_number"]), parsePositiveInt(file.tags["track_number"])
+}
+
+// scoreAlbumReEnrichMatch combines ISRC, title, duration and position into
+// a 0..1 score. Signals missing on either side are left out of the average.
+func scoreAlbumReEnrichMatch(file albumReEnrichFile, track ExtTrackMetadata) float64 {
+	if isrc := file.tags["isrc"]; isrc != "" && strings.EqualFold(isrc, track.ISRC) {
+		return 1
+	}
+
+	var total, weight float64
+	if title := file.tags["title"]; title != "" && track.Name != "" {
+		similarity := calculateStringSimilarity(normalizeStringForMatching(title), normalizeStringForMatching(track.Name))
+		if titlesMatch(title, track.Name) {
+			similarity = max(similarity, 0.9)
+		}
+		total += 0.5 * similarity
+		weight += 0.5
+	}
+	if file.durationMS > 0 && track.DurationMS > 0 {
+		diff := (file.durationMS - track.DurationMS) / 1000
+		if diff < 0 {
+			diff = -diff
+		}
+		var s float64
+		switch {
+		case diff <= 2:
+			s = 1
+		case diff <= 5:
+			s = 0.7
+		case diff <= 10:
+			s = 0.3
+		}
+		total += 0.3 * s
+		weight += 0.3
+	}
+	if disc, number := albumReEnrichTrackNumber(file); number > 0 && track.TrackNumber > 0 {
+		var s float64
+		if number == track.TrackNumber && (disc == 0 || track.DiscNumber == 0 || disc == track.DiscNumber) {
+			s = 1
+		}
+		total += 0.25 * s
+		weight += 0.25
+	}
+	if weight == 0 {
+		return 0
+	}
+	return total / weight
+}
+
+type albumReEnrichAssignment struct {
+	file  int
+	track int
+	score float64
+}
+
+// alignAlbumReEnrichFiles pairs files with release tracks greedily by best
+// score; files below the threshold stay unmatched.
+func alignAlbumReEnrichFiles(files []albumReEnrichFile, tracks []ExtTrackMetadata) []albumReEnrichAssignment {
+	var pairs []albumReEnrichAssignment
+	for i := range files {
+		for j := range tracks {
+			if score := scoreAlbumReEnrichMatch(files[i], tracks[j]); score >= albumReEnrichMinMatchScore {
+				pairs = append(pairs, albumReEnrichAssignment{file: i, track: j, score: score})
+			}
+		}
+	}
+	sort.SliceStable(pairs, func(a, b int) bool { return pairs[a].score > pairs[b].score })
+
+	usedFiles := make(map[int]bool)
+	usedTracks := make(map[int]bool)
+	var result []albumReEnrichAssignment
+	for _, pair := range pairs {
+		if usedFiles[pair.file] || usedTracks[pair.track] {
+			continue
+		}
+		usedFiles[pair.file] = true
+		usedTracks[pair.track] = true
+		result = append(result, pair)
+	}
+	return result
+}
+
+func fetchReleaseTracks(providerID, albumID string) ([]ExtTrackMetadata, error) {
+	var payload *AlbumResponsePayload
+	var err error
+	switch providerID {
+	case "qobuz":
+		payload, err = NewQobuzDownloader().GetAlbumMetadata(stripProviderIDPrefix(albumID, "qobuz"))
+	case "tidal":
+		payload, err = NewTidalDownloader().GetAlbumMetadata(stripProviderIDPrefix(albumID, "tidal"))
+	case "musicbrainz":
+		ctx, cancel := context.WithTimeout(context.Background(), albumReEnrichReleaseTimeout)
+		defer cancel()
+		releaseTracks, mbErr := GetMusicBrainzClient().GetRelease(ctx, albumID)
+		if mbErr != nil {
+			return nil, mbErr
+		}
+		tracks := make([]ExtTrackMetadata, 0, len(releaseTracks))
+		for _, track := range releaseTracks {
+			tracks = append(tracks, normalizeBuiltInMetadataTrack(track, providerID))
+		}
+		return tracks, nil
+	default:
+		for _, provider := range getExtensionManager().GetMetadataProviders() {
+			if provider.extension.ID != providerID {
+				continue
+			}
+			album, extErr := provider.GetAlbum(albumID)
+			if extErr != nil {
+				return nil, extErr
+			}
+			for i := range album.Tracks {
+				if album.Tracks[i].ProviderID == "" {
+					album.Tracks[i].ProviderID = providerID
+				}
+			}
+			return album.Tracks, nil
+		}
+		return nil, fmt.Errorf("metadata provider not available: %s", providerID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	tracks := make([]ExtTrackMetadata, 0, len(payload.TrackList))
+	for _, albumTrack := range payload.TrackList {
+		track := normalizeBuiltInMetadataTrack(TrackMetadata{
+			SpotifyID:   albumTrack.SpotifyID,
+			Artists:     albumTrack.Artists,
+			Name:        albumTrack.Name,
+			AlbumName:   albumTrack.AlbumName,
+			AlbumArtist: albumTrack.AlbumArtist,
+			DurationMS:  albumTrack.DurationMS,
+			Images:      albumTrack.Images,
+			ReleaseDate: albumTrack.ReleaseDate,
+			TrackNumber: albumTrack.TrackNumber,
+			TotalTracks: albumTrack.TotalTracks,
+			DiscNumber:  albumTrack.DiscNumber,
+			TotalDiscs:  albumTrack.TotalDiscs,
+			ISRC:        albumTrack.ISRC,
+			AlbumID:     albumTrack.AlbumID,
+			AlbumType:   albumTrack.AlbumType,
+			Composer:    albumTrack.Composer,
+		}, providerID)
+		track.Genre = payload.AlbumInfo.Genre
+		track.Label = payload.AlbumInfo.Label
+		track.Copyright = payload.AlbumInfo.Copyright
+		if track.AlbumArtist == "" {
+			track.AlbumArtist = payload.AlbumInfo.Artists
+		}
+		tracks = append(tracks, track)
+	}
+	return tracks, nil
+}
+
+// fillAlbumReEnrichTotals derives per-disc track counts and the disc count
+// when a provider leaves them out.
+func fillAlbumReEnrichTotals(tracks []ExtTrackMetadata) {
+	perDisc := make(map[int]int)
+	discs := 0
+	for _, track := range tracks {
+		disc := max(track.DiscNumber, 1)
+		perDisc[disc]++
+		discs = max(discs, disc)
+	}
+	for i := range tracks {
+		if tracks[i].TotalTracks == 0 {
+			tracks[i].TotalTracks = perDisc[max(tracks[i].DiscNumber, 1)]
+		}
+		if tracks[i].TotalDiscs == 0 && tracks[i].DiscNumber > 0 {
+			tracks[i].TotalDiscs = discs
+		}
+	}
+}
+
+type albumReEnrichCandidate struct {
+	providerID string
+	albumID    string
+	albumName  string
+	artist     string
+	relevance  float64
+}
+
+func findAlbumReEnrichCandidates(album, artist string, files []albumReEnrichFile) []albumReEnrichCandidate {
+	var queries []string
+	if !isPlaceholderReEnrichValue(album) {
+		queries = append(queries, strings.TrimSpace(artist+" "+album))
+	}
+	for _, file := range files {
+		if title := file.tags["title"]; !isPlaceholderReEnrichValue(title) {
+			queries = append(queries, strings.TrimSpace(title+" "+artist))
+			break
+		}
+	}
+
+	seen := make(map[string]bool)
+	var candidates []albumReEnrichCandidate
+	for _, query := range queries {
+		hits, err := searchAlbumReEnrichCandidates(query, albumReEnrichSearchLimit)
+		if err != nil {
+			GoLog("[ReEnrichAlbum] Search failed for %q: %v\n", query, err)
+			continue
+		}
+		for _, hit := range hits {
+			if hit.AlbumID == "" || hit.ProviderID == "" {
+				continue
+			}
+			key := hit.ProviderID + ":" + hit.AlbumID
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			relevance := calculateStringSimilarity(normalizeStringForMatching(album), normalizeStringForMatching(hit.AlbumName))
+			if artist != "" && (artistsMatch(artist, hit.AlbumArtist) || artistsMatch(artist, hit.Artists)) {
+				relevance += 0.5
+			}
+			candidates = append(candidates, albumReEnrichCandidate{
+				providerID: hit.ProviderID,
+				albumID:    hit.AlbumID,
+				albumName:  hit.AlbumName,
+				artist:     hit.AlbumArtist,
+				relevance:  relevance,
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].relevance > candidates[j].relevance })
+	if len(candidates) > albumReEnrichMaxCandidates {
+		candidates = candidates[:albumReEnrichMaxCandidates]
+	}
+	return candidates
+}
+
+// proposedAlbumReEnrichFields lists the tag values a matched release track
+// would write, restricted to the requested update groups.
+func proposedAlbumReEnrichFields(track ExtTrackMetadata, groups []string) map[string]string {
+	values := map[string]string{
+		"title":        track.Name,
+		"artist":       track.Artists,
+		"album":        track.AlbumName,
+		"album_artist": track.AlbumArtist,
+		"date":         track.ReleaseDate,
+		"isrc":         track.ISRC,
+		"genre":        track.Genre,
+		"label":        track.Label,
+		"copyright":    track.Copyright,
+		"composer":     track.Composer,
+	}
+	if track.TrackNumber > 0 {
+		values["track_number"] = strconv.Itoa(track.TrackNumber)
+	}
+	if track.TotalTracks > 0 {
+		values["track_total"] = strconv.Itoa(track.TotalTracks)
+	}
+	if track.DiscNumber > 0 {
+		values["disc_number"] = strconv.Itoa(track.DiscNumber)
+	}
+	if track.TotalDiscs > 0 {
+		values["disc_total"] = strconv.Itoa(track.TotalDiscs)
+	}
+
+	proposed := make(map[string]string)
+	for group, fields := range albumReEnrichFieldGroups {
+		if !albumReEnrichGroupEnabled(groups, group) {
+			continue
+		}
+		for _, field := range fields {
+			if value := strings.TrimSpace(values[field]); value != "" {
+				proposed[field] = value
+			}
+		}
+	}
+	if albumReEnrichGroupEnabled(groups, "release_info") {
+		ids := TrackIdentifiers{ReleaseIdentifiers: track.ReleaseIdentifiers}
+		switch track.ProviderID {
+		case "qobuz":
+			ids.QobuzTrackID = stripProviderIDPrefix(track.ID, "qobuz")
+		case "tidal":
+			ids.TidalTrackID = stripProviderIDPrefix(track.ID, "tidal")
+		}
+		for field, value := range ids.fields() {
+			proposed[field] = value
+		}
+	}
+	return proposed
+}
+
+func diffAlbumReEnrichFields(current, proposed map[string]string) []AlbumReEnrichChange {
+	changes := []AlbumReEnrichChange{}
+	for field, value := range proposed {
+		if current[field] != value {
+			changes = append(changes, AlbumReEnrichChange{Field: field, Current: current[field], Proposed: value})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Field < changes[j].Field })
+	return changes
+}
+
+func planAlbumReEnrichGroup(album, artist string, files []albumReEnrichFile, groups []string) AlbumReEnrichGroup {
+	group := AlbumReEnrichGroup{Album: album, AlbumArtist: artist}
+
+	var bestTracks []ExtTrackMetadata
+	var bestAssignments []albumReEnrichAssignment
+	var bestCandidate albumReEnrichCandidate
+	bestScore := 0.0
+	for _, candidate := range findAlbumReEnrichCandidates(album, artist, files) {
+		tracks, err := fetchAlbumReEnrichRelease(candidate.providerID, candidate.albumID)
+		if err != nil || len(tracks) == 0 {
+			GoLog("[ReEnrichAlbum] Failed to load %s release %s: %v\n", candidate.providerID, candidate.albumID, err)
+			continue
+		}
+		assignments := alignAlbumReEnrichFiles(files, tracks)
+
+		// Favour releases that explain every file with strong matches and
+		// whose track count is close to the number of files.
+		var sum float64
+		for _, a := range assignments {
+			sum += a.score
+		}
+		score := sum / float64(len(files))
+		if extra := len(tracks) - len(files); extra > 0 {
+			score -= 0.01 * float64(extra)
+		}
+		if score > bestScore {
+			bestScore = score
+			bestTracks = tracks
+			bestAssignments = assignments
+			bestCandidate = candidate
+		}
+	}
+
+	matched := make(map[int]albumReEnrichAssignment)
+	for _, a := range bestAssignments {
+		matched[a.file] = a
+	}
+
+	if bestTracks == nil {
+		group.Error = "no matching release found"
+	} else {
+		fillAlbumReEnrichTotals(bestTracks)
+		group.ProviderID = bestCandidate.providerID
+		group.ReleaseID = bestCandidate.albumID
+		group.ReleaseName = bestCandidate.albumName
+		group.ReleaseArtist = bestCandidate.artist
+		group.ReleaseTrackCount = len(bestTracks)
+	}
+
+	for i, file := range files {
+		plan := AlbumReEnrichFilePlan{FilePath: file.path, Changes: []AlbumReEnrichChange{}}
+		if a, ok := matched[i]; ok {
+			track := bestTracks[a.track]
+			plan.Matched = true
+			plan.MatchScore = a.score
+			plan.MatchedTrack = &track
+			plan.Changes = diffAlbumReEnrichFields(file.tags, proposedAlbumReEnrichFields(track, groups))
+		}
+		group.Files = append(group.Files, plan)
+	}
+	return group
+}
+
+// collectBulkAudioPaths returns the explicit files plus every audio file
+// under folderPath, leaving out CUE sheets.
+func collectBulkAudioPaths(folderPath string, files []string) ([]string, error) {
+	paths := append([]string(nil), files...)
+	if folder := strings.TrimSpace(folderPath); folder != "" {
+		info, err := os.Stat(folder)
+		if err != nil {
+			return nil, fmt.Errorf("folder not found: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("path is not a folder: %s", folder)
+		}
+		files, err := collectLibraryAudioFiles(folder, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if !strings.EqualFold(filepath.Ext(file.path), ".cue") {
+				paths = append(paths, file.path)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no audio files found")
+	}
+	return paths, nil
+}
+
+// PlanAlbumReEnrich groups files by their album tags, resolves one release
+// per group and returns the proposed tag changes for review. Nothing is
+// written until ApplyAlbumReEnrich is called with the returned plan_id.
+func PlanAlbumReEnrich(requestJSON string) (string, error) {
+	var req AlbumReEnrichRequest
+	if err := json.Unmarshal([]byte(requestJSON), &req); err != nil {
+		return "", fmt.Errorf("failed to parse request: %w", err)
+	}
+	paths, err := collectBulkAudioPaths(req.FolderPath, req.Files)
+	if err != nil {
+		return "", err
+	}
+
+	type albumGroup struct {
+		album, artist string
+		files         []albumReEnrichFile
+	}
+	groups := make(map[string]*albumGroup)
+	var order []string
+	var unreadable []AlbumReEnrichFilePlan
+	for _, path := range paths {
+		file, err := readAlbumReEnrichFile(path)
+		if err != nil {
+			GoLog("[ReEnrichAlbum] Skipping unreadable file %s: %v\n", path, err)
+			unreadable = append(unreadable, AlbumReEnrichFilePlan{FilePath: path, Changes: []AlbumReEnrichChange{}})
+			continue
+		}
+		key, album, artist := albumReEnrichGroupKey(file)
+		g, ok := groups[key]
+		if !ok {
+			g = &albumGroup{album: album, artist: artist}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.files = append(g.files, file)
+	}
+
+	plan := &AlbumReEnrichPlan{
+		PlanID:    fmt.Sprintf("album-reenrich-%d", time.Now().UnixNano()),
+		CreatedAt: time.Now().UnixMilli(),
+		Groups:    []AlbumReEnrichGroup{},
+	}
+	for _, key := range order {
+		g := groups[key]
+		GoLog("[ReEnrichAlbum] Resolving %q by %q (%d files)\n", g.album, g.artist, len(g.files))
+		plan.Groups = append(plan.Groups, planAlbumReEnrichGroup(g.album, g.artist, g.files, req.UpdateFields))
+	}
+	if len(unreadable) > 0 {
+		plan.Groups = append(plan.Groups, AlbumReEnrichGroup{Error: "could not read tags", Files: unreadable})
+	}
+
+	albumReEnrichPlansMu.Lock()
+	albumReEnrichPlans[plan.PlanID] = plan
+	albumReEnrichPlansMu.Unlock()
+
+	jsonBytes, err := json.Marshal(plan)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal plan: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+// ApplyAlbumReEnrich writes the approved changes of a plan. Each approval may
+// limit the fields to apply; formats without a native writer are returned
+// with method "ffmpeg" and the fields to write, like EditFileMetadata.
+func ApplyAlbumReEnrich(requestJSON string) (string, error) {
+	var req AlbumReEnrichApplyRequest
+	if err := json.Unmarshal([]byte(requestJSON), &req); err != nil {
+		return "", fmt.Errorf("failed to parse request: %w", err)
+	}
+
+	albumReEnrichPlansMu.Lock()
+	plan, ok := albumReEnrichPlans[req.PlanID]
+	if ok {
+		delete(albumReEnrichPlans, req.PlanID)
+	}
+	albumReEnrichPlansMu.Unlock()
+	if !ok {
+		return "", fmt.Errorf("album re-enrich plan not found: %s", req.PlanID)
+	}
+
+	planned := make(map[string]AlbumReEnrichFilePlan)
+	for _, group := range plan.Groups {
+		for _, file := range group.Files {
+			planned[file.FilePath] = file
+		}
+	}
+
+	results := make([]AlbumReEnrichApplyResult, 0, len(req.Approved))
+	for _, approval := range req.Approved {
+		result := AlbumReEnrichApplyResult{FilePath: approval.FilePath}
+		file, ok := planned[approval.FilePath]
+		if !ok || !file.Matched {
+			result.Error = "file has no proposed changes in this plan"
+			results = append(results, result)
+			continue
+		}
+
+		allowed := make(map[string]bool, len(approval.Fields))
+		for _, field := range approval.Fields {
+			allowed[field] = true
+		}
+		fields := make(map[string]string)
+		for _, change := range file.Changes {
+			if len(allowed) == 0 || allowed[change.Field] {
+				fields[change.Field] = change.Proposed
+			}
+		}
+		if len(fields) == 0 {
+			result.Success = true
+			results = append(results, result)
+			continue
+		}
+
+		fieldsJSON, _ := json.Marshal(fields)
+		raw, err := EditFileMetadata(file.FilePath, string(fieldsJSON))
+		if err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		var resp struct {
+			Method string `json:"method"`
+		}
+		_ = json.Unmarshal([]byte(raw), &resp)
+		result.Success = true
+		result.Method = resp.Method
+		if resp.Method == "ffmpeg" {
+			result.Fields = fields
+		}
+		results = append(results, result)
+	}
+
+	GoLog("[ReEnrichAlbum] Applied plan %s to %d files\n", req.PlanID, len(results))
+	jsonBytes, err := json.Marshal(results)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal results: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+func DiscardAlbumReEnrichPlan(planID string) {
+	albumReEnrichPlansMu.Lock()
+	defer albumReEnrichPlansMu.Unlock()
+	delete(albumReEnrichPlans, planID)
+}
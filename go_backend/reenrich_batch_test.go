@@ -0,0 +1,114 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestAlbumReEnrichPlanAndApply(t *testing.T) {
+	dir := t.TempDir()
+	tagged := func(name string, fields map[string]string) string {
+		path := writeTestFLAC(t, dir, name, buildTestFLAC(t, 2, 64))
+		fieldsJSON, _ := json.Marshal(fields)
+		if _, err := EditFileMetadata(path, string(fieldsJSON)); err != nil {
+			t.Fatalf("EditFileMetadata(%s) failed: %v", name, err)
+		}
+		return path
+	}
+	first := tagged("01.flac", map[string]string{
+		"title": "Intro", "artist": "Band", "album": "Record", "track_number": "1",
+	})
+	second := tagged("02.flac", map[string]string{
+		"title": "Second Song (Remastered)", "artist": "Band", "album": "Record", "track_number": "2",
+	})
+
+	origSearch, origFetch := searchAlbumReEnrichCandidates, fetchAlbumReEnrichRelease
+	defer func() {
+		searchAlbumReEnrichCandidates, fetchAlbumReEnrichRelease = origSearch, origFetch
+	}()
+	searchAlbumReEnrichCandidates = func(query string, limit int) ([]ExtTrackMetadata, error) {
+		return []ExtTrackMetadata{
+			{ProviderID: "qobuz", AlbumID: "qobuz:other", AlbumName: "Unrelated", AlbumArtist: "Someone"},
+			{ProviderID: "qobuz", AlbumID: "qobuz:rec", AlbumName: "Record", AlbumArtist: "Band"},
+		}, nil
+	}
+	fetchAlbumReEnrichRelease = func(providerID, albumID string) ([]ExtTrackMetadata, error) {
+		if albumID != "qobuz:rec" {
+			return []ExtTrackMetadata{{Name: "Nothing Alike", TrackNumber: 9}}, nil
+		}
+		return []ExtTrackMetadata{
+			{ID: "qobuz:11", ProviderID: "qobuz", Name: "Intro", Artists: "Band", AlbumName: "Record", AlbumArtist: "Band", TrackNumber: 1, ISRC: "AAA000000001", ReleaseDate: "2001-02-03"},
+			{ID: "qobuz:12", ProviderID: "qobuz", Name: "Second Song", Artists: "Band", AlbumName: "Record", AlbumArtist: "Band", TrackNumber: 2, ISRC: "AAA000000002", ReleaseDate: "2001-02-03"},
+		}, nil
+	}
+
+	reqJSON, _ := json.Marshal(AlbumReEnrichRequest{FolderPath: dir, UpdateFields: []string{"basic_tags", "track_info", "release_info"}})
+	planJSON, err := PlanAlbumReEnrich(string(reqJSON))
+	if err != nil {
+		t.Fatalf("PlanAlbumReEnrich failed: %v", err)
+	}
+	var plan AlbumReEnrichPlan
+	if err := json.Unmarshal([]byte(planJSON), &plan); err != nil {
+		t.Fatalf("unmarshal plan: %v", err)
+	}
+	if len(plan.Groups) != 1 {
+		t.Fatalf("expected one album group, got %d", len(plan.Groups))
+	}
+	group := plan.Groups[0]
+	if group.ReleaseID != "qobuz:rec" || len(group.Files) != 2 {
+		t.Fatalf("unexpected group: %+v", group)
+	}
+
+	changes := make(map[string]map[string]string)
+	for _, file := range group.Files {
+		if !file.Matched {
+			t.Fatalf("expected %s to match", file.FilePath)
+		}
+		changes[file.FilePath] = make(map[string]string)
+		for _, change := range file.Changes {
+			changes[file.FilePath][change.Field] = change.Proposed
+		}
+	}
+	if got := changes[second]["title"]; got != "Second Song" {
+		t.Fatalf("expected title change for second file, got %q", got)
+	}
+	if _, ok := changes[first]["title"]; ok {
+		t.Fatalf("unchanged title should not be proposed")
+	}
+	if got := changes[first]["qobuz_track_id"]; got != "11" {
+		t.Fatalf("qobuz_track_id = %q, want 11", got)
+	}
+	if got := changes[first]["track_total"]; got != "2" {
+		t.Fatalf("track_total = %q, want 2", got)
+	}
+
+	applyJSON, _ := json.Marshal(AlbumReEnrichApplyRequest{
+		PlanID: plan.PlanID,
+		Approved: []AlbumReEnrichApproval{
+			{FilePath: first},
+			{FilePath: second, Fields: []string{"isrc"}},
+		},
+	})
+	if _, err := ApplyAlbumReEnrich(string(applyJSON)); err != nil {
+		t.Fatalf("ApplyAlbumReEnrich failed: %v", err)
+	}
+
+	firstFile, err := readAlbumReEnrichFile(first)
+	if err != nil {
+		t.Fatalf("read first: %v", err)
+	}
+	if firstFile.tags["isrc"] != "AAA000000001" || firstFile.tags["date"] != "2001-02-03" || firstFile.tags["qobuz_track_id"] != "11" {
+		t.Fatalf("first file not updated: %v", firstFile.tags)
+	}
+	secondFile, err := readAlbumReEnrichFile(second)
+	if err != nil {
+		t.Fatalf("read second: %v", err)
+	}
+	if secondFile.tags["isrc"] != "AAA000000002" || secondFile.tags["title"] != "Second Song (Remastered)" {
+		t.Fatalf("second file should only get isrc: %v", secondFile.tags)
+	}
+
+	if _, err := ApplyAlbumReEnrich(string(applyJSON)); err == nil {
+		t.Fatalf("expected applied plan to be discarded")
+	}
+}
@@ -0,0 +1,554 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	replayGainReferenceLUFS = -18.0
+	loudnessAbsoluteGate    = -70.0
+	loudnessRelativeGate    = -10.0
+	loudnessBlockMs         = 100
+	loudnessBlocksPerGate   = 4
+	truePeakTapsPerPhase    = 12
+)
+
+// pcmDecoder yields planar PCM normalized to [-1, 1]. Read returns io.EOF
+// once the stream is exhausted.
+type pcmDecoder interface {
+	SampleRate() int
+	Channels() int
+	Read() ([][]float64, error)
+	Close() error
+}
+
+var pcmDecoderOpeners = map[string]func(string) (pcmDecoder, error){
+	".flac": openFLACPCMDecoder,
+	".m4a":  openMP4FLACPCMDecoder,
+}
+
+func openPCMDecoder(filePath string) (pcmDecoder, error) {
+	opener, ok := pcmDecoderOpeners[strings.ToLower(filepath.Ext(filePath))]
+	if !ok {
+		return nil, fmt.Errorf("no PCM decoder for %s", filepath.Ext(filePath))
+	}
+	return opener(filePath)
+}
+
+type flacPCMDecoder struct {
+	dec   *flacDecoder
+	scale float64
+}
+
+func openFLACPCMDecoder(filePath string) (pcmDecoder, error) {
+	return newFLACPCMDecoder(openFLACDecoderFile(filePath))
+}
+
+func openMP4FLACPCMDecoder(filePath string) (pcmDecoder, error) {
+	return newFLACPCMDecoder(openMP4FLACDecoderFile(filePath))
+}
+
+func newFLACPCMDecoder(dec *flacDecoder, err error) (pcmDecoder, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &flacPCMDecoder{
+		dec:   dec,
+		scale: 1.0 / float64(int64(1)<<(dec.Info.BitsPerSample-1)),
+	}, nil
+}
+
+func (d *flacPCMDecoder) SampleRate() int { return d.dec.Info.SampleRate }
+func (d *flacPCMDecoder) Channels() int   { return d.dec.Info.Channels }
+func (d *flacPCMDecoder) Close() error    { return d.dec.Close() }
+
+func (d *flacPCMDecoder) Read() ([][]float64, error) {
+	frame, err := d.dec.NextFrame()
+	if err != nil {
+		return nil, err
+	}
+	out := make([][]float64, len(frame.Samples))
+	for ch, samples := range frame.Samples {
+		out[ch] = make([]float64, len(samples))
+		for i, v := range samples {
+			out[ch][i] = float64(v) * d.scale
+		}
+	}
+	return out, nil
+}
+
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// newKWeightingFilters derives the BS.1770 pre-filter and RLB high-pass for
+// any sample rate, matching the published 48 kHz coefficients.
+func newKWeightingFilters(sampleRate int) (biquad, biquad) {
+	fs := float64(sampleRate)
+
+	f0 := 1681.974450955533
+	gain := 3.999843853973347
+	q := 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / fs)
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	f0 = 38.13547087602444
+	q = 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / fs)
+	a0 = 1 + k/q + k*k
+	highPass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+	return shelf, highPass
+}
+
+func loudnessChannelWeight(channels, ch int) float64 {
+	switch channels {
+	case 5:
+		if ch >= 3 {
+			return 1.41
+		}
+	case 6:
+		if ch == 3 {
+			return 0
+		}
+		if ch >= 4 {
+			return 1.41
+		}
+	}
+	return 1
+}
+
+// truePeakMeter upsamples with a windowed-sinc polyphase interpolator and
+// tracks the highest absolute value seen on any channel.
+type truePeakMeter struct {
+	factor  int
+	phases  [][]float64
+	history [][]float64
+	peak    float64
+}
+
+func newTruePeakMeter(sampleRate, channels int) *truePeakMeter {
+	factor := 1
+	switch {
+	case sampleRate < 96000:
+		factor = 4
+	case sampleRate < 192000:
+		factor = 2
+	}
+
+	m := &truePeakMeter{factor: factor, history: make([][]float64, channels)}
+	for ch := range m.history {
+		m.history[ch] = make([]float64, truePeakTapsPerPhase)
+	}
+	if factor == 1 {
+		return m
+	}
+
+	taps := truePeakTapsPerPhase * factor
+	center := float64(taps-1) / 2
+	m.phases = make([][]float64, factor)
+	for p := range m.phases {
+		m.phases[p] = make([]float64, truePeakTapsPerPhase)
+	}
+	for i := 0; i < taps; i++ {
+		x := (float64(i) - center) / float64(factor)
+		sinc := 1.0
+		if x != 0 {
+			sinc = math.Sin(math.Pi*x) / (math.Pi * x)
+		}
+		window := 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(taps-1))
+		m.phases[i%factor][i/factor] = sinc * window
+	}
+	return m
+}
+
+func (m *truePeakMeter) process(ch int, x float64) {
+	if v := math.Abs(x); v > m.peak {
+		m.peak = v
+	}
+	if m.factor == 1 {
+		return
+	}
+	h := m.history[ch]
+	copy(h[1:], h[:len(h)-1])
+	h[0] = x
+	for _, coeffs := range m.phases {
+		var y float64
+		for i, c := range coeffs {
+			y += c * h[i]
+		}
+		if v := math.Abs(y); v > m.peak {
+			m.peak = v
+		}
+	}
+}
+
+type loudnessMeter struct {
+	channels   int
+	blockSize  int
+	filters    [][2]biquad
+	blockSum   float64
+	blockFill  int
+	subBlocks  []float64
+	gateBlocks []float64
+	samplePeak float64
+	truePeak   *truePeakMeter
+}
+
+func newLoudnessMeter(sampleRate, channels int) *loudnessMeter {
+	m := &loudnessMeter{
+		channels:  channels,
+		blockSize: sampleRate * loudnessBlockMs / 1000,
+		filters:   make([][2]biquad, channels),
+		truePeak:  newTruePeakMeter(sampleRate, channels),
+	}
+	for ch := range m.filters {
+		shelf, highPass := newKWeightingFilters(sampleRate)
+		m.filters[ch] = [2]biquad{shelf, highPass}
+	}
+	return m
+}
+
+func (m *loudnessMeter) add(samples [][]float64) {
+	n := len(samples[0])
+	for i := 0; i < n; i++ {
+		var weighted float64
+		for ch := 0; ch < m.channels && ch < len(samples); ch++ {
+			x := samples[ch][i]
+			if v := math.Abs(x); v > m.samplePeak {
+				m.samplePeak = v
+			}
+			m.truePeak.process(ch, x)
+
+			y := m.filters[ch][0].process(x)
+			y = m.filters[ch][1].process(y)
+			weighted += loudnessChannelWeight(m.channels, ch) * y * y
+		}
+
+		m.blockSum += weighted
+		m.blockFill++
+		if m.blockFill < m.blockSize {
+			continue
+		}
+		m.subBlocks = append(m.subBlocks, m.blockSum/float64(m.blockSize))
+		m.blockSum, m.blockFill = 0, 0
+
+		// 400 ms gating blocks with 75% overlap are built from the last four
+		// 100 ms sub-blocks.
+		if len(m.subBlocks) >= loudnessBlocksPerGate {
+			var sum float64
+			for _, v := range m.subBlocks[len(m.subBlocks)-loudnessBlocksPerGate:] {
+				sum += v
+			}
+			m.gateBlocks = append(m.gateBlocks, sum/loudnessBlocksPerGate)
+		}
+	}
+}
+
+func energyToLUFS(energy float64) float64 {
+	return -0.691 + 10*math.Log10(energy)
+}
+
+// gatedLoudness applies the BS.1770 absolute and relative gates to a set of
+// 400 ms block energies. Album loudness passes the blocks of every track.
+func gatedLoudness(blocks []float64) float64 {
+	var sum float64
+	var count int
+	for _, z := range blocks {
+		if z > 0 && energyToLUFS(z) > loudnessAbsoluteGate {
+			sum += z
+			count++
+		}
+	}
+	if count == 0 {
+		return math.Inf(-1)
+	}
+
+	relativeGate := energyToLUFS(sum/float64(count)) + loudnessRelativeGate
+	sum, count = 0, 0
+	for _, z := range blocks {
+		if z > 0 {
+			if l := energyToLUFS(z); l > loudnessAbsoluteGate && l > relativeGate {
+				sum += z
+				count++
+			}
+		}
+	}
+	if count == 0 {
+		return math.Inf(-1)
+	}
+	return energyToLUFS(sum / float64(count))
+}
+
+type ReplayGainTrackResult struct {
+	FilePath       string  `json:"filePath"`
+	IntegratedLUFS float64 `json:"integratedLufs"`
+	TrackGainDB    float64 `json:"trackGainDb"`
+	TrackPeak      float64 `json:"trackPeak"`
+	SamplePeak     float64 `json:"samplePeak"`
+	Written        bool    `json:"written,omitempty"`
+	WriteMethod    string  `json:"writeMethod,omitempty"`
+	Error          string  `json:"error,omitempty"`
+
+	gateBlocks []float64
+}
+
+type ReplayGainAlbumResult struct {
+	Album          string                  `json:"album"`
+	IntegratedLUFS float64                 `json:"integratedLufs"`
+	AlbumGainDB    float64                 `json:"albumGainDb"`
+	AlbumPeak      float64                 `json:"albumPeak"`
+	Tracks         []ReplayGainTrackResult `json:"tracks"`
+}
+
+type ReplayGainRequest struct {
+	Files  []string `json:"files"`
+	Folder string   `json:"folder"`
+	Mode   string   `json:"mode"`
+	Write  bool     `json:"write"`
+}
+
+type ReplayGainResponse struct {
+	Mode   string                  `json:"mode"`
+	Tracks []ReplayGainTrackResult `json:"tracks,omitempty"`
+	Albums []ReplayGainAlbumResult `json:"albums,omitempty"`
+}
+
+func roundReplayGain(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func analyzeTrackLoudness(filePath string) (*ReplayGainTrackResult, error) {
+	dec, err := openPCMDecoder(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer dec.Close()
+
+	meter := newLoudnessMeter(dec.SampleRate(), dec.Channels())
+	for {
+		samples, err := dec.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		meter.add(samples)
+	}
+
+	loudness := gatedLoudness(meter.gateBlocks)
+	if math.IsInf(loudness, -1) {
+		return nil, fmt.Errorf("audio is too short or silent to measure")
+	}
+	return &ReplayGainTrackResult{
+		FilePath:       filePath,
+		IntegratedLUFS: roundReplayGain(loudness),
+		TrackGainDB:    roundReplayGain(replayGainReferenceLUFS - loudness),
+		TrackPeak:      meter.truePeak.peak,
+		SamplePeak:     meter.samplePeak,
+		gateBlocks:     meter.gateBlocks,
+	}, nil
+}
+
+func replayGainAlbumKey(filePath string) (string, string) {
+	dir := filepath.Dir(filePath)
+	meta, err := ReadMetadata(filePath)
+	if err != nil || strings.TrimSpace(meta.Album) == "" {
+		return "dir:" + dir, filepath.Base(dir)
+	}
+	albumArtist := strings.TrimSpace(meta.AlbumArtist)
+	if albumArtist == "" {
+		albumArtist = strings.TrimSpace(meta.Artist)
+	}
+	return strings.ToLower(albumArtist + "\x00" + meta.Album), meta.Album
+}
+
+func writeReplayGainFields(result *ReplayGainTrackResult, fields map[string]string) {
+	payload, _ := json.Marshal(fields)
+	raw, err := EditFileMetadata(result.FilePath, string(payload))
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	var resp struct {
+		Method string `json:"method"`
+	}
+	_ = json.Unmarshal([]byte(raw), &resp)
+	result.WriteMethod = resp.Method
+	result.Written = strings.HasPrefix(resp.Method, "native")
+}
+
+func collectReplayGainFiles(req ReplayGainRequest) ([]string, error) {
+	files := append([]string(nil), req.Files...)
+	if req.Folder != "" {
+		audioFiles, err := collectLibraryAudioFiles(req.Folder, nil)
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range audioFiles {
+			if _, ok := pcmDecoderOpeners[strings.ToLower(filepath.Ext(file.path))]; ok {
+				files = append(files, file.path)
+			}
+		}
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no files to analyze")
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+func analyzeReplayGainTracks(files []string) []ReplayGainTrackResult {
+	tracks := make([]ReplayGainTrackResult, 0, len(files))
+	for _, path := range files {
+		result, err := analyzeTrackLoudness(path)
+		if err != nil {
+			GoLog("[ReplayGain] Failed to analyze %s: %v\n", filepath.Base(path), err)
+			tracks = append(tracks, ReplayGainTrackResult{FilePath: path, Error: err.Error()})
+			continue
+		}
+		tracks = append(tracks, *result)
+	}
+	return tracks
+}
+
+func AnalyzeReplayGain(requestJSON string) (string, error) {
+	var req ReplayGainRequest
+	if err := json.Unmarshal([]byte(requestJSON), &req); err != nil {
+		return "", fmt.Errorf("invalid request: %w", err)
+	}
+	mode := strings.ToLower(strings.TrimSpace(req.Mode))
+	if mode == "" {
+		mode = "track"
+	}
+	if mode != "track" && mode != "album" {
+		return "", fmt.Errorf("unknown ReplayGain mode: %s", req.Mode)
+	}
+
+	files, err := collectReplayGainFiles(req)
+	if err != nil {
+		return "", err
+	}
+
+	tracks := analyzeReplayGainTracks(files)
+
+	resp := ReplayGainResponse{Mode: mode}
+	if mode == "track" {
+		for i := range tracks {
+			if req.Write && tracks[i].Error == "" {
+				writeReplayGainFields(&tracks[i], map[string]string{
+					"replaygain_track_gain": fmt.Sprintf("%+.2f dB", tracks[i].TrackGainDB),
+					"replaygain_track_peak": fmt.Sprintf("%.6f", tracks[i].TrackPeak),
+				})
+			}
+		}
+		resp.Tracks = tracks
+	} else {
+		resp.Albums = buildReplayGainAlbums(tracks, req.Write)
+	}
+
+	jsonBytes, err := json.Marshal(resp)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal result: %w", err)
+	}
+	return string(jsonBytes), nil
+}
+
+func buildReplayGainAlbums(tracks []ReplayGainTrackResult, write bool) []ReplayGainAlbumResult {
+	var order []string
+	groups := make(map[string]*ReplayGainAlbumResult)
+	for _, track := range tracks {
+		key, name := replayGainAlbumKey(track.FilePath)
+		album, ok := groups[key]
+		if !ok {
+			album = &ReplayGainAlbumResult{Album: name}
+			groups[key] = album
+			order = append(order, key)
+		}
+		album.Tracks = append(album.Tracks, track)
+	}
+
+	albums := make([]ReplayGainAlbumResult, 0, len(order))
+	for _, key := range order {
+		album := groups[key]
+		finalizeReplayGainAlbum(album, write)
+		albums = append(albums, *album)
+	}
+	return albums
+}
+
+// finalizeReplayGainAlbum pools the gating blocks of every analyzed track to
+// derive album loudness and peak, optionally writing all four tags.
+func finalizeReplayGainAlbum(album *ReplayGainAlbumResult, write bool) {
+	var blocks []float64
+	for _, track := range album.Tracks {
+		if track.Error != "" {
+			continue
+		}
+		blocks = append(blocks, track.gateBlocks...)
+		album.AlbumPeak = math.Max(album.AlbumPeak, track.TrackPeak)
+	}
+
+	loudness := gatedLoudness(blocks)
+	if !math.IsInf(loudness, -1) {
+		album.IntegratedLUFS = roundReplayGain(loudness)
+		album.AlbumGainDB = roundReplayGain(replayGainReferenceLUFS - loudness)
+	}
+
+	if write && len(blocks) > 0 {
+		for i := range album.Tracks {
+			track := &album.Tracks[i]
+			if track.Error != "" {
+				continue
+			}
+			writeReplayGainFields(track, map[string]string{
+				"replaygain_track_gain": fmt.Sprintf("%+.2f dB", track.TrackGainDB),
+				"replaygain_track_peak": fmt.Sprintf("%.6f", track.TrackPeak),
+				"replaygain_album_gain": fmt.Sprintf("%+.2f dB", album.AlbumGainDB),
+				"replaygain_album_peak": fmt.Sprintf("%.6f", album.AlbumPeak),
+			})
+		}
+	}
+
+	GoLog("[ReplayGain] Album %q: %.2f LUFS, gain %.2f dB, peak %.6f (%d tracks)\n",
+		album.Album, album.IntegratedLUFS, album.AlbumGainDB, album.AlbumPeak, len(album.Tracks))
+}
+
+func AnalyzeReplayGainForFile(filePath string, write bool) (string, error) {
+	if _, err := os.Stat(filePath); err != nil {
+		return "", fmt.Errorf("file not found: %w", err)
+	}
+	payload, _ := json.Marshal(ReplayGainRequest{Files: []string{filePath}, Mode: "track", Write: write})
+	return AnalyzeReplayGain(string(payload))
+}
@@ -0,0 +1,78 @@
+package gobackend
+
+import (
+	"encoding/json"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeTrackLoudnessMatchesReferenceSine(t *testing.T) {
+	dir := t.TempDir()
+	// EBU Tech 3341 case 1: a stereo 1 kHz sine at -23 dBFS reads -23 LUFS.
+	amplitude := math.Pow(10, -23.0/20) * 32767
+	data := buildTestFLACWith(t, 60, 4096, func(ch, i int) int32 {
+		return int32(math.Round(amplitude * math.Sin(2*math.Pi*997*float64(i)/44100)))
+	})
+	path := writeTestFLAC(t, dir, "sine.flac", data)
+
+	result, err := analyzeTrackLoudness(path)
+	if err != nil {
+		t.Fatalf("analyzeTrackLoudness failed: %v", err)
+	}
+	if math.Abs(result.IntegratedLUFS+23) > 0.2 {
+		t.Fatalf("expected about -23 LUFS, got %.2f", result.IntegratedLUFS)
+	}
+	if math.Abs(result.TrackGainDB-5) > 0.2 {
+		t.Fatalf("expected about +5 dB gain, got %.2f", result.TrackGainDB)
+	}
+	if result.TrackPeak < result.SamplePeak || math.Abs(result.TrackPeak-math.Pow(10, -23.0/20)) > 0.005 {
+		t.Fatalf("unexpected peaks: true=%f sample=%f", result.TrackPeak, result.SamplePeak)
+	}
+}
+
+func TestAnalyzeReplayGainAlbumModeWritesTags(t *testing.T) {
+	dir := t.TempDir()
+	for i, dbfs := range []float64{-20, -26} {
+		amplitude := math.Pow(10, dbfs/20) * 32767
+		data := buildTestFLACWith(t, 20, 4096, func(ch, n int) int32 {
+			return int32(math.Round(amplitude * math.Sin(2*math.Pi*440*float64(n)/44100)))
+		})
+		writeTestFLAC(t, dir, []string{"01.flac", "02.flac"}[i], data)
+	}
+
+	payload, _ := json.Marshal(ReplayGainRequest{Folder: dir, Mode: "album", Write: true})
+	raw, err := AnalyzeReplayGain(string(payload))
+	if err != nil {
+		t.Fatalf("AnalyzeReplayGain failed: %v", err)
+	}
+	var resp ReplayGainResponse
+	if err := json.Unmarshal([]byte(raw), &resp); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if len(resp.Albums) != 1 || len(resp.Albums[0].Tracks) != 2 {
+		t.Fatalf("expected one album with two tracks: %s", raw)
+	}
+	album := resp.Albums[0]
+	quiet, loud := album.Tracks[1], album.Tracks[0]
+	if quiet.TrackGainDB-loud.TrackGainDB < 5.5 || album.AlbumGainDB <= loud.TrackGainDB || album.AlbumGainDB >= quiet.TrackGainDB {
+		t.Fatalf("unexpected gains: %+v", album)
+	}
+	if !loud.Written {
+		t.Fatalf("expected native write, got %+v", loud)
+	}
+
+	meta, err := ReadMetadata(filepath.Join(dir, "01.flac"))
+	if err != nil {
+		t.Fatalf("ReadMetadata failed: %v", err)
+	}
+	if meta.ReplayGainAlbumGain == "" || meta.ReplayGainTrackPeak == "" {
+		t.Fatalf("expected ReplayGain tags to be written, got %+v", meta)
+	}
+	for _, gain := range []string{meta.ReplayGainTrackGain, meta.ReplayGainAlbumGain} {
+		if !strings.HasPrefix(gain, "+") && !strings.HasPrefix(gain, "-") {
+			t.Fatalf("expected signed gain value, got %q", gain)
+		}
+	}
+}
@@ -45,8 +45,8 @@ var (
 	songLinkSearchByISRC = func(ctx context.Context, isrc string) (*TrackMetadata, error) {
 		return GetDeezerClient().SearchByISRC(ctx, isrc)
 	}
-	songLinkCheckAvailabilityFromDeezer = func(s *SongLinkClient, deezerTrackID string) (*TrackAvailability, error) {
-		return s.CheckAvailabilityFromDeezer(deezerTrackID)
+	songLinkCheckAvailabilityFromDeezer = func(ctx context.Context, s *SongLinkClient, deezerTrackID string) (*TrackAvailability, error) {
+		return s.CheckAvailabilityFromDeezerContext(ctx, deezerTrackID)
 	}
 	songLinkRetryConfig = DefaultRetryConfig
 )
@@ -96,25 +96,25 @@ func songLinkBaseURL() string {
 // resolveTrackPlatforms resolves a music URL to all platforms.
 // Spotify URLs use the resolve API; if that fails, falls back to SongLink.
 // All other URLs go directly to SongLink.
-func (s *SongLinkClient) resolveTrackPlatforms(inputURL string) (map[string]songLinkPlatformLink, error) {
+func (s *SongLinkClient) resolveTrackPlatforms(ctx context.Context, inputURL string) (map[string]songLinkPlatformLink, error) {
 	if isSpotifyURL(inputURL) {
 		payload, err := json.Marshal(map[string]string{"url": inputURL})
 		if err != nil {
 			return nil, fmt.Errorf("failed to encode resolve request: %w", err)
 		}
-		links, err := s.doResolveRequest(payload)
+		links, err := s.doResolveRequest(ctx, payload)
 		if err == nil {
 			return links, nil
 		}
 		GoLog("[SongLink] Resolve proxy failed for %s: %v, falling back to SongLink", inputURL, err)
-		return s.songLinkByTargetURL(inputURL)
+		return s.songLinkByTargetURL(ctx, inputURL)
 	}
-	return s.songLinkByTargetURL(inputURL)
+	return s.songLinkByTargetURL(ctx, inputURL)
 }
 
 // resolveTrackPlatformsByPlatform resolves using platform + type + id.
 // Spotify uses the resolve API with SongLink fallback; all other platforms use SongLink directly.
-func (s *SongLinkClient) resolveTrackPlatformsByPlatform(platform, entityType, entityID string) (map[string]songLinkPlatformLink, error) {
+func (s *SongLinkClient) resolveTrackPlatformsByPlatform(ctx context.Context, platform, entityType, entityID string) (map[string]songLinkPlatformLink, error) {
 	if strings.EqualFold(platform, "spotify") {
 		payload, err := json.Marshal(map[string]string{
 			"platform": platform,
@@ -124,14 +124,14 @@ func (s *SongLinkClient) resolveTrackPlatformsByPlatform(platform, entityType, e
 		if err != nil {
 			return nil, fmt.Errorf("failed to encode resolve request: %w", err)
 		}
-		links, err := s.doResolveRequest(payload)
+		links, err := s.doResolveRequest(ctx, payload)
 		if err == nil {
 			return links, nil
 		}
 		GoLog("[SongLink] Resolve proxy failed for %s/%s/%s: %v, falling back to SongLink", platform, entityType, entityID, err)
-		return s.songLinkByPlatform(platform, entityType, entityID)
+		return s.songLinkByPlatform(ctx, platform, entityType, entityID)
 	}
-	return s.songLinkByPlatform(platform, entityType, entityID)
+	return s.songLinkByPlatform(ctx, platform, entityType, entityID)
 }
 
 func isSpotifyURL(u string) bool {
@@ -141,8 +141,8 @@ func isSpotifyURL(u string) bool {
 
 // doResolveRequest sends a JSON payload to the resolve API (api.zarz.moe)
 // and parses the response into a platform link map.
-func (s *SongLinkClient) doResolveRequest(payload []byte) (map[string]songLinkPlatformLink, error) {
-	req, err := http.NewRequest("POST", resolveAPIURL, bytes.NewReader(payload))
+func (s *SongLinkClient) doResolveRequest(ctx context.Context, payload []byte) (map[string]songLinkPlatformLink, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", resolveAPIURL, bytes.NewReader(payload))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create resolve request: %w", err)
 	}
@@ -229,20 +229,24 @@ func extractResolveURLValue(raw json.RawMessage) string {
 }
 
 // songLinkByTargetURL calls the SongLink API with a target URL (for non-Spotify URLs).
-func (s *SongLinkClient) songLinkByTargetURL(targetURL string) (map[string]songLinkPlatformLink, error) {
-	songLinkRateLimiter.WaitForSlot()
+func (s *SongLinkClient) songLinkByTargetURL(ctx context.Context, targetURL string) (map[string]songLinkPlatformLink, error) {
+	if err := songLinkRateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
 
 	apiURL := fmt.Sprintf("%s?url=%s&userCountry=%s",
 		songLinkBaseURL(),
 		url.QueryEscape(targetURL),
 		url.QueryEscape(GetSongLinkRegion()))
 
-	return s.doSongLinkRequest(apiURL)
+	return s.doSongLinkRequest(ctx, apiURL)
 }
 
 // songLinkByPlatform calls the SongLink API with platform + type + id (for non-Spotify platforms).
-func (s *SongLinkClient) songLinkByPlatform(platform, entityType, entityID string) (map[string]songLinkPlatformLink, error) {
-	songLinkRateLimiter.WaitForSlot()
+func (s *SongLinkClient) songLinkByPlatform(ctx context.Context, platform, entityType, entityID string) (map[string]songLinkPlatformLink, error) {
+	if err := songLinkRateLimiter.Wait(ctx); err != nil {
+		return nil, err
+	}
 
 	apiURL := fmt.Sprintf("%s?platform=%s&type=%s&id=%s&userCountry=%s",
 		songLinkBaseURL(),
@@ -251,12 +255,12 @@ func (s *SongLinkClient) songLinkByPlatform(platform, entityType, entityID strin
 		url.QueryEscape(entityID),
 		url.QueryEscape(GetSongLinkRegion()))
 
-	return s.doSongLinkRequest(apiURL)
+	return s.doSongLinkRequest(ctx, apiURL)
 }
 
 // doSongLinkRequest calls the SongLink API and parses the response.
-func (s *SongLinkClient) doSongLinkRequest(apiURL string) (map[string]songLinkPlatformLink, error) {
-	req, err := http.NewRequest("GET", apiURL, nil)
+func (s *SongLinkClient) doSongLinkRequest(ctx context.Context, apiURL string) (map[string]songLinkPlatformLink, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", apiURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create SongLink request: %w", err)
 	}
@@ -269,7 +273,7 @@ func (s *SongLinkClient) doSongLinkRequest(apiURL string) (map[string]songLinkPl
 	defer resp.Body.Close()
 
 	if resp.StatusCode == 429 {
-		return nil, fmt.Errorf("SongLink rate limit exceeded")
+		return nil, newDownloadError("songlink", ErrRateLimited, fmt.Errorf("SongLink rate limit exceeded"))
 	}
 	if resp.StatusCode != 200 {
 		return nil, fmt.Errorf("SongLink returned status %d", resp.StatusCode)
@@ -295,33 +299,37 @@ func (s *SongLinkClient) doSongLinkRequest(apiURL string) (map[string]songLinkPl
 }
 
 func (s *SongLinkClient) CheckTrackAvailability(spotifyTrackID string, isrc string) (*TrackAvailability, error) {
+	return s.CheckTrackAvailabilityContext(context.Background(), spotifyTrackID, isrc)
+}
+
+func (s *SongLinkClient) CheckTrackAvailabilityContext(ctx context.Context, spotifyTrackID string, isrc string) (*TrackAvailability, error) {
 	spotifyTrackID = strings.TrimSpace(spotifyTrackID)
 	isrc = strings.ToUpper(strings.TrimSpace(isrc))
 
 	switch {
 	case spotifyTrackID != "":
-		return s.checkTrackAvailabilityFromSpotify(spotifyTrackID)
+		return s.checkTrackAvailabilityFromSpotify(ctx, spotifyTrackID)
 	case isrc != "":
-		return s.checkTrackAvailabilityFromISRC(isrc)
+		return s.checkTrackAvailabilityFromISRC(ctx, isrc)
 	default:
 		return nil, fmt.Errorf("spotify track ID and ISRC are empty")
 	}
 }
 
-func (s *SongLinkClient) checkTrackAvailabilityFromSpotify(spotifyTrackID string) (*TrackAvailability, error) {
+func (s *SongLinkClient) checkTrackAvailabilityFromSpotify(ctx context.Context, spotifyTrackID string) (*TrackAvailability, error) {
 	spotifyURL := fmt.Sprintf("https://open.spotify.com/track/%s", spotifyTrackID)
-	links, err := s.resolveTrackPlatforms(spotifyURL)
+	links, err := s.resolveTrackPlatforms(ctx, spotifyURL)
 	if err != nil {
 		return nil, fmt.Errorf("resolve proxy failed for Spotify %s: %w", spotifyTrackID, err)
 	}
 	return buildTrackAvailabilityFromSongLinkLinks(spotifyTrackID, links), nil
 }
 
-func (s *SongLinkClient) checkTrackAvailabilityFromISRC(isrc string) (*TrackAvailability, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), SongLinkTimeout)
+func (s *SongLinkClient) checkTrackAvailabilityFromISRC(ctx context.Context, isrc string) (*TrackAvailability, error) {
+	searchCtx, cancel := context.WithTimeout(ctx, SongLinkTimeout)
 	defer cancel()
 
-	track, err := songLinkSearchByISRC(ctx, isrc)
+	track, err := songLinkSearchByISRC(searchCtx, isrc)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve Deezer track from ISRC %s: %w", isrc, err)
 	}
@@ -331,7 +339,7 @@ func (s *SongLinkClient) checkTrackAvailabilityFromISRC(isrc string) (*TrackAvai
 		return nil, fmt.Errorf("failed to resolve Deezer track ID from ISRC %s", isrc)
 	}
 
-	availability, err := songLinkCheckAvailabilityFromDeezer(s, deezerTrackID)
+	availability, err := songLinkCheckAvailabilityFromDeezer(ctx, s, deezerTrackID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to resolve SongLink availability from ISRC %s via Deezer %s: %w", isrc, deezerTrackID, err)
 	}
@@ -516,7 +524,7 @@ func (s *SongLinkClient) GetDeezerIDFromSpotify(spotifyTrackID string) (string,
 	}
 
 	if !availability.Deezer || availability.DeezerID == "" {
-		return "", fmt.Errorf("track not found on Deezer")
+		return "", newDownloadError("songlink", ErrTrackNotFound, fmt.Errorf("track not found on Deezer"))
 	}
 
 	return availability.DeezerID, nil
@@ -529,7 +537,7 @@ func (s *SongLinkClient) GetYouTubeURLFromSpotify(spotifyTrackID string) (string
 	}
 
 	if !availability.YouTube || availability.YouTubeURL == "" {
-		return "", fmt.Errorf("track not found on YouTube")
+		return "", newDownloadError("songlink", ErrTrackNotFound, fmt.Errorf("track not found on YouTube"))
 	}
 
 	return availability.YouTubeURL, nil
@@ -544,7 +552,7 @@ type AlbumAvailability struct {
 
 func (s *SongLinkClient) CheckAlbumAvailability(spotifyAlbumID string) (*AlbumAvailability, error) {
 	spotifyURL := fmt.Sprintf("https://open.spotify.com/album/%s", spotifyAlbumID)
-	links, err := s.resolveTrackPlatforms(spotifyURL)
+	links, err := s.resolveTrackPlatforms(context.Background(), spotifyURL)
 	if err != nil {
 		return nil, fmt.Errorf("resolve proxy failed for album %s: %w", spotifyAlbumID, err)
 	}
@@ -577,15 +585,22 @@ func (s *SongLinkClient) GetDeezerAlbumIDFromSpotify(spotifyAlbumID string) (str
 
 // This is useful when we have Deezer metadata and want to find the track on other platforms
 func (s *SongLinkClient) CheckAvailabilityFromDeezer(deezerTrackID string) (*TrackAvailability, error) {
+	return s.CheckAvailabilityFromDeezerContext(context.Background(), deezerTrackID)
+}
+
+func (s *SongLinkClient) CheckAvailabilityFromDeezerContext(ctx context.Context, deezerTrackID string) (*TrackAvailability, error) {
 	if deezerTrackID == "" {
 		return nil, fmt.Errorf("deezer track ID is empty")
 	}
 
-	availability, err := s.checkAvailabilityFromDeezerSongLink(deezerTrackID)
+	availability, err := s.checkAvailabilityFromDeezerSongLink(ctx, deezerTrackID)
 	if err != nil {
+		if ctx.Err() != nil {
+			return nil, err
+		}
 		LogWarn("SongLink", "SongLink failed for Deezer, trying IDHS fallback: %v", err)
 		idhsClient := NewIDHSClient()
-		availability, err = idhsClient.GetAvailabilityFromDeezer(deezerTrackID)
+		availability, err = idhsClient.GetAvailabilityFromDeezer(ctx, deezerTrackID)
 		if err != nil {
 			return nil, fmt.Errorf("both SongLink and IDHS failed: %w", err)
 		}
@@ -595,9 +610,9 @@ func (s *SongLinkClient) CheckAvailabilityFromDeezer(deezerTrackID string) (*Tra
 	return availability, nil
 }
 
-func (s *SongLinkClient) checkAvailabilityFromDeezerSongLink(deezerTrackID string) (*TrackAvailability, error) {
+func (s *SongLinkClient) checkAvailabilityFromDeezerSongLink(ctx context.Context, deezerTrackID string) (*TrackAvailability, error) {
 	deezerURL := fmt.Sprintf("https://www.deezer.com/track/%s", deezerTrackID)
-	links, err := s.resolveTrackPlatforms(deezerURL)
+	links, err := s.resolveTrackPlatforms(ctx, deezerURL)
 	if err != nil {
 		return nil, fmt.Errorf("resolve failed for Deezer %s: %w", deezerTrackID, err)
 	}
@@ -620,7 +635,7 @@ func (s *SongLinkClient) CheckAvailabilityByPlatform(platform, entityType, entit
 		return nil, fmt.Errorf("%s ID is empty", platform)
 	}
 
-	links, err := s.resolveTrackPlatformsByPlatform(platform, entityType, entityID)
+	links, err := s.resolveTrackPlatformsByPlatform(context.Background(), platform, entityType, entityID)
 	if err != nil {
 		return nil, fmt.Errorf("resolve failed for %s %s: %w", platform, entityID, err)
 	}
@@ -692,7 +707,7 @@ func (s *SongLinkClient) GetSpotifyIDFromDeezer(deezerTrackID string) (string, e
 	}
 
 	if availability.SpotifyID == "" {
-		return "", fmt.Errorf("track not found on Spotify")
+		return "", newDownloadError("songlink", ErrTrackNotFound, fmt.Errorf("track not found on Spotify"))
 	}
 
 	return availability.SpotifyID, nil
@@ -705,7 +720,7 @@ func (s *SongLinkClient) GetTidalURLFromDeezer(deezerTrackID string) (string, er
 	}
 
 	if !availability.Tidal || availability.TidalURL == "" {
-		return "", fmt.Errorf("track not found on Tidal")
+		return "", newDownloadError("songlink", ErrTrackNotFound, fmt.Errorf("track not found on Tidal"))
 	}
 
 	return availability.TidalURL, nil
@@ -718,7 +733,7 @@ func (s *SongLinkClient) GetAmazonURLFromDeezer(deezerTrackID string) (string, e
 	}
 
 	if !availability.Amazon || availability.AmazonURL == "" {
-		return "", fmt.Errorf("track not found on Amazon Music")
+		return "", newDownloadError("songlink", ErrTrackNotFound, fmt.Errorf("track not found on Amazon Music"))
 	}
 
 	return availability.AmazonURL, nil
@@ -731,14 +746,14 @@ func (s *SongLinkClient) GetYouTubeURLFromDeezer(deezerTrackID string) (string,
 	}
 
 	if !availability.YouTube || availability.YouTubeURL == "" {
-		return "", fmt.Errorf("track not found on YouTube")
+		return "", newDownloadError("songlink", ErrTrackNotFound, fmt.Errorf("track not found on YouTube"))
 	}
 
 	return availability.YouTubeURL, nil
 }
 
 func (s *SongLinkClient) CheckAvailabilityFromURL(inputURL string) (*TrackAvailability, error) {
-	links, err := s.resolveTrackPlatforms(inputURL)
+	links, err := s.resolveTrackPlatforms(context.Background(), inputURL)
 	if err != nil {
 		return nil, fmt.Errorf("resolve failed for URL %s: %w", inputURL, err)
 	}
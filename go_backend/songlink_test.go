@@ -1,6 +1,7 @@
 package gobackend
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"strings"
@@ -185,7 +186,7 @@ func TestCheckAvailabilityFromDeezerUsesSongLink(t *testing.T) {
 		},
 	}
 
-	availability, err := client.checkAvailabilityFromDeezerSongLink("908604612")
+	availability, err := client.checkAvailabilityFromDeezerSongLink(context.Background(), "908604612")
 	if err != nil {
 		t.Fatalf("checkAvailabilityFromDeezerSongLink() error = %v", err)
 	}
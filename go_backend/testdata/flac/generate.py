@@ -0,0 +1,428 @@
+#!/usr/bin/env python3
+"""Regenerates the FLAC fixtures used by flac_verify_test.go.
+
+The encoder is written from the FLAC format specification and shares no code
+with the Go decoder: CRCs, bit packing, prediction and the STREAMINFO MD5 are
+computed here from the source PCM. Each frame is encoded according to an
+explicit plan so every decoder path is exercised on purpose:
+
+  fixed_16.flac   16-bit stereo, fixed predictors of order 0-4, verbatim,
+                  Rice partition orders 0-3, short final frame.
+  lpc_16.flac     16-bit stereo, LPC of order 1-32 with 12-15 bit
+                  coefficients, left/side, right/side and mid/side frames,
+                  4- and 5-bit Rice parameters, partition orders up to 5.
+  escape_24.flac  24-bit stereo, constant subframes, escaped partitions
+                  (including zero-width ones), wasted bits, Rice parameters
+                  above 14, sample size and rate taken from STREAMINFO, and an
+                  8-bit block size in the final frame header.
+
+Run from this directory: python3 generate.py
+"""
+
+import hashlib
+import math
+import struct
+
+
+class BitWriter:
+    def __init__(self):
+        self.bits = []
+
+    def write(self, value, n):
+        for i in range(n - 1, -1, -1):
+            self.bits.append((value >> i) & 1)
+
+    def write_signed(self, value, n):
+        if n:
+            self.write(value & ((1 << n) - 1), n)
+
+    def write_unary(self, q):
+        self.bits.extend([0] * q)
+        self.bits.append(1)
+
+    def align(self):
+        while len(self.bits) % 8:
+            self.bits.append(0)
+
+    def to_bytes(self):
+        assert len(self.bits) % 8 == 0
+        out = bytearray()
+        for i in range(0, len(self.bits), 8):
+            b = 0
+            for bit in self.bits[i:i + 8]:
+                b = b << 1 | bit
+            out.append(b)
+        return bytes(out)
+
+
+def crc8(data):
+    crc = 0
+    for b in data:
+        crc ^= b
+        for _ in range(8):
+            crc = ((crc << 1) ^ 0x07) & 0xFF if crc & 0x80 else (crc << 1) & 0xFF
+    return crc
+
+
+def crc16(data):
+    crc = 0
+    for b in data:
+        crc ^= b << 8
+        for _ in range(8):
+            crc = ((crc << 1) ^ 0x8005) & 0xFFFF if crc & 0x8000 else (crc << 1) & 0xFFFF
+    return crc
+
+
+def signed_bits(values):
+    """Smallest two's complement width holding every value (0 if all zero)."""
+    width = 0
+    for v in values:
+        n = 1
+        while not -(1 << (n - 1)) <= v <= (1 << (n - 1)) - 1:
+            n += 1
+        if v != 0:
+            width = max(width, n)
+    return width
+
+
+FIXED_COEFFS = [[], [1], [2, -1], [3, -3, 1], [4, -6, 4, -1]]
+
+
+def fixed_residual(x, order):
+    c = FIXED_COEFFS[order]
+    return [x[i] - sum(c[j] * x[i - j - 1] for j in range(order)) for i in range(order, len(x))]
+
+
+def lpc_coefficients(x, order, precision):
+    n = len(x)
+    autoc = [sum(x[i] * x[i - lag] for i in range(lag, n)) for lag in range(order + 1)]
+    if autoc[0] == 0:
+        return [0] * order, 0
+    err = float(autoc[0])
+    lpc = [0.0] * order
+    for i in range(order):
+        acc = autoc[i + 1] - sum(lpc[j] * autoc[i - j] for j in range(i))
+        k = acc / err if err else 0.0
+        new = lpc[:]
+        new[i] = k
+        for j in range(i):
+            new[j] = lpc[j] - k * lpc[i - j - 1]
+        lpc = new
+        err *= 1.0 - k * k
+        if err <= 0:
+            err = 1e-9
+    cmax = max(abs(c) for c in lpc) or 1.0
+    log2cmax = math.frexp(cmax)[1]
+    shift = max(0, min(15, precision - 1 - log2cmax))
+    qmax = (1 << (precision - 1)) - 1
+    qmin = -(1 << (precision - 1))
+    return [max(qmin, min(qmax, int(round(c * (1 << shift))))) for c in lpc], shift
+
+
+def lpc_residual(x, coeffs, shift):
+    order = len(coeffs)
+    return [x[i] - (sum(coeffs[j] * x[i - j - 1] for j in range(order)) >> shift)
+            for i in range(order, len(x))]
+
+
+def fold(r):
+    return r << 1 if r >= 0 else (-r << 1) - 1
+
+
+def write_residual(w, residual, order, block_size, method, partition_order, escape):
+    """escape is True (every partition), False, or a set of partition indexes."""
+    param_bits, escape_code = (4, 0xF) if method == 0 else (5, 0x1F)
+    partitions = 1 << partition_order
+    size = block_size >> partition_order
+    assert block_size % partitions == 0 and size >= order
+    w.write(method, 2)
+    w.write(partition_order, 4)
+    pos = 0
+    for p in range(partitions):
+        count = size - order if p == 0 else size
+        part = residual[pos:pos + count]
+        pos += count
+        if escape is True or (escape and p in escape):
+            raw = signed_bits(part)
+            w.write(escape_code, param_bits)
+            w.write(raw, 5)
+            for r in part:
+                w.write_signed(r, raw)
+            continue
+        best = None
+        for k in range(escape_code):
+            cost = sum((fold(r) >> k) + 1 + k for r in part)
+            if best is None or cost < best[0]:
+                best = (cost, k)
+        k = best[1]
+        w.write(k, param_bits)
+        for r in part:
+            u = fold(r)
+            w.write_unary(u >> k)
+            w.write(u & ((1 << k) - 1), k)
+    assert pos == len(residual)
+
+
+def wasted_bits(x):
+    acc = 0
+    for v in x:
+        acc |= v
+    if acc == 0:
+        return 0
+    k = 0
+    while not acc & 1:
+        acc >>= 1
+        k += 1
+    return k
+
+
+def write_subframe(w, x, bps, spec):
+    kind = spec[0]
+    opts = spec[1] if len(spec) > 1 else {}
+    shift = wasted_bits(x) if opts.get('wasted') else 0
+    if shift:
+        x = [v >> shift for v in x]
+        bps -= shift
+    assert all(-(1 << (bps - 1)) <= v < (1 << (bps - 1)) for v in x)
+
+    if kind == 'constant':
+        assert all(v == x[0] for v in x)
+        type_code = 0
+    elif kind == 'verbatim':
+        type_code = 1
+    elif kind == 'fixed':
+        type_code = 8 + opts['order']
+    elif kind == 'lpc':
+        type_code = 32 + opts['order'] - 1
+    else:
+        raise ValueError(kind)
+
+    w.write(0, 1)
+    w.write(type_code, 6)
+    if shift:
+        w.write(1, 1)
+        w.write_unary(shift - 1)
+    else:
+        w.write(0, 1)
+
+    if kind == 'constant':
+        w.write_signed(x[0], bps)
+        return
+    if kind == 'verbatim':
+        for v in x:
+            w.write_signed(v, bps)
+        return
+
+    order = opts['order']
+    for v in x[:order]:
+        w.write_signed(v, bps)
+    if kind == 'fixed':
+        residual = fixed_residual(x, order)
+    else:
+        precision = opts['precision']
+        coeffs, qshift = lpc_coefficients(x, order, precision)
+        w.write(precision - 1, 4)
+        w.write_signed(qshift, 5)
+        for c in coeffs:
+            w.write_signed(c, precision)
+        residual = lpc_residual(x, coeffs, qshift)
+    assert all(-(1 << 31) <= r < (1 << 31) for r in residual)
+    write_residual(w, residual, order, len(x), opts.get('method', 0),
+                   opts.get('partition_order', 0), opts.get('escape', False))
+
+
+BLOCK_SIZE_CODES = {192: 1, 576: 2, 1152: 3, 2304: 4, 4608: 5,
+                    256: 8, 512: 9, 1024: 10, 2048: 11, 4096: 12}
+SAMPLE_RATE_CODES = {88200: 1, 176400: 2, 192000: 3, 8000: 4, 16000: 5, 22050: 6,
+                     24000: 7, 32000: 8, 44100: 9, 48000: 10, 96000: 11}
+SAMPLE_SIZE_CODES = {8: 1, 12: 2, 16: 4, 20: 5, 24: 6}
+CHANNEL_MODES = {'independent': 1, 'left_side': 8, 'side_right': 9, 'mid_side': 10}
+
+
+def encode_frame(number, left, right, bps, rate, plan):
+    n = len(left)
+    mode = plan['mode']
+    if mode == 'independent':
+        channels = [(left, bps), (right, bps)]
+    elif mode == 'left_side':
+        channels = [(left, bps), ([l - r for l, r in zip(left, right)], bps + 1)]
+    elif mode == 'side_right':
+        channels = [([l - r for l, r in zip(left, right)], bps + 1), (right, bps)]
+    else:
+        channels = [([(l + r) >> 1 for l, r in zip(left, right)], bps),
+                    ([l - r for l, r in zip(left, right)], bps + 1)]
+
+    w = BitWriter()
+    w.write(0xFFF8, 16)
+    if n in BLOCK_SIZE_CODES and not plan.get('explicit_size'):
+        w.write(BLOCK_SIZE_CODES[n], 4)
+        size_tail = None
+    elif n <= 256:
+        w.write(6, 4)
+        size_tail = (n - 1, 8)
+    else:
+        w.write(7, 4)
+        size_tail = (n - 1, 16)
+    w.write(0 if plan.get('rate_from_streaminfo') else SAMPLE_RATE_CODES[rate], 4)
+    w.write(CHANNEL_MODES[mode], 4)
+    w.write(0 if plan.get('size_from_streaminfo') else SAMPLE_SIZE_CODES[bps], 3)
+    w.write(0, 1)
+    assert number < 128
+    w.write(number, 8)
+    if size_tail:
+        w.write(*size_tail)
+    header = w.to_bytes()
+    w.write(crc8(header), 8)
+
+    for (samples, width), spec in zip(channels, plan['subframes']):
+        write_subframe(w, samples, width, spec)
+    w.align()
+    body = w.to_bytes()
+    return body + struct.pack('>H', crc16(body))
+
+
+def pcm_bytes(left, right, bps):
+    width = (bps + 7) // 8
+    out = bytearray()
+    for l, r in zip(left, right):
+        for v in (l, r):
+            out += (v & ((1 << (8 * width)) - 1)).to_bytes(width, 'little')
+    return bytes(out)
+
+
+def metadata_block(block_type, data, last):
+    return bytes([(0x80 if last else 0) | block_type]) + len(data).to_bytes(3, 'big') + data
+
+
+def encode_file(path, left, right, bps, rate, block_size, plans):
+    frames = []
+    for i, plan in enumerate(plans):
+        start = i * block_size
+        frames.append(encode_frame(i, left[start:start + block_size],
+                                   right[start:start + block_size], bps, rate, plan))
+    assert len(plans) * block_size >= len(left) > (len(plans) - 1) * block_size
+
+    w = BitWriter()
+    w.write(block_size, 16)
+    w.write(block_size, 16)
+    w.write(min(len(f) for f in frames), 24)
+    w.write(max(len(f) for f in frames), 24)
+    w.write(rate, 20)
+    w.write(1, 3)
+    w.write(bps - 1, 5)
+    w.write(len(left), 36)
+    streaminfo = w.to_bytes() + hashlib.md5(pcm_bytes(left, right, bps)).digest()
+
+    vendor = b'spotiflac fixture encoder'
+    comment = struct.pack('<I', len(vendor)) + vendor + struct.pack('<I', 1)
+    tag = b'TITLE=' + path.split('.')[0].encode()
+    comment += struct.pack('<I', len(tag)) + tag
+
+    with open(path, 'wb') as f:
+        f.write(b'fLaC')
+        f.write(metadata_block(0, streaminfo, False))
+        f.write(metadata_block(4, comment, False))
+        f.write(metadata_block(1, bytes(16), True))
+        for frame in frames:
+            f.write(frame)
+
+
+class Noise:
+    def __init__(self, seed):
+        self.state = seed
+
+    def next(self, amplitude):
+        self.state = (self.state * 1103515245 + 12345) & 0x7FFFFFFF
+        return (self.state % (2 * amplitude + 1)) - amplitude
+
+
+def tone(n, peak, freqs, rate, noise, noise_amp, phase=0.0):
+    out = []
+    for i in range(n):
+        v = sum(math.sin(2 * math.pi * f * i / rate + phase * k) for k, f in enumerate(freqs))
+        out.append(int(round(v * peak / len(freqs))) + noise.next(noise_amp))
+    return out
+
+
+def clamp(x, bps):
+    lo, hi = -(1 << (bps - 1)), (1 << (bps - 1)) - 1
+    return [max(lo, min(hi, v)) for v in x]
+
+
+def fixed_16():
+    rate, bs, n = 44100, 1152, 3 * 1152 + 700
+    noise = Noise(1)
+    left = clamp(tone(n, 20000, [220, 331], rate, noise, 40), 16)
+    right = clamp(tone(n, 16000, [440, 97], rate, noise, 40, 0.7), 16)
+    plans = [
+        {'mode': 'independent', 'subframes': [('fixed', {'order': 0}), ('fixed', {'order': 1})]},
+        {'mode': 'independent', 'subframes': [('fixed', {'order': 2, 'partition_order': 2}),
+                                              ('fixed', {'order': 3, 'partition_order': 1})]},
+        {'mode': 'independent', 'subframes': [('fixed', {'order': 4, 'partition_order': 3}),
+                                              ('verbatim',)]},
+        {'mode': 'independent', 'subframes': [('fixed', {'order': 2, 'partition_order': 2}),
+                                              ('fixed', {'order': 1, 'partition_order': 2})]},
+    ]
+    encode_file('fixed_16.flac', left, right, 16, rate, bs, plans)
+
+
+def lpc_16():
+    rate, bs, n = 48000, 2304, 3 * 2304 + 1000
+    noise = Noise(2)
+    left = clamp(tone(n, 24000, [180, 523, 1210], rate, noise, 30), 16)
+    right = [v - 900 + noise.next(20) for v in left[:2304]]
+    right += clamp(tone(n - 2304, 22000, [180, 640], rate, noise, 30, 1.1), 16)
+    right = clamp(right, 16)
+    plans = [
+        {'mode': 'left_side', 'subframes': [
+            ('lpc', {'order': 8, 'precision': 12, 'partition_order': 4}),
+            ('lpc', {'order': 2, 'precision': 13, 'method': 1, 'partition_order': 2})]},
+        {'mode': 'side_right', 'subframes': [
+            ('lpc', {'order': 12, 'precision': 15, 'method': 1, 'partition_order': 5}),
+            ('lpc', {'order': 1, 'precision': 14})]},
+        {'mode': 'mid_side', 'subframes': [
+            ('lpc', {'order': 32, 'precision': 14, 'partition_order': 3}),
+            ('lpc', {'order': 16, 'precision': 15, 'method': 1, 'partition_order': 4})]},
+        {'mode': 'mid_side', 'subframes': [
+            ('lpc', {'order': 5, 'precision': 12, 'partition_order': 3}),
+            ('fixed', {'order': 2, 'partition_order': 3})]},
+    ]
+    encode_file('lpc_16.flac', left, right, 16, rate, bs, plans)
+
+
+def escape_24():
+    rate, bs, n = 96000, 1024, 3 * 1024 + 200
+    noise = Noise(3)
+    # Frame 0: constant left; right is silent for its first quarter.
+    left = [-1234567] * bs
+    right = [0] * 256 + clamp(tone(bs - 256, 3000000, [1000], rate, noise, 500), 24)
+    # Frame 1: 16-bit audio padded to 24 bits, so 8 bits are wasted.
+    left += [v << 8 for v in clamp(tone(bs, 20000, [700, 90], rate, noise, 10), 16)]
+    right += [v << 8 for v in clamp(tone(bs, 18000, [700, 3000], rate, noise, 10, 0.4), 16)]
+    # Frame 2: loud noise drives Rice parameters past the 4-bit range.
+    left += [noise.next(8000000) for _ in range(bs)]
+    right += [noise.next(4000000) for _ in range(bs)]
+    # Frame 3: short tail.
+    left += clamp(tone(n - 3 * bs, 5000000, [2500], rate, noise, 1000), 24)
+    right += clamp(tone(n - 3 * bs, 5000000, [2500], rate, noise, 1000, 0.3), 24)
+    plans = [
+        {'mode': 'independent', 'subframes': [
+            ('constant',),
+            ('fixed', {'order': 2, 'partition_order': 2, 'escape': {0, 2}})]},
+        {'mode': 'mid_side', 'subframes': [
+            ('lpc', {'order': 16, 'precision': 15, 'partition_order': 2, 'wasted': True}),
+            ('fixed', {'order': 3, 'partition_order': 1, 'wasted': True, 'escape': {1}})]},
+        {'mode': 'independent', 'size_from_streaminfo': True, 'rate_from_streaminfo': True,
+         'subframes': [('fixed', {'order': 0, 'method': 1}),
+                       ('verbatim', {'wasted': True})]},
+        {'mode': 'side_right', 'subframes': [
+            ('lpc', {'order': 4, 'precision': 13, 'partition_order': 3, 'escape': True}),
+            ('lpc', {'order': 6, 'precision': 12, 'partition_order': 2})]},
+    ]
+    encode_file('escape_24.flac', left, right, 24, rate, bs, plans)
+
+
+if __name__ == '__main__':
+    fixed_16()
+    lpc_16()
+    escape_24()
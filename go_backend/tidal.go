@@ -28,11 +28,11 @@ type TidalDownloader struct {
 var (
 	globalTidalDownloader       *TidalDownloader
 	tidalDownloaderOnce         sync.Once
-	tidalGetTrackSearchPageFunc = func(t *TidalDownloader, query string, limit int) (*tidalPublicTrackSearchResponse, error) {
-		return t.getTrackSearchPage(query, limit)
+	tidalGetTrackSearchPageFunc = func(ctx context.Context, t *TidalDownloader, query string, limit int) (*tidalPublicTrackSearchResponse, error) {
+		return t.getTrackSearchPage(ctx, query, limit)
 	}
-	tidalGetPublicTrackFunc = func(t *TidalDownloader, resourceID string) (*TidalTrack, error) {
-		return t.getPublicTrack(resourceID)
+	tidalGetPublicTrackFunc = func(ctx context.Context, t *TidalDownloader, resourceID string) (*TidalTrack, error) {
+		return t.getPublicTrack(ctx, resourceID)
 	}
 )
 
@@ -514,8 +514,8 @@ func tidalBuildMetadataURL(path string, extraQuery url.Values) string {
 	return baseURL.String()
 }
 
-func (t *TidalDownloader) getTidalMetadataJSON(requestURL string, target interface{}) error {
-	req, err := http.NewRequest("GET", requestURL, nil)
+func (t *TidalDownloader) getTidalMetadataJSON(ctx context.Context, requestURL string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
 		return err
 	}
@@ -536,7 +536,7 @@ func (t *TidalDownloader) getTidalMetadataJSON(requestURL string, target interfa
 	return json.NewDecoder(resp.Body).Decode(target)
 }
 
-func (t *TidalDownloader) getPublicTrack(resourceID string) (*TidalTrack, error) {
+func (t *TidalDownloader) getPublicTrack(ctx context.Context, resourceID string) (*TidalTrack, error) {
 	trackID, err := strconv.ParseInt(strings.TrimSpace(resourceID), 10, 64)
 	if err != nil || trackID <= 0 {
 		return nil, fmt.Errorf("invalid tidal track ID: %s", resourceID)
@@ -544,7 +544,7 @@ func (t *TidalDownloader) getPublicTrack(resourceID string) (*TidalTrack, error)
 
 	requestURL := tidalBuildMetadataURL(fmt.Sprintf("tracks/%d", trackID), nil)
 	var track TidalTrack
-	if err := t.getTidalMetadataJSON(requestURL, &track); err != nil {
+	if err := t.getTidalMetadataJSON(ctx, requestURL, &track); err != nil {
 		return nil, err
 	}
 	return &track, nil
@@ -558,7 +558,7 @@ func (t *TidalDownloader) getAlbumPage(resourceID string) (*tidalPublicAlbumPage
 
 	requestURL := tidalBuildMetadataURL("pages/album", url.Values{"albumId": {albumID}})
 	var page tidalPublicAlbumPage
-	if err := t.getTidalMetadataJSON(requestURL, &page); err != nil {
+	if err := t.getTidalMetadataJSON(context.Background(), requestURL, &page); err != nil {
 		return nil, err
 	}
 	return &page, nil
@@ -572,7 +572,7 @@ func (t *TidalDownloader) getArtistPage(resourceID string) (*tidalPublicArtistPa
 
 	requestURL := tidalBuildMetadataURL("pages/artist", url.Values{"artistId": {artistID}})
 	var page tidalPublicArtistPage
-	if err := t.getTidalMetadataJSON(requestURL, &page); err != nil {
+	if err := t.getTidalMetadataJSON(context.Background(), requestURL, &page); err != nil {
 		return nil, err
 	}
 	return &page, nil
@@ -589,7 +589,7 @@ func (t *TidalDownloader) getArtistAlbumsPage(dataAPIPath string, offset, limit
 
 	requestURL := tidalBuildMetadataURL(dataAPIPath, extraQuery)
 	var page tidalPublicArtistAlbumsPage
-	if err := t.getTidalMetadataJSON(requestURL, &page); err != nil {
+	if err := t.getTidalMetadataJSON(context.Background(), requestURL, &page); err != nil {
 		return nil, err
 	}
 	return &page, nil
@@ -603,7 +603,7 @@ func (t *TidalDownloader) getPlaylist(resourceID string) (*tidalPublicPlaylist,
 
 	requestURL := tidalBuildMetadataURL("playlists/"+url.PathEscape(playlistID), nil)
 	var playlist tidalPublicPlaylist
-	if err := t.getTidalMetadataJSON(requestURL, &playlist); err != nil {
+	if err := t.getTidalMetadataJSON(context.Background(), requestURL, &playlist); err != nil {
 		return nil, err
 	}
 	return &playlist, nil
@@ -623,13 +623,13 @@ func (t *TidalDownloader) getPlaylistItemsPage(resourceID string, offset, limit
 		},
 	)
 	var page tidalPublicPlaylistItemsPage
-	if err := t.getTidalMetadataJSON(requestURL, &page); err != nil {
+	if err := t.getTidalMetadataJSON(context.Background(), requestURL, &page); err != nil {
 		return nil, err
 	}
 	return &page, nil
 }
 
-func (t *TidalDownloader) getTrackSearchPage(query string, limit int) (*tidalPublicTrackSearchResponse, error) {
+func (t *TidalDownloader) getTrackSearchPage(ctx context.Context, query string, limit int) (*tidalPublicTrackSearchResponse, error) {
 	cleanQuery := strings.TrimSpace(query)
 	if cleanQuery == "" {
 		return nil, fmt.Errorf("empty tidal search query")
@@ -647,7 +647,7 @@ func (t *TidalDownloader) getTrackSearchPage(query string, limit int) (*tidalPub
 		},
 	)
 	var page tidalPublicTrackSearchResponse
-	if err := t.getTidalMetadataJSON(requestURL, &page); err != nil {
+	if err := t.getTidalMetadataJSON(ctx, requestURL, &page); err != nil {
 		return nil, err
 	}
 	return &page, nil
@@ -799,7 +799,7 @@ func (t *TidalDownloader) SearchTrackByISRC(isrc string) (*TidalTrack, error) {
 		return nil, fmt.Errorf("empty tidal ISRC")
 	}
 
-	page, err := tidalGetTrackSearchPageFunc(t, normalizedISRC, 20)
+	page, err := tidalGetTrackSearchPageFunc(context.Background(), t, normalizedISRC, 20)
 	if err != nil {
 		return nil, err
 	}
@@ -813,7 +813,7 @@ func (t *TidalDownloader) SearchTrackByISRC(isrc string) (*TidalTrack, error) {
 	return nil, fmt.Errorf("no exact tidal ISRC match found for %s", normalizedISRC)
 }
 
-func (t *TidalDownloader) SearchTrackByMetadataWithISRC(trackName, artistName, albumName, spotifyISRC string, expectedDuration int) (*TidalTrack, error) {
+func (t *TidalDownloader) SearchTrackByMetadataWithISRC(ctx context.Context, trackName, artistName, albumName, spotifyISRC string, expectedDuration int) (*TidalTrack, error) {
 	queryParts := make([]string, 0, 3)
 	if trimmed := strings.TrimSpace(trackName); trimmed != "" {
 		queryParts = append(queryParts, trimmed)
@@ -845,7 +845,7 @@ func (t *TidalDownloader) SearchTrackByMetadataWithISRC(trackName, artistName, a
 		}
 		seenQueries[query] = struct{}{}
 
-		page, err := tidalGetTrackSearchPageFunc(t, query, 20)
+		page, err := tidalGetTrackSearchPageFunc(ctx, t, query, 20)
 		if err != nil {
 			return nil, err
 		}
@@ -889,11 +889,11 @@ func (t *TidalDownloader) SearchTrackByMetadataWithISRC(trackName, artistName, a
 }
 
 func (t *TidalDownloader) SearchTrackByMetadata(trackName, artistName string) (*TidalTrack, error) {
-	return t.SearchTrackByMetadataWithISRC(trackName, artistName, "", "", 0)
+	return t.SearchTrackByMetadataWithISRC(context.Background(), trackName, artistName, "", "", 0)
 }
 
 func (t *TidalDownloader) SearchTracks(query string, limit int) ([]ExtTrackMetadata, error) {
-	page, err := t.getTrackSearchPage(query, limit)
+	page, err := t.getTrackSearchPage(context.Background(), query, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -940,7 +940,7 @@ func (t *TidalDownloader) SearchAll(query string, trackLimit, artistLimit int, f
 	}
 
 	if trackLimit > 0 {
-		page, err := t.getTrackSearchPage(cleanQuery, trackLimit)
+		page, err := t.getTrackSearchPage(context.Background(), cleanQuery, trackLimit)
 		if err != nil {
 			GoLog("[Tidal] Track search failed: %v\n", err)
 			return nil, fmt.Errorf("tidal track search failed: %w", err)
@@ -966,7 +966,7 @@ func (t *TidalDownloader) SearchAll(query string, trackLimit, artistLimit int, f
 				URL        string `json:"url"`
 			} `json:"items"`
 		}
-		if err := t.getTidalMetadataJSON(requestURL, &artistResp); err == nil {
+		if err := t.getTidalMetadataJSON(context.Background(), requestURL, &artistResp); err == nil {
 			GoLog("[Tidal] Got %d artists from API\n", len(artistResp.Items))
 			for _, artist := range artistResp.Items {
 				result.Artists = append(result.Artists, SearchArtistResult{
@@ -991,7 +991,7 @@ func (t *TidalDownloader) SearchAll(query string, trackLimit, artistLimit int, f
 		var albumResp struct {
 			Items []tidalPublicAlbum `json:"items"`
 		}
-		if err := t.getTidalMetadataJSON(requestURL, &albumResp); err == nil {
+		if err := t.getTidalMetadataJSON(context.Background(), requestURL, &albumResp); err == nil {
 			GoLog("[Tidal] Got %d albums from API\n", len(albumResp.Items))
 			for i := range albumResp.Items {
 				album := &albumResp.Items[i]
@@ -1019,7 +1019,7 @@ func (t *TidalDownloader) SearchAll(query string, trackLimit, artistLimit int, f
 }
 
 func (t *TidalDownloader) GetTrackMetadata(resourceID string) (*TrackResponse, error) {
-	track, err := t.getPublicTrack(resourceID)
+	track, err := t.getPublicTrack(context.Background(), resourceID)
 	if err != nil {
 		return nil, err
 	}
@@ -1193,34 +1193,29 @@ type TidalDownloadInfo struct {
 	SampleRate int
 }
 
-type tidalAPIResult struct {
-	apiURL   string
-	info     TidalDownloadInfo
-	err      error
-	duration time.Duration
-}
-
 const (
 	tidalAPITimeoutMobile = 25 * time.Second
 	tidalMaxRetries       = 2
 	tidalRetryDelay       = 500 * time.Millisecond
 )
 
-func fetchTidalURLWithRetry(api string, trackID int64, quality string, timeout time.Duration) (TidalDownloadInfo, error) {
+func fetchTidalURLWithRetry(ctx context.Context, api string, trackID int64, quality string, timeout time.Duration) (TidalDownloadInfo, error) {
 	var lastErr error
 	retryDelay := tidalRetryDelay
 
 	for attempt := 0; attempt <= tidalMaxRetries; attempt++ {
 		if attempt > 0 {
 			GoLog("[Tidal] Retry %d/%d for %s after %v\n", attempt, tidalMaxRetries, api, retryDelay)
-			time.Sleep(retryDelay)
+			if err := sleepWithContext(ctx, retryDelay); err != nil {
+				return TidalDownloadInfo{}, err
+			}
 			retryDelay *= 2
 		}
 
 		client := NewHTTPClientWithTimeout(timeout)
 		reqURL := fmt.Sprintf("%s/track/?id=%d&quality=%s", api, trackID, quality)
 
-		req, err := http.NewRequest("GET", reqURL, nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", reqURL, nil)
 		if err != nil {
 			lastErr = err
 			continue
@@ -1229,6 +1224,9 @@ func fetchTidalURLWithRetry(api string, trackID int64, quality string, timeout t
 		resp, err := client.Do(req)
 		if err != nil {
 			lastErr = err
+			if ctx.Err() != nil {
+				return TidalDownloadInfo{}, ctx.Err()
+			}
 			errStr := strings.ToLower(err.Error())
 			if strings.Contains(errStr, "timeout") ||
 				strings.Contains(errStr, "reset") ||
@@ -1248,7 +1246,7 @@ func fetchTidalURLWithRetry(api string, trackID int64, quality string, timeout t
 		if resp.StatusCode == 429 {
 			io.Copy(io.Discard, resp.Body)
 			resp.Body.Close()
-			lastErr = fmt.Errorf("rate limited")
+			lastErr = newDownloadError("tidal", ErrRateLimited, fmt.Errorf("rate limited"))
 			retryDelay = 2 * time.Second
 			continue
 		}
@@ -1256,7 +1254,7 @@ func fetchTidalURLWithRetry(api string, trackID int64, quality string, timeout t
 		if resp.StatusCode != 200 {
 			io.Copy(io.Discard, resp.Body)
 			resp.Body.Close()
-			return TidalDownloadInfo{}, fmt.Errorf("HTTP %d", resp.StatusCode)
+			return TidalDownloadInfo{}, mirrorStatusError("tidal", resp.StatusCode)
 		}
 
 		body, err := io.ReadAll(resp.Body)
@@ -1269,7 +1267,7 @@ func fetchTidalURLWithRetry(api string, trackID int64, quality string, timeout t
 		var v2Response TidalAPIResponseV2
 		if err := json.Unmarshal(body, &v2Response); err == nil && v2Response.Data.Manifest != "" {
 			if v2Response.Data.AssetPresentation == "PREVIEW" {
-				return TidalDownloadInfo{}, fmt.Errorf("returned PREVIEW instead of FULL")
+				return TidalDownloadInfo{}, newDownloadError("tidal", ErrRegionLocked, fmt.Errorf("returned PREVIEW instead of FULL"))
 			}
 
 			return TidalDownloadInfo{
@@ -1294,7 +1292,7 @@ func fetchTidalURLWithRetry(api string, trackID int64, quality string, timeout t
 			}
 		}
 
-		return TidalDownloadInfo{}, fmt.Errorf("no download URL or manifest in response")
+		return TidalDownloadInfo{}, newDownloadError("tidal", ErrQualityUnavailable, fmt.Errorf("no download URL or manifest in response"))
 	}
 
 	if lastErr != nil {
@@ -1303,64 +1301,37 @@ func fetchTidalURLWithRetry(api string, trackID int64, quality string, timeout t
 	return TidalDownloadInfo{}, fmt.Errorf("all retries failed")
 }
 
-func getDownloadURLParallel(apis []string, trackID int64, quality string) (string, TidalDownloadInfo, error) {
+func getDownloadURLParallel(ctx context.Context, apis []string, trackID int64, quality string) (string, TidalDownloadInfo, error) {
 	if len(apis) == 0 {
 		return "", TidalDownloadInfo{}, fmt.Errorf("no APIs available")
 	}
 
-	GoLog("[Tidal] Requesting download URL from %d APIs in parallel (with retry)...\n", len(apis))
+	GoLog("[Tidal] Requesting download URL from %d APIs by health (with retry)...\n", len(apis))
 
-	resultChan := make(chan tidalAPIResult, len(apis))
+	infos := make([]TidalDownloadInfo, len(apis))
 	startTime := time.Now()
-
-	for _, apiURL := range apis {
-		go func(api string) {
-			reqStart := time.Now()
-			info, err := fetchTidalURLWithRetry(api, trackID, quality, tidalAPITimeoutMobile)
-			resultChan <- tidalAPIResult{
-				apiURL:   api,
-				info:     info,
-				err:      err,
-				duration: time.Since(reqStart),
-			}
-		}(apiURL)
-	}
-
-	var errors []string
-
-	for i := 0; i < len(apis); i++ {
-		result := <-resultChan
-		if result.err == nil {
-			GoLog("[Tidal] [Parallel] Got response from %s (%d-bit/%dHz) in %v\n",
-				result.apiURL, result.info.BitDepth, result.info.SampleRate, result.duration)
-
-			go func(remaining int) {
-				for j := 0; j < remaining; j++ {
-					<-resultChan
-				}
-			}(len(apis) - i - 1)
-
-			GoLog("[Tidal] [Parallel] Total time: %v (first success)\n", time.Since(startTime))
-			return result.apiURL, result.info, nil
-		}
-		errMsg := result.err.Error()
-		if len(errMsg) > 50 {
-			errMsg = errMsg[:50] + "..."
-		}
-		errors = append(errors, fmt.Sprintf("%s: %s", result.apiURL, errMsg))
+	index, err := providerHealth.raceMirrors(ctx, "Tidal", apis, func(raceCtx context.Context, i int) error {
+		info, err := fetchTidalURLWithRetry(raceCtx, apis[i], trackID, quality, tidalAPITimeoutMobile)
+		infos[i] = info
+		return err
+	})
+	if err != nil {
+		GoLog("[Tidal] [Parallel] All %d APIs failed in %v\n", len(apis), time.Since(startTime))
+		return "", TidalDownloadInfo{}, err
 	}
 
-	GoLog("[Tidal] [Parallel] All %d APIs failed in %v\n", len(apis), time.Since(startTime))
-	return "", TidalDownloadInfo{}, fmt.Errorf("all %d Tidal APIs failed. Errors: %v", len(apis), errors)
+	GoLog("[Tidal] [Parallel] Got response from %s (%d-bit/%dHz) in %v\n",
+		apis[index], infos[index].BitDepth, infos[index].SampleRate, time.Since(startTime))
+	return apis[index], infos[index], nil
 }
 
-func (t *TidalDownloader) GetDownloadURL(trackID int64, quality string) (TidalDownloadInfo, error) {
+func (t *TidalDownloader) GetDownloadURL(ctx context.Context, trackID int64, quality string) (TidalDownloadInfo, error) {
 	apis := t.GetAvailableAPIs()
 	if len(apis) == 0 {
 		return TidalDownloadInfo{}, fmt.Errorf("no API URL configured")
 	}
 
-	_, info, err := getDownloadURLParallel(apis, trackID, quality)
+	_, info, err := getDownloadURLParallel(ctx, apis, trackID, quality)
 	if err != nil {
 		return TidalDownloadInfo{}, fmt.Errorf("failed to get download URL: %w", err)
 	}
@@ -1648,6 +1619,7 @@ func (t *TidalDownloader) downloadFromManifest(ctx context.Context, manifestB64,
 		GoLog("[Tidal] Failed to create M4A file: %v\n", err)
 		return fmt.Errorf("failed to create M4A file: %w", err)
 	}
+	segmentLimiter := &byteRateLimiter{}
 
 	GoLog("[Tidal] Downloading init segment...\n")
 	if isDownloadCancelled(itemID) {
@@ -1679,7 +1651,7 @@ func (t *TidalDownloader) downloadFromManifest(ctx context.Context, manifestB64,
 		GoLog("[Tidal] Init segment HTTP error: %d\n", resp.StatusCode)
 		return fmt.Errorf("init segment download failed with status %d", resp.StatusCode)
 	}
-	_, err = io.Copy(out, resp.Body)
+	_, err = io.Copy(out, newThrottledReader(resp.Body, itemID, segmentLimiter))
 	resp.Body.Close()
 	if err != nil {
 		out.Close()
@@ -1732,7 +1704,7 @@ func (t *TidalDownloader) downloadFromManifest(ctx context.Context, manifestB64,
 			GoLog("[Tidal] Segment %d HTTP error: %d\n", i+1, resp.StatusCode)
 			return fmt.Errorf("segment %d download failed with status %d", i+1, resp.StatusCode)
 		}
-		_, err = io.Copy(out, resp.Body)
+		_, err = io.Copy(out, newThrottledReader(resp.Body, itemID, segmentLimiter))
 		resp.Body.Close()
 		if err != nil {
 			out.Close()
@@ -1768,6 +1740,7 @@ type TidalDownloadResult struct {
 	ISRC        string
 	Copyright   string
 	LyricsLRC   string // LRC content for embedding in converted files
+	Quality     string // quality actually requested after the metered cap
 }
 
 func artistsMatch(spotifyArtist, tidalArtist string) bool {
@@ -2051,7 +2024,7 @@ func parseTidalRequestTrackID(raw string) (int64, bool) {
 	return trackID, true
 }
 
-func resolveTidalTrackForRequest(req DownloadRequest, downloader *TidalDownloader, logPrefix string) (*TidalTrack, error) {
+func resolveTidalTrackForRequest(ctx context.Context, req DownloadRequest, downloader *TidalDownloader, logPrefix string) (*TidalTrack, error) {
 	if downloader == nil {
 		downloader = NewTidalDownloader()
 	}
@@ -2083,6 +2056,7 @@ func resolveTidalTrackForRequest(req DownloadRequest, downloader *TidalDownloade
 	if !gotTidalID && req.ISRC != "" && req.TrackName != "" && req.ArtistName != "" {
 		GoLog("[%s] Trying Tidal public metadata search with ISRC\n", logPrefix)
 		searchTrack, searchErr := downloader.SearchTrackByMetadataWithISRC(
+			ctx,
 			req.TrackName,
 			req.ArtistName,
 			req.AlbumName,
@@ -2128,7 +2102,7 @@ func resolveTidalTrackForRequest(req DownloadRequest, downloader *TidalDownloade
 		if req.DeezerID != "" {
 			GoLog("[%s] Using Deezer ID for SongLink lookup: %s\n", logPrefix, req.DeezerID)
 			songlink := NewSongLinkClient()
-			availability, slErr := songlink.CheckAvailabilityFromDeezer(req.DeezerID)
+			availability, slErr := songlink.CheckAvailabilityFromDeezerContext(ctx, req.DeezerID)
 			if slErr == nil {
 				resolveFromAvailability(availability)
 			} else {
@@ -2141,7 +2115,7 @@ func resolveTidalTrackForRequest(req DownloadRequest, downloader *TidalDownloade
 				deezerID := strings.TrimPrefix(req.SpotifyID, "deezer:")
 				GoLog("[%s] Using Deezer ID for SongLink lookup: %s\n", logPrefix, deezerID)
 				songlink := NewSongLinkClient()
-				availability, slErr := songlink.CheckAvailabilityFromDeezer(deezerID)
+				availability, slErr := songlink.CheckAvailabilityFromDeezerContext(ctx, deezerID)
 				if slErr == nil {
 					resolveFromAvailability(availability)
 				} else {
@@ -2152,19 +2126,24 @@ func resolveTidalTrackForRequest(req DownloadRequest, downloader *TidalDownloade
 
 		if !gotTidalID && req.SpotifyID != "" && !strings.HasPrefix(req.SpotifyID, "deezer:") {
 			songlink := NewSongLinkClient()
-			availability, slErr := songlink.CheckTrackAvailability(req.SpotifyID, req.ISRC)
+			availability, slErr := songlink.CheckTrackAvailabilityContext(ctx, req.SpotifyID, req.ISRC)
 			if slErr == nil {
 				resolveFromAvailability(availability)
 			}
 		}
 	}
 
+	if ctx.Err() != nil {
+		return nil, ctx.Err()
+	}
 	if !gotTidalID || trackID <= 0 {
 		return nil, fmt.Errorf("failed to find tidal track id from request/cache/songlink")
 	}
 
-	actualTrack, fetchErr := tidalGetPublicTrackFunc(downloader, strconv.FormatInt(trackID, 10))
-	if fetchErr != nil {
+	actualTrack, fetchErr := tidalGetPublicTrackFunc(ctx, downloader, strconv.FormatInt(trackID, 10))
+	if fetchErr != nil && ctx.Err() != nil {
+		return nil, ctx.Err()
+	} else if fetchErr != nil {
 		GoLog("[%s] Warning: could not fetch Tidal track %d for verification: %v\n", logPrefix, trackID, fetchErr)
 	} else {
 		providerArtist := actualTrack.Artist.Name
@@ -2219,7 +2198,7 @@ func resolveTidalTrackForRequest(req DownloadRequest, downloader *TidalDownloade
 	return track, nil
 }
 
-func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
+func downloadFromTidal(ctx context.Context, req DownloadRequest) (TidalDownloadResult, error) {
 	downloader := NewTidalDownloader()
 
 	isSafOutput := isFDOutput(req.OutputFD) || strings.TrimSpace(req.OutputPath) != ""
@@ -2229,25 +2208,30 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 		}
 	}
 
-	track, err := resolveTidalTrackForRequest(req, downloader, "Tidal")
+	resolveSpan := startTraceSpan(req.ItemID, "resolve", "tidal")
+	track, err := resolveTidalTrackForRequest(ctx, req, downloader, "Tidal")
+	if track != nil {
+		resolveSpan.set("track_id", track.ID)
+	}
+	resolveSpan.end(err)
+	if err != nil {
+		return TidalDownloadResult{}, classifyDownloadErrorOr("tidal", ErrTrackNotFound,
+			asDownloadCancelled(ctx, req.ItemID, "track resolution", err))
+	}
+
+	quality, err := meteredQualityForBuiltIn("tidal", req.Quality)
 	if err != nil {
 		return TidalDownloadResult{}, err
 	}
-
-	quality := req.Quality
 	if quality == "" || quality == "DEFAULT" {
 		quality = "LOSSLESS"
 	}
 
-	filename := buildFilenameFromTemplate(req.FilenameFormat, map[string]interface{}{
-		"title":  req.TrackName,
-		"artist": req.ArtistName,
-		"album":  req.AlbumName,
-		"track":  req.TrackNumber,
-		"year":   extractYear(req.ReleaseDate),
-		"date":   req.ReleaseDate,
-		"disc":   req.DiscNumber,
-	})
+	templateMetadata := filenameTemplateMetadata(req, "tidal")
+	if quality == "LOSSLESS" {
+		templateMetadata["bit_depth"], templateMetadata["sample_rate"] = 16, 44.1
+	}
+	filename := buildFilePathFromTemplate(req.FilenameFormat, templateMetadata)
 
 	outputExt := strings.TrimSpace(req.OutputExt)
 	if outputExt == "" {
@@ -2270,12 +2254,10 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 		m4aPath = outputPath
 	} else {
 		if outputExt == ".m4a" || quality == "HIGH" {
-			filename = sanitizeFilename(filename) + ".m4a"
-			outputPath = filepath.Join(req.OutputDir, filename)
+			outputPath = filepath.Join(req.OutputDir, filename+".m4a")
 			m4aPath = outputPath
 		} else {
-			filename = sanitizeFilename(filename) + ".flac"
-			outputPath = filepath.Join(req.OutputDir, filename)
+			outputPath = filepath.Join(req.OutputDir, filename+".flac")
 			m4aPath = strings.TrimSuffix(outputPath, ".flac") + ".m4a"
 		}
 
@@ -2287,6 +2269,9 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 				return TidalDownloadResult{FilePath: "EXISTS:" + m4aPath}, nil
 			}
 		}
+		if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+			return TidalDownloadResult{}, fmt.Errorf("failed to create output folder: %w", err)
+		}
 	}
 
 	if !isSafOutput {
@@ -2299,9 +2284,14 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 
 	GoLog("[Tidal] Using quality: %s\n", quality)
 
-	downloadInfo, err := downloader.GetDownloadURL(track.ID, quality)
+	urlSpan := startTraceSpan(req.ItemID, "url_fetch", "tidal").set("quality", quality)
+	downloadInfo, err := downloader.GetDownloadURL(ctx, track.ID, quality)
+	urlSpan.set("bit_depth", downloadInfo.BitDepth).set("sample_rate", downloadInfo.SampleRate).end(err)
 	if err != nil {
-		return TidalDownloadResult{}, fmt.Errorf("failed to get download URL: %w", err)
+		if ctx.Err() != nil {
+			return TidalDownloadResult{}, asDownloadCancelled(ctx, req.ItemID, "download URL lookup", err)
+		}
+		return TidalDownloadResult{}, classifyDownloadError("tidal", fmt.Errorf("failed to get download URL: %w", err))
 	}
 
 	GoLog("[Tidal] Actual quality: %d-bit/%dHz\n", downloadInfo.BitDepth, downloadInfo.SampleRate)
@@ -2317,9 +2307,11 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 			embedLyrics = false
 		}
 		parallelResult = FetchCoverAndLyricsParallel(
+			ctx,
 			coverURL,
 			req.EmbedMaxQualityCover,
 			req.SpotifyID,
+			req.ISRC,
 			req.TrackName,
 			req.ArtistName,
 			embedLyrics,
@@ -2335,9 +2327,12 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 		return "Direct URL"
 	}())
 
-	if err := downloader.DownloadFile(downloadInfo.URL, outputPath, req.OutputFD, req.ItemID); err != nil {
-		if errors.Is(err, ErrDownloadCancelled) {
-			return TidalDownloadResult{}, ErrDownloadCancelled
+	downloadSpan := startTraceSpan(req.ItemID, "download", "tidal")
+	err = downloader.DownloadFile(downloadInfo.URL, outputPath, req.OutputFD, req.ItemID)
+	downloadSpan.end(err)
+	if err != nil {
+		if errors.Is(err, ErrDownloadCancelled) || ctx.Err() != nil {
+			return TidalDownloadResult{}, asDownloadCancelled(ctx, req.ItemID, "audio download", err)
 		}
 		GoLog("[Tidal] Download failed with error: %v\n", err)
 		return TidalDownloadResult{}, fmt.Errorf("download failed: %w", err)
@@ -2396,6 +2391,11 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 		Label:         req.Label,
 		Copyright:     copyright,
 		Composer:      req.Composer,
+
+		TrackIdentifiers: trackIdentifiersForDownload(req, ProviderIdentifiers{
+			TidalTrackID: formatProviderTrackID(track.ID),
+			SourceURL:    tidalTrackExternalURL(track),
+		}),
 	}
 
 	var coverData []byte
@@ -2414,7 +2414,10 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 
 	if (isSafOutput && actualExt == ".flac") || (!isSafOutput && strings.HasSuffix(actualOutputPath, ".flac")) {
 		if req.EmbedMetadata {
-			if err := EmbedMetadataWithCoverData(actualOutputPath, metadata, coverData); err != nil {
+			tagSpan := startTraceSpan(req.ItemID, "tag", "tidal")
+			err := EmbedMetadataWithCoverData(actualOutputPath, metadata, coverData)
+			tagSpan.end(err)
+			if err != nil {
 				fmt.Printf("Warning: failed to embed metadata: %v\n", err)
 			}
 		} else {
@@ -2429,7 +2432,7 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 
 			if !isSafOutput && (lyricsMode == "external" || lyricsMode == "both") {
 				GoLog("[Tidal] Saving external LRC file...\n")
-				if lrcPath, lrcErr := SaveLRCFile(actualOutputPath, parallelResult.LyricsLRC); lrcErr != nil {
+				if lrcPath, lrcErr := SaveLyricsFile(actualOutputPath, parallelResult.LyricsLRC, req.LyricsFormat); lrcErr != nil {
 					GoLog("[Tidal] Warning: failed to save LRC file: %v\n", lrcErr)
 				} else {
 					GoLog("[Tidal] LRC file saved: %s\n", lrcPath)
@@ -2438,7 +2441,7 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 
 			if lyricsMode == "embed" || lyricsMode == "both" {
 				GoLog("[Tidal] Embedding parallel-fetched lyrics (%d lines)...\n", len(parallelResult.LyricsData.Lines))
-				if embedErr := EmbedLyrics(actualOutputPath, parallelResult.LyricsLRC); embedErr != nil {
+				if embedErr := EmbedLyrics(actualOutputPath, lyricsForEmbedding(parallelResult.LyricsLRC, req.LyricsFormat)); embedErr != nil {
 					GoLog("[Tidal] Warning: failed to embed lyrics: %v\n", embedErr)
 				} else {
 					fmt.Println("[Tidal] Lyrics embedded successfully")
@@ -2459,7 +2462,7 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 
 				if !isSafOutput && (lyricsMode == "external" || lyricsMode == "both") {
 					GoLog("[Tidal] Saving external LRC file for M4A (mode: %s)...\n", lyricsMode)
-					if lrcPath, lrcErr := SaveLRCFile(actualOutputPath, parallelResult.LyricsLRC); lrcErr != nil {
+					if lrcPath, lrcErr := SaveLyricsFile(actualOutputPath, parallelResult.LyricsLRC, req.LyricsFormat); lrcErr != nil {
 						GoLog("[Tidal] Warning: failed to save LRC file: %v\n", lrcErr)
 					} else {
 						GoLog("[Tidal] LRC file saved: %s\n", lrcPath)
@@ -2507,6 +2510,7 @@ func downloadFromTidal(req DownloadRequest) (TidalDownloadResult, error) {
 		ISRC:        track.ISRC,
 		Copyright:   copyright,
 		LyricsLRC:   lyricsLRC,
+		Quality:     quality,
 	}, nil
 }
 
@@ -125,6 +125,7 @@ func trackMatchesRequest(req DownloadRequest, resolved resolvedTrackInfo, logPre
 			!artistsMatch(req.ArtistName, resolved.ArtistName) {
 			GoLog("[%s] Verification failed: artist mismatch — expected '%s', got '%s'\n",
 				logPrefix, req.ArtistName, resolved.ArtistName)
+			traceMatchDecision(req, logPrefix, resolved.Title, resolved.ArtistName, resolved.ISRC, resolved.Duration, "artist_mismatch")
 			return false
 		}
 
@@ -132,6 +133,7 @@ func trackMatchesRequest(req DownloadRequest, resolved resolvedTrackInfo, logPre
 			!titlesMatch(req.TrackName, resolved.Title) {
 			GoLog("[%s] Verification failed: title mismatch — expected '%s', got '%s'\n",
 				logPrefix, req.TrackName, resolved.Title)
+			traceMatchDecision(req, logPrefix, resolved.Title, resolved.ArtistName, resolved.ISRC, resolved.Duration, "title_mismatch")
 			return false
 		}
 	}
@@ -145,9 +147,11 @@ func trackMatchesRequest(req DownloadRequest, resolved resolvedTrackInfo, logPre
 		if diff > 10 {
 			GoLog("[%s] Verification failed: duration mismatch — expected %ds, got %ds\n",
 				logPrefix, expectedDurationSec, resolved.Duration)
+			traceMatchDecision(req, logPrefix, resolved.Title, resolved.ArtistName, resolved.ISRC, resolved.Duration, "duration_mismatch")
 			return false
 		}
 	}
 
+	traceMatchDecision(req, logPrefix, resolved.Title, resolved.ArtistName, resolved.ISRC, resolved.Duration, "")
 	return true
 }
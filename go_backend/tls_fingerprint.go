@@ -0,0 +1,277 @@
+package gobackend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	tlsProfileAuto       = "auto"
+	tlsProfileRandomized = "randomized"
+
+	maxTLSFingerprintAttempts = 3
+	cloudflareChallengeSniff  = 256 * 1024
+)
+
+// tlsFingerprintProfiles lists the ClientHello profiles the uTLS transport
+// can present, newest browsers first.
+var tlsFingerprintProfiles = []string{
+	"chrome_133",
+	"chrome_131",
+	"chrome_120",
+	"firefox_120",
+	"firefox_105",
+	"safari_16",
+	"ios_14",
+	"edge_85",
+	tlsProfileRandomized,
+}
+
+var defaultTLSFingerprintRotation = []string{
+	"chrome_133",
+	"firefox_120",
+	"safari_16",
+	"chrome_120",
+	tlsProfileRandomized,
+}
+
+// tlsProfileUserAgents pairs each named ClientHello with a User-Agent of the
+// same browser. The randomized profile has no browser to match.
+var tlsProfileUserAgents = map[string]string{
+	"chrome_133":  "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/133.0.0.0 Safari/537.36",
+	"chrome_131":  "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/131.0.0.0 Safari/537.36",
+	"chrome_120":  "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"firefox_120": "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:120.0) Gecko/20100101 Firefox/120.0",
+	"firefox_105": "Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:105.0) Gecko/20100101 Firefox/105.0",
+	"safari_16":   "Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/16.0 Safari/605.1.15",
+	"ios_14":      "Mozilla/5.0 (iPhone; CPU iPhone OS 14_0 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/14.0 Mobile/15E148 Safari/604.1",
+	"edge_85":     "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/85.0.4183.83 Safari/537.36 Edg/85.0.564.44",
+}
+
+var cloudflareChallengeMarkers = []string{
+	"cloudflare", "cf-ray", "checking your browser",
+	"please wait", "ddos protection", "ray id",
+	"enable javascript", "challenge-platform",
+}
+
+// TLSFingerprintSettings picks the ClientHello used by the Cloudflare bypass
+// client. Profile "auto" walks Rotation; a named profile is tried first and
+// Rotation only after a challenge.
+type TLSFingerprintSettings struct {
+	Profile           string   `json:"profile"`
+	Rotation          []string `json:"rotation,omitempty"`
+	RotateOnChallenge bool     `json:"rotate_on_challenge"`
+}
+
+type tlsFingerprintSelector struct {
+	mu       sync.Mutex
+	settings TLSFingerprintSettings
+	order    []string
+	hosts    map[string]int
+}
+
+var tlsFingerprints = newTLSFingerprintSelector()
+
+func newTLSFingerprintSelector() *tlsFingerprintSelector {
+	s := &tlsFingerprintSelector{}
+	s.apply(defaultTLSFingerprintSettings())
+	return s
+}
+
+func defaultTLSFingerprintSettings() TLSFingerprintSettings {
+	return TLSFingerprintSettings{
+		Profile:           tlsProfileAuto,
+		Rotation:          slices.Clone(defaultTLSFingerprintRotation),
+		RotateOnChallenge: true,
+	}
+}
+
+func (s *tlsFingerprintSelector) apply(settings TLSFingerprintSettings) {
+	order := make([]string, 0, len(settings.Rotation)+1)
+	if settings.Profile != tlsProfileAuto {
+		order = append(order, settings.Profile)
+	}
+	for _, profile := range settings.Rotation {
+		if !slices.Contains(order, profile) {
+			order = append(order, profile)
+		}
+	}
+
+	s.mu.Lock()
+	s.settings = settings
+	s.order = order
+	s.hosts = make(map[string]int)
+	s.mu.Unlock()
+}
+
+// profileFor returns the profile currently used for host.
+func (s *tlsFingerprintSelector) profileFor(host string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order[s.hosts[host]%len(s.order)]
+}
+
+// rotate moves host to the next profile unless another request already moved
+// it away from the profile that was challenged.
+func (s *tlsFingerprintSelector) rotate(host, challenged string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	index := s.hosts[host] % len(s.order)
+	if s.order[index] == challenged {
+		index = (index + 1) % len(s.order)
+		s.hosts[host] = index
+	}
+	return s.order[index]
+}
+
+func (s *tlsFingerprintSelector) maxAttempts() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.settings.RotateOnChallenge {
+		return 1
+	}
+	return min(len(s.order), maxTLSFingerprintAttempts)
+}
+
+func (s *tlsFingerprintSelector) activeHosts() map[string]string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	hosts := make(map[string]string, len(s.hosts))
+	for host, index := range s.hosts {
+		hosts[host] = s.order[index%len(s.order)]
+	}
+	return hosts
+}
+
+// userAgentForTLSProfile returns the User-Agent matching profile, or "" when
+// the request's own User-Agent should be kept.
+func userAgentForTLSProfile(profile string) string {
+	return tlsProfileUserAgents[profile]
+}
+
+// isBrowserUserAgent reports whether ua is empty or a browser string that
+// may be swapped for the profile's. App and extension User-Agents are kept.
+func isBrowserUserAgent(ua string) bool {
+	return ua == "" || strings.HasPrefix(ua, "Mozilla/5.0 ")
+}
+
+func normalizeTLSProfile(profile string) (string, error) {
+	profile = strings.ToLower(strings.TrimSpace(profile))
+	if profile == "" || profile == tlsProfileAuto {
+		return tlsProfileAuto, nil
+	}
+	if !slices.Contains(tlsFingerprintProfiles, profile) {
+		return "", fmt.Errorf("unknown TLS fingerprint profile %q", profile)
+	}
+	return profile, nil
+}
+
+func SetTLSFingerprintSettings(settingsJSON string) error {
+	settings := defaultTLSFingerprintSettings()
+	if strings.TrimSpace(settingsJSON) != "" {
+		if err := json.Unmarshal([]byte(settingsJSON), &settings); err != nil {
+			return fmt.Errorf("invalid TLS fingerprint settings: %w", err)
+		}
+	}
+
+	profile, err := normalizeTLSProfile(settings.Profile)
+	if err != nil {
+		return err
+	}
+	settings.Profile = profile
+
+	rotation := make([]string, 0, len(settings.Rotation))
+	for _, name := range settings.Rotation {
+		name, err := normalizeTLSProfile(name)
+		if err != nil {
+			return err
+		}
+		if name != tlsProfileAuto && !slices.Contains(rotation, name) {
+			rotation = append(rotation, name)
+		}
+	}
+	if len(rotation) == 0 {
+		rotation = slices.Clone(defaultTLSFingerprintRotation)
+	}
+	settings.Rotation = rotation
+
+	tlsFingerprints.apply(settings)
+	closeIdleBypassConnections()
+	GoLog("[HTTP] TLS fingerprint profile set to %s (rotation: %v, rotate on challenge: %v)\n",
+		settings.Profile, settings.Rotation, settings.RotateOnChallenge)
+	return nil
+}
+
+// GetTLSFingerprintSettings returns the settings together with the profiles
+// available and the profile each host is currently using.
+func GetTLSFingerprintSettings() (string, error) {
+	tlsFingerprints.mu.Lock()
+	settings := tlsFingerprints.settings
+	tlsFingerprints.mu.Unlock()
+
+	jsonBytes, err := json.Marshal(struct {
+		TLSFingerprintSettings
+		AvailableProfiles []string          `json:"available_profiles"`
+		ActiveHosts       map[string]string `json:"active_hosts"`
+	}{
+		TLSFingerprintSettings: settings,
+		AvailableProfiles:      tlsFingerprintProfiles,
+		ActiveHosts:            tlsFingerprints.activeHosts(),
+	})
+	if err != nil {
+		return "", err
+	}
+	return string(jsonBytes), nil
+}
+
+// isCloudflareChallenge reports whether resp is a Cloudflare block page. The
+// sniffed part of the body is put back so resp stays readable.
+func isCloudflareChallenge(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	if strings.EqualFold(resp.Header.Get("Cf-Mitigated"), "challenge") {
+		return true
+	}
+
+	sniffed, err := io.ReadAll(io.LimitReader(resp.Body, cloudflareChallengeSniff))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(sniffed), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+
+	bodyStr := strings.ToLower(string(sniffed))
+	for _, marker := range cloudflareChallengeMarkers {
+		if strings.Contains(bodyStr, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// replayableRequest returns a copy of req whose body can be sent again, or
+// nil when the body cannot be rewound.
+func replayableRequest(req *http.Request) *http.Request {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req.Clone(req.Context())
+	}
+	if req.GetBody == nil {
+		return nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil
+	}
+	clone := req.Clone(req.Context())
+	clone.Body = body
+	return clone
+}
@@ -0,0 +1,117 @@
+package gobackend
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestTLSFingerprintSelectorRotatesPerHost(t *testing.T) {
+	t.Cleanup(func() { SetTLSFingerprintSettings("") })
+
+	if err := SetTLSFingerprintSettings(`{"profile":"firefox_120","rotation":["chrome_133","firefox_120","safari_16"],"rotate_on_challenge":true}`); err != nil {
+		t.Fatalf("SetTLSFingerprintSettings: %v", err)
+	}
+
+	if got := tlsFingerprints.profileFor("a.example"); got != "firefox_120" {
+		t.Fatalf("expected pinned profile first, got %s", got)
+	}
+	if got := tlsFingerprints.rotate("a.example", "firefox_120"); got != "chrome_133" {
+		t.Fatalf("expected rotation to chrome_133, got %s", got)
+	}
+	// A second request challenged on the old profile must not skip ahead.
+	if got := tlsFingerprints.rotate("a.example", "firefox_120"); got != "chrome_133" {
+		t.Fatalf("stale rotation advanced the host to %s", got)
+	}
+	if got := tlsFingerprints.profileFor("b.example"); got != "firefox_120" {
+		t.Fatalf("rotation leaked to another host: %s", got)
+	}
+	if got := tlsFingerprints.maxAttempts(); got != 3 {
+		t.Fatalf("expected 3 attempts, got %d", got)
+	}
+
+	if err := SetTLSFingerprintSettings(`{"profile":"chrome_131","rotate_on_challenge":false}`); err != nil {
+		t.Fatalf("SetTLSFingerprintSettings: %v", err)
+	}
+	if got := tlsFingerprints.maxAttempts(); got != 1 {
+		t.Fatalf("expected a single attempt with rotation off, got %d", got)
+	}
+	if got := tlsFingerprints.profileFor("a.example"); got != "chrome_131" {
+		t.Fatalf("expected settings change to reset hosts, got %s", got)
+	}
+}
+
+func TestSetTLSFingerprintSettingsRejectsUnknownProfile(t *testing.T) {
+	t.Cleanup(func() { SetTLSFingerprintSettings("") })
+
+	if err := SetTLSFingerprintSettings(`{"profile":"netscape_4"}`); err == nil {
+		t.Fatal("expected unknown profile to be rejected")
+	}
+	if err := SetTLSFingerprintSettings(`{"rotation":["chrome_133","opera_9"]}`); err == nil {
+		t.Fatal("expected unknown rotation entry to be rejected")
+	}
+	if got := tlsFingerprints.profileFor("a.example"); got != defaultTLSFingerprintRotation[0] {
+		t.Fatalf("rejected settings were applied: %s", got)
+	}
+}
+
+func TestTLSProfileUserAgentsMatchBrowser(t *testing.T) {
+	markers := map[string]string{
+		"chrome": "Chrome/", "firefox": "Firefox/", "safari": "Version/16", "ios": "iPhone OS 14", "edge": "Edg/",
+	}
+	for _, profile := range tlsFingerprintProfiles {
+		ua := userAgentForTLSProfile(profile)
+		if profile == tlsProfileRandomized {
+			if ua != "" {
+				t.Fatalf("randomized profile must keep the request's User-Agent, got %q", ua)
+			}
+			continue
+		}
+		marker := markers[strings.SplitN(profile, "_", 2)[0]]
+		if marker == "" || !strings.Contains(ua, marker) {
+			t.Fatalf("profile %s has mismatched User-Agent %q", profile, ua)
+		}
+	}
+
+	if !isBrowserUserAgent(getRandomUserAgent()) || !isBrowserUserAgent("") {
+		t.Fatal("expected random browser User-Agent to follow the profile")
+	}
+	if isBrowserUserAgent(appUserAgent()) {
+		t.Fatal("app User-Agent must be kept")
+	}
+}
+
+func TestIsCloudflareChallenge(t *testing.T) {
+	newResp := func(status int, header, body string) *http.Response {
+		resp := &http.Response{
+			StatusCode: status,
+			Header:     make(http.Header),
+			Body:       io.NopCloser(strings.NewReader(body)),
+		}
+		if header != "" {
+			resp.Header.Set("Cf-Mitigated", header)
+		}
+		return resp
+	}
+
+	if !isCloudflareChallenge(newResp(http.StatusForbidden, "challenge", "")) {
+		t.Fatal("expected cf-mitigated header to count as a challenge")
+	}
+	if isCloudflareChallenge(newResp(http.StatusOK, "", "cloudflare")) {
+		t.Fatal("2xx responses are never challenges")
+	}
+
+	resp := newResp(http.StatusServiceUnavailable, "", "<title>Just a moment...</title><script src=/cdn-cgi/challenge-platform/x.js>")
+	if !isCloudflareChallenge(resp) {
+		t.Fatal("expected challenge page body to be detected")
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "Just a moment") {
+		t.Fatalf("sniffed body was not restored: %q", body)
+	}
+
+	if isCloudflareChallenge(newResp(http.StatusForbidden, "", `{"error":"forbidden"}`)) {
+		t.Fatal("plain 403 should not be treated as a challenge")
+	}
+}
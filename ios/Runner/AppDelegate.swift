@@ -314,6 +314,14 @@ import Gobackend  // Import Go framework
             if let error = error { throw error }
             return response
             
+        case "buildFilePath":
+            let args = call.arguments as! [String: Any]
+            let template = args["template"] as! String
+            let metadata = args["metadata"] as! String
+            let response = GobackendBuildFilePath(template, metadata, &error)
+            if let error = error { throw error }
+            return response
+            
         case "sanitizeFilename":
             let args = call.arguments as! [String: Any]
             let filename = args["filename"] as! String
@@ -658,6 +666,19 @@ import Gobackend  // Import Go framework
             if let error = error { throw error }
             return nil
             
+        case "setBandwidthSettings":
+            let args = call.arguments as! [String: Any]
+            let settingsJson = args["settings_json"] as? String ?? ""
+            GobackendSetBandwidthSettingsJSON(settingsJson, &error)
+            if let error = error { throw error }
+            return nil
+            
+        case "setNetworkMetered":
+            let args = call.arguments as! [String: Any]
+            let metered = args["metered"] as? Bool ?? false
+            GobackendSetNetworkMetered(metered)
+            return nil
+            
         case "setMetadataProviderPriority":
             let args = call.arguments as! [String: Any]
             let priorityJson = args["priority"] as! String
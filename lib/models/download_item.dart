@@ -12,7 +12,20 @@ enum DownloadStatus {
   skipped,
 }
 
-enum DownloadErrorType { unknown, notFound, rateLimit, network, permission }
+enum DownloadErrorType {
+  unknown,
+  notFound,
+  rateLimit,
+  network,
+  permission,
+  regionLocked,
+  qualityUnavailable,
+  authExpired,
+  diskFull,
+  extensionCrashed,
+  integrityFailed,
+  ispBlocked,
+}
 
 @JsonSerializable()
 class DownloadItem {
@@ -27,6 +40,7 @@ class DownloadItem {
   final String? filePath;
   final String? error;
   final DownloadErrorType? errorType;
+  final bool retryable; // Backend says the failure is worth retrying later
   final DateTime createdAt;
   final String? qualityOverride; // Override quality for this specific download
   final String? playlistName; // Playlist context for folder organization
@@ -43,6 +57,7 @@ class DownloadItem {
     this.filePath,
     this.error,
     this.errorType,
+    this.retryable = false,
     required this.createdAt,
     this.qualityOverride,
     this.playlistName,
@@ -60,6 +75,7 @@ class DownloadItem {
     String? filePath,
     String? error,
     DownloadErrorType? errorType,
+    bool? retryable,
     DateTime? createdAt,
     String? qualityOverride,
     String? playlistName,
@@ -76,6 +92,7 @@ class DownloadItem {
       filePath: filePath ?? this.filePath,
       error: error ?? this.error,
       errorType: errorType ?? this.errorType,
+      retryable: retryable ?? this.retryable,
       createdAt: createdAt ?? this.createdAt,
       qualityOverride: qualityOverride ?? this.qualityOverride,
       playlistName: playlistName ?? this.playlistName,
@@ -94,6 +111,20 @@ class DownloadItem {
         return 'Connection failed, check your internet';
       case DownloadErrorType.permission:
         return 'Cannot write to folder, check storage permission';
+      case DownloadErrorType.regionLocked:
+        return 'Not available in your region';
+      case DownloadErrorType.qualityUnavailable:
+        return 'Requested quality is not available';
+      case DownloadErrorType.authExpired:
+        return 'Service login expired, sign in again';
+      case DownloadErrorType.diskFull:
+        return 'Not enough storage space';
+      case DownloadErrorType.extensionCrashed:
+        return 'Extension stopped unexpectedly, try again';
+      case DownloadErrorType.integrityFailed:
+        return 'Downloaded file was corrupted, try again';
+      case DownloadErrorType.ispBlocked:
+        return 'Service is blocked by your network provider';
       default:
         return error ?? 'An error occurred';
     }
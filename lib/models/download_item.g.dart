@@ -20,6 +20,7 @@ DownloadItem _$DownloadItemFromJson(Map<String, dynamic> json) => DownloadItem(
   filePath: json['filePath'] as String?,
   error: json['error'] as String?,
   errorType: $enumDecodeNullable(_$DownloadErrorTypeEnumMap, json['errorType']),
+  retryable: json['retryable'] as bool? ?? false,
   createdAt: DateTime.parse(json['createdAt'] as String),
   qualityOverride: json['qualityOverride'] as String?,
   playlistName: json['playlistName'] as String?,
@@ -38,6 +39,7 @@ Map<String, dynamic> _$DownloadItemToJson(DownloadItem instance) =>
       'filePath': instance.filePath,
       'error': instance.error,
       'errorType': _$DownloadErrorTypeEnumMap[instance.errorType],
+      'retryable': instance.retryable,
       'createdAt': instance.createdAt.toIso8601String(),
       'qualityOverride': instance.qualityOverride,
       'playlistName': instance.playlistName,
@@ -58,4 +60,11 @@ const _$DownloadErrorTypeEnumMap = {
   DownloadErrorType.rateLimit: 'rateLimit',
   DownloadErrorType.network: 'network',
   DownloadErrorType.permission: 'permission',
+  DownloadErrorType.regionLocked: 'regionLocked',
+  DownloadErrorType.qualityUnavailable: 'qualityUnavailable',
+  DownloadErrorType.authExpired: 'authExpired',
+  DownloadErrorType.diskFull: 'diskFull',
+  DownloadErrorType.extensionCrashed: 'extensionCrashed',
+  DownloadErrorType.integrityFailed: 'integrityFailed',
+  DownloadErrorType.ispBlocked: 'ispBlocked',
 };
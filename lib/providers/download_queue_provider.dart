@@ -1314,6 +1314,8 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
   Timer? _progressStreamBootstrapTimer;
   Timer? _queuePersistDebounce;
   StreamSubscription<Map<String, dynamic>>? _progressStreamSub;
+  StreamSubscription<List<ConnectivityResult>>? _connectivitySub;
+  bool _pausedForMeteredNetwork = false;
   int _downloadCount = 0;
   static const _cleanupInterval = 50;
   static const _progressPollingInterval = Duration(milliseconds: 1200);
@@ -1410,10 +1412,16 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
       }
     });
 
+    _connectivitySub = Connectivity().onConnectivityChanged.listen(
+      _onConnectivityChanged,
+    );
+
     ref.onDispose(() {
       _progressTimer?.cancel();
       _progressStreamBootstrapTimer?.cancel();
       _progressStreamSub?.cancel();
+      _connectivitySub?.cancel();
+      _connectivitySub = null;
       _progressTimer = null;
       _progressStreamBootstrapTimer = null;
       _progressStreamSub = null;
@@ -1430,10 +1438,32 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
       updateSettings(ref.read(settingsProvider));
       await _initOutputDir();
       await _loadQueueFromStorage();
+      await _onConnectivityChanged(await Connectivity().checkConnectivity());
     });
     return const DownloadQueueState();
   }
 
+  // Mobile data without WiFi or ethernet counts as metered. The backend
+  // applies the metered policy; the queue resumes here once it may no
+  // longer refuse downloads.
+  Future<void> _onConnectivityChanged(List<ConnectivityResult> results) async {
+    final metered =
+        results.contains(ConnectivityResult.mobile) &&
+        !results.contains(ConnectivityResult.wifi) &&
+        !results.contains(ConnectivityResult.ethernet);
+    if (PlatformBridge.supportsCoreBackend) {
+      try {
+        await PlatformBridge.setNetworkMetered(metered);
+      } catch (e) {
+        _log.w('Failed to report metered network to backend: $e');
+      }
+    }
+    if (!metered && _pausedForMeteredNetwork) {
+      _log.i('Network is no longer metered, resuming queue');
+      resumeQueue();
+    }
+  }
+
   Future<void> _loadQueueFromStorage() async {
     if (_isLoaded) return;
     _isLoaded = true;
@@ -2389,6 +2419,35 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
     );
   }
 
+  DownloadErrorType _downloadErrorTypeFromBackend(String errorType) {
+    switch (errorType) {
+      case 'not_found':
+        return DownloadErrorType.notFound;
+      case 'rate_limit':
+        return DownloadErrorType.rateLimit;
+      case 'network':
+        return DownloadErrorType.network;
+      case 'permission':
+        return DownloadErrorType.permission;
+      case 'region_locked':
+        return DownloadErrorType.regionLocked;
+      case 'quality_unavailable':
+        return DownloadErrorType.qualityUnavailable;
+      case 'auth_expired':
+        return DownloadErrorType.authExpired;
+      case 'disk_full':
+        return DownloadErrorType.diskFull;
+      case 'extension_crashed':
+        return DownloadErrorType.extensionCrashed;
+      case 'integrity_failed':
+        return DownloadErrorType.integrityFailed;
+      case 'isp_blocked':
+        return DownloadErrorType.ispBlocked;
+      default:
+        return DownloadErrorType.unknown;
+    }
+  }
+
   String _determineOutputExt(String quality, String service) {
     final extensionPreferred = _extensionPreferredOutputExt(service);
     if (extensionPreferred != null) {
@@ -2885,6 +2944,7 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
     String? filePath,
     String? error,
     DownloadErrorType? errorType,
+    bool? retryable,
   }) {
     final items = state.items;
     final index = items.indexWhere((item) => item.id == id);
@@ -2898,6 +2958,7 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
       filePath: filePath,
       error: error,
       errorType: errorType,
+      retryable: retryable,
     );
 
     if (current.status == next.status &&
@@ -2905,7 +2966,8 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
         current.speedMBps == next.speedMBps &&
         current.filePath == next.filePath &&
         current.error == next.error &&
-        current.errorType == next.errorType) {
+        current.errorType == next.errorType &&
+        current.retryable == next.retryable) {
       return;
     }
 
@@ -3133,6 +3195,7 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
   }
 
   void resumeQueue() {
+    _pausedForMeteredNetwork = false;
     if (state.isPaused) {
       state = state.copyWith(isPaused: false);
       _log.i('Queue resumed');
@@ -3183,6 +3246,7 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
           status: DownloadStatus.queued,
           progress: 0,
           error: null,
+          retryable: false,
         );
       }
       return i;
@@ -4138,6 +4202,7 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
       final hasWifi = connectivityResult.contains(ConnectivityResult.wifi);
       if (!hasWifi) {
         _log.w('WiFi-only mode enabled but no WiFi connection. Queue paused.');
+        _pausedForMeteredNetwork = true;
         state = state.copyWith(isProcessing: false, isPaused: true);
         return;
       }
@@ -4557,24 +4622,6 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
       String? safFileName;
       String? safBaseName;
       String safOutputExt = _determineOutputExt(quality, item.service);
-      if (isSafMode) {
-        final effectiveFormat = _shouldTreatAsSingleRelease(trackToDownload)
-            ? state.singleFilenameFormat
-            : state.filenameFormat;
-        final baseName = await PlatformBridge.buildFilename(effectiveFormat, {
-          'title': trackToDownload.name,
-          'artist': trackToDownload.artistName,
-          'album': trackToDownload.albumName,
-          'track': trackToDownload.trackNumber ?? 0,
-          'disc': trackToDownload.discNumber ?? 0,
-          'year': _extractYear(trackToDownload.releaseDate) ?? '',
-          'date': trackToDownload.releaseDate ?? '',
-        });
-        final sanitized = await PlatformBridge.sanitizeFilename(baseName);
-        safBaseName = sanitized;
-        safFileName = '$sanitized$safOutputExt';
-      }
-      String? finalSafFileName = safFileName;
 
       String? genre;
       String? label;
@@ -4674,6 +4721,49 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
         }
       }
 
+      if (isSafMode) {
+        // The template may contain folders; SAF creates them under the tree
+        // URI from the relative dir, so only the last segment is a file name.
+        final effectiveFormat = _shouldTreatAsSingleRelease(trackToDownload)
+            ? state.singleFilenameFormat
+            : state.filenameFormat;
+        final relativePath = await PlatformBridge.buildFilePath(
+          effectiveFormat,
+          {
+            'title': trackToDownload.name,
+            'artist': trackToDownload.artistName,
+            'album': trackToDownload.albumName,
+            'album_artist': trackToDownload.albumArtist ?? '',
+            'track': trackToDownload.trackNumber ?? 0,
+            'disc': trackToDownload.discNumber ?? 0,
+            'total_tracks': trackToDownload.totalTracks ?? 0,
+            'total_discs': trackToDownload.totalDiscs ?? 0,
+            'year': _extractYear(trackToDownload.releaseDate) ?? '',
+            'date': trackToDownload.releaseDate ?? '',
+            'isrc': trackToDownload.isrc ?? '',
+            'composer': trackToDownload.composer ?? '',
+            'genre': genre ?? '',
+            'label': label ?? '',
+            'quality': quality,
+            'provider': item.service,
+          },
+        );
+        final segments = relativePath
+            .split('/')
+            .where((segment) => segment.isNotEmpty)
+            .toList();
+        final baseName = segments.isNotEmpty ? segments.removeLast() : '';
+        if (segments.isNotEmpty) {
+          effectiveOutputDir = [
+            if (effectiveOutputDir.isNotEmpty) effectiveOutputDir,
+            ...segments,
+          ].join('/');
+        }
+        safBaseName = baseName;
+        safFileName = '$baseName$safOutputExt';
+      }
+      String? finalSafFileName = safFileName;
+
       Map<String, dynamic> result;
 
       final hasActiveExtensions = extensionState.extensions.any(
@@ -4881,6 +4971,16 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
 
         _log.i('Download success, file: $filePath');
 
+        // The metered network policy can lower a built-in provider's quality;
+        // post-processing must follow what was downloaded, not what was asked.
+        final backendQuality = result['quality'] as String?;
+        if (backendQuality != null &&
+            backendQuality.isNotEmpty &&
+            backendQuality != quality) {
+          _log.i('Backend downloaded $backendQuality instead of $quality');
+          quality = backendQuality;
+        }
+
         final actualBitDepth = result['actual_bit_depth'] as int?;
         final actualSampleRate = result['actual_sample_rate'] as int?;
         String actualQuality = quality;
@@ -5997,31 +6097,53 @@ class DownloadQueueNotifier extends Notifier<DownloadQueueState> {
           }
           return;
         }
+        if (errorTypeStr == 'metered_paused') {
+          // The backend refuses new downloads on a metered network; keep the
+          // item queued and stop starting others until the network changes.
+          _requeueItemForPause(item.id);
+          _pausedForMeteredNetwork = true;
+          if (!state.isPaused) {
+            state = state.copyWith(isPaused: true);
+            _log.w('Metered network: queue paused by download policy');
+          }
+          return;
+        }
 
-        DownloadErrorType errorType;
-        switch (errorTypeStr) {
-          case 'not_found':
-            errorType = DownloadErrorType.notFound;
-            break;
-          case 'rate_limit':
-            errorType = DownloadErrorType.rateLimit;
-            break;
-          case 'network':
-            errorType = DownloadErrorType.network;
-            break;
-          case 'permission':
-            errorType = DownloadErrorType.permission;
-            break;
-          default:
-            errorType = DownloadErrorType.unknown;
+        final attempts = (result['attempts'] as List<dynamic>? ?? const [])
+            .whereType<Map<dynamic, dynamic>>()
+            .toList();
+        for (final attempt in attempts) {
+          _log.d(
+            'Attempt ${attempt['provider']}: '
+            '${attempt['success'] == true ? 'ok' : attempt['error_type'] ?? 'unknown'}'
+            ' in ${attempt['duration_ms']}ms',
+          );
         }
 
-        _log.e('Download failed: $errorMsg (type: $errorTypeStr)');
+        var errorType = _downloadErrorTypeFromBackend(errorTypeStr);
+        if (errorType == DownloadErrorType.unknown) {
+          // Fall back to the last provider that reported a typed failure.
+          for (final attempt in attempts.reversed) {
+            final attemptType = _downloadErrorTypeFromBackend(
+              attempt['error_type'] as String? ?? '',
+            );
+            if (attemptType != DownloadErrorType.unknown) {
+              errorType = attemptType;
+              break;
+            }
+          }
+        }
+        final retryable = result['retryable'] == true;
+
+        _log.e(
+          'Download failed: $errorMsg (type: $errorTypeStr, retryable: $retryable)',
+        );
         updateItemStatus(
           item.id,
           DownloadStatus.failed,
           error: errorMsg,
           errorType: errorType,
+          retryable: retryable,
         );
         _failedInSession++;
 
@@ -66,6 +66,7 @@ class SettingsNotifier extends Notifier<AppSettings> {
     _syncLyricsSettingsToBackend();
     _syncNetworkCompatibilitySettingsToBackend();
     _syncExtensionFallbackSettingsToBackend();
+    _syncBandwidthSettingsToBackend();
   }
 
   void _syncLyricsSettingsToBackend() {
@@ -99,6 +100,18 @@ class SettingsNotifier extends Notifier<AppSettings> {
     });
   }
 
+  // WiFi-only mode maps onto the backend's metered network policy, so a
+  // queue already running stops starting downloads on mobile data.
+  void _syncBandwidthSettingsToBackend() {
+    if (!PlatformBridge.supportsCoreBackend) return;
+
+    PlatformBridge.setBandwidthSettings({
+      'metered': {'pause_queue': state.downloadNetworkMode == 'wifi_only'},
+    }).catchError((Object e) {
+      _log.w('Failed to sync bandwidth settings to backend: $e');
+    });
+  }
+
   void _syncExtensionFallbackSettingsToBackend() {
     if (!PlatformBridge.supportsCoreBackend) return;
 
@@ -493,6 +506,7 @@ class SettingsNotifier extends Notifier<AppSettings> {
   void setDownloadNetworkMode(String mode) {
     state = state.copyWith(downloadNetworkMode: mode);
     _saveSettings();
+    _syncBandwidthSettingsToBackend();
   }
 
   void setNetworkCompatibilityMode(bool enabled) {
@@ -147,6 +147,17 @@ class PlatformBridge {
     return result as String;
   }
 
+  static Future<String> buildFilePath(
+    String template,
+    Map<String, dynamic> metadata,
+  ) async {
+    final result = await _channel.invokeMethod('buildFilePath', {
+      'template': template,
+      'metadata': jsonEncode(metadata),
+    });
+    return result as String;
+  }
+
   static Future<String> sanitizeFilename(String filename) async {
     final result = await _channel.invokeMethod('sanitizeFilename', {
       'filename': filename,
@@ -775,6 +786,18 @@ class PlatformBridge {
     });
   }
 
+  static Future<void> setBandwidthSettings(
+    Map<String, dynamic> settings,
+  ) async {
+    await _channel.invokeMethod('setBandwidthSettings', {
+      'settings_json': jsonEncode(settings),
+    });
+  }
+
+  static Future<void> setNetworkMetered(bool metered) async {
+    await _channel.invokeMethod('setNetworkMetered', {'metered': metered});
+  }
+
   static Future<void> setMetadataProviderPriority(
     List<String> providerIds,
   ) async {
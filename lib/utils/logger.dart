@@ -193,7 +193,10 @@ class LogBuffer extends ChangeNotifier {
         final message = logMap['message'] as String? ?? '';
 
         DateTime parsedTime = DateTime.now();
-        if (timestamp.isNotEmpty) {
+        final rfc3339Time = DateTime.tryParse(timestamp);
+        if (rfc3339Time != null) {
+          parsedTime = rfc3339Time.toLocal();
+        } else if (timestamp.isNotEmpty) {
           try {
             final parts = timestamp.split(':');
             if (parts.length >= 3) {